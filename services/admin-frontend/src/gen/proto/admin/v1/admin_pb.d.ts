@@ -49,6 +49,26 @@ export declare type AdminWireguardInterface = Message<"william.admin.v1.AdminWir
    * @generated from field: string endpoint = 7;
    */
   endpoint: string;
+
+  /**
+   * @generated from field: string peer_key_policy = 8;
+   */
+  peerKeyPolicy: string;
+
+  /**
+   * @generated from field: int64 peer_ttl_seconds = 9;
+   */
+  peerTtlSeconds: bigint;
+
+  /**
+   * @generated from field: uint32 max_devices_per_user = 10;
+   */
+  maxDevicesPerUser: number;
+
+  /**
+   * @generated from field: string isolation_mode = 11;
+   */
+  isolationMode: string;
 };
 
 /**
@@ -133,6 +153,26 @@ export declare type CreateAdminInterfaceRequest = Message<"william.admin.v1.Crea
    * @generated from field: string endpoint = 5;
    */
   endpoint: string;
+
+  /**
+   * @generated from field: string peer_key_policy = 6;
+   */
+  peerKeyPolicy: string;
+
+  /**
+   * @generated from field: int64 peer_ttl_seconds = 7;
+   */
+  peerTtlSeconds: bigint;
+
+  /**
+   * @generated from field: uint32 max_devices_per_user = 8;
+   */
+  maxDevicesPerUser: number;
+
+  /**
+   * @generated from field: string isolation_mode = 9;
+   */
+  isolationMode: string;
 };
 
 /**
@@ -190,6 +230,26 @@ export declare type UpdateAdminInterfaceRequest = Message<"william.admin.v1.Upda
    * @generated from field: string name = 6;
    */
   name: string;
+
+  /**
+   * @generated from field: string peer_key_policy = 7;
+   */
+  peerKeyPolicy: string;
+
+  /**
+   * @generated from field: int64 peer_ttl_seconds = 8;
+   */
+  peerTtlSeconds: bigint;
+
+  /**
+   * @generated from field: uint32 max_devices_per_user = 9;
+   */
+  maxDevicesPerUser: number;
+
+  /**
+   * @generated from field: string isolation_mode = 10;
+   */
+  isolationMode: string;
 };
 
 /**
@@ -230,6 +290,43 @@ export declare type DeleteAdminInterfaceRequest = Message<"william.admin.v1.Dele
  */
 export declare const DeleteAdminInterfaceRequestSchema: GenMessage<DeleteAdminInterfaceRequest>;
 
+/**
+ * @generated from message william.admin.v1.RotateInterfaceKeyRequest
+ */
+export declare type RotateInterfaceKeyRequest = Message<"william.admin.v1.RotateInterfaceKeyRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+};
+
+/**
+ * Describes the message william.admin.v1.RotateInterfaceKeyRequest.
+ * Use `create(RotateInterfaceKeyRequestSchema)` to create a new message.
+ */
+export declare const RotateInterfaceKeyRequestSchema: GenMessage<RotateInterfaceKeyRequest>;
+
+/**
+ * @generated from message william.admin.v1.RotateInterfaceKeyResponse
+ */
+export declare type RotateInterfaceKeyResponse = Message<"william.admin.v1.RotateInterfaceKeyResponse"> & {
+  /**
+   * @generated from field: william.admin.v1.AdminWireguardInterface interface = 1;
+   */
+  interface?: AdminWireguardInterface;
+
+  /**
+   * @generated from field: repeated string updated_peer_ids = 2;
+   */
+  updatedPeerIds: string[];
+};
+
+/**
+ * Describes the message william.admin.v1.RotateInterfaceKeyResponse.
+ * Use `create(RotateInterfaceKeyResponseSchema)` to create a new message.
+ */
+export declare const RotateInterfaceKeyResponseSchema: GenMessage<RotateInterfaceKeyResponse>;
+
 /**
  * @generated from message william.admin.v1.AllowedEmail
  */
@@ -248,6 +345,11 @@ export declare type AllowedEmail = Message<"william.admin.v1.AllowedEmail"> & {
    * @generated from field: google.protobuf.Timestamp created_at = 3;
    */
   createdAt?: Timestamp;
+
+  /**
+   * @generated from field: string rule_type = 4;
+   */
+  ruleType: string;
 };
 
 /**
@@ -301,6 +403,11 @@ export declare type CreateAllowedEmailRequest = Message<"william.admin.v1.Create
    * @generated from field: string email = 2;
    */
   email: string;
+
+  /**
+   * @generated from field: string rule_type = 3;
+   */
+  ruleType: string;
 };
 
 /**
@@ -309,6 +416,43 @@ export declare type CreateAllowedEmailRequest = Message<"william.admin.v1.Create
  */
 export declare const CreateAllowedEmailRequestSchema: GenMessage<CreateAllowedEmailRequest>;
 
+/**
+ * @generated from message william.admin.v1.PreviewAllowedEmailRuleRequest
+ */
+export declare type PreviewAllowedEmailRuleRequest = Message<"william.admin.v1.PreviewAllowedEmailRuleRequest"> & {
+  /**
+   * @generated from field: string rule_type = 1;
+   */
+  ruleType: string;
+
+  /**
+   * @generated from field: string pattern = 2;
+   */
+  pattern: string;
+};
+
+/**
+ * Describes the message william.admin.v1.PreviewAllowedEmailRuleRequest.
+ * Use `create(PreviewAllowedEmailRuleRequestSchema)` to create a new message.
+ */
+export declare const PreviewAllowedEmailRuleRequestSchema: GenMessage<PreviewAllowedEmailRuleRequest>;
+
+/**
+ * @generated from message william.admin.v1.PreviewAllowedEmailRuleResponse
+ */
+export declare type PreviewAllowedEmailRuleResponse = Message<"william.admin.v1.PreviewAllowedEmailRuleResponse"> & {
+  /**
+   * @generated from field: repeated string emails = 1;
+   */
+  emails: string[];
+};
+
+/**
+ * Describes the message william.admin.v1.PreviewAllowedEmailRuleResponse.
+ * Use `create(PreviewAllowedEmailRuleResponseSchema)` to create a new message.
+ */
+export declare const PreviewAllowedEmailRuleResponseSchema: GenMessage<PreviewAllowedEmailRuleResponse>;
+
 /**
  * @generated from message william.admin.v1.DeleteAllowedEmailRequest
  */
@@ -322,6 +466,11 @@ export declare type DeleteAllowedEmailRequest = Message<"william.admin.v1.Delete
    * @generated from field: string email = 2;
    */
   email: string;
+
+  /**
+   * @generated from field: bool suspend_peers = 3;
+   */
+  suspendPeers: boolean;
 };
 
 /**
@@ -330,6 +479,153 @@ export declare type DeleteAllowedEmailRequest = Message<"william.admin.v1.Delete
  */
 export declare const DeleteAllowedEmailRequestSchema: GenMessage<DeleteAllowedEmailRequest>;
 
+/**
+ * @generated from message william.admin.v1.DeleteAllowedEmailResponse
+ */
+export declare type DeleteAllowedEmailResponse = Message<"william.admin.v1.DeleteAllowedEmailResponse"> & {
+  /**
+   * @generated from field: repeated string removed_peer_ids = 1;
+   */
+  removedPeerIds: string[];
+
+  /**
+   * @generated from field: repeated string suspended_peer_ids = 2;
+   */
+  suspendedPeerIds: string[];
+};
+
+/**
+ * Describes the message william.admin.v1.DeleteAllowedEmailResponse.
+ * Use `create(DeleteAllowedEmailResponseSchema)` to create a new message.
+ */
+export declare const DeleteAllowedEmailResponseSchema: GenMessage<DeleteAllowedEmailResponse>;
+
+/**
+ * @generated from message william.admin.v1.InterfaceGroup
+ */
+export declare type InterfaceGroup = Message<"william.admin.v1.InterfaceGroup"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string group_name = 2;
+   */
+  groupName: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   */
+  createdAt?: Timestamp;
+};
+
+/**
+ * Describes the message william.admin.v1.InterfaceGroup.
+ * Use `create(InterfaceGroupSchema)` to create a new message.
+ */
+export declare const InterfaceGroupSchema: GenMessage<InterfaceGroup>;
+
+/**
+ * @generated from message william.admin.v1.ListInterfaceGroupsRequest
+ */
+export declare type ListInterfaceGroupsRequest = Message<"william.admin.v1.ListInterfaceGroupsRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ListInterfaceGroupsRequest.
+ * Use `create(ListInterfaceGroupsRequestSchema)` to create a new message.
+ */
+export declare const ListInterfaceGroupsRequestSchema: GenMessage<ListInterfaceGroupsRequest>;
+
+/**
+ * @generated from message william.admin.v1.ListInterfaceGroupsResponse
+ */
+export declare type ListInterfaceGroupsResponse = Message<"william.admin.v1.ListInterfaceGroupsResponse"> & {
+  /**
+   * @generated from field: repeated william.admin.v1.InterfaceGroup groups = 1;
+   */
+  groups: InterfaceGroup[];
+};
+
+/**
+ * Describes the message william.admin.v1.ListInterfaceGroupsResponse.
+ * Use `create(ListInterfaceGroupsResponseSchema)` to create a new message.
+ */
+export declare const ListInterfaceGroupsResponseSchema: GenMessage<ListInterfaceGroupsResponse>;
+
+/**
+ * @generated from message william.admin.v1.CreateInterfaceGroupRequest
+ */
+export declare type CreateInterfaceGroupRequest = Message<"william.admin.v1.CreateInterfaceGroupRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string group_name = 2;
+   */
+  groupName: string;
+};
+
+/**
+ * Describes the message william.admin.v1.CreateInterfaceGroupRequest.
+ * Use `create(CreateInterfaceGroupRequestSchema)` to create a new message.
+ */
+export declare const CreateInterfaceGroupRequestSchema: GenMessage<CreateInterfaceGroupRequest>;
+
+/**
+ * @generated from message william.admin.v1.DeleteInterfaceGroupRequest
+ */
+export declare type DeleteInterfaceGroupRequest = Message<"william.admin.v1.DeleteInterfaceGroupRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string group_name = 2;
+   */
+  groupName: string;
+
+  /**
+   * @generated from field: bool suspend_peers = 3;
+   */
+  suspendPeers: boolean;
+};
+
+/**
+ * Describes the message william.admin.v1.DeleteInterfaceGroupRequest.
+ * Use `create(DeleteInterfaceGroupRequestSchema)` to create a new message.
+ */
+export declare const DeleteInterfaceGroupRequestSchema: GenMessage<DeleteInterfaceGroupRequest>;
+
+/**
+ * @generated from message william.admin.v1.DeleteInterfaceGroupResponse
+ */
+export declare type DeleteInterfaceGroupResponse = Message<"william.admin.v1.DeleteInterfaceGroupResponse"> & {
+  /**
+   * @generated from field: repeated string removed_peer_ids = 1;
+   */
+  removedPeerIds: string[];
+
+  /**
+   * @generated from field: repeated string suspended_peer_ids = 2;
+   */
+  suspendedPeerIds: string[];
+};
+
+/**
+ * Describes the message william.admin.v1.DeleteInterfaceGroupResponse.
+ * Use `create(DeleteInterfaceGroupResponseSchema)` to create a new message.
+ */
+export declare const DeleteInterfaceGroupResponseSchema: GenMessage<DeleteInterfaceGroupResponse>;
+
 /**
  * @generated from message william.admin.v1.AdminPeer
  */
@@ -358,6 +654,21 @@ export declare type AdminPeer = Message<"william.admin.v1.AdminPeer"> & {
    * @generated from field: google.protobuf.Timestamp created_at = 5;
    */
   createdAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 6;
+   */
+  expiresAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp suspended_at = 7;
+   */
+  suspendedAt?: Timestamp;
+
+  /**
+   * @generated from field: string device_name = 8;
+   */
+  deviceName: string;
 };
 
 /**
@@ -414,6 +725,38 @@ export declare type DeleteAdminPeerRequest = Message<"william.admin.v1.DeleteAdm
  */
 export declare const DeleteAdminPeerRequestSchema: GenMessage<DeleteAdminPeerRequest>;
 
+/**
+ * @generated from message william.admin.v1.SuspendAdminPeerRequest
+ */
+export declare type SuspendAdminPeerRequest = Message<"william.admin.v1.SuspendAdminPeerRequest"> & {
+  /**
+   * @generated from field: string peer_id = 1;
+   */
+  peerId: string;
+};
+
+/**
+ * Describes the message william.admin.v1.SuspendAdminPeerRequest.
+ * Use `create(SuspendAdminPeerRequestSchema)` to create a new message.
+ */
+export declare const SuspendAdminPeerRequestSchema: GenMessage<SuspendAdminPeerRequest>;
+
+/**
+ * @generated from message william.admin.v1.ResumeAdminPeerRequest
+ */
+export declare type ResumeAdminPeerRequest = Message<"william.admin.v1.ResumeAdminPeerRequest"> & {
+  /**
+   * @generated from field: string peer_id = 1;
+   */
+  peerId: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ResumeAdminPeerRequest.
+ * Use `create(ResumeAdminPeerRequestSchema)` to create a new message.
+ */
+export declare const ResumeAdminPeerRequestSchema: GenMessage<ResumeAdminPeerRequest>;
+
 /**
  * @generated from message william.admin.v1.CreateWireguardPeerRequest
  */
@@ -432,6 +775,16 @@ export declare type CreateWireguardPeerRequest = Message<"william.admin.v1.Creat
    * @generated from field: repeated string allowed_ips = 3;
    */
   allowedIps: string[];
+
+  /**
+   * @generated from field: string public_key = 4;
+   */
+  publicKey: string;
+
+  /**
+   * @generated from field: string address = 5;
+   */
+  address: string;
 };
 
 /**
@@ -514,61 +867,154 @@ export declare type UpdateWireguardPeerAllowedIPsRequest = Message<"william.admi
 export declare const UpdateWireguardPeerAllowedIPsRequestSchema: GenMessage<UpdateWireguardPeerAllowedIPsRequest>;
 
 /**
- * @generated from message william.admin.v1.InterfaceRoute
+ * @generated from message william.admin.v1.AccessRule
  */
-export declare type InterfaceRoute = Message<"william.admin.v1.InterfaceRoute"> & {
+export declare type AccessRule = Message<"william.admin.v1.AccessRule"> & {
   /**
-   * @generated from field: string interface_id = 1;
+   * @generated from field: string cidr = 1;
    */
-  interfaceId: string;
+  cidr: string;
 
   /**
-   * @generated from field: string cidr = 2;
+   * @generated from field: string protocol = 2;
    */
-  cidr: string;
+  protocol: string;
 
   /**
-   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   * @generated from field: string ports = 3;
    */
-  createdAt?: Timestamp;
+  ports: string;
 };
 
 /**
- * Describes the message william.admin.v1.InterfaceRoute.
- * Use `create(InterfaceRouteSchema)` to create a new message.
+ * Describes the message william.admin.v1.AccessRule.
+ * Use `create(AccessRuleSchema)` to create a new message.
  */
-export declare const InterfaceRouteSchema: GenMessage<InterfaceRoute>;
+export declare const AccessRuleSchema: GenMessage<AccessRule>;
 
 /**
- * @generated from message william.admin.v1.PeerRoute
+ * @generated from message william.admin.v1.SyncPeerFirewallRulesRequest
  */
-export declare type PeerRoute = Message<"william.admin.v1.PeerRoute"> & {
+export declare type SyncPeerFirewallRulesRequest = Message<"william.admin.v1.SyncPeerFirewallRulesRequest"> & {
   /**
-   * @generated from field: string peer_id = 1;
+   * @generated from field: string interface_id = 1;
    */
-  peerId: string;
+  interfaceId: string;
 
   /**
-   * @generated from field: string cidr = 2;
+   * @generated from field: string peer_allowed_ip = 2;
    */
-  cidr: string;
+  peerAllowedIp: string;
 
   /**
-   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   * @generated from field: repeated string allowed_ips = 3;
    */
-  createdAt?: Timestamp;
+  allowedIps: string[];
+
+  /**
+   * @generated from field: repeated william.admin.v1.AccessRule rules = 4;
+   */
+  rules: AccessRule[];
 };
 
 /**
- * Describes the message william.admin.v1.PeerRoute.
- * Use `create(PeerRouteSchema)` to create a new message.
+ * Describes the message william.admin.v1.SyncPeerFirewallRulesRequest.
+ * Use `create(SyncPeerFirewallRulesRequestSchema)` to create a new message.
  */
-export declare const PeerRouteSchema: GenMessage<PeerRoute>;
+export declare const SyncPeerFirewallRulesRequestSchema: GenMessage<SyncPeerFirewallRulesRequest>;
 
 /**
- * @generated from message william.admin.v1.ListInterfaceRoutesRequest
+ * @generated from message william.admin.v1.RemovePeerFirewallRulesRequest
  */
-export declare type ListInterfaceRoutesRequest = Message<"william.admin.v1.ListInterfaceRoutesRequest"> & {
+export declare type RemovePeerFirewallRulesRequest = Message<"william.admin.v1.RemovePeerFirewallRulesRequest"> & {
+  /**
+   * @generated from field: string peer_allowed_ip = 1;
+   */
+  peerAllowedIp: string;
+};
+
+/**
+ * Describes the message william.admin.v1.RemovePeerFirewallRulesRequest.
+ * Use `create(RemovePeerFirewallRulesRequestSchema)` to create a new message.
+ */
+export declare const RemovePeerFirewallRulesRequestSchema: GenMessage<RemovePeerFirewallRulesRequest>;
+
+/**
+ * @generated from message william.admin.v1.InterfaceRoute
+ */
+export declare type InterfaceRoute = Message<"william.admin.v1.InterfaceRoute"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string cidr = 2;
+   */
+  cidr: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   */
+  createdAt?: Timestamp;
+
+  /**
+   * @generated from field: string protocol = 4;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 5;
+   */
+  ports: string;
+};
+
+/**
+ * Describes the message william.admin.v1.InterfaceRoute.
+ * Use `create(InterfaceRouteSchema)` to create a new message.
+ */
+export declare const InterfaceRouteSchema: GenMessage<InterfaceRoute>;
+
+/**
+ * @generated from message william.admin.v1.PeerRoute
+ */
+export declare type PeerRoute = Message<"william.admin.v1.PeerRoute"> & {
+  /**
+   * @generated from field: string peer_id = 1;
+   */
+  peerId: string;
+
+  /**
+   * @generated from field: string cidr = 2;
+   */
+  cidr: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   */
+  createdAt?: Timestamp;
+
+  /**
+   * @generated from field: string protocol = 4;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 5;
+   */
+  ports: string;
+};
+
+/**
+ * Describes the message william.admin.v1.PeerRoute.
+ * Use `create(PeerRouteSchema)` to create a new message.
+ */
+export declare const PeerRouteSchema: GenMessage<PeerRoute>;
+
+/**
+ * @generated from message william.admin.v1.ListInterfaceRoutesRequest
+ */
+export declare type ListInterfaceRoutesRequest = Message<"william.admin.v1.ListInterfaceRoutesRequest"> & {
   /**
    * @generated from field: string interface_id = 1;
    */
@@ -610,6 +1056,16 @@ export declare type CreateInterfaceRouteRequest = Message<"william.admin.v1.Crea
    * @generated from field: string cidr = 2;
    */
   cidr: string;
+
+  /**
+   * @generated from field: string protocol = 3;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 4;
+   */
+  ports: string;
 };
 
 /**
@@ -631,6 +1087,16 @@ export declare type DeleteInterfaceRouteRequest = Message<"william.admin.v1.Dele
    * @generated from field: string cidr = 2;
    */
   cidr: string;
+
+  /**
+   * @generated from field: string protocol = 3;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 4;
+   */
+  ports: string;
 };
 
 /**
@@ -684,6 +1150,16 @@ export declare type CreatePeerRouteRequest = Message<"william.admin.v1.CreatePee
    * @generated from field: string cidr = 2;
    */
   cidr: string;
+
+  /**
+   * @generated from field: string protocol = 3;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 4;
+   */
+  ports: string;
 };
 
 /**
@@ -705,6 +1181,16 @@ export declare type DeletePeerRouteRequest = Message<"william.admin.v1.DeletePee
    * @generated from field: string cidr = 2;
    */
   cidr: string;
+
+  /**
+   * @generated from field: string protocol = 3;
+   */
+  protocol: string;
+
+  /**
+   * @generated from field: string ports = 4;
+   */
+  ports: string;
 };
 
 /**
@@ -713,6 +1199,236 @@ export declare type DeletePeerRouteRequest = Message<"william.admin.v1.DeletePee
  */
 export declare const DeletePeerRouteRequestSchema: GenMessage<DeletePeerRouteRequest>;
 
+/**
+ * @generated from message william.admin.v1.IpAllocation
+ */
+export declare type IpAllocation = Message<"william.admin.v1.IpAllocation"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string address = 2;
+   */
+  address: string;
+
+  /**
+   * @generated from field: string peer_id = 3;
+   */
+  peerId: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp released_at = 4;
+   */
+  releasedAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 5;
+   */
+  createdAt?: Timestamp;
+};
+
+/**
+ * Describes the message william.admin.v1.IpAllocation.
+ * Use `create(IpAllocationSchema)` to create a new message.
+ */
+export declare const IpAllocationSchema: GenMessage<IpAllocation>;
+
+/**
+ * @generated from message william.admin.v1.IpReservation
+ */
+export declare type IpReservation = Message<"william.admin.v1.IpReservation"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string cidr = 2;
+   */
+  cidr: string;
+
+  /**
+   * @generated from field: string description = 3;
+   */
+  description: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 4;
+   */
+  createdAt?: Timestamp;
+};
+
+/**
+ * Describes the message william.admin.v1.IpReservation.
+ * Use `create(IpReservationSchema)` to create a new message.
+ */
+export declare const IpReservationSchema: GenMessage<IpReservation>;
+
+/**
+ * @generated from message william.admin.v1.ListIpAllocationsRequest
+ */
+export declare type ListIpAllocationsRequest = Message<"william.admin.v1.ListIpAllocationsRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ListIpAllocationsRequest.
+ * Use `create(ListIpAllocationsRequestSchema)` to create a new message.
+ */
+export declare const ListIpAllocationsRequestSchema: GenMessage<ListIpAllocationsRequest>;
+
+/**
+ * @generated from message william.admin.v1.ListIpAllocationsResponse
+ */
+export declare type ListIpAllocationsResponse = Message<"william.admin.v1.ListIpAllocationsResponse"> & {
+  /**
+   * @generated from field: repeated william.admin.v1.IpAllocation allocations = 1;
+   */
+  allocations: IpAllocation[];
+
+  /**
+   * @generated from field: repeated william.admin.v1.IpReservation reservations = 2;
+   */
+  reservations: IpReservation[];
+};
+
+/**
+ * Describes the message william.admin.v1.ListIpAllocationsResponse.
+ * Use `create(ListIpAllocationsResponseSchema)` to create a new message.
+ */
+export declare const ListIpAllocationsResponseSchema: GenMessage<ListIpAllocationsResponse>;
+
+/**
+ * @generated from message william.admin.v1.CreateIpReservationRequest
+ */
+export declare type CreateIpReservationRequest = Message<"william.admin.v1.CreateIpReservationRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string cidr = 2;
+   */
+  cidr: string;
+
+  /**
+   * @generated from field: string description = 3;
+   */
+  description: string;
+};
+
+/**
+ * Describes the message william.admin.v1.CreateIpReservationRequest.
+ * Use `create(CreateIpReservationRequestSchema)` to create a new message.
+ */
+export declare const CreateIpReservationRequestSchema: GenMessage<CreateIpReservationRequest>;
+
+/**
+ * @generated from message william.admin.v1.DeleteIpReservationRequest
+ */
+export declare type DeleteIpReservationRequest = Message<"william.admin.v1.DeleteIpReservationRequest"> & {
+  /**
+   * @generated from field: string interface_id = 1;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string cidr = 2;
+   */
+  cidr: string;
+};
+
+/**
+ * Describes the message william.admin.v1.DeleteIpReservationRequest.
+ * Use `create(DeleteIpReservationRequestSchema)` to create a new message.
+ */
+export declare const DeleteIpReservationRequestSchema: GenMessage<DeleteIpReservationRequest>;
+
+/**
+ * @generated from message william.admin.v1.AdminRoleAssignment
+ */
+export declare type AdminRoleAssignment = Message<"william.admin.v1.AdminRoleAssignment"> & {
+  /**
+   * @generated from field: string subject = 1;
+   */
+  subject: string;
+
+  /**
+   * @generated from field: string role = 2;
+   */
+  role: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 3;
+   */
+  createdAt?: Timestamp;
+};
+
+/**
+ * Describes the message william.admin.v1.AdminRoleAssignment.
+ * Use `create(AdminRoleAssignmentSchema)` to create a new message.
+ */
+export declare const AdminRoleAssignmentSchema: GenMessage<AdminRoleAssignment>;
+
+/**
+ * @generated from message william.admin.v1.ListAdminRoleAssignmentsResponse
+ */
+export declare type ListAdminRoleAssignmentsResponse = Message<"william.admin.v1.ListAdminRoleAssignmentsResponse"> & {
+  /**
+   * @generated from field: repeated william.admin.v1.AdminRoleAssignment assignments = 1;
+   */
+  assignments: AdminRoleAssignment[];
+};
+
+/**
+ * Describes the message william.admin.v1.ListAdminRoleAssignmentsResponse.
+ * Use `create(ListAdminRoleAssignmentsResponseSchema)` to create a new message.
+ */
+export declare const ListAdminRoleAssignmentsResponseSchema: GenMessage<ListAdminRoleAssignmentsResponse>;
+
+/**
+ * @generated from message william.admin.v1.SetAdminRoleAssignmentRequest
+ */
+export declare type SetAdminRoleAssignmentRequest = Message<"william.admin.v1.SetAdminRoleAssignmentRequest"> & {
+  /**
+   * @generated from field: string subject = 1;
+   */
+  subject: string;
+
+  /**
+   * @generated from field: string role = 2;
+   */
+  role: string;
+};
+
+/**
+ * Describes the message william.admin.v1.SetAdminRoleAssignmentRequest.
+ * Use `create(SetAdminRoleAssignmentRequestSchema)` to create a new message.
+ */
+export declare const SetAdminRoleAssignmentRequestSchema: GenMessage<SetAdminRoleAssignmentRequest>;
+
+/**
+ * @generated from message william.admin.v1.DeleteAdminRoleAssignmentRequest
+ */
+export declare type DeleteAdminRoleAssignmentRequest = Message<"william.admin.v1.DeleteAdminRoleAssignmentRequest"> & {
+  /**
+   * @generated from field: string subject = 1;
+   */
+  subject: string;
+};
+
+/**
+ * Describes the message william.admin.v1.DeleteAdminRoleAssignmentRequest.
+ * Use `create(DeleteAdminRoleAssignmentRequestSchema)` to create a new message.
+ */
+export declare const DeleteAdminRoleAssignmentRequestSchema: GenMessage<DeleteAdminRoleAssignmentRequest>;
+
 /**
  * @generated from message william.admin.v1.PeerStat
  */
@@ -834,6 +1550,181 @@ export declare type ListWireguardConfigsResponse = Message<"william.admin.v1.Lis
  */
 export declare const ListWireguardConfigsResponseSchema: GenMessage<ListWireguardConfigsResponse>;
 
+/**
+ * @generated from message william.admin.v1.ReconcileChange
+ */
+export declare type ReconcileChange = Message<"william.admin.v1.ReconcileChange"> & {
+  /**
+   * @generated from field: string kind = 1;
+   */
+  kind: string;
+
+  /**
+   * @generated from field: string interface_id = 2;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string target = 3;
+   */
+  target: string;
+
+  /**
+   * @generated from field: string detail = 4;
+   */
+  detail: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ReconcileChange.
+ * Use `create(ReconcileChangeSchema)` to create a new message.
+ */
+export declare const ReconcileChangeSchema: GenMessage<ReconcileChange>;
+
+/**
+ * @generated from message william.admin.v1.PlanWireguardReconcileResponse
+ */
+export declare type PlanWireguardReconcileResponse = Message<"william.admin.v1.PlanWireguardReconcileResponse"> & {
+  /**
+   * @generated from field: repeated william.admin.v1.ReconcileChange changes = 1;
+   */
+  changes: ReconcileChange[];
+};
+
+/**
+ * Describes the message william.admin.v1.PlanWireguardReconcileResponse.
+ * Use `create(PlanWireguardReconcileResponseSchema)` to create a new message.
+ */
+export declare const PlanWireguardReconcileResponseSchema: GenMessage<PlanWireguardReconcileResponse>;
+
+/**
+ * @generated from message william.admin.v1.AuditEvent
+ */
+export declare type AuditEvent = Message<"william.admin.v1.AuditEvent"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp occurred_at = 2;
+   */
+  occurredAt?: Timestamp;
+
+  /**
+   * @generated from field: string actor = 3;
+   */
+  actor: string;
+
+  /**
+   * @generated from field: string action = 4;
+   */
+  action: string;
+
+  /**
+   * @generated from field: string target_type = 5;
+   */
+  targetType: string;
+
+  /**
+   * @generated from field: string target_id = 6;
+   */
+  targetId: string;
+
+  /**
+   * @generated from field: string before_json = 7;
+   */
+  beforeJson: string;
+
+  /**
+   * @generated from field: string after_json = 8;
+   */
+  afterJson: string;
+
+  /**
+   * @generated from field: string request_id = 9;
+   */
+  requestId: string;
+};
+
+/**
+ * Describes the message william.admin.v1.AuditEvent.
+ * Use `create(AuditEventSchema)` to create a new message.
+ */
+export declare const AuditEventSchema: GenMessage<AuditEvent>;
+
+/**
+ * @generated from message william.admin.v1.ListAuditEventsRequest
+ */
+export declare type ListAuditEventsRequest = Message<"william.admin.v1.ListAuditEventsRequest"> & {
+  /**
+   * @generated from field: string actor = 1;
+   */
+  actor: string;
+
+  /**
+   * @generated from field: string action = 2;
+   */
+  action: string;
+
+  /**
+   * @generated from field: string target_type = 3;
+   */
+  targetType: string;
+
+  /**
+   * @generated from field: string target_id = 4;
+   */
+  targetId: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp since = 5;
+   */
+  since?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp until = 6;
+   */
+  until?: Timestamp;
+
+  /**
+   * @generated from field: int32 page_size = 7;
+   */
+  pageSize: number;
+
+  /**
+   * @generated from field: string page_token = 8;
+   */
+  pageToken: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ListAuditEventsRequest.
+ * Use `create(ListAuditEventsRequestSchema)` to create a new message.
+ */
+export declare const ListAuditEventsRequestSchema: GenMessage<ListAuditEventsRequest>;
+
+/**
+ * @generated from message william.admin.v1.ListAuditEventsResponse
+ */
+export declare type ListAuditEventsResponse = Message<"william.admin.v1.ListAuditEventsResponse"> & {
+  /**
+   * @generated from field: repeated william.admin.v1.AuditEvent events = 1;
+   */
+  events: AuditEvent[];
+
+  /**
+   * @generated from field: string next_page_token = 2;
+   */
+  nextPageToken: string;
+};
+
+/**
+ * Describes the message william.admin.v1.ListAuditEventsResponse.
+ * Use `create(ListAuditEventsResponseSchema)` to create a new message.
+ */
+export declare const ListAuditEventsResponseSchema: GenMessage<ListAuditEventsResponse>;
+
 /**
  * @generated from service william.admin.v1.WilliamAdminService
  */
@@ -878,6 +1769,14 @@ export declare const WilliamAdminService: GenService<{
     input: typeof DeleteAdminInterfaceRequestSchema;
     output: typeof EmptySchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.RotateInterfaceKey
+   */
+  rotateInterfaceKey: {
+    methodKind: "unary";
+    input: typeof RotateInterfaceKeyRequestSchema;
+    output: typeof RotateInterfaceKeyResponseSchema;
+  },
   /**
    * @generated from rpc william.admin.v1.WilliamAdminService.ListAllowedEmails
    */
@@ -900,8 +1799,40 @@ export declare const WilliamAdminService: GenService<{
   deleteAllowedEmail: {
     methodKind: "unary";
     input: typeof DeleteAllowedEmailRequestSchema;
+    output: typeof DeleteAllowedEmailResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.PreviewAllowedEmailRule
+   */
+  previewAllowedEmailRule: {
+    methodKind: "unary";
+    input: typeof PreviewAllowedEmailRuleRequestSchema;
+    output: typeof PreviewAllowedEmailRuleResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.ListInterfaceGroups
+   */
+  listInterfaceGroups: {
+    methodKind: "unary";
+    input: typeof ListInterfaceGroupsRequestSchema;
+    output: typeof ListInterfaceGroupsResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.CreateInterfaceGroup
+   */
+  createInterfaceGroup: {
+    methodKind: "unary";
+    input: typeof CreateInterfaceGroupRequestSchema;
     output: typeof EmptySchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.DeleteInterfaceGroup
+   */
+  deleteInterfaceGroup: {
+    methodKind: "unary";
+    input: typeof DeleteInterfaceGroupRequestSchema;
+    output: typeof DeleteInterfaceGroupResponseSchema;
+  },
   /**
    * @generated from rpc william.admin.v1.WilliamAdminService.ListPeers
    */
@@ -918,6 +1849,22 @@ export declare const WilliamAdminService: GenService<{
     input: typeof DeleteAdminPeerRequestSchema;
     output: typeof EmptySchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.SuspendPeer
+   */
+  suspendPeer: {
+    methodKind: "unary";
+    input: typeof SuspendAdminPeerRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.ResumePeer
+   */
+  resumePeer: {
+    methodKind: "unary";
+    input: typeof ResumeAdminPeerRequestSchema;
+    output: typeof EmptySchema;
+  },
   /**
    * @generated from rpc william.admin.v1.WilliamAdminService.CreateWireguardPeer
    */
@@ -942,6 +1889,30 @@ export declare const WilliamAdminService: GenService<{
     input: typeof DeleteWireguardPeerRequestSchema;
     output: typeof EmptySchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.SyncPeerFirewallRules
+   */
+  syncPeerFirewallRules: {
+    methodKind: "unary";
+    input: typeof SyncPeerFirewallRulesRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.RemovePeerFirewallRules
+   */
+  removePeerFirewallRules: {
+    methodKind: "unary";
+    input: typeof RemovePeerFirewallRulesRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.EnsureFirewallChain
+   */
+  ensureFirewallChain: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof EmptySchema;
+  },
   /**
    * @generated from rpc william.admin.v1.WilliamAdminService.ListInterfaceRoutes
    */
@@ -990,6 +1961,54 @@ export declare const WilliamAdminService: GenService<{
     input: typeof DeletePeerRouteRequestSchema;
     output: typeof EmptySchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.ListIpAllocations
+   */
+  listIpAllocations: {
+    methodKind: "unary";
+    input: typeof ListIpAllocationsRequestSchema;
+    output: typeof ListIpAllocationsResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.CreateIpReservation
+   */
+  createIpReservation: {
+    methodKind: "unary";
+    input: typeof CreateIpReservationRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.DeleteIpReservation
+   */
+  deleteIpReservation: {
+    methodKind: "unary";
+    input: typeof DeleteIpReservationRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.ListAdminRoleAssignments
+   */
+  listAdminRoleAssignments: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof ListAdminRoleAssignmentsResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.SetAdminRoleAssignment
+   */
+  setAdminRoleAssignment: {
+    methodKind: "unary";
+    input: typeof SetAdminRoleAssignmentRequestSchema;
+    output: typeof EmptySchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.DeleteAdminRoleAssignment
+   */
+  deleteAdminRoleAssignment: {
+    methodKind: "unary";
+    input: typeof DeleteAdminRoleAssignmentRequestSchema;
+    output: typeof EmptySchema;
+  },
   /**
    * @generated from rpc william.admin.v1.WilliamAdminService.ListPeerStats
    */
@@ -1014,5 +2033,21 @@ export declare const WilliamAdminService: GenService<{
     input: typeof ListWireguardConfigsRequestSchema;
     output: typeof ListWireguardConfigsResponseSchema;
   },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.PlanWireguardReconcile
+   */
+  planWireguardReconcile: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof PlanWireguardReconcileResponseSchema;
+  },
+  /**
+   * @generated from rpc william.admin.v1.WilliamAdminService.ListAuditEvents
+   */
+  listAuditEvents: {
+    methodKind: "unary";
+    input: typeof ListAuditEventsRequestSchema;
+    output: typeof ListAuditEventsResponseSchema;
+  },
 }>;
 
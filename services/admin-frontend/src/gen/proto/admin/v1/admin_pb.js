@@ -9,7 +9,7 @@ import { file_google_protobuf_empty, file_google_protobuf_timestamp } from "@buf
  * Describes the file proto/admin/v1/admin.proto.
  */
 export const file_proto_admin_v1_admin = /*@__PURE__*/
-  fileDesc("Chpwcm90by9hZG1pbi92MS9hZG1pbi5wcm90bxIQd2lsbGlhbS5hZG1pbi52MSKMAQoXQWRtaW5XaXJlZ3VhcmRJbnRlcmZhY2USCgoCaWQYASABKAkSDAoEbmFtZRgCIAEoCRIPCgdhZGRyZXNzGAMgASgJEhMKC2xpc3Rlbl9wb3J0GAQgASgNEhIKCnB1YmxpY19rZXkYBSABKAkSCwoDbXR1GAYgASgNEhAKCGVuZHBvaW50GAcgASgJIlwKG0xpc3RBZG1pbkludGVyZmFjZXNSZXNwb25zZRI9CgppbnRlcmZhY2VzGAEgAygLMikud2lsbGlhbS5hZG1pbi52MS5BZG1pbldpcmVndWFyZEludGVyZmFjZSImChhHZXRBZG1pbkludGVyZmFjZVJlcXVlc3QSCgoCaWQYASABKAkiWQoZR2V0QWRtaW5JbnRlcmZhY2VSZXNwb25zZRI8CglpbnRlcmZhY2UYASABKAsyKS53aWxsaWFtLmFkbWluLnYxLkFkbWluV2lyZWd1YXJkSW50ZXJmYWNlInAKG0NyZWF0ZUFkbWluSW50ZXJmYWNlUmVxdWVzdBIMCgRuYW1lGAEgASgJEg8KB2FkZHJlc3MYAiABKAkSEwoLbGlzdGVuX3BvcnQYAyABKA0SCwoDbXR1GAQgASgNEhAKCGVuZHBvaW50GAUgASgJIlwKHENyZWF0ZUFkbWluSW50ZXJmYWNlUmVzcG9uc2USPAoJaW50ZXJmYWNlGAEgASgLMikud2lsbGlhbS5hZG1pbi52MS5BZG1pbldpcmVndWFyZEludGVyZmFjZSJ8ChtVcGRhdGVBZG1pbkludGVyZmFjZVJlcXVlc3QSCgoCaWQYASABKAkSDwoHYWRkcmVzcxgCIAEoCRITCgtsaXN0ZW5fcG9ydBgDIAEoDRILCgNtdHUYBCABKA0SEAoIZW5kcG9pbnQYBSABKAkSDAoEbmFtZRgGIAEoCSJcChxVcGRhdGVBZG1pbkludGVyZmFjZVJlc3BvbnNlEjwKCWludGVyZmFjZRgBIAEoCzIpLndpbGxpYW0uYWRtaW4udjEuQWRtaW5XaXJlZ3VhcmRJbnRlcmZhY2UiKQobRGVsZXRlQWRtaW5JbnRlcmZhY2VSZXF1ZXN0EgoKAmlkGAEgASgJImMKDEFsbG93ZWRFbWFpbBIUCgxpbnRlcmZhY2VfaWQYASABKAkSDQoFZW1haWwYAiABKAkSLgoKY3JlYXRlZF9hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiMAoYTGlzdEFsbG93ZWRFbWFpbHNSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCSJLChlMaXN0QWxsb3dlZEVtYWlsc1Jlc3BvbnNlEi4KBmVtYWlscxgBIAMoCzIeLndpbGxpYW0uYWRtaW4udjEuQWxsb3dlZEVtYWlsIkAKGUNyZWF0ZUFsbG93ZWRFbWFpbFJlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEg0KBWVtYWlsGAIgASgJIkAKGURlbGV0ZUFsbG93ZWRFbWFpbFJlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEg0KBWVtYWlsGAIgASgJIoUBCglBZG1pblBlZXISDwoHcGVlcl9pZBgBIAEoCRINCgVlbWFpbBgCIAEoCRIUCgxpbnRlcmZhY2VfaWQYAyABKAkSEgoKYWxsb3dlZF9pcBgEIAEoCRIuCgpjcmVhdGVkX2F0GAUgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCItChVMaXN0QWRtaW5QZWVyc1JlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJIkQKFkxpc3RBZG1pblBlZXJzUmVzcG9uc2USKgoFcGVlcnMYASADKAsyGy53aWxsaWFtLmFkbWluLnYxLkFkbWluUGVlciIpChZEZWxldGVBZG1pblBlZXJSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkiWQoaQ3JlYXRlV2lyZWd1YXJkUGVlclJlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEhAKCGVuZHBvaW50GAIgASgJEhMKC2FsbG93ZWRfaXBzGAMgAygJIm0KG0NyZWF0ZVdpcmVndWFyZFBlZXJSZXNwb25zZRIUCgxpbnRlcmZhY2VfaWQYASABKAkSDwoHcGVlcl9pZBgCIAEoCRISCgphbGxvd2VkX2lwGAMgASgJEhMKC3BlZXJfY29uZmlnGAQgASgJIi0KGkRlbGV0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkiYgokVXBkYXRlV2lyZWd1YXJkUGVlckFsbG93ZWRJUHNSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIPCgdwZWVyX2lkGAIgASgJEhMKC2FsbG93ZWRfaXBzGAMgAygJImQKDkludGVyZmFjZVJvdXRlEhQKDGludGVyZmFjZV9pZBgBIAEoCRIMCgRjaWRyGAIgASgJEi4KCmNyZWF0ZWRfYXQYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wIloKCVBlZXJSb3V0ZRIPCgdwZWVyX2lkGAEgASgJEgwKBGNpZHIYAiABKAkSLgoKY3JlYXRlZF9hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiMgoaTGlzdEludGVyZmFjZVJvdXRlc1JlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJIk8KG0xpc3RJbnRlcmZhY2VSb3V0ZXNSZXNwb25zZRIwCgZyb3V0ZXMYASADKAsyIC53aWxsaWFtLmFkbWluLnYxLkludGVyZmFjZVJvdXRlIkEKG0NyZWF0ZUludGVyZmFjZVJvdXRlUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkSDAoEY2lkchgCIAEoCSJBChtEZWxldGVJbnRlcmZhY2VSb3V0ZVJlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEgwKBGNpZHIYAiABKAkiKAoVTGlzdFBlZXJSb3V0ZXNSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkiRQoWTGlzdFBlZXJSb3V0ZXNSZXNwb25zZRIrCgZyb3V0ZXMYASADKAsyGy53aWxsaWFtLmFkbWluLnYxLlBlZXJSb3V0ZSI3ChZDcmVhdGVQZWVyUm91dGVSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkSDAoEY2lkchgCIAEoCSI3ChZEZWxldGVQZWVyUm91dGVSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkSDAoEY2lkchgCIAEoCSJwCghQZWVyU3RhdBIPCgdwZWVyX2lkGAEgASgJEhQKDGludGVyZmFjZV9pZBgCIAEoCRIQCghyeF9ieXRlcxgDIAEoBBIQCgh0eF9ieXRlcxgEIAEoBBIZChFsYXN0X2hhbmRzaGFrZV9hdBgFIAEoAyJCChVMaXN0UGVlclN0YXRzUmVzcG9uc2USKQoFc3RhdHMYASADKAsyGi53aWxsaWFtLmFkbWluLnYxLlBlZXJTdGF0IikKGEdldEZpcmV3YWxsUnVsZXNSZXNwb25zZRINCgVydWxlcxgBIAEoCSI3Cg9XaXJlZ3VhcmRDb25maWcSFAoMaW50ZXJmYWNlX2lkGAEgASgJEg4KBmNvbmZpZxgCIAEoCSIzChtMaXN0V2lyZWd1YXJkQ29uZmlnc1JlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJIlIKHExpc3RXaXJlZ3VhcmRDb25maWdzUmVzcG9uc2USMgoHY29uZmlncxgBIAMoCzIhLndpbGxpYW0uYWRtaW4udjEuV2lyZWd1YXJkQ29uZmlnMo0RChNXaWxsaWFtQWRtaW5TZXJ2aWNlElcKDkxpc3RJbnRlcmZhY2VzEhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5Gi0ud2lsbGlhbS5hZG1pbi52MS5MaXN0QWRtaW5JbnRlcmZhY2VzUmVzcG9uc2USZwoMR2V0SW50ZXJmYWNlEioud2lsbGlhbS5hZG1pbi52MS5HZXRBZG1pbkludGVyZmFjZVJlcXVlc3QaKy53aWxsaWFtLmFkbWluLnYxLkdldEFkbWluSW50ZXJmYWNlUmVzcG9uc2UScAoPQ3JlYXRlSW50ZXJmYWNlEi0ud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVBZG1pbkludGVyZmFjZVJlcXVlc3QaLi53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUFkbWluSW50ZXJmYWNlUmVzcG9uc2UScAoPVXBkYXRlSW50ZXJmYWNlEi0ud2lsbGlhbS5hZG1pbi52MS5VcGRhdGVBZG1pbkludGVyZmFjZVJlcXVlc3QaLi53aWxsaWFtLmFkbWluLnYxLlVwZGF0ZUFkbWluSW50ZXJmYWNlUmVzcG9uc2USWAoPRGVsZXRlSW50ZXJmYWNlEi0ud2lsbGlhbS5hZG1pbi52MS5EZWxldGVBZG1pbkludGVyZmFjZVJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSbAoRTGlzdEFsbG93ZWRFbWFpbHMSKi53aWxsaWFtLmFkbWluLnYxLkxpc3RBbGxvd2VkRW1haWxzUmVxdWVzdBorLndpbGxpYW0uYWRtaW4udjEuTGlzdEFsbG93ZWRFbWFpbHNSZXNwb25zZRJZChJDcmVhdGVBbGxvd2VkRW1haWwSKy53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUFsbG93ZWRFbWFpbFJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSWQoSRGVsZXRlQWxsb3dlZEVtYWlsEisud2lsbGlhbS5hZG1pbi52MS5EZWxldGVBbGxvd2VkRW1haWxSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5El4KCUxpc3RQZWVycxInLndpbGxpYW0uYWRtaW4udjEuTGlzdEFkbWluUGVlcnNSZXF1ZXN0Gigud2lsbGlhbS5hZG1pbi52MS5MaXN0QWRtaW5QZWVyc1Jlc3BvbnNlEk4KCkRlbGV0ZVBlZXISKC53aWxsaWFtLmFkbWluLnYxLkRlbGV0ZUFkbWluUGVlclJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkScgoTQ3JlYXRlV2lyZWd1YXJkUGVlchIsLndpbGxpYW0uYWRtaW4udjEuQ3JlYXRlV2lyZWd1YXJkUGVlclJlcXVlc3QaLS53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZVdpcmVndWFyZFBlZXJSZXNwb25zZRJvCh1VcGRhdGVXaXJlZ3VhcmRQZWVyQWxsb3dlZElQcxI2LndpbGxpYW0uYWRtaW4udjEuVXBkYXRlV2lyZWd1YXJkUGVlckFsbG93ZWRJUHNSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5ElsKE0RlbGV0ZVdpcmVndWFyZFBlZXISLC53aWxsaWFtLmFkbWluLnYxLkRlbGV0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EnIKE0xpc3RJbnRlcmZhY2VSb3V0ZXMSLC53aWxsaWFtLmFkbWluLnYxLkxpc3RJbnRlcmZhY2VSb3V0ZXNSZXF1ZXN0Gi0ud2lsbGlhbS5hZG1pbi52MS5MaXN0SW50ZXJmYWNlUm91dGVzUmVzcG9uc2USXQoUQ3JlYXRlSW50ZXJmYWNlUm91dGUSLS53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUludGVyZmFjZVJvdXRlUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJdChREZWxldGVJbnRlcmZhY2VSb3V0ZRItLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlSW50ZXJmYWNlUm91dGVSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EmMKDkxpc3RQZWVyUm91dGVzEicud2lsbGlhbS5hZG1pbi52MS5MaXN0UGVlclJvdXRlc1JlcXVlc3QaKC53aWxsaWFtLmFkbWluLnYxLkxpc3RQZWVyUm91dGVzUmVzcG9uc2USUwoPQ3JlYXRlUGVlclJvdXRlEigud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVQZWVyUm91dGVSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5ElMKD0RlbGV0ZVBlZXJSb3V0ZRIoLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlUGVlclJvdXRlUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJQCg1MaXN0UGVlclN0YXRzEhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5Gicud2lsbGlhbS5hZG1pbi52MS5MaXN0UGVlclN0YXRzUmVzcG9uc2USVgoQR2V0RmlyZXdhbGxSdWxlcxIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRoqLndpbGxpYW0uYWRtaW4udjEuR2V0RmlyZXdhbGxSdWxlc1Jlc3BvbnNlEnUKFExpc3RXaXJlZ3VhcmRDb25maWdzEi0ud2lsbGlhbS5hZG1pbi52MS5MaXN0V2lyZWd1YXJkQ29uZmlnc1JlcXVlc3QaLi53aWxsaWFtLmFkbWluLnYxLkxpc3RXaXJlZ3VhcmRDb25maWdzUmVzcG9uc2ViBnByb3RvMw", [file_google_protobuf_empty, file_google_protobuf_timestamp]);
+  fileDesc("Chpwcm90by9hZG1pbi92MS9hZG1pbi5wcm90bxIQd2lsbGlhbS5hZG1pbi52MSL1AQoXQWRtaW5XaXJlZ3VhcmRJbnRlcmZhY2USCgoCaWQYASABKAkSDAoEbmFtZRgCIAEoCRIPCgdhZGRyZXNzGAMgASgJEhMKC2xpc3Rlbl9wb3J0GAQgASgNEhIKCnB1YmxpY19rZXkYBSABKAkSCwoDbXR1GAYgASgNEhAKCGVuZHBvaW50GAcgASgJEhcKD3BlZXJfa2V5X3BvbGljeRgIIAEoCRIYChBwZWVyX3R0bF9zZWNvbmRzGAkgASgDEhwKFG1heF9kZXZpY2VzX3Blcl91c2VyGAogASgNEhYKDmlzb2xhdGlvbl9tb2RlGAsgASgJIlwKG0xpc3RBZG1pbkludGVyZmFjZXNSZXNwb25zZRI9CgppbnRlcmZhY2VzGAEgAygLMikud2lsbGlhbS5hZG1pbi52MS5BZG1pbldpcmVndWFyZEludGVyZmFjZSImChhHZXRBZG1pbkludGVyZmFjZVJlcXVlc3QSCgoCaWQYASABKAkiWQoZR2V0QWRtaW5JbnRlcmZhY2VSZXNwb25zZRI8CglpbnRlcmZhY2UYASABKAsyKS53aWxsaWFtLmFkbWluLnYxLkFkbWluV2lyZWd1YXJkSW50ZXJmYWNlItkBChtDcmVhdGVBZG1pbkludGVyZmFjZVJlcXVlc3QSDAoEbmFtZRgBIAEoCRIPCgdhZGRyZXNzGAIgASgJEhMKC2xpc3Rlbl9wb3J0GAMgASgNEgsKA210dRgEIAEoDRIQCghlbmRwb2ludBgFIAEoCRIXCg9wZWVyX2tleV9wb2xpY3kYBiABKAkSGAoQcGVlcl90dGxfc2Vjb25kcxgHIAEoAxIcChRtYXhfZGV2aWNlc19wZXJfdXNlchgIIAEoDRIWCg5pc29sYXRpb25fbW9kZRgJIAEoCSJcChxDcmVhdGVBZG1pbkludGVyZmFjZVJlc3BvbnNlEjwKCWludGVyZmFjZRgBIAEoCzIpLndpbGxpYW0uYWRtaW4udjEuQWRtaW5XaXJlZ3VhcmRJbnRlcmZhY2Ui5QEKG1VwZGF0ZUFkbWluSW50ZXJmYWNlUmVxdWVzdBIKCgJpZBgBIAEoCRIPCgdhZGRyZXNzGAIgASgJEhMKC2xpc3Rlbl9wb3J0GAMgASgNEgsKA210dRgEIAEoDRIQCghlbmRwb2ludBgFIAEoCRIMCgRuYW1lGAYgASgJEhcKD3BlZXJfa2V5X3BvbGljeRgHIAEoCRIYChBwZWVyX3R0bF9zZWNvbmRzGAggASgDEhwKFG1heF9kZXZpY2VzX3Blcl91c2VyGAkgASgNEhYKDmlzb2xhdGlvbl9tb2RlGAogASgJIlwKHFVwZGF0ZUFkbWluSW50ZXJmYWNlUmVzcG9uc2USPAoJaW50ZXJmYWNlGAEgASgLMikud2lsbGlhbS5hZG1pbi52MS5BZG1pbldpcmVndWFyZEludGVyZmFjZSIpChtEZWxldGVBZG1pbkludGVyZmFjZVJlcXVlc3QSCgoCaWQYASABKAkiJwoZUm90YXRlSW50ZXJmYWNlS2V5UmVxdWVzdBIKCgJpZBgBIAEoCSJ0ChpSb3RhdGVJbnRlcmZhY2VLZXlSZXNwb25zZRI8CglpbnRlcmZhY2UYASABKAsyKS53aWxsaWFtLmFkbWluLnYxLkFkbWluV2lyZWd1YXJkSW50ZXJmYWNlEhgKEHVwZGF0ZWRfcGVlcl9pZHMYAiADKAkidgoMQWxsb3dlZEVtYWlsEhQKDGludGVyZmFjZV9pZBgBIAEoCRINCgVlbWFpbBgCIAEoCRIuCgpjcmVhdGVkX2F0GAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIRCglydWxlX3R5cGUYBCABKAkiMAoYTGlzdEFsbG93ZWRFbWFpbHNSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCSJLChlMaXN0QWxsb3dlZEVtYWlsc1Jlc3BvbnNlEi4KBmVtYWlscxgBIAMoCzIeLndpbGxpYW0uYWRtaW4udjEuQWxsb3dlZEVtYWlsIlMKGUNyZWF0ZUFsbG93ZWRFbWFpbFJlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEg0KBWVtYWlsGAIgASgJEhEKCXJ1bGVfdHlwZRgDIAEoCSJECh5QcmV2aWV3QWxsb3dlZEVtYWlsUnVsZVJlcXVlc3QSEQoJcnVsZV90eXBlGAEgASgJEg8KB3BhdHRlcm4YAiABKAkiMQofUHJldmlld0FsbG93ZWRFbWFpbFJ1bGVSZXNwb25zZRIOCgZlbWFpbHMYASADKAkiVwoZRGVsZXRlQWxsb3dlZEVtYWlsUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkSDQoFZW1haWwYAiABKAkSFQoNc3VzcGVuZF9wZWVycxgDIAEoCCJSChpEZWxldGVBbGxvd2VkRW1haWxSZXNwb25zZRIYChByZW1vdmVkX3BlZXJfaWRzGAEgAygJEhoKEnN1c3BlbmRlZF9wZWVyX2lkcxgCIAMoCSJqCg5JbnRlcmZhY2VHcm91cBIUCgxpbnRlcmZhY2VfaWQYASABKAkSEgoKZ3JvdXBfbmFtZRgCIAEoCRIuCgpjcmVhdGVkX2F0GAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCIyChpMaXN0SW50ZXJmYWNlR3JvdXBzUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkiTwobTGlzdEludGVyZmFjZUdyb3Vwc1Jlc3BvbnNlEjAKBmdyb3VwcxgBIAMoCzIgLndpbGxpYW0uYWRtaW4udjEuSW50ZXJmYWNlR3JvdXAiRwobQ3JlYXRlSW50ZXJmYWNlR3JvdXBSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRISCgpncm91cF9uYW1lGAIgASgJIl4KG0RlbGV0ZUludGVyZmFjZUdyb3VwUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkSEgoKZ3JvdXBfbmFtZRgCIAEoCRIVCg1zdXNwZW5kX3BlZXJzGAMgASgIIlQKHERlbGV0ZUludGVyZmFjZUdyb3VwUmVzcG9uc2USGAoQcmVtb3ZlZF9wZWVyX2lkcxgBIAMoCRIaChJzdXNwZW5kZWRfcGVlcl9pZHMYAiADKAki/AEKCUFkbWluUGVlchIPCgdwZWVyX2lkGAEgASgJEg0KBWVtYWlsGAIgASgJEhQKDGludGVyZmFjZV9pZBgDIAEoCRISCgphbGxvd2VkX2lwGAQgASgJEi4KCmNyZWF0ZWRfYXQYBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEi4KCmV4cGlyZXNfYXQYBiABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEjAKDHN1c3BlbmRlZF9hdBgHIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEwoLZGV2aWNlX25hbWUYCCABKAkiLQoVTGlzdEFkbWluUGVlcnNSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCSJEChZMaXN0QWRtaW5QZWVyc1Jlc3BvbnNlEioKBXBlZXJzGAEgAygLMhsud2lsbGlhbS5hZG1pbi52MS5BZG1pblBlZXIiKQoWRGVsZXRlQWRtaW5QZWVyUmVxdWVzdBIPCgdwZWVyX2lkGAEgASgJIioKF1N1c3BlbmRBZG1pblBlZXJSZXF1ZXN0Eg8KB3BlZXJfaWQYASABKAkiKQoWUmVzdW1lQWRtaW5QZWVyUmVxdWVzdBIPCgdwZWVyX2lkGAEgASgJIn4KGkNyZWF0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIQCghlbmRwb2ludBgCIAEoCRITCgthbGxvd2VkX2lwcxgDIAMoCRISCgpwdWJsaWNfa2V5GAQgASgJEg8KB2FkZHJlc3MYBSABKAkibQobQ3JlYXRlV2lyZWd1YXJkUGVlclJlc3BvbnNlEhQKDGludGVyZmFjZV9pZBgBIAEoCRIPCgdwZWVyX2lkGAIgASgJEhIKCmFsbG93ZWRfaXAYAyABKAkSEwoLcGVlcl9jb25maWcYBCABKAkiLQoaRGVsZXRlV2lyZWd1YXJkUGVlclJlcXVlc3QSDwoHcGVlcl9pZBgBIAEoCSJiCiRVcGRhdGVXaXJlZ3VhcmRQZWVyQWxsb3dlZElQc1JlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJEg8KB3BlZXJfaWQYAiABKAkSEwoLYWxsb3dlZF9pcHMYAyADKAkiOwoKQWNjZXNzUnVsZRIMCgRjaWRyGAEgASgJEhAKCHByb3RvY29sGAIgASgJEg0KBXBvcnRzGAMgASgJIo8BChxTeW5jUGVlckZpcmV3YWxsUnVsZXNSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIXCg9wZWVyX2FsbG93ZWRfaXAYAiABKAkSEwoLYWxsb3dlZF9pcHMYAyADKAkSKwoFcnVsZXMYBCADKAsyHC53aWxsaWFtLmFkbWluLnYxLkFjY2Vzc1J1bGUiOQoeUmVtb3ZlUGVlckZpcmV3YWxsUnVsZXNSZXF1ZXN0EhcKD3BlZXJfYWxsb3dlZF9pcBgBIAEoCSKFAQoOSW50ZXJmYWNlUm91dGUSFAoMaW50ZXJmYWNlX2lkGAEgASgJEgwKBGNpZHIYAiABKAkSLgoKY3JlYXRlZF9hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEAoIcHJvdG9jb2wYBCABKAkSDQoFcG9ydHMYBSABKAkiewoJUGVlclJvdXRlEg8KB3BlZXJfaWQYASABKAkSDAoEY2lkchgCIAEoCRIuCgpjcmVhdGVkX2F0GAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIQCghwcm90b2NvbBgEIAEoCRINCgVwb3J0cxgFIAEoCSIyChpMaXN0SW50ZXJmYWNlUm91dGVzUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkiTwobTGlzdEludGVyZmFjZVJvdXRlc1Jlc3BvbnNlEjAKBnJvdXRlcxgBIAMoCzIgLndpbGxpYW0uYWRtaW4udjEuSW50ZXJmYWNlUm91dGUiYgobQ3JlYXRlSW50ZXJmYWNlUm91dGVSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIMCgRjaWRyGAIgASgJEhAKCHByb3RvY29sGAMgASgJEg0KBXBvcnRzGAQgASgJImIKG0RlbGV0ZUludGVyZmFjZVJvdXRlUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkSDAoEY2lkchgCIAEoCRIQCghwcm90b2NvbBgDIAEoCRINCgVwb3J0cxgEIAEoCSIoChVMaXN0UGVlclJvdXRlc1JlcXVlc3QSDwoHcGVlcl9pZBgBIAEoCSJFChZMaXN0UGVlclJvdXRlc1Jlc3BvbnNlEisKBnJvdXRlcxgBIAMoCzIbLndpbGxpYW0uYWRtaW4udjEuUGVlclJvdXRlIlgKFkNyZWF0ZVBlZXJSb3V0ZVJlcXVlc3QSDwoHcGVlcl9pZBgBIAEoCRIMCgRjaWRyGAIgASgJEhAKCHByb3RvY29sGAMgASgJEg0KBXBvcnRzGAQgASgJIlgKFkRlbGV0ZVBlZXJSb3V0ZVJlcXVlc3QSDwoHcGVlcl9pZBgBIAEoCRIMCgRjaWRyGAIgASgJEhAKCHByb3RvY29sGAMgASgJEg0KBXBvcnRzGAQgASgJIqcBCgxJcEFsbG9jYXRpb24SFAoMaW50ZXJmYWNlX2lkGAEgASgJEg8KB2FkZHJlc3MYAiABKAkSDwoHcGVlcl9pZBgDIAEoCRIvCgtyZWxlYXNlZF9hdBgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLgoKY3JlYXRlZF9hdBgFIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAieAoNSXBSZXNlcnZhdGlvbhIUCgxpbnRlcmZhY2VfaWQYASABKAkSDAoEY2lkchgCIAEoCRITCgtkZXNjcmlwdGlvbhgDIAEoCRIuCgpjcmVhdGVkX2F0GAQgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCIwChhMaXN0SXBBbGxvY2F0aW9uc1JlcXVlc3QSFAoMaW50ZXJmYWNlX2lkGAEgASgJIocBChlMaXN0SXBBbGxvY2F0aW9uc1Jlc3BvbnNlEjMKC2FsbG9jYXRpb25zGAEgAygLMh4ud2lsbGlhbS5hZG1pbi52MS5JcEFsbG9jYXRpb24SNQoMcmVzZXJ2YXRpb25zGAIgAygLMh8ud2lsbGlhbS5hZG1pbi52MS5JcFJlc2VydmF0aW9uIlUKGkNyZWF0ZUlwUmVzZXJ2YXRpb25SZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIMCgRjaWRyGAIgASgJEhMKC2Rlc2NyaXB0aW9uGAMgASgJIkAKGkRlbGV0ZUlwUmVzZXJ2YXRpb25SZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCRIMCgRjaWRyGAIgASgJImQKE0FkbWluUm9sZUFzc2lnbm1lbnQSDwoHc3ViamVjdBgBIAEoCRIMCgRyb2xlGAIgASgJEi4KCmNyZWF0ZWRfYXQYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wIl4KIExpc3RBZG1pblJvbGVBc3NpZ25tZW50c1Jlc3BvbnNlEjoKC2Fzc2lnbm1lbnRzGAEgAygLMiUud2lsbGlhbS5hZG1pbi52MS5BZG1pblJvbGVBc3NpZ25tZW50Ij4KHVNldEFkbWluUm9sZUFzc2lnbm1lbnRSZXF1ZXN0Eg8KB3N1YmplY3QYASABKAkSDAoEcm9sZRgCIAEoCSIzCiBEZWxldGVBZG1pblJvbGVBc3NpZ25tZW50UmVxdWVzdBIPCgdzdWJqZWN0GAEgASgJInAKCFBlZXJTdGF0Eg8KB3BlZXJfaWQYASABKAkSFAoMaW50ZXJmYWNlX2lkGAIgASgJEhAKCHJ4X2J5dGVzGAMgASgEEhAKCHR4X2J5dGVzGAQgASgEEhkKEWxhc3RfaGFuZHNoYWtlX2F0GAUgASgDIkIKFUxpc3RQZWVyU3RhdHNSZXNwb25zZRIpCgVzdGF0cxgBIAMoCzIaLndpbGxpYW0uYWRtaW4udjEuUGVlclN0YXQiKQoYR2V0RmlyZXdhbGxSdWxlc1Jlc3BvbnNlEg0KBXJ1bGVzGAEgASgJIjcKD1dpcmVndWFyZENvbmZpZxIUCgxpbnRlcmZhY2VfaWQYASABKAkSDgoGY29uZmlnGAIgASgJIjMKG0xpc3RXaXJlZ3VhcmRDb25maWdzUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkiUgocTGlzdFdpcmVndWFyZENvbmZpZ3NSZXNwb25zZRIyCgdjb25maWdzGAEgAygLMiEud2lsbGlhbS5hZG1pbi52MS5XaXJlZ3VhcmRDb25maWciVQoPUmVjb25jaWxlQ2hhbmdlEgwKBGtpbmQYASABKAkSFAoMaW50ZXJmYWNlX2lkGAIgASgJEg4KBnRhcmdldBgDIAEoCRIOCgZkZXRhaWwYBCABKAkiVAoeUGxhbldpcmVndWFyZFJlY29uY2lsZVJlc3BvbnNlEjIKB2NoYW5nZXMYASADKAsyIS53aWxsaWFtLmFkbWluLnYxLlJlY29uY2lsZUNoYW5nZSLNAQoKQXVkaXRFdmVudBIKCgJpZBgBIAEoCRIvCgtvY2N1cnJlZF9hdBgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASDQoFYWN0b3IYAyABKAkSDgoGYWN0aW9uGAQgASgJEhMKC3RhcmdldF90eXBlGAUgASgJEhEKCXRhcmdldF9pZBgGIAEoCRITCgtiZWZvcmVfanNvbhgHIAEoCRISCgphZnRlcl9qc29uGAggASgJEhIKCnJlcXVlc3RfaWQYCSABKAki3AEKFkxpc3RBdWRpdEV2ZW50c1JlcXVlc3QSDQoFYWN0b3IYASABKAkSDgoGYWN0aW9uGAIgASgJEhMKC3RhcmdldF90eXBlGAMgASgJEhEKCXRhcmdldF9pZBgEIAEoCRIpCgVzaW5jZRgFIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASKQoFdW50aWwYBiABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhEKCXBhZ2Vfc2l6ZRgHIAEoBRISCgpwYWdlX3Rva2VuGAggASgJImAKF0xpc3RBdWRpdEV2ZW50c1Jlc3BvbnNlEiwKBmV2ZW50cxgBIAMoCzIcLndpbGxpYW0uYWRtaW4udjEuQXVkaXRFdmVudBIXCg9uZXh0X3BhZ2VfdG9rZW4YAiABKAkytR8KE1dpbGxpYW1BZG1pblNlcnZpY2USVwoOTGlzdEludGVyZmFjZXMSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaLS53aWxsaWFtLmFkbWluLnYxLkxpc3RBZG1pbkludGVyZmFjZXNSZXNwb25zZRJnCgxHZXRJbnRlcmZhY2USKi53aWxsaWFtLmFkbWluLnYxLkdldEFkbWluSW50ZXJmYWNlUmVxdWVzdBorLndpbGxpYW0uYWRtaW4udjEuR2V0QWRtaW5JbnRlcmZhY2VSZXNwb25zZRJwCg9DcmVhdGVJbnRlcmZhY2USLS53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUFkbWluSW50ZXJmYWNlUmVxdWVzdBouLndpbGxpYW0uYWRtaW4udjEuQ3JlYXRlQWRtaW5JbnRlcmZhY2VSZXNwb25zZRJwCg9VcGRhdGVJbnRlcmZhY2USLS53aWxsaWFtLmFkbWluLnYxLlVwZGF0ZUFkbWluSW50ZXJmYWNlUmVxdWVzdBouLndpbGxpYW0uYWRtaW4udjEuVXBkYXRlQWRtaW5JbnRlcmZhY2VSZXNwb25zZRJYCg9EZWxldGVJbnRlcmZhY2USLS53aWxsaWFtLmFkbWluLnYxLkRlbGV0ZUFkbWluSW50ZXJmYWNlUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJvChJSb3RhdGVJbnRlcmZhY2VLZXkSKy53aWxsaWFtLmFkbWluLnYxLlJvdGF0ZUludGVyZmFjZUtleVJlcXVlc3QaLC53aWxsaWFtLmFkbWluLnYxLlJvdGF0ZUludGVyZmFjZUtleVJlc3BvbnNlEmwKEUxpc3RBbGxvd2VkRW1haWxzEioud2lsbGlhbS5hZG1pbi52MS5MaXN0QWxsb3dlZEVtYWlsc1JlcXVlc3QaKy53aWxsaWFtLmFkbWluLnYxLkxpc3RBbGxvd2VkRW1haWxzUmVzcG9uc2USWQoSQ3JlYXRlQWxsb3dlZEVtYWlsEisud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVBbGxvd2VkRW1haWxSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5Em8KEkRlbGV0ZUFsbG93ZWRFbWFpbBIrLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlQWxsb3dlZEVtYWlsUmVxdWVzdBosLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlQWxsb3dlZEVtYWlsUmVzcG9uc2USfgoXUHJldmlld0FsbG93ZWRFbWFpbFJ1bGUSMC53aWxsaWFtLmFkbWluLnYxLlByZXZpZXdBbGxvd2VkRW1haWxSdWxlUmVxdWVzdBoxLndpbGxpYW0uYWRtaW4udjEuUHJldmlld0FsbG93ZWRFbWFpbFJ1bGVSZXNwb25zZRJyChNMaXN0SW50ZXJmYWNlR3JvdXBzEiwud2lsbGlhbS5hZG1pbi52MS5MaXN0SW50ZXJmYWNlR3JvdXBzUmVxdWVzdBotLndpbGxpYW0uYWRtaW4udjEuTGlzdEludGVyZmFjZUdyb3Vwc1Jlc3BvbnNlEl0KFENyZWF0ZUludGVyZmFjZUdyb3VwEi0ud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVJbnRlcmZhY2VHcm91cFJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSdQoURGVsZXRlSW50ZXJmYWNlR3JvdXASLS53aWxsaWFtLmFkbWluLnYxLkRlbGV0ZUludGVyZmFjZUdyb3VwUmVxdWVzdBouLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlSW50ZXJmYWNlR3JvdXBSZXNwb25zZRJeCglMaXN0UGVlcnMSJy53aWxsaWFtLmFkbWluLnYxLkxpc3RBZG1pblBlZXJzUmVxdWVzdBooLndpbGxpYW0uYWRtaW4udjEuTGlzdEFkbWluUGVlcnNSZXNwb25zZRJOCgpEZWxldGVQZWVyEigud2lsbGlhbS5hZG1pbi52MS5EZWxldGVBZG1pblBlZXJSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5ElAKC1N1c3BlbmRQZWVyEikud2lsbGlhbS5hZG1pbi52MS5TdXNwZW5kQWRtaW5QZWVyUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJOCgpSZXN1bWVQZWVyEigud2lsbGlhbS5hZG1pbi52MS5SZXN1bWVBZG1pblBlZXJSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EnIKE0NyZWF0ZVdpcmVndWFyZFBlZXISLC53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0Gi0ud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVXaXJlZ3VhcmRQZWVyUmVzcG9uc2USbwodVXBkYXRlV2lyZWd1YXJkUGVlckFsbG93ZWRJUHMSNi53aWxsaWFtLmFkbWluLnYxLlVwZGF0ZVdpcmVndWFyZFBlZXJBbGxvd2VkSVBzUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJbChNEZWxldGVXaXJlZ3VhcmRQZWVyEiwud2lsbGlhbS5hZG1pbi52MS5EZWxldGVXaXJlZ3VhcmRQZWVyUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJfChVTeW5jUGVlckZpcmV3YWxsUnVsZXMSLi53aWxsaWFtLmFkbWluLnYxLlN5bmNQZWVyRmlyZXdhbGxSdWxlc1JlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSYwoXUmVtb3ZlUGVlckZpcmV3YWxsUnVsZXMSMC53aWxsaWFtLmFkbWluLnYxLlJlbW92ZVBlZXJGaXJld2FsbFJ1bGVzUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJFChNFbnN1cmVGaXJld2FsbENoYWluEhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EnIKE0xpc3RJbnRlcmZhY2VSb3V0ZXMSLC53aWxsaWFtLmFkbWluLnYxLkxpc3RJbnRlcmZhY2VSb3V0ZXNSZXF1ZXN0Gi0ud2lsbGlhbS5hZG1pbi52MS5MaXN0SW50ZXJmYWNlUm91dGVzUmVzcG9uc2USXQoUQ3JlYXRlSW50ZXJmYWNlUm91dGUSLS53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUludGVyZmFjZVJvdXRlUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJdChREZWxldGVJbnRlcmZhY2VSb3V0ZRItLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlSW50ZXJmYWNlUm91dGVSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EmMKDkxpc3RQZWVyUm91dGVzEicud2lsbGlhbS5hZG1pbi52MS5MaXN0UGVlclJvdXRlc1JlcXVlc3QaKC53aWxsaWFtLmFkbWluLnYxLkxpc3RQZWVyUm91dGVzUmVzcG9uc2USUwoPQ3JlYXRlUGVlclJvdXRlEigud2lsbGlhbS5hZG1pbi52MS5DcmVhdGVQZWVyUm91dGVSZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5ElMKD0RlbGV0ZVBlZXJSb3V0ZRIoLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlUGVlclJvdXRlUmVxdWVzdBoWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRJsChFMaXN0SXBBbGxvY2F0aW9ucxIqLndpbGxpYW0uYWRtaW4udjEuTGlzdElwQWxsb2NhdGlvbnNSZXF1ZXN0Gisud2lsbGlhbS5hZG1pbi52MS5MaXN0SXBBbGxvY2F0aW9uc1Jlc3BvbnNlElsKE0NyZWF0ZUlwUmVzZXJ2YXRpb24SLC53aWxsaWFtLmFkbWluLnYxLkNyZWF0ZUlwUmVzZXJ2YXRpb25SZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5ElsKE0RlbGV0ZUlwUmVzZXJ2YXRpb24SLC53aWxsaWFtLmFkbWluLnYxLkRlbGV0ZUlwUmVzZXJ2YXRpb25SZXF1ZXN0GhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5EmYKGExpc3RBZG1pblJvbGVBc3NpZ25tZW50cxIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRoyLndpbGxpYW0uYWRtaW4udjEuTGlzdEFkbWluUm9sZUFzc2lnbm1lbnRzUmVzcG9uc2USYQoWU2V0QWRtaW5Sb2xlQXNzaWdubWVudBIvLndpbGxpYW0uYWRtaW4udjEuU2V0QWRtaW5Sb2xlQXNzaWdubWVudFJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSZwoZRGVsZXRlQWRtaW5Sb2xlQXNzaWdubWVudBIyLndpbGxpYW0uYWRtaW4udjEuRGVsZXRlQWRtaW5Sb2xlQXNzaWdubWVudFJlcXVlc3QaFi5nb29nbGUucHJvdG9idWYuRW1wdHkSUAoNTGlzdFBlZXJTdGF0cxIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRonLndpbGxpYW0uYWRtaW4udjEuTGlzdFBlZXJTdGF0c1Jlc3BvbnNlElYKEEdldEZpcmV3YWxsUnVsZXMSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaKi53aWxsaWFtLmFkbWluLnYxLkdldEZpcmV3YWxsUnVsZXNSZXNwb25zZRJ1ChRMaXN0V2lyZWd1YXJkQ29uZmlncxItLndpbGxpYW0uYWRtaW4udjEuTGlzdFdpcmVndWFyZENvbmZpZ3NSZXF1ZXN0Gi4ud2lsbGlhbS5hZG1pbi52MS5MaXN0V2lyZWd1YXJkQ29uZmlnc1Jlc3BvbnNlEmIKFlBsYW5XaXJlZ3VhcmRSZWNvbmNpbGUSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaMC53aWxsaWFtLmFkbWluLnYxLlBsYW5XaXJlZ3VhcmRSZWNvbmNpbGVSZXNwb25zZRJmCg9MaXN0QXVkaXRFdmVudHMSKC53aWxsaWFtLmFkbWluLnYxLkxpc3RBdWRpdEV2ZW50c1JlcXVlc3QaKS53aWxsaWFtLmFkbWluLnYxLkxpc3RBdWRpdEV2ZW50c1Jlc3BvbnNlYgZwcm90bzM", [file_google_protobuf_empty, file_google_protobuf_timestamp]);
 
 /**
  * Describes the message william.admin.v1.AdminWireguardInterface.
@@ -74,208 +74,425 @@ export const UpdateAdminInterfaceResponseSchema = /*@__PURE__*/
 export const DeleteAdminInterfaceRequestSchema = /*@__PURE__*/
   messageDesc(file_proto_admin_v1_admin, 8);
 
+/**
+ * Describes the message william.admin.v1.RotateInterfaceKeyRequest.
+ * Use `create(RotateInterfaceKeyRequestSchema)` to create a new message.
+ */
+export const RotateInterfaceKeyRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 9);
+
+/**
+ * Describes the message william.admin.v1.RotateInterfaceKeyResponse.
+ * Use `create(RotateInterfaceKeyResponseSchema)` to create a new message.
+ */
+export const RotateInterfaceKeyResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 10);
+
 /**
  * Describes the message william.admin.v1.AllowedEmail.
  * Use `create(AllowedEmailSchema)` to create a new message.
  */
 export const AllowedEmailSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 9);
+  messageDesc(file_proto_admin_v1_admin, 11);
 
 /**
  * Describes the message william.admin.v1.ListAllowedEmailsRequest.
  * Use `create(ListAllowedEmailsRequestSchema)` to create a new message.
  */
 export const ListAllowedEmailsRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 10);
+  messageDesc(file_proto_admin_v1_admin, 12);
 
 /**
  * Describes the message william.admin.v1.ListAllowedEmailsResponse.
  * Use `create(ListAllowedEmailsResponseSchema)` to create a new message.
  */
 export const ListAllowedEmailsResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 11);
+  messageDesc(file_proto_admin_v1_admin, 13);
 
 /**
  * Describes the message william.admin.v1.CreateAllowedEmailRequest.
  * Use `create(CreateAllowedEmailRequestSchema)` to create a new message.
  */
 export const CreateAllowedEmailRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 12);
+  messageDesc(file_proto_admin_v1_admin, 14);
+
+/**
+ * Describes the message william.admin.v1.PreviewAllowedEmailRuleRequest.
+ * Use `create(PreviewAllowedEmailRuleRequestSchema)` to create a new message.
+ */
+export const PreviewAllowedEmailRuleRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 15);
+
+/**
+ * Describes the message william.admin.v1.PreviewAllowedEmailRuleResponse.
+ * Use `create(PreviewAllowedEmailRuleResponseSchema)` to create a new message.
+ */
+export const PreviewAllowedEmailRuleResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 16);
 
 /**
  * Describes the message william.admin.v1.DeleteAllowedEmailRequest.
  * Use `create(DeleteAllowedEmailRequestSchema)` to create a new message.
  */
 export const DeleteAllowedEmailRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 13);
+  messageDesc(file_proto_admin_v1_admin, 17);
+
+/**
+ * Describes the message william.admin.v1.DeleteAllowedEmailResponse.
+ * Use `create(DeleteAllowedEmailResponseSchema)` to create a new message.
+ */
+export const DeleteAllowedEmailResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 18);
+
+/**
+ * Describes the message william.admin.v1.InterfaceGroup.
+ * Use `create(InterfaceGroupSchema)` to create a new message.
+ */
+export const InterfaceGroupSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 19);
+
+/**
+ * Describes the message william.admin.v1.ListInterfaceGroupsRequest.
+ * Use `create(ListInterfaceGroupsRequestSchema)` to create a new message.
+ */
+export const ListInterfaceGroupsRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 20);
+
+/**
+ * Describes the message william.admin.v1.ListInterfaceGroupsResponse.
+ * Use `create(ListInterfaceGroupsResponseSchema)` to create a new message.
+ */
+export const ListInterfaceGroupsResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 21);
+
+/**
+ * Describes the message william.admin.v1.CreateInterfaceGroupRequest.
+ * Use `create(CreateInterfaceGroupRequestSchema)` to create a new message.
+ */
+export const CreateInterfaceGroupRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 22);
+
+/**
+ * Describes the message william.admin.v1.DeleteInterfaceGroupRequest.
+ * Use `create(DeleteInterfaceGroupRequestSchema)` to create a new message.
+ */
+export const DeleteInterfaceGroupRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 23);
+
+/**
+ * Describes the message william.admin.v1.DeleteInterfaceGroupResponse.
+ * Use `create(DeleteInterfaceGroupResponseSchema)` to create a new message.
+ */
+export const DeleteInterfaceGroupResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 24);
 
 /**
  * Describes the message william.admin.v1.AdminPeer.
  * Use `create(AdminPeerSchema)` to create a new message.
  */
 export const AdminPeerSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 14);
+  messageDesc(file_proto_admin_v1_admin, 25);
 
 /**
  * Describes the message william.admin.v1.ListAdminPeersRequest.
  * Use `create(ListAdminPeersRequestSchema)` to create a new message.
  */
 export const ListAdminPeersRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 15);
+  messageDesc(file_proto_admin_v1_admin, 26);
 
 /**
  * Describes the message william.admin.v1.ListAdminPeersResponse.
  * Use `create(ListAdminPeersResponseSchema)` to create a new message.
  */
 export const ListAdminPeersResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 16);
+  messageDesc(file_proto_admin_v1_admin, 27);
 
 /**
  * Describes the message william.admin.v1.DeleteAdminPeerRequest.
  * Use `create(DeleteAdminPeerRequestSchema)` to create a new message.
  */
 export const DeleteAdminPeerRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 17);
+  messageDesc(file_proto_admin_v1_admin, 28);
+
+/**
+ * Describes the message william.admin.v1.SuspendAdminPeerRequest.
+ * Use `create(SuspendAdminPeerRequestSchema)` to create a new message.
+ */
+export const SuspendAdminPeerRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 29);
+
+/**
+ * Describes the message william.admin.v1.ResumeAdminPeerRequest.
+ * Use `create(ResumeAdminPeerRequestSchema)` to create a new message.
+ */
+export const ResumeAdminPeerRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 30);
 
 /**
  * Describes the message william.admin.v1.CreateWireguardPeerRequest.
  * Use `create(CreateWireguardPeerRequestSchema)` to create a new message.
  */
 export const CreateWireguardPeerRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 18);
+  messageDesc(file_proto_admin_v1_admin, 31);
 
 /**
  * Describes the message william.admin.v1.CreateWireguardPeerResponse.
  * Use `create(CreateWireguardPeerResponseSchema)` to create a new message.
  */
 export const CreateWireguardPeerResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 19);
+  messageDesc(file_proto_admin_v1_admin, 32);
 
 /**
  * Describes the message william.admin.v1.DeleteWireguardPeerRequest.
  * Use `create(DeleteWireguardPeerRequestSchema)` to create a new message.
  */
 export const DeleteWireguardPeerRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 20);
+  messageDesc(file_proto_admin_v1_admin, 33);
 
 /**
  * Describes the message william.admin.v1.UpdateWireguardPeerAllowedIPsRequest.
  * Use `create(UpdateWireguardPeerAllowedIPsRequestSchema)` to create a new message.
  */
 export const UpdateWireguardPeerAllowedIPsRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 21);
+  messageDesc(file_proto_admin_v1_admin, 34);
+
+/**
+ * Describes the message william.admin.v1.AccessRule.
+ * Use `create(AccessRuleSchema)` to create a new message.
+ */
+export const AccessRuleSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 35);
+
+/**
+ * Describes the message william.admin.v1.SyncPeerFirewallRulesRequest.
+ * Use `create(SyncPeerFirewallRulesRequestSchema)` to create a new message.
+ */
+export const SyncPeerFirewallRulesRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 36);
+
+/**
+ * Describes the message william.admin.v1.RemovePeerFirewallRulesRequest.
+ * Use `create(RemovePeerFirewallRulesRequestSchema)` to create a new message.
+ */
+export const RemovePeerFirewallRulesRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 37);
 
 /**
  * Describes the message william.admin.v1.InterfaceRoute.
  * Use `create(InterfaceRouteSchema)` to create a new message.
  */
 export const InterfaceRouteSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 22);
+  messageDesc(file_proto_admin_v1_admin, 38);
 
 /**
  * Describes the message william.admin.v1.PeerRoute.
  * Use `create(PeerRouteSchema)` to create a new message.
  */
 export const PeerRouteSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 23);
+  messageDesc(file_proto_admin_v1_admin, 39);
 
 /**
  * Describes the message william.admin.v1.ListInterfaceRoutesRequest.
  * Use `create(ListInterfaceRoutesRequestSchema)` to create a new message.
  */
 export const ListInterfaceRoutesRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 24);
+  messageDesc(file_proto_admin_v1_admin, 40);
 
 /**
  * Describes the message william.admin.v1.ListInterfaceRoutesResponse.
  * Use `create(ListInterfaceRoutesResponseSchema)` to create a new message.
  */
 export const ListInterfaceRoutesResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 25);
+  messageDesc(file_proto_admin_v1_admin, 41);
 
 /**
  * Describes the message william.admin.v1.CreateInterfaceRouteRequest.
  * Use `create(CreateInterfaceRouteRequestSchema)` to create a new message.
  */
 export const CreateInterfaceRouteRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 26);
+  messageDesc(file_proto_admin_v1_admin, 42);
 
 /**
  * Describes the message william.admin.v1.DeleteInterfaceRouteRequest.
  * Use `create(DeleteInterfaceRouteRequestSchema)` to create a new message.
  */
 export const DeleteInterfaceRouteRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 27);
+  messageDesc(file_proto_admin_v1_admin, 43);
 
 /**
  * Describes the message william.admin.v1.ListPeerRoutesRequest.
  * Use `create(ListPeerRoutesRequestSchema)` to create a new message.
  */
 export const ListPeerRoutesRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 28);
+  messageDesc(file_proto_admin_v1_admin, 44);
 
 /**
  * Describes the message william.admin.v1.ListPeerRoutesResponse.
  * Use `create(ListPeerRoutesResponseSchema)` to create a new message.
  */
 export const ListPeerRoutesResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 29);
+  messageDesc(file_proto_admin_v1_admin, 45);
 
 /**
  * Describes the message william.admin.v1.CreatePeerRouteRequest.
  * Use `create(CreatePeerRouteRequestSchema)` to create a new message.
  */
 export const CreatePeerRouteRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 30);
+  messageDesc(file_proto_admin_v1_admin, 46);
 
 /**
  * Describes the message william.admin.v1.DeletePeerRouteRequest.
  * Use `create(DeletePeerRouteRequestSchema)` to create a new message.
  */
 export const DeletePeerRouteRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 31);
+  messageDesc(file_proto_admin_v1_admin, 47);
+
+/**
+ * Describes the message william.admin.v1.IpAllocation.
+ * Use `create(IpAllocationSchema)` to create a new message.
+ */
+export const IpAllocationSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 48);
+
+/**
+ * Describes the message william.admin.v1.IpReservation.
+ * Use `create(IpReservationSchema)` to create a new message.
+ */
+export const IpReservationSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 49);
+
+/**
+ * Describes the message william.admin.v1.ListIpAllocationsRequest.
+ * Use `create(ListIpAllocationsRequestSchema)` to create a new message.
+ */
+export const ListIpAllocationsRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 50);
+
+/**
+ * Describes the message william.admin.v1.ListIpAllocationsResponse.
+ * Use `create(ListIpAllocationsResponseSchema)` to create a new message.
+ */
+export const ListIpAllocationsResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 51);
+
+/**
+ * Describes the message william.admin.v1.CreateIpReservationRequest.
+ * Use `create(CreateIpReservationRequestSchema)` to create a new message.
+ */
+export const CreateIpReservationRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 52);
+
+/**
+ * Describes the message william.admin.v1.DeleteIpReservationRequest.
+ * Use `create(DeleteIpReservationRequestSchema)` to create a new message.
+ */
+export const DeleteIpReservationRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 53);
+
+/**
+ * Describes the message william.admin.v1.AdminRoleAssignment.
+ * Use `create(AdminRoleAssignmentSchema)` to create a new message.
+ */
+export const AdminRoleAssignmentSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 54);
+
+/**
+ * Describes the message william.admin.v1.ListAdminRoleAssignmentsResponse.
+ * Use `create(ListAdminRoleAssignmentsResponseSchema)` to create a new message.
+ */
+export const ListAdminRoleAssignmentsResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 55);
+
+/**
+ * Describes the message william.admin.v1.SetAdminRoleAssignmentRequest.
+ * Use `create(SetAdminRoleAssignmentRequestSchema)` to create a new message.
+ */
+export const SetAdminRoleAssignmentRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 56);
+
+/**
+ * Describes the message william.admin.v1.DeleteAdminRoleAssignmentRequest.
+ * Use `create(DeleteAdminRoleAssignmentRequestSchema)` to create a new message.
+ */
+export const DeleteAdminRoleAssignmentRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 57);
 
 /**
  * Describes the message william.admin.v1.PeerStat.
  * Use `create(PeerStatSchema)` to create a new message.
  */
 export const PeerStatSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 32);
+  messageDesc(file_proto_admin_v1_admin, 58);
 
 /**
  * Describes the message william.admin.v1.ListPeerStatsResponse.
  * Use `create(ListPeerStatsResponseSchema)` to create a new message.
  */
 export const ListPeerStatsResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 33);
+  messageDesc(file_proto_admin_v1_admin, 59);
 
 /**
  * Describes the message william.admin.v1.GetFirewallRulesResponse.
  * Use `create(GetFirewallRulesResponseSchema)` to create a new message.
  */
 export const GetFirewallRulesResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 34);
+  messageDesc(file_proto_admin_v1_admin, 60);
 
 /**
  * Describes the message william.admin.v1.WireguardConfig.
  * Use `create(WireguardConfigSchema)` to create a new message.
  */
 export const WireguardConfigSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 35);
+  messageDesc(file_proto_admin_v1_admin, 61);
 
 /**
  * Describes the message william.admin.v1.ListWireguardConfigsRequest.
  * Use `create(ListWireguardConfigsRequestSchema)` to create a new message.
  */
 export const ListWireguardConfigsRequestSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 36);
+  messageDesc(file_proto_admin_v1_admin, 62);
 
 /**
  * Describes the message william.admin.v1.ListWireguardConfigsResponse.
  * Use `create(ListWireguardConfigsResponseSchema)` to create a new message.
  */
 export const ListWireguardConfigsResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_admin_v1_admin, 37);
+  messageDesc(file_proto_admin_v1_admin, 63);
+
+/**
+ * Describes the message william.admin.v1.ReconcileChange.
+ * Use `create(ReconcileChangeSchema)` to create a new message.
+ */
+export const ReconcileChangeSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 64);
+
+/**
+ * Describes the message william.admin.v1.PlanWireguardReconcileResponse.
+ * Use `create(PlanWireguardReconcileResponseSchema)` to create a new message.
+ */
+export const PlanWireguardReconcileResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 65);
+
+/**
+ * Describes the message william.admin.v1.AuditEvent.
+ * Use `create(AuditEventSchema)` to create a new message.
+ */
+export const AuditEventSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 66);
+
+/**
+ * Describes the message william.admin.v1.ListAuditEventsRequest.
+ * Use `create(ListAuditEventsRequestSchema)` to create a new message.
+ */
+export const ListAuditEventsRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 67);
+
+/**
+ * Describes the message william.admin.v1.ListAuditEventsResponse.
+ * Use `create(ListAuditEventsResponseSchema)` to create a new message.
+ */
+export const ListAuditEventsResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_admin_v1_admin, 68);
 
 /**
  * @generated from service william.admin.v1.WilliamAdminService
@@ -4,7 +4,7 @@
 
 import type { GenFile, GenMessage, GenService } from "@bufbuild/protobuf/codegenv2";
 import type { Message } from "@bufbuild/protobuf";
-import type { EmptySchema } from "@bufbuild/protobuf/wkt";
+import type { EmptySchema, Timestamp } from "@bufbuild/protobuf/wkt";
 
 /**
  * Describes the file proto/server/v1/server.proto.
@@ -44,6 +44,21 @@ export declare type WireguardInterface = Message<"william.v1.WireguardInterface"
    * @generated from field: uint32 mtu = 6;
    */
   mtu: number;
+
+  /**
+   * @generated from field: string peer_key_policy = 7;
+   */
+  peerKeyPolicy: string;
+
+  /**
+   * @generated from field: int64 peer_ttl_seconds = 8;
+   */
+  peerTtlSeconds: bigint;
+
+  /**
+   * @generated from field: uint32 max_devices_per_user = 9;
+   */
+  maxDevicesPerUser: number;
 };
 
 /**
@@ -76,6 +91,16 @@ export declare type CreateWireguardPeerRequest = Message<"william.v1.CreateWireg
    * @generated from field: string wireguard_interface_id = 1;
    */
   wireguardInterfaceId: string;
+
+  /**
+   * @generated from field: string public_key = 2;
+   */
+  publicKey: string;
+
+  /**
+   * @generated from field: string device_name = 3;
+   */
+  deviceName: string;
 };
 
 /**
@@ -97,6 +122,11 @@ export declare type CreateWireguardPeerResponse = Message<"william.v1.CreateWire
    * @generated from field: string peer_config = 2;
    */
   peerConfig: string;
+
+  /**
+   * @generated from field: string device_name = 3;
+   */
+  deviceName: string;
 };
 
 /**
@@ -146,6 +176,21 @@ export declare type GetMyWireguardPeerResponse = Message<"william.v1.GetMyWiregu
    * @generated from field: string peer_config = 2;
    */
   peerConfig: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 3;
+   */
+  expiresAt?: Timestamp;
+
+  /**
+   * @generated from field: bool suspended = 4;
+   */
+  suspended: boolean;
+
+  /**
+   * @generated from field: string device_name = 5;
+   */
+  deviceName: string;
 };
 
 /**
@@ -183,6 +228,21 @@ export declare type GetMyWireguardPeerByInterfaceResponse = Message<"william.v1.
    * @generated from field: string peer_config = 2;
    */
   peerConfig: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 3;
+   */
+  expiresAt?: Timestamp;
+
+  /**
+   * @generated from field: bool suspended = 4;
+   */
+  suspended: boolean;
+
+  /**
+   * @generated from field: string device_name = 5;
+   */
+  deviceName: string;
 };
 
 /**
@@ -191,6 +251,110 @@ export declare type GetMyWireguardPeerByInterfaceResponse = Message<"william.v1.
  */
 export declare const GetMyWireguardPeerByInterfaceResponseSchema: GenMessage<GetMyWireguardPeerByInterfaceResponse>;
 
+/**
+ * @generated from message william.v1.MyWireguardPeer
+ */
+export declare type MyWireguardPeer = Message<"william.v1.MyWireguardPeer"> & {
+  /**
+   * @generated from field: string peer_id = 1;
+   */
+  peerId: string;
+
+  /**
+   * @generated from field: string interface_id = 2;
+   */
+  interfaceId: string;
+
+  /**
+   * @generated from field: string device_name = 3;
+   */
+  deviceName: string;
+
+  /**
+   * @generated from field: string allowed_ip = 4;
+   */
+  allowedIp: string;
+
+  /**
+   * @generated from field: string peer_config = 5;
+   */
+  peerConfig: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 6;
+   */
+  createdAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 7;
+   */
+  expiresAt?: Timestamp;
+
+  /**
+   * @generated from field: bool suspended = 8;
+   */
+  suspended: boolean;
+};
+
+/**
+ * Describes the message william.v1.MyWireguardPeer.
+ * Use `create(MyWireguardPeerSchema)` to create a new message.
+ */
+export declare const MyWireguardPeerSchema: GenMessage<MyWireguardPeer>;
+
+/**
+ * @generated from message william.v1.ListMyPeersResponse
+ */
+export declare type ListMyPeersResponse = Message<"william.v1.ListMyPeersResponse"> & {
+  /**
+   * @generated from field: repeated william.v1.MyWireguardPeer peers = 1;
+   */
+  peers: MyWireguardPeer[];
+};
+
+/**
+ * Describes the message william.v1.ListMyPeersResponse.
+ * Use `create(ListMyPeersResponseSchema)` to create a new message.
+ */
+export declare const ListMyPeersResponseSchema: GenMessage<ListMyPeersResponse>;
+
+/**
+ * @generated from message william.v1.RenewMyWireguardPeerRequest
+ */
+export declare type RenewMyWireguardPeerRequest = Message<"william.v1.RenewMyWireguardPeerRequest"> & {
+  /**
+   * @generated from field: string peer_id = 1;
+   */
+  peerId: string;
+
+  /**
+   * @generated from field: int64 duration_seconds = 2;
+   */
+  durationSeconds: bigint;
+};
+
+/**
+ * Describes the message william.v1.RenewMyWireguardPeerRequest.
+ * Use `create(RenewMyWireguardPeerRequestSchema)` to create a new message.
+ */
+export declare const RenewMyWireguardPeerRequestSchema: GenMessage<RenewMyWireguardPeerRequest>;
+
+/**
+ * @generated from message william.v1.RenewMyWireguardPeerResponse
+ */
+export declare type RenewMyWireguardPeerResponse = Message<"william.v1.RenewMyWireguardPeerResponse"> & {
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 1;
+   */
+  expiresAt?: Timestamp;
+};
+
+/**
+ * Describes the message william.v1.RenewMyWireguardPeerResponse.
+ * Use `create(RenewMyWireguardPeerResponseSchema)` to create a new message.
+ */
+export declare const RenewMyWireguardPeerResponseSchema: GenMessage<RenewMyWireguardPeerResponse>;
+
 /**
  * @generated from message william.v1.PeerStatus
  */
@@ -284,6 +448,14 @@ export declare const WilliamService: GenService<{
     input: typeof GetMyWireguardPeerByInterfaceRequestSchema;
     output: typeof GetMyWireguardPeerByInterfaceResponseSchema;
   },
+  /**
+   * @generated from rpc william.v1.WilliamService.ListMyPeers
+   */
+  listMyPeers: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof ListMyPeersResponseSchema;
+  },
   /**
    * @generated from rpc william.v1.WilliamService.DeleteWireguardPeer
    */
@@ -292,6 +464,14 @@ export declare const WilliamService: GenService<{
     input: typeof DeleteWireguardPeerRequestSchema;
     output: typeof DeleteWireguardPeerResponseSchema;
   },
+  /**
+   * @generated from rpc william.v1.WilliamService.RenewMyWireguardPeer
+   */
+  renewMyWireguardPeer: {
+    methodKind: "unary";
+    input: typeof RenewMyWireguardPeerRequestSchema;
+    output: typeof RenewMyWireguardPeerResponseSchema;
+  },
   /**
    * @generated from rpc william.v1.WilliamService.ListPeerStatuses
    */
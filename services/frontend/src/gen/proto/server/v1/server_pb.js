@@ -3,13 +3,13 @@
 /* eslint-disable */
 
 import { fileDesc, messageDesc, serviceDesc } from "@bufbuild/protobuf/codegenv2";
-import { file_google_protobuf_empty } from "@bufbuild/protobuf/wkt";
+import { file_google_protobuf_empty, file_google_protobuf_timestamp } from "@bufbuild/protobuf/wkt";
 
 /**
  * Describes the file proto/server/v1/server.proto.
  */
 export const file_proto_server_v1_server = /*@__PURE__*/
-  fileDesc("Chxwcm90by9zZXJ2ZXIvdjEvc2VydmVyLnByb3RvEgp3aWxsaWFtLnYxInUKEldpcmVndWFyZEludGVyZmFjZRIKCgJpZBgBIAEoCRIMCgRuYW1lGAIgASgJEg8KB2FkZHJlc3MYAyABKAkSEwoLbGlzdGVuX3BvcnQYBCABKA0SEgoKcHVibGljX2tleRgFIAEoCRILCgNtdHUYBiABKA0iVQofTGlzdFdpcmVndWFyZEludGVyZmFjZXNSZXNwb25zZRIyCgppbnRlcmZhY2VzGAEgAygLMh4ud2lsbGlhbS52MS5XaXJlZ3VhcmRJbnRlcmZhY2UiPAoaQ3JlYXRlV2lyZWd1YXJkUGVlclJlcXVlc3QSHgoWd2lyZWd1YXJkX2ludGVyZmFjZV9pZBgBIAEoCSJDChtDcmVhdGVXaXJlZ3VhcmRQZWVyUmVzcG9uc2USDwoHcGVlcl9pZBgBIAEoCRITCgtwZWVyX2NvbmZpZxgCIAEoCSItChpEZWxldGVXaXJlZ3VhcmRQZWVyUmVxdWVzdBIPCgdwZWVyX2lkGAEgASgJIh0KG0RlbGV0ZVdpcmVndWFyZFBlZXJSZXNwb25zZSJCChpHZXRNeVdpcmVndWFyZFBlZXJSZXNwb25zZRIPCgdwZWVyX2lkGAEgASgJEhMKC3BlZXJfY29uZmlnGAIgASgJIjwKJEdldE15V2lyZWd1YXJkUGVlckJ5SW50ZXJmYWNlUmVxdWVzdBIUCgxpbnRlcmZhY2VfaWQYASABKAkiTQolR2V0TXlXaXJlZ3VhcmRQZWVyQnlJbnRlcmZhY2VSZXNwb25zZRIPCgdwZWVyX2lkGAEgASgJEhMKC3BlZXJfY29uZmlnGAIgASgJIooBCgpQZWVyU3RhdHVzEg8KB3BlZXJfaWQYASABKAkSFAoMaW50ZXJmYWNlX2lkGAIgASgJEhYKDmludGVyZmFjZV9uYW1lGAMgASgJEhAKCHJ4X2J5dGVzGAQgASgEEhAKCHR4X2J5dGVzGAUgASgEEhkKEWxhc3RfaGFuZHNoYWtlX2F0GAYgASgDIkQKGExpc3RQZWVyU3RhdHVzZXNSZXNwb25zZRIoCghzdGF0dXNlcxgBIAMoCzIWLndpbGxpYW0udjEuUGVlclN0YXR1czLvBAoOV2lsbGlhbVNlcnZpY2USXgoXTGlzdFdpcmVndWFyZEludGVyZmFjZXMSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaKy53aWxsaWFtLnYxLkxpc3RXaXJlZ3VhcmRJbnRlcmZhY2VzUmVzcG9uc2USZgoTQ3JlYXRlV2lyZWd1YXJkUGVlchImLndpbGxpYW0udjEuQ3JlYXRlV2lyZWd1YXJkUGVlclJlcXVlc3QaJy53aWxsaWFtLnYxLkNyZWF0ZVdpcmVndWFyZFBlZXJSZXNwb25zZRJUChJHZXRNeVdpcmVndWFyZFBlZXISFi5nb29nbGUucHJvdG9idWYuRW1wdHkaJi53aWxsaWFtLnYxLkdldE15V2lyZWd1YXJkUGVlclJlc3BvbnNlEoQBCh1HZXRNeVdpcmVndWFyZFBlZXJCeUludGVyZmFjZRIwLndpbGxpYW0udjEuR2V0TXlXaXJlZ3VhcmRQZWVyQnlJbnRlcmZhY2VSZXF1ZXN0GjEud2lsbGlhbS52MS5HZXRNeVdpcmVndWFyZFBlZXJCeUludGVyZmFjZVJlc3BvbnNlEmYKE0RlbGV0ZVdpcmVndWFyZFBlZXISJi53aWxsaWFtLnYxLkRlbGV0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0Gicud2lsbGlhbS52MS5EZWxldGVXaXJlZ3VhcmRQZWVyUmVzcG9uc2USUAoQTGlzdFBlZXJTdGF0dXNlcxIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRokLndpbGxpYW0udjEuTGlzdFBlZXJTdGF0dXNlc1Jlc3BvbnNlYgZwcm90bzM", [file_google_protobuf_empty]);
+  fileDesc("Chxwcm90by9zZXJ2ZXIvdjEvc2VydmVyLnByb3RvEgp3aWxsaWFtLnYxIsYBChJXaXJlZ3VhcmRJbnRlcmZhY2USCgoCaWQYASABKAkSDAoEbmFtZRgCIAEoCRIPCgdhZGRyZXNzGAMgASgJEhMKC2xpc3Rlbl9wb3J0GAQgASgNEhIKCnB1YmxpY19rZXkYBSABKAkSCwoDbXR1GAYgASgNEhcKD3BlZXJfa2V5X3BvbGljeRgHIAEoCRIYChBwZWVyX3R0bF9zZWNvbmRzGAggASgDEhwKFG1heF9kZXZpY2VzX3Blcl91c2VyGAkgASgNIlUKH0xpc3RXaXJlZ3VhcmRJbnRlcmZhY2VzUmVzcG9uc2USMgoKaW50ZXJmYWNlcxgBIAMoCzIeLndpbGxpYW0udjEuV2lyZWd1YXJkSW50ZXJmYWNlImUKGkNyZWF0ZVdpcmVndWFyZFBlZXJSZXF1ZXN0Eh4KFndpcmVndWFyZF9pbnRlcmZhY2VfaWQYASABKAkSEgoKcHVibGljX2tleRgCIAEoCRITCgtkZXZpY2VfbmFtZRgDIAEoCSJYChtDcmVhdGVXaXJlZ3VhcmRQZWVyUmVzcG9uc2USDwoHcGVlcl9pZBgBIAEoCRITCgtwZWVyX2NvbmZpZxgCIAEoCRITCgtkZXZpY2VfbmFtZRgDIAEoCSItChpEZWxldGVXaXJlZ3VhcmRQZWVyUmVxdWVzdBIPCgdwZWVyX2lkGAEgASgJIh0KG0RlbGV0ZVdpcmVndWFyZFBlZXJSZXNwb25zZSKaAQoaR2V0TXlXaXJlZ3VhcmRQZWVyUmVzcG9uc2USDwoHcGVlcl9pZBgBIAEoCRITCgtwZWVyX2NvbmZpZxgCIAEoCRIuCgpleHBpcmVzX2F0GAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIRCglzdXNwZW5kZWQYBCABKAgSEwoLZGV2aWNlX25hbWUYBSABKAkiPAokR2V0TXlXaXJlZ3VhcmRQZWVyQnlJbnRlcmZhY2VSZXF1ZXN0EhQKDGludGVyZmFjZV9pZBgBIAEoCSKlAQolR2V0TXlXaXJlZ3VhcmRQZWVyQnlJbnRlcmZhY2VSZXNwb25zZRIPCgdwZWVyX2lkGAEgASgJEhMKC3BlZXJfY29uZmlnGAIgASgJEi4KCmV4cGlyZXNfYXQYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhEKCXN1c3BlbmRlZBgEIAEoCBITCgtkZXZpY2VfbmFtZRgFIAEoCSLpAQoPTXlXaXJlZ3VhcmRQZWVyEg8KB3BlZXJfaWQYASABKAkSFAoMaW50ZXJmYWNlX2lkGAIgASgJEhMKC2RldmljZV9uYW1lGAMgASgJEhIKCmFsbG93ZWRfaXAYBCABKAkSEwoLcGVlcl9jb25maWcYBSABKAkSLgoKY3JlYXRlZF9hdBgGIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLgoKZXhwaXJlc19hdBgHIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEQoJc3VzcGVuZGVkGAggASgIIkEKE0xpc3RNeVBlZXJzUmVzcG9uc2USKgoFcGVlcnMYASADKAsyGy53aWxsaWFtLnYxLk15V2lyZWd1YXJkUGVlciJIChtSZW5ld015V2lyZWd1YXJkUGVlclJlcXVlc3QSDwoHcGVlcl9pZBgBIAEoCRIYChBkdXJhdGlvbl9zZWNvbmRzGAIgASgDIk4KHFJlbmV3TXlXaXJlZ3VhcmRQZWVyUmVzcG9uc2USLgoKZXhwaXJlc19hdBgBIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiigEKClBlZXJTdGF0dXMSDwoHcGVlcl9pZBgBIAEoCRIUCgxpbnRlcmZhY2VfaWQYAiABKAkSFgoOaW50ZXJmYWNlX25hbWUYAyABKAkSEAoIcnhfYnl0ZXMYBCABKAQSEAoIdHhfYnl0ZXMYBSABKAQSGQoRbGFzdF9oYW5kc2hha2VfYXQYBiABKAMiRAoYTGlzdFBlZXJTdGF0dXNlc1Jlc3BvbnNlEigKCHN0YXR1c2VzGAEgAygLMhYud2lsbGlhbS52MS5QZWVyU3RhdHVzMqIGCg5XaWxsaWFtU2VydmljZRJeChdMaXN0V2lyZWd1YXJkSW50ZXJmYWNlcxIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRorLndpbGxpYW0udjEuTGlzdFdpcmVndWFyZEludGVyZmFjZXNSZXNwb25zZRJmChNDcmVhdGVXaXJlZ3VhcmRQZWVyEiYud2lsbGlhbS52MS5DcmVhdGVXaXJlZ3VhcmRQZWVyUmVxdWVzdBonLndpbGxpYW0udjEuQ3JlYXRlV2lyZWd1YXJkUGVlclJlc3BvbnNlElQKEkdldE15V2lyZWd1YXJkUGVlchIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRomLndpbGxpYW0udjEuR2V0TXlXaXJlZ3VhcmRQZWVyUmVzcG9uc2UShAEKHUdldE15V2lyZWd1YXJkUGVlckJ5SW50ZXJmYWNlEjAud2lsbGlhbS52MS5HZXRNeVdpcmVndWFyZFBlZXJCeUludGVyZmFjZVJlcXVlc3QaMS53aWxsaWFtLnYxLkdldE15V2lyZWd1YXJkUGVlckJ5SW50ZXJmYWNlUmVzcG9uc2USRgoLTGlzdE15UGVlcnMSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaHy53aWxsaWFtLnYxLkxpc3RNeVBlZXJzUmVzcG9uc2USZgoTRGVsZXRlV2lyZWd1YXJkUGVlchImLndpbGxpYW0udjEuRGVsZXRlV2lyZWd1YXJkUGVlclJlcXVlc3QaJy53aWxsaWFtLnYxLkRlbGV0ZVdpcmVndWFyZFBlZXJSZXNwb25zZRJpChRSZW5ld015V2lyZWd1YXJkUGVlchInLndpbGxpYW0udjEuUmVuZXdNeVdpcmVndWFyZFBlZXJSZXF1ZXN0Gigud2lsbGlhbS52MS5SZW5ld015V2lyZWd1YXJkUGVlclJlc3BvbnNlElAKEExpc3RQZWVyU3RhdHVzZXMSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaJC53aWxsaWFtLnYxLkxpc3RQZWVyU3RhdHVzZXNSZXNwb25zZWIGcHJvdG8z", [file_google_protobuf_empty, file_google_protobuf_timestamp]);
 
 /**
  * Describes the message william.v1.WireguardInterface.
@@ -74,19 +74,47 @@ export const GetMyWireguardPeerByInterfaceRequestSchema = /*@__PURE__*/
 export const GetMyWireguardPeerByInterfaceResponseSchema = /*@__PURE__*/
   messageDesc(file_proto_server_v1_server, 8);
 
+/**
+ * Describes the message william.v1.MyWireguardPeer.
+ * Use `create(MyWireguardPeerSchema)` to create a new message.
+ */
+export const MyWireguardPeerSchema = /*@__PURE__*/
+  messageDesc(file_proto_server_v1_server, 9);
+
+/**
+ * Describes the message william.v1.ListMyPeersResponse.
+ * Use `create(ListMyPeersResponseSchema)` to create a new message.
+ */
+export const ListMyPeersResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_server_v1_server, 10);
+
+/**
+ * Describes the message william.v1.RenewMyWireguardPeerRequest.
+ * Use `create(RenewMyWireguardPeerRequestSchema)` to create a new message.
+ */
+export const RenewMyWireguardPeerRequestSchema = /*@__PURE__*/
+  messageDesc(file_proto_server_v1_server, 11);
+
+/**
+ * Describes the message william.v1.RenewMyWireguardPeerResponse.
+ * Use `create(RenewMyWireguardPeerResponseSchema)` to create a new message.
+ */
+export const RenewMyWireguardPeerResponseSchema = /*@__PURE__*/
+  messageDesc(file_proto_server_v1_server, 12);
+
 /**
  * Describes the message william.v1.PeerStatus.
  * Use `create(PeerStatusSchema)` to create a new message.
  */
 export const PeerStatusSchema = /*@__PURE__*/
-  messageDesc(file_proto_server_v1_server, 9);
+  messageDesc(file_proto_server_v1_server, 13);
 
 /**
  * Describes the message william.v1.ListPeerStatusesResponse.
  * Use `create(ListPeerStatusesResponseSchema)` to create a new message.
  */
 export const ListPeerStatusesResponseSchema = /*@__PURE__*/
-  messageDesc(file_proto_server_v1_server, 10);
+  messageDesc(file_proto_server_v1_server, 14);
 
 /**
  * @generated from service william.v1.WilliamService
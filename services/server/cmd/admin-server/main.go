@@ -5,7 +5,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"connectrpc.com/connect"
 	"github.com/nomuken/william/services/server/gen/proto/admin/v1/adminv1connect"
 	"github.com/nomuken/william/services/server/internal/domain"
 	"github.com/nomuken/william/services/server/internal/infra"
@@ -13,6 +15,12 @@ import (
 	"github.com/nomuken/william/services/server/internal/usecase"
 )
 
+// peerReaperActor is the audit actor of peers removed because they expired.
+const peerReaperActor = "system:peer-reaper"
+
+// reconcilerActor is the audit actor of drift fixed by the wireguard reconciler.
+const reconcilerActor = "system:reconciler"
+
 func main() {
 	database, err := infra.OpenDatabase()
 	if err != nil {
@@ -24,36 +32,165 @@ func main() {
 		log.Fatal(err)
 	}
 
-	peerStore := infra.NewSQLPeerStore(database)
-	interfaceStore := infra.NewSQLInterfaceStore(database)
+	secretBox, err := infra.LoadSecretBox()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encrypted, err := infra.EncryptStoredSecrets(context.Background(), database, secretBox, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if encrypted.PeerConfigs > 0 || encrypted.InterfaceKeys > 0 {
+		log.Printf("encrypted stored secrets: peer_configs=%d interface_keys=%d", encrypted.PeerConfigs, encrypted.InterfaceKeys)
+	}
+
+	peerStore := infra.NewSQLPeerStore(database, secretBox)
+	interfaceStore := infra.NewSQLInterfaceStore(database, secretBox)
 	allowedEmailStore := infra.NewSQLAllowedEmailStore(database)
 	interfaceRouteStore := infra.NewSQLInterfaceRouteStore(database)
 	peerRouteStore := infra.NewSQLPeerRouteStore(database)
 
+	ipReuseCooldown, err := infra.LoadIPReuseCooldown()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ipAllocationStore := infra.NewSQLIPAllocationStore(database, ipReuseCooldown)
+
 	devMode := os.Getenv("WILLIAM_DEV") == "1"
 	var repository domain.WireguardRepository
+	// The dev repository keeps no live state to drift from, so it goes without a reconciler.
+	var reconciler domain.WireguardReconciler
 	if devMode {
 		repository = infra.NewMockWireguardRepository(interfaceStore, peerStore)
 	} else {
-		repository = infra.NewCommandWireguardRepository()
+		firewall, err := infra.NewFirewallDriverFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		repository, err = infra.NewWireguardRepositoryFromEnv(firewall)
+		if err != nil {
+			log.Fatal(err)
+		}
 		infra.BootstrapWireguardOrFatal(context.Background(), repository, interfaceStore, peerStore, interfaceRouteStore, peerRouteStore)
+		reconciler = infra.NewWireguardReconciler(repository, firewall, interfaceStore, peerStore, interfaceRouteStore, peerRouteStore)
+	}
+
+	auditSinks, err := infra.LoadAuditSinks()
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditor := usecase.NewAuditor(infra.NewSQLAuditStore(database), auditSinks...)
+
+	groupStore := infra.NewSQLGroupStore(database)
+	adminService := usecase.NewAdminService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore, peerRouteStore, ipAllocationStore, groupStore, infra.NewSQLTransactor(database), reconciler, auditor)
+
+	reaperInterval, err := infra.LoadPeerReaperInterval()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if reaperInterval > 0 {
+		go runPeerReaper(domain.WithAuditActor(context.Background(), peerReaperActor), adminService, reaperInterval)
 	}
 
-	adminService := usecase.NewAdminService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore, peerRouteStore)
+	reconcileInterval, err := infra.LoadReconcileInterval()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if reconciler != nil && reconcileInterval > 0 {
+		go runReconciler(domain.WithAuditActor(context.Background(), reconcilerActor), adminService, reconcileInterval)
+	}
 
-	adminHandler := connecthandler.NewAdminHandler(adminService)
+	authenticator, err := infra.LoadAdminAuthenticator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	adminAccessService := usecase.NewAdminAccessService(infra.NewSQLAdminRoleStore(database), infra.LoadAdminBootstrapOwners(), auditor)
 
-	adminPath, adminConnectHandler := adminv1connect.NewWilliamAdminServiceHandler(adminHandler)
+	adminHandler := connecthandler.NewAdminHandler(adminService, adminAccessService)
+
+	adminPath, adminConnectHandler := adminv1connect.NewWilliamAdminServiceHandler(
+		adminHandler,
+		connect.WithInterceptors(connecthandler.NewRequestIDInterceptor(), connecthandler.NewAdminAuthInterceptor(authenticator, adminAccessService)),
+	)
 	mux := http.NewServeMux()
 	mux.Handle(adminPath, adminConnectHandler)
 
+	serviceTLSConfig, err := infra.LoadAdminServiceTLSConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := authenticator.CheckServiceTokenTransport(serviceTLSConfig != nil); err != nil {
+		log.Fatal(err)
+	}
+	var handler http.Handler = mux
+	if serviceTLSConfig != nil {
+		// william-server gets a mutual TLS listener of its own; the service token is only honoured there.
+		serviceAddr := os.Getenv("WILLIAM_ADMIN_SERVICE_ADDR")
+		if serviceAddr == "" {
+			serviceAddr = ":8443"
+		}
+		serviceServer := &http.Server{Addr: serviceAddr, Handler: mux, TLSConfig: serviceTLSConfig}
+		go func() {
+			log.Printf("William admin server listening for william-server on %s (mutual TLS)", serviceAddr)
+			if err := serviceServer.ListenAndServeTLS("", ""); err != nil {
+				log.Fatal(err)
+			}
+		}()
+		handler = infra.WithoutServiceToken(mux)
+	}
+
 	addr := os.Getenv("WILLIAM_ADMIN_ADDR")
 	if addr == "" {
 		addr = ":8081"
 	}
 
 	log.Printf("William admin server listening on %s", addr)
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	if err := http.ListenAndServe(addr, handler); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runPeerReaper removes expired peers from wireguard, the firewall and the database every interval.
+func runPeerReaper(ctx context.Context, adminService *usecase.AdminService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		reaped, err := adminService.ReapExpiredPeers(ctx, time.Now())
+		if len(reaped) > 0 {
+			log.Printf("reaped expired peers: %d", len(reaped))
+		}
+		if err != nil {
+			log.Printf("reap expired peers: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runReconciler fixes drift between the database and live wireguard and firewall state every interval.
+func runReconciler(ctx context.Context, adminService *usecase.AdminService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changes, err := adminService.ReconcileWireguard(ctx)
+		for _, change := range changes {
+			log.Printf("reconciled %s: interface=%s target=%s %s", change.Kind, change.InterfaceID, change.Target, change.Detail)
+		}
+		if err != nil {
+			log.Printf("reconcile wireguard: %v", err)
+		}
+	}
+}
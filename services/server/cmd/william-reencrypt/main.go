@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/nomuken/william/services/server/internal/infra"
+)
+
+// william-reencrypt re-seals stored secrets with the active master key.
+// Run it after adding a new key to WILLIAM_MASTER_KEY; the old key can be dropped once it finishes.
+func main() {
+	database, err := infra.OpenDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := infra.RunMigrations(database); err != nil {
+		log.Fatal(err)
+	}
+
+	secretBox, err := infra.LoadSecretBox()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := infra.EncryptStoredSecrets(context.Background(), database, secretBox, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("re-encrypted stored secrets: peer_configs=%d interface_keys=%d", result.PeerConfigs, result.InterfaceKeys)
+}
@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"os"
 
+	"connectrpc.com/connect"
 	"github.com/nomuken/william/services/server/gen/proto/server/v1/williamv1connect"
 	"github.com/nomuken/william/services/server/internal/infra"
 	"github.com/nomuken/william/services/server/internal/transport/connecthandler"
+	"github.com/nomuken/william/services/server/internal/transport/scimhandler"
 	"github.com/nomuken/william/services/server/internal/usecase"
 )
 
@@ -22,19 +24,47 @@ func main() {
 		log.Fatal(err)
 	}
 
-	repository := infra.NewAdminRPCWireguardRepository(nil)
-	peerStore := infra.NewSQLPeerStore(database)
-	interfaceStore := infra.NewSQLInterfaceStore(database)
+	secretBox, err := infra.LoadSecretBox()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	adminClientConfig, err := infra.LoadAdminServiceClientConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repository := infra.NewAdminRPCWireguardRepository(adminClientConfig)
+	peerStore := infra.NewSQLPeerStore(database, secretBox)
+	interfaceStore := infra.NewSQLInterfaceStore(database, secretBox)
 	allowedEmailStore := infra.NewSQLAllowedEmailStore(database)
 	interfaceRouteStore := infra.NewSQLInterfaceRouteStore(database)
-	wireguardService := usecase.NewWireguardService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore)
+	peerRouteStore := infra.NewSQLPeerRouteStore(database)
+	groupStore := infra.NewSQLGroupStore(database)
 
-	userHandler := connecthandler.NewWilliamHandler(wireguardService)
+	auditSinks, err := infra.LoadAuditSinks()
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditor := usecase.NewAuditor(infra.NewSQLAuditStore(database), auditSinks...)
+	wireguardService := usecase.NewWireguardService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore, peerRouteStore, groupStore, auditor)
+
+	authenticator, err := infra.LoadUserAuthenticator()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userHandler := connecthandler.NewWilliamHandler(wireguardService, authenticator)
 
-	path, connectHandler := williamv1connect.NewWilliamServiceHandler(userHandler)
+	path, connectHandler := williamv1connect.NewWilliamServiceHandler(userHandler, connect.WithInterceptors(connecthandler.NewRequestIDInterceptor()))
 	mux := http.NewServeMux()
 	mux.Handle(path, connectHandler)
 
+	// SCIM provisioning is only served when the identity provider has been given a token.
+	if scimToken := os.Getenv("WILLIAM_SCIM_TOKEN"); scimToken != "" {
+		scimService := usecase.NewScimService(infra.NewSQLScimStore(database), allowedEmailStore, peerStore, infra.NewAdminRPCAccessRevoker(adminClientConfig))
+		mux.Handle(scimhandler.PathPrefix, scimhandler.NewScimHandler(scimService, scimToken))
+	}
+
 	addr := os.Getenv("WILLIAM_ADDR")
 	if addr == "" {
 		addr = ":8080"
@@ -0,0 +1,2 @@
+-- Encrypted wireguard private key of the interface; empty until bootstrap generates one
+ALTER TABLE interfaces ADD COLUMN private_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE interfaces DROP COLUMN peer_key_policy;
@@ -0,0 +1,2 @@
+-- Who generates peer key pairs: server_generated or client_supplied
+ALTER TABLE interfaces ADD COLUMN peer_key_policy TEXT NOT NULL DEFAULT 'server_generated';
@@ -0,0 +1,27 @@
+CREATE TABLE ip_allocations (
+  interface_id TEXT NOT NULL REFERENCES interfaces(id) ON DELETE CASCADE,
+  address TEXT NOT NULL,
+  peer_id TEXT,
+  released_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (interface_id, address)
+);
+
+CREATE INDEX ip_allocations_peer_id_idx ON ip_allocations(peer_id);
+
+CREATE TABLE ip_reservations (
+  interface_id TEXT NOT NULL REFERENCES interfaces(id) ON DELETE CASCADE,
+  cidr TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (interface_id, cidr)
+);
+
+-- Record the addresses of existing peers so they are not handed out again.
+INSERT INTO ip_allocations (interface_id, address, peer_id)
+SELECT peers.interface_id, trim(item.address), peers.peer_id
+FROM peers
+JOIN interfaces ON interfaces.id = peers.interface_id
+CROSS JOIN LATERAL unnest(string_to_array(peers.allowed_ip, ',')) AS item(address)
+WHERE trim(item.address) <> ''
+ON CONFLICT (interface_id, address) DO NOTHING;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS admin_role_assignments;
@@ -0,0 +1,5 @@
+CREATE TABLE admin_role_assignments (
+  subject TEXT PRIMARY KEY,
+  role TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS peers_expires_at_idx;
+ALTER TABLE interfaces DROP COLUMN peer_ttl_seconds;
+ALTER TABLE peers DROP COLUMN expires_at;
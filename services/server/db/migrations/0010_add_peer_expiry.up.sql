@@ -0,0 +1,4 @@
+ALTER TABLE peers ADD COLUMN expires_at TIMESTAMP;
+ALTER TABLE interfaces ADD COLUMN peer_ttl_seconds INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX peers_expires_at_idx ON peers(expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE peers DROP COLUMN suspended_at;
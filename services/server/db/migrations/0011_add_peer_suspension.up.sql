@@ -0,0 +1 @@
+ALTER TABLE peers ADD COLUMN suspended_at TIMESTAMP;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS allowed_emails_rule_type_idx;
+DELETE FROM allowed_emails WHERE rule_type <> 'exact';
+ALTER TABLE allowed_emails DROP COLUMN rule_type;
@@ -0,0 +1,3 @@
+ALTER TABLE allowed_emails ADD COLUMN rule_type TEXT NOT NULL DEFAULT 'exact';
+
+CREATE INDEX allowed_emails_rule_type_idx ON allowed_emails(rule_type) WHERE rule_type <> 'exact';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS group_members_email_idx;
+DROP TABLE IF EXISTS group_members;
+DROP INDEX IF EXISTS interface_groups_group_name_idx;
+DROP TABLE IF EXISTS interface_groups;
@@ -0,0 +1,17 @@
+CREATE TABLE interface_groups (
+  interface_id TEXT NOT NULL REFERENCES interfaces(id) ON DELETE CASCADE,
+  group_name TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (interface_id, group_name)
+);
+
+CREATE INDEX interface_groups_group_name_idx ON interface_groups(group_name);
+
+CREATE TABLE group_members (
+  group_name TEXT NOT NULL,
+  email TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (group_name, email)
+);
+
+CREATE INDEX group_members_email_idx ON group_members(email);
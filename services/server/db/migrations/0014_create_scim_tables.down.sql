@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS scim_group_members_user_id_idx;
+DROP TABLE IF EXISTS scim_group_members;
+DROP TABLE IF EXISTS scim_groups;
+DROP INDEX IF EXISTS scim_users_user_name_idx;
+DROP TABLE IF EXISTS scim_users;
@@ -0,0 +1,27 @@
+CREATE TABLE scim_users (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::text,
+  user_name TEXT NOT NULL,
+  external_id TEXT NOT NULL DEFAULT '',
+  display_name TEXT NOT NULL DEFAULT '',
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX scim_users_user_name_idx ON scim_users(lower(user_name));
+
+CREATE TABLE scim_groups (
+  id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::text,
+  display_name TEXT NOT NULL UNIQUE,
+  external_id TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE scim_group_members (
+  group_id TEXT NOT NULL REFERENCES scim_groups(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES scim_users(id) ON DELETE CASCADE,
+  PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX scim_group_members_user_id_idx ON scim_group_members(user_id);
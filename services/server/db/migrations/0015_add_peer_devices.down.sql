@@ -0,0 +1,19 @@
+ALTER TABLE interfaces DROP COLUMN max_devices_per_user;
+
+DROP INDEX peers_email_interface_device_idx;
+
+-- Keep only the oldest device of each user per interface
+DELETE FROM peers
+WHERE peer_id IN (
+  SELECT peer_id
+  FROM (
+    SELECT peer_id, ROW_NUMBER() OVER (PARTITION BY email, interface_id ORDER BY created_at, peer_id) AS position
+    FROM peers
+  ) ranked
+  WHERE position > 1
+);
+
+CREATE INDEX peers_peer_id_idx ON peers(peer_id);
+ALTER TABLE peers DROP CONSTRAINT peers_pkey;
+ALTER TABLE peers ADD PRIMARY KEY (email, interface_id);
+ALTER TABLE peers DROP COLUMN device_name;
@@ -0,0 +1,9 @@
+-- Peers are identified by peer_id so a user can own several named devices on one interface
+ALTER TABLE peers ADD COLUMN device_name TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE peers DROP CONSTRAINT peers_pkey;
+ALTER TABLE peers ADD PRIMARY KEY (peer_id);
+DROP INDEX peers_peer_id_idx;
+
+CREATE UNIQUE INDEX peers_email_interface_device_idx ON peers(email, interface_id, device_name);
+
+ALTER TABLE interfaces ADD COLUMN max_devices_per_user INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,29 @@
+CREATE TABLE audit_events (
+  id BIGSERIAL PRIMARY KEY,
+  occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  before_value JSONB,
+  after_value JSONB,
+  request_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_occurred_at_idx ON audit_events(occurred_at);
+CREATE INDEX audit_events_target_idx ON audit_events(target_type, target_id);
+CREATE INDEX audit_events_actor_idx ON audit_events(actor);
+
+CREATE FUNCTION audit_events_reject_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+  BEFORE UPDATE OR DELETE ON audit_events
+  FOR EACH ROW EXECUTE FUNCTION audit_events_reject_change();
+
+CREATE TRIGGER audit_events_no_truncate
+  BEFORE TRUNCATE ON audit_events
+  FOR EACH STATEMENT EXECUTE FUNCTION audit_events_reject_change();
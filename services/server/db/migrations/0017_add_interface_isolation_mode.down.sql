@@ -0,0 +1 @@
+ALTER TABLE interfaces DROP COLUMN isolation_mode;
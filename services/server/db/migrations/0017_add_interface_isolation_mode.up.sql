@@ -0,0 +1,2 @@
+-- What the firewall does with peer traffic no route allows: routes_only, peer_to_peer or open
+ALTER TABLE interfaces ADD COLUMN isolation_mode TEXT NOT NULL DEFAULT 'routes_only';
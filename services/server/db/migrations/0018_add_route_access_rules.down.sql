@@ -0,0 +1,14 @@
+-- Keep one route per CIDR
+DELETE FROM peer_allowed_routes
+WHERE ctid NOT IN (SELECT MIN(ctid) FROM peer_allowed_routes GROUP BY peer_id, cidr);
+ALTER TABLE peer_allowed_routes DROP CONSTRAINT peer_allowed_routes_pkey;
+ALTER TABLE peer_allowed_routes DROP COLUMN ports;
+ALTER TABLE peer_allowed_routes DROP COLUMN protocol;
+ALTER TABLE peer_allowed_routes ADD PRIMARY KEY (peer_id, cidr);
+
+DELETE FROM interface_allowed_routes
+WHERE ctid NOT IN (SELECT MIN(ctid) FROM interface_allowed_routes GROUP BY interface_id, cidr);
+ALTER TABLE interface_allowed_routes DROP CONSTRAINT interface_allowed_routes_pkey;
+ALTER TABLE interface_allowed_routes DROP COLUMN ports;
+ALTER TABLE interface_allowed_routes DROP COLUMN protocol;
+ALTER TABLE interface_allowed_routes ADD PRIMARY KEY (interface_id, cidr);
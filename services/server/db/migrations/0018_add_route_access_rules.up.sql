@@ -0,0 +1,10 @@
+-- Routes become access rules: protocol is any, tcp, udp or icmp; ports lists tcp/udp port ranges ("22,8000-8080"), empty for all ports
+ALTER TABLE interface_allowed_routes ADD COLUMN protocol TEXT NOT NULL DEFAULT 'any';
+ALTER TABLE interface_allowed_routes ADD COLUMN ports TEXT NOT NULL DEFAULT '';
+ALTER TABLE interface_allowed_routes DROP CONSTRAINT interface_allowed_routes_pkey;
+ALTER TABLE interface_allowed_routes ADD PRIMARY KEY (interface_id, cidr, protocol, ports);
+
+ALTER TABLE peer_allowed_routes ADD COLUMN protocol TEXT NOT NULL DEFAULT 'any';
+ALTER TABLE peer_allowed_routes ADD COLUMN ports TEXT NOT NULL DEFAULT '';
+ALTER TABLE peer_allowed_routes DROP CONSTRAINT peer_allowed_routes_pkey;
+ALTER TABLE peer_allowed_routes ADD PRIMARY KEY (peer_id, cidr, protocol, ports);
@@ -1,30 +1,42 @@
 -- name: GetPeerByEmail :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1
+ORDER BY created_at
 LIMIT 1;
 
 -- name: GetPeerByID :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE peer_id = $1
 LIMIT 1;
 
 -- name: GetPeerByEmailAndInterface :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1 AND interface_id = $2
+ORDER BY created_at
 LIMIT 1;
 
 -- name: CreatePeer :exec
-INSERT INTO peers (email, peer_id, interface_id, allowed_ip, config)
-VALUES ($1, $2, $3, $4, $5);
+INSERT INTO peers (email, peer_id, interface_id, allowed_ip, config, expires_at, device_name)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
 
 -- name: UpdatePeerConfig :exec
 UPDATE peers
 SET config = $1
 WHERE peer_id = $2;
 
+-- name: UpdatePeerExpiry :exec
+UPDATE peers
+SET expires_at = $1
+WHERE peer_id = $2;
+
+-- name: UpdatePeerSuspension :exec
+UPDATE peers
+SET suspended_at = $1
+WHERE peer_id = $2;
+
 -- name: DeletePeerByID :exec
 DELETE FROM peers
 WHERE peer_id = $1;
@@ -34,51 +46,62 @@ DELETE FROM peers
 WHERE interface_id = $1;
 
 -- name: ListPeers :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 ORDER BY created_at DESC;
 
 -- name: ListPeersByEmail :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1
 ORDER BY created_at DESC;
 
 -- name: ListPeersByInterface :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE interface_id = $1
 ORDER BY created_at DESC;
 
+-- name: ListExpiredPeers :many
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
+FROM peers
+WHERE expires_at IS NOT NULL AND expires_at <= $1
+ORDER BY expires_at;
+
 -- name: CreateInterface :exec
-INSERT INTO interfaces (id, name, address, listen_port, mtu, endpoint)
-VALUES ($1, $2, $3, $4, $5, $6);
+INSERT INTO interfaces (id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
 
 -- name: UpdateInterface :exec
 UPDATE interfaces
-SET name = $1, address = $2, listen_port = $3, mtu = $4, endpoint = $5
-WHERE id = $6;
+SET name = $1, address = $2, listen_port = $3, mtu = $4, endpoint = $5, peer_key_policy = $6, peer_ttl_seconds = $7, max_devices_per_user = $8, isolation_mode = $9
+WHERE id = $10;
+
+-- name: UpdateInterfacePrivateKey :exec
+UPDATE interfaces
+SET private_key = $1
+WHERE id = $2;
 
 -- name: DeleteInterface :exec
 DELETE FROM interfaces
 WHERE id = $1;
 
 -- name: GetInterface :one
-SELECT id, name, address, listen_port, mtu, endpoint, created_at
+SELECT id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode, created_at
 FROM interfaces
 WHERE id = $1
 LIMIT 1;
 
 -- name: ListInterfaces :many
-SELECT id, name, address, listen_port, mtu, endpoint, created_at
+SELECT id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode, created_at
 FROM interfaces
 ORDER BY id;
 
 -- name: CreateAllowedEmail :exec
-INSERT INTO allowed_emails (interface_id, email)
-VALUES ($1, $2);
+INSERT INTO allowed_emails (interface_id, email, rule_type)
+VALUES ($1, $2, $3);
 
--- name: DeleteAllowedEmail :exec
+-- name: DeleteAllowedEmail :execrows
 DELETE FROM allowed_emails
 WHERE interface_id = $1 AND email = $2;
 
@@ -87,18 +110,36 @@ DELETE FROM allowed_emails
 WHERE interface_id = $1;
 
 -- name: ListAllowedEmails :many
-SELECT interface_id, email, created_at
+SELECT interface_id, email, created_at, rule_type
 FROM allowed_emails
 WHERE interface_id = $1
 ORDER BY email;
 
+-- name: ListAllowedEmailPatterns :many
+SELECT interface_id, email, created_at, rule_type
+FROM allowed_emails
+WHERE rule_type <> 'exact'
+ORDER BY interface_id, email;
+
+-- name: ListAllowedEmailPatternsByInterface :many
+SELECT interface_id, email, created_at, rule_type
+FROM allowed_emails
+WHERE interface_id = $1 AND rule_type <> 'exact'
+ORDER BY email;
+
+-- name: ListKnownEmails :many
+SELECT email FROM peers
+UNION
+SELECT email FROM allowed_emails WHERE rule_type = 'exact'
+ORDER BY email;
+
 -- name: ListAllowedInterfacesByEmail :many
 SELECT interface_id
 FROM allowed_emails
-WHERE email = $1
+WHERE rule_type = 'exact' AND email = $1
 ORDER BY interface_id;
 
 -- name: AllowedEmailExists :one
 SELECT COUNT(1)
 FROM allowed_emails
-WHERE interface_id = $1 AND email = $2;
+WHERE interface_id = $1 AND rule_type = 'exact' AND email = $2;
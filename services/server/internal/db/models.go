@@ -5,6 +5,7 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
@@ -15,20 +16,29 @@ type Peer struct {
 	AllowedIp   string
 	Config      string
 	CreatedAt   time.Time
+	ExpiresAt   sql.NullTime
+	SuspendedAt sql.NullTime
+	DeviceName  string
 }
 
 type Interface struct {
-	ID         string
-	Name       string
-	Address    string
-	ListenPort int64
-	Mtu        int64
-	Endpoint   string
-	CreatedAt  time.Time
+	ID                string
+	Name              string
+	Address           string
+	ListenPort        int64
+	Mtu               int64
+	Endpoint          string
+	PrivateKey        string
+	PeerKeyPolicy     string
+	PeerTtlSeconds    int64
+	CreatedAt         time.Time
+	MaxDevicesPerUser int64
+	IsolationMode     string
 }
 
 type AllowedEmail struct {
 	InterfaceID string
 	Email       string
 	CreatedAt   time.Time
+	RuleType    string
 }
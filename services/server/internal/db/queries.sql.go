@@ -7,11 +7,12 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createPeer = `-- name: CreatePeer :exec
-INSERT INTO peers (email, peer_id, interface_id, allowed_ip, config)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO peers (email, peer_id, interface_id, allowed_ip, config, expires_at, device_name)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 `
 
 type CreatePeerParams struct {
@@ -20,6 +21,8 @@ type CreatePeerParams struct {
 	InterfaceID string
 	AllowedIp   string
 	Config      string
+	ExpiresAt   sql.NullTime
+	DeviceName  string
 }
 
 func (q *Queries) CreatePeer(ctx context.Context, arg CreatePeerParams) error {
@@ -29,6 +32,8 @@ func (q *Queries) CreatePeer(ctx context.Context, arg CreatePeerParams) error {
 		arg.InterfaceID,
 		arg.AllowedIp,
 		arg.Config,
+		arg.ExpiresAt,
+		arg.DeviceName,
 	)
 	return err
 }
@@ -52,6 +57,38 @@ func (q *Queries) UpdatePeerConfig(ctx context.Context, arg UpdatePeerConfigPara
 	return err
 }
 
+const updatePeerExpiry = `-- name: UpdatePeerExpiry :exec
+UPDATE peers
+SET expires_at = $1
+WHERE peer_id = $2
+`
+
+type UpdatePeerExpiryParams struct {
+	ExpiresAt sql.NullTime
+	PeerID    string
+}
+
+func (q *Queries) UpdatePeerExpiry(ctx context.Context, arg UpdatePeerExpiryParams) error {
+	_, err := q.db.ExecContext(ctx, updatePeerExpiry, arg.ExpiresAt, arg.PeerID)
+	return err
+}
+
+const updatePeerSuspension = `-- name: UpdatePeerSuspension :exec
+UPDATE peers
+SET suspended_at = $1
+WHERE peer_id = $2
+`
+
+type UpdatePeerSuspensionParams struct {
+	SuspendedAt sql.NullTime
+	PeerID      string
+}
+
+func (q *Queries) UpdatePeerSuspension(ctx context.Context, arg UpdatePeerSuspensionParams) error {
+	_, err := q.db.ExecContext(ctx, updatePeerSuspension, arg.SuspendedAt, arg.PeerID)
+	return err
+}
+
 const deletePeerByID = `-- name: DeletePeerByID :exec
 DELETE FROM peers
 WHERE peer_id = $1
@@ -63,9 +100,10 @@ func (q *Queries) DeletePeerByID(ctx context.Context, peerID string) error {
 }
 
 const getPeerByEmail = `-- name: GetPeerByEmail :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1
+ORDER BY created_at
 LIMIT 1
 `
 
@@ -79,12 +117,15 @@ func (q *Queries) GetPeerByEmail(ctx context.Context, email string) (Peer, error
 		&i.AllowedIp,
 		&i.Config,
 		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.SuspendedAt,
+		&i.DeviceName,
 	)
 	return i, err
 }
 
 const getPeerByID = `-- name: GetPeerByID :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE peer_id = $1
 LIMIT 1
@@ -100,14 +141,18 @@ func (q *Queries) GetPeerByID(ctx context.Context, peerID string) (Peer, error)
 		&i.AllowedIp,
 		&i.Config,
 		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.SuspendedAt,
+		&i.DeviceName,
 	)
 	return i, err
 }
 
 const getPeerByEmailAndInterface = `-- name: GetPeerByEmailAndInterface :one
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1 AND interface_id = $2
+ORDER BY created_at
 LIMIT 1
 `
 
@@ -126,6 +171,9 @@ func (q *Queries) GetPeerByEmailAndInterface(ctx context.Context, arg GetPeerByE
 		&i.AllowedIp,
 		&i.Config,
 		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.SuspendedAt,
+		&i.DeviceName,
 	)
 	return i, err
 }
@@ -141,7 +189,7 @@ func (q *Queries) DeletePeersByInterface(ctx context.Context, interfaceID string
 }
 
 const listPeers = `-- name: ListPeers :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 ORDER BY created_at DESC
 `
@@ -162,6 +210,9 @@ func (q *Queries) ListPeers(ctx context.Context) ([]Peer, error) {
 			&i.AllowedIp,
 			&i.Config,
 			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.SuspendedAt,
+			&i.DeviceName,
 		); err != nil {
 			return nil, err
 		}
@@ -177,7 +228,7 @@ func (q *Queries) ListPeers(ctx context.Context) ([]Peer, error) {
 }
 
 const listPeersByEmail = `-- name: ListPeersByEmail :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE email = $1
 ORDER BY created_at DESC
@@ -199,6 +250,9 @@ func (q *Queries) ListPeersByEmail(ctx context.Context, email string) ([]Peer, e
 			&i.AllowedIp,
 			&i.Config,
 			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.SuspendedAt,
+			&i.DeviceName,
 		); err != nil {
 			return nil, err
 		}
@@ -214,7 +268,7 @@ func (q *Queries) ListPeersByEmail(ctx context.Context, email string) ([]Peer, e
 }
 
 const listPeersByInterface = `-- name: ListPeersByInterface :many
-SELECT email, peer_id, interface_id, allowed_ip, config, created_at
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
 FROM peers
 WHERE interface_id = $1
 ORDER BY created_at DESC
@@ -236,6 +290,49 @@ func (q *Queries) ListPeersByInterface(ctx context.Context, interfaceID string)
 			&i.AllowedIp,
 			&i.Config,
 			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.SuspendedAt,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredPeers = `-- name: ListExpiredPeers :many
+SELECT email, peer_id, interface_id, allowed_ip, config, created_at, expires_at, suspended_at, device_name
+FROM peers
+WHERE expires_at IS NOT NULL AND expires_at <= $1
+ORDER BY expires_at
+`
+
+func (q *Queries) ListExpiredPeers(ctx context.Context, expiresAt sql.NullTime) ([]Peer, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredPeers, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Peer
+	for rows.Next() {
+		var i Peer
+		if err := rows.Scan(
+			&i.Email,
+			&i.PeerID,
+			&i.InterfaceID,
+			&i.AllowedIp,
+			&i.Config,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.SuspendedAt,
+			&i.DeviceName,
 		); err != nil {
 			return nil, err
 		}
@@ -251,17 +348,22 @@ func (q *Queries) ListPeersByInterface(ctx context.Context, interfaceID string)
 }
 
 const createInterface = `-- name: CreateInterface :exec
-INSERT INTO interfaces (id, name, address, listen_port, mtu, endpoint)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO interfaces (id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 `
 
 type CreateInterfaceParams struct {
-	ID         string
-	Name       string
-	Address    string
-	ListenPort int64
-	Mtu        int64
-	Endpoint   string
+	ID                string
+	Name              string
+	Address           string
+	ListenPort        int64
+	Mtu               int64
+	Endpoint          string
+	PrivateKey        string
+	PeerKeyPolicy     string
+	PeerTtlSeconds    int64
+	MaxDevicesPerUser int64
+	IsolationMode     string
 }
 
 func (q *Queries) CreateInterface(ctx context.Context, arg CreateInterfaceParams) error {
@@ -272,23 +374,32 @@ func (q *Queries) CreateInterface(ctx context.Context, arg CreateInterfaceParams
 		arg.ListenPort,
 		arg.Mtu,
 		arg.Endpoint,
+		arg.PrivateKey,
+		arg.PeerKeyPolicy,
+		arg.PeerTtlSeconds,
+		arg.MaxDevicesPerUser,
+		arg.IsolationMode,
 	)
 	return err
 }
 
 const updateInterface = `-- name: UpdateInterface :exec
 UPDATE interfaces
-SET name = $1, address = $2, listen_port = $3, mtu = $4, endpoint = $5
-WHERE id = $6
+SET name = $1, address = $2, listen_port = $3, mtu = $4, endpoint = $5, peer_key_policy = $6, peer_ttl_seconds = $7, max_devices_per_user = $8, isolation_mode = $9
+WHERE id = $10
 `
 
 type UpdateInterfaceParams struct {
-	Name       string
-	Address    string
-	ListenPort int64
-	Mtu        int64
-	Endpoint   string
-	ID         string
+	Name              string
+	Address           string
+	ListenPort        int64
+	Mtu               int64
+	Endpoint          string
+	PeerKeyPolicy     string
+	PeerTtlSeconds    int64
+	MaxDevicesPerUser int64
+	IsolationMode     string
+	ID                string
 }
 
 func (q *Queries) UpdateInterface(ctx context.Context, arg UpdateInterfaceParams) error {
@@ -298,11 +409,31 @@ func (q *Queries) UpdateInterface(ctx context.Context, arg UpdateInterfaceParams
 		arg.ListenPort,
 		arg.Mtu,
 		arg.Endpoint,
+		arg.PeerKeyPolicy,
+		arg.PeerTtlSeconds,
+		arg.MaxDevicesPerUser,
+		arg.IsolationMode,
 		arg.ID,
 	)
 	return err
 }
 
+const updateInterfacePrivateKey = `-- name: UpdateInterfacePrivateKey :exec
+UPDATE interfaces
+SET private_key = $1
+WHERE id = $2
+`
+
+type UpdateInterfacePrivateKeyParams struct {
+	PrivateKey string
+	ID         string
+}
+
+func (q *Queries) UpdateInterfacePrivateKey(ctx context.Context, arg UpdateInterfacePrivateKeyParams) error {
+	_, err := q.db.ExecContext(ctx, updateInterfacePrivateKey, arg.PrivateKey, arg.ID)
+	return err
+}
+
 const deleteInterface = `-- name: DeleteInterface :exec
 DELETE FROM interfaces
 WHERE id = $1
@@ -314,7 +445,7 @@ func (q *Queries) DeleteInterface(ctx context.Context, id string) error {
 }
 
 const getInterface = `-- name: GetInterface :one
-SELECT id, name, address, listen_port, mtu, endpoint, created_at
+SELECT id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode, created_at
 FROM interfaces
 WHERE id = $1
 LIMIT 1
@@ -330,13 +461,18 @@ func (q *Queries) GetInterface(ctx context.Context, id string) (Interface, error
 		&i.ListenPort,
 		&i.Mtu,
 		&i.Endpoint,
+		&i.PrivateKey,
+		&i.PeerKeyPolicy,
+		&i.PeerTtlSeconds,
+		&i.MaxDevicesPerUser,
+		&i.IsolationMode,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const listInterfaces = `-- name: ListInterfaces :many
-SELECT id, name, address, listen_port, mtu, endpoint, created_at
+SELECT id, name, address, listen_port, mtu, endpoint, private_key, peer_key_policy, peer_ttl_seconds, max_devices_per_user, isolation_mode, created_at
 FROM interfaces
 ORDER BY id
 `
@@ -357,6 +493,11 @@ func (q *Queries) ListInterfaces(ctx context.Context) ([]Interface, error) {
 			&i.ListenPort,
 			&i.Mtu,
 			&i.Endpoint,
+			&i.PrivateKey,
+			&i.PeerKeyPolicy,
+			&i.PeerTtlSeconds,
+			&i.MaxDevicesPerUser,
+			&i.IsolationMode,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -370,21 +511,22 @@ func (q *Queries) ListInterfaces(ctx context.Context) ([]Interface, error) {
 }
 
 const createAllowedEmail = `-- name: CreateAllowedEmail :exec
-INSERT INTO allowed_emails (interface_id, email)
-VALUES ($1, $2)
+INSERT INTO allowed_emails (interface_id, email, rule_type)
+VALUES ($1, $2, $3)
 `
 
 type CreateAllowedEmailParams struct {
 	InterfaceID string
 	Email       string
+	RuleType    string
 }
 
 func (q *Queries) CreateAllowedEmail(ctx context.Context, arg CreateAllowedEmailParams) error {
-	_, err := q.db.ExecContext(ctx, createAllowedEmail, arg.InterfaceID, arg.Email)
+	_, err := q.db.ExecContext(ctx, createAllowedEmail, arg.InterfaceID, arg.Email, arg.RuleType)
 	return err
 }
 
-const deleteAllowedEmail = `-- name: DeleteAllowedEmail :exec
+const deleteAllowedEmail = `-- name: DeleteAllowedEmail :execrows
 DELETE FROM allowed_emails
 WHERE interface_id = $1 AND email = $2
 `
@@ -394,9 +536,12 @@ type DeleteAllowedEmailParams struct {
 	Email       string
 }
 
-func (q *Queries) DeleteAllowedEmail(ctx context.Context, arg DeleteAllowedEmailParams) error {
-	_, err := q.db.ExecContext(ctx, deleteAllowedEmail, arg.InterfaceID, arg.Email)
-	return err
+func (q *Queries) DeleteAllowedEmail(ctx context.Context, arg DeleteAllowedEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAllowedEmail, arg.InterfaceID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteAllowedEmailsByInterface = `-- name: DeleteAllowedEmailsByInterface :exec
@@ -410,7 +555,7 @@ func (q *Queries) DeleteAllowedEmailsByInterface(ctx context.Context, interfaceI
 }
 
 const listAllowedEmails = `-- name: ListAllowedEmails :many
-SELECT interface_id, email, created_at
+SELECT interface_id, email, created_at, rule_type
 FROM allowed_emails
 WHERE interface_id = $1
 ORDER BY email
@@ -429,6 +574,7 @@ func (q *Queries) ListAllowedEmails(ctx context.Context, interfaceID string) ([]
 			&i.InterfaceID,
 			&i.Email,
 			&i.CreatedAt,
+			&i.RuleType,
 		); err != nil {
 			return nil, err
 		}
@@ -440,10 +586,101 @@ func (q *Queries) ListAllowedEmails(ctx context.Context, interfaceID string) ([]
 	return items, nil
 }
 
+const listAllowedEmailPatterns = `-- name: ListAllowedEmailPatterns :many
+SELECT interface_id, email, created_at, rule_type
+FROM allowed_emails
+WHERE rule_type <> 'exact'
+ORDER BY interface_id, email
+`
+
+func (q *Queries) ListAllowedEmailPatterns(ctx context.Context) ([]AllowedEmail, error) {
+	rows, err := q.db.QueryContext(ctx, listAllowedEmailPatterns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AllowedEmail
+	for rows.Next() {
+		var i AllowedEmail
+		if err := rows.Scan(
+			&i.InterfaceID,
+			&i.Email,
+			&i.CreatedAt,
+			&i.RuleType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllowedEmailPatternsByInterface = `-- name: ListAllowedEmailPatternsByInterface :many
+SELECT interface_id, email, created_at, rule_type
+FROM allowed_emails
+WHERE interface_id = $1 AND rule_type <> 'exact'
+ORDER BY email
+`
+
+func (q *Queries) ListAllowedEmailPatternsByInterface(ctx context.Context, interfaceID string) ([]AllowedEmail, error) {
+	rows, err := q.db.QueryContext(ctx, listAllowedEmailPatternsByInterface, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AllowedEmail
+	for rows.Next() {
+		var i AllowedEmail
+		if err := rows.Scan(
+			&i.InterfaceID,
+			&i.Email,
+			&i.CreatedAt,
+			&i.RuleType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKnownEmails = `-- name: ListKnownEmails :many
+SELECT email FROM peers
+UNION
+SELECT email FROM allowed_emails WHERE rule_type = 'exact'
+ORDER BY email
+`
+
+func (q *Queries) ListKnownEmails(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listKnownEmails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAllowedInterfacesByEmail = `-- name: ListAllowedInterfacesByEmail :many
 SELECT interface_id
 FROM allowed_emails
-WHERE email = $1
+WHERE rule_type = 'exact' AND email = $1
 ORDER BY interface_id
 `
 
@@ -470,7 +707,7 @@ func (q *Queries) ListAllowedInterfacesByEmail(ctx context.Context, email string
 const allowedEmailExists = `-- name: AllowedEmailExists :one
 SELECT COUNT(1)
 FROM allowed_emails
-WHERE interface_id = $1 AND email = $2
+WHERE interface_id = $1 AND rule_type = 'exact' AND email = $2
 `
 
 type AllowedEmailExistsParams struct {
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Admin roles, from least to most privileged. Each role includes the permissions of the roles before it.
+const (
+	AdminRoleViewer   = "viewer"
+	AdminRoleOperator = "operator"
+	AdminRoleOwner    = "owner"
+)
+
+// AdminPrincipal is an authenticated caller of the admin API.
+// Role is only set when the authenticator already decided it, e.g. for service tokens;
+// otherwise the role is looked up from the role assignments.
+type AdminPrincipal struct {
+	Subject string
+	Role    string
+}
+
+type AdminRoleAssignment struct {
+	Subject   string
+	Role      string
+	CreatedAt time.Time
+}
+
+type AdminRoleStore interface {
+	Get(ctx context.Context, subject string) (AdminRoleAssignment, error)
+	List(ctx context.Context) ([]AdminRoleAssignment, error)
+	Upsert(ctx context.Context, subject string, role string) error
+	Delete(ctx context.Context, subject string) error
+}
+
+// AdminRoleAllows reports whether role grants everything required needs.
+func AdminRoleAllows(role string, required string) bool {
+	requiredRank := adminRoleRank(required)
+	return requiredRank > 0 && adminRoleRank(role) >= requiredRank
+}
+
+// IsAdminRole reports whether role is one of the known admin roles.
+func IsAdminRole(role string) bool {
+	return adminRoleRank(role) > 0
+}
+
+func adminRoleRank(role string) int {
+	switch role {
+	case AdminRoleViewer:
+		return 1
+	case AdminRoleOperator:
+		return 2
+	case AdminRoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Allowed email rule types. An exact rule matches one address, a domain rule such as
+// "*@corp.example.com" matches every address in that domain and its subdomains, and a
+// regex rule matches addresses against an anchored regular expression.
+const (
+	AllowedEmailRuleExact  = "exact"
+	AllowedEmailRuleDomain = "domain"
+	AllowedEmailRuleRegex  = "regex"
+)
+
+// NormalizeAllowedEmailRule validates a rule and returns its canonical type and pattern.
+// An empty rule type means exact; domain patterns are stored lowercased as "*@domain".
+func NormalizeAllowedEmailRule(ruleType string, pattern string) (string, string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "", "", errors.New("pattern is required")
+	}
+
+	switch ruleType {
+	case "", AllowedEmailRuleExact:
+		return AllowedEmailRuleExact, pattern, nil
+	case AllowedEmailRuleDomain:
+		domain := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(pattern, "*"), "@"))
+		if domain == "" || strings.ContainsAny(domain, "@*") || strings.HasPrefix(domain, ".") {
+			return "", "", fmt.Errorf("invalid domain pattern %q", pattern)
+		}
+		return AllowedEmailRuleDomain, "*@" + domain, nil
+	case AllowedEmailRuleRegex:
+		if _, err := compileAllowedEmailRegex(pattern); err != nil {
+			return "", "", fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		return AllowedEmailRuleRegex, pattern, nil
+	default:
+		return "", "", fmt.Errorf("unknown rule type %q", ruleType)
+	}
+}
+
+// AllowedEmailRuleMatches reports whether email is granted by the rule. Invalid patterns match nothing.
+func AllowedEmailRuleMatches(ruleType string, pattern string, email string) bool {
+	switch ruleType {
+	case AllowedEmailRuleExact:
+		return email == pattern
+	case AllowedEmailRuleDomain:
+		_, emailDomain, found := strings.Cut(email, "@")
+		if !found {
+			return false
+		}
+		emailDomain = strings.ToLower(emailDomain)
+		domain := strings.TrimPrefix(pattern, "*@")
+		return emailDomain == domain || strings.HasSuffix(emailDomain, "."+domain)
+	case AllowedEmailRuleRegex:
+		expression, err := compileAllowedEmailRegex(pattern)
+		if err != nil {
+			return false
+		}
+		return expression.MatchString(email)
+	default:
+		return false
+	}
+}
+
+// allowedEmailRegexes caches compiled regex rules by pattern; rules are few and matched on every peer creation.
+var allowedEmailRegexes sync.Map
+
+// compileAllowedEmailRegex anchors the pattern so a rule cannot match on a substring of an address.
+func compileAllowedEmailRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := allowedEmailRegexes.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	expression, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	allowedEmailRegexes.Store(pattern, expression)
+	return expression, nil
+}
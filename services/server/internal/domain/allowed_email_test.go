@@ -0,0 +1,68 @@
+package domain
+
+import "testing"
+
+func TestAllowedEmailRuleMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleType string
+		pattern  string
+		email    string
+		want     bool
+	}{
+		{name: "exact", ruleType: AllowedEmailRuleExact, pattern: "alice@example.com", email: "alice@example.com", want: true},
+		{name: "exact other address", ruleType: AllowedEmailRuleExact, pattern: "alice@example.com", email: "bob@example.com"},
+		{name: "exact is case sensitive", ruleType: AllowedEmailRuleExact, pattern: "alice@example.com", email: "Alice@example.com"},
+		{name: "domain", ruleType: AllowedEmailRuleDomain, pattern: "*@example.com", email: "alice@example.com", want: true},
+		{name: "domain ignores case", ruleType: AllowedEmailRuleDomain, pattern: "*@example.com", email: "alice@EXAMPLE.com", want: true},
+		{name: "domain subdomain", ruleType: AllowedEmailRuleDomain, pattern: "*@example.com", email: "alice@corp.example.com", want: true},
+		{name: "domain suffix of another domain", ruleType: AllowedEmailRuleDomain, pattern: "*@example.com", email: "alice@badexample.com"},
+		{name: "domain parent", ruleType: AllowedEmailRuleDomain, pattern: "*@corp.example.com", email: "alice@example.com"},
+		{name: "domain without at", ruleType: AllowedEmailRuleDomain, pattern: "*@example.com", email: "example.com"},
+		{name: "regex", ruleType: AllowedEmailRuleRegex, pattern: `[a-z]+\+ctf@example\.com`, email: "alice+ctf@example.com", want: true},
+		{name: "regex is anchored", ruleType: AllowedEmailRuleRegex, pattern: `alice@example\.com`, email: "alice@example.com.evil.test"},
+		{name: "regex alternation is anchored", ruleType: AllowedEmailRuleRegex, pattern: `alice@a\.test|bob@b\.test`, email: "mallory+bob@b.test"},
+		{name: "invalid regex", ruleType: AllowedEmailRuleRegex, pattern: `(`, email: "("},
+		{name: "unknown type", ruleType: "glob", pattern: "*", email: "alice@example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := AllowedEmailRuleMatches(test.ruleType, test.pattern, test.email); got != test.want {
+				t.Errorf("AllowedEmailRuleMatches(%q, %q, %q) = %v, want %v", test.ruleType, test.pattern, test.email, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAllowedEmailRule(t *testing.T) {
+	tests := []struct {
+		ruleType    string
+		pattern     string
+		wantType    string
+		wantPattern string
+		wantErr     bool
+	}{
+		{ruleType: "", pattern: " alice@example.com ", wantType: AllowedEmailRuleExact, wantPattern: "alice@example.com"},
+		{ruleType: AllowedEmailRuleDomain, pattern: "Example.COM", wantType: AllowedEmailRuleDomain, wantPattern: "*@example.com"},
+		{ruleType: AllowedEmailRuleDomain, pattern: "@example.com", wantType: AllowedEmailRuleDomain, wantPattern: "*@example.com"},
+		{ruleType: AllowedEmailRuleDomain, pattern: "*@.example.com", wantErr: true},
+		{ruleType: AllowedEmailRuleRegex, pattern: `.*@example\.com`, wantType: AllowedEmailRuleRegex, wantPattern: `.*@example\.com`},
+		{ruleType: AllowedEmailRuleRegex, pattern: `(`, wantErr: true},
+		{ruleType: AllowedEmailRuleExact, pattern: " ", wantErr: true},
+		{ruleType: "glob", pattern: "*", wantErr: true},
+	}
+
+	for _, test := range tests {
+		ruleType, pattern, err := NormalizeAllowedEmailRule(test.ruleType, test.pattern)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeAllowedEmailRule(%q, %q) succeeded", test.ruleType, test.pattern)
+			}
+			continue
+		}
+		if err != nil || ruleType != test.wantType || pattern != test.wantPattern {
+			t.Errorf("NormalizeAllowedEmailRule(%q, %q) = %q, %q, %v", test.ruleType, test.pattern, ruleType, pattern, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Audit target types.
+const (
+	AuditTargetInterface      = "interface"
+	AuditTargetAllowedEmail   = "allowed_email"
+	AuditTargetInterfaceGroup = "interface_group"
+	AuditTargetPeer           = "peer"
+	AuditTargetInterfaceRoute = "interface_route"
+	AuditTargetPeerRoute      = "peer_route"
+	AuditTargetIPReservation  = "ip_reservation"
+	AuditTargetAdminRole      = "admin_role"
+	AuditTargetFirewall       = "firewall"
+)
+
+// Audit actions, named <target type>.<verb>.
+const (
+	AuditActionInterfaceCreate      = "interface.create"
+	AuditActionInterfaceUpdate      = "interface.update"
+	AuditActionInterfaceDelete      = "interface.delete"
+	AuditActionInterfaceRotateKey   = "interface.rotate_key"
+	AuditActionInterfaceReconcile   = "interface.reconcile"
+	AuditActionAllowedEmailCreate   = "allowed_email.create"
+	AuditActionAllowedEmailDelete   = "allowed_email.delete"
+	AuditActionInterfaceGroupCreate = "interface_group.create"
+	AuditActionInterfaceGroupDelete = "interface_group.delete"
+	AuditActionPeerCreate           = "peer.create"
+	AuditActionPeerDelete           = "peer.delete"
+	AuditActionPeerSuspend          = "peer.suspend"
+	AuditActionPeerResume           = "peer.resume"
+	AuditActionPeerRenew            = "peer.renew"
+	AuditActionPeerExpire           = "peer.expire"
+	AuditActionPeerUpdateAllowedIPs = "peer.update_allowed_ips"
+	AuditActionPeerReconcile        = "peer.reconcile"
+	AuditActionInterfaceRouteCreate = "interface_route.create"
+	AuditActionInterfaceRouteDelete = "interface_route.delete"
+	AuditActionPeerRouteCreate      = "peer_route.create"
+	AuditActionPeerRouteDelete      = "peer_route.delete"
+	AuditActionIPReservationCreate  = "ip_reservation.create"
+	AuditActionIPReservationDelete  = "ip_reservation.delete"
+	AuditActionAdminRoleSet         = "admin_role.set"
+	AuditActionAdminRoleDelete      = "admin_role.delete"
+	AuditActionFirewallSync         = "firewall.sync"
+	AuditActionFirewallRemove       = "firewall.remove"
+	AuditActionFirewallReconcile    = "firewall.reconcile"
+)
+
+// AuditEvent records one mutation. Before and After are JSON objects holding the changed values,
+// and are empty when the target did not exist before or no longer exists after the mutation.
+// Secrets such as private keys and peer configs are never recorded.
+type AuditEvent struct {
+	ID         int64
+	OccurredAt time.Time
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     json.RawMessage
+	After      json.RawMessage
+	RequestID  string
+}
+
+// AuditEventFilter selects audit events. Empty fields match everything. Events are returned newest
+// first, starting below BeforeID when it is set.
+type AuditEventFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Until      time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// AuditStore keeps audit events. It only appends; events are never changed or removed.
+type AuditStore interface {
+	Append(ctx context.Context, event AuditEvent) error
+	List(ctx context.Context, filter AuditEventFilter) ([]AuditEvent, error)
+}
+
+// AuditSink receives a copy of every audit event, e.g. to ship it to a log collector.
+type AuditSink interface {
+	Write(event AuditEvent) error
+}
+
+// RequestIDHeader carries the request ID between clients, william-server and admin-server,
+// so audit events recorded by both servers for one user action share an ID.
+const RequestIDHeader = "X-Request-Id"
+
+type auditActorKey struct{}
+
+type requestIDKey struct{}
+
+// WithAuditActor returns a context whose mutations are recorded as done by actor.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor stored by WithAuditActor, or "" when there is none.
+func AuditActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// WithRequestID returns a context carrying the ID of the request being served.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, or "" when there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
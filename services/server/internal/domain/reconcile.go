@@ -0,0 +1,33 @@
+package domain
+
+import "context"
+
+// Kinds of reconcile changes.
+const (
+	ReconcileCreateInterface      = "create_interface"
+	ReconcileUpdateInterface      = "update_interface"
+	ReconcileAddPeer              = "add_peer"
+	ReconcileRemovePeer           = "remove_peer"
+	ReconcileUpdatePeerAllowedIPs = "update_peer_allowed_ips"
+	ReconcileSyncFirewallRules    = "sync_firewall_rules"
+	ReconcileRemoveFirewallRules  = "remove_firewall_rules"
+	ReconcileSyncIsolationRules   = "sync_isolation_rules"
+)
+
+// ReconcileChange is one fix that brings live wireguard or firewall state back in line with the database.
+// Target is the peer ID for peer and firewall sync changes, the source address for stale firewall rules,
+// and empty for interface changes, including isolation rules. Detail describes the drift for people, e.g. "mtu 1380 -> 1420".
+type ReconcileChange struct {
+	Kind        string
+	InterfaceID string
+	Target      string
+	Detail      string
+}
+
+// WireguardReconciler compares the interfaces, peers and routes in the database with live wireguard and firewall state.
+type WireguardReconciler interface {
+	// Plan returns the changes that would bring live state in line with the database, without making them.
+	Plan(ctx context.Context) ([]ReconcileChange, error)
+	// Apply makes the planned changes and returns the ones it made.
+	Apply(ctx context.Context) ([]ReconcileChange, error)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrScimConflict is returned when a SCIM user name or group display name is already taken.
+var ErrScimConflict = errors.New("scim resource already exists")
+
+// ScimUser is a user provisioned by the identity provider. UserName is the user's email.
+type ScimUser struct {
+	ID          string
+	UserName    string
+	ExternalID  string
+	DisplayName string
+	Active      bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ScimGroup is a group provisioned by the identity provider. Its DisplayName is the group name
+// used by interface group bindings, and MemberIDs are the IDs of its member users.
+type ScimGroup struct {
+	ID          string
+	DisplayName string
+	ExternalID  string
+	MemberIDs   []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type ScimStore interface {
+	ListUsers(ctx context.Context, userName string) ([]ScimUser, error)
+	GetUser(ctx context.Context, id string) (ScimUser, error)
+	CreateUser(ctx context.Context, user ScimUser) (ScimUser, error)
+	UpdateUser(ctx context.Context, user ScimUser) (ScimUser, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	ListGroups(ctx context.Context, displayName string) ([]ScimGroup, error)
+	GetGroup(ctx context.Context, id string) (ScimGroup, error)
+	CreateGroup(ctx context.Context, group ScimGroup) (ScimGroup, error)
+	UpdateGroup(ctx context.Context, group ScimGroup) (ScimGroup, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// AccessRevoker withdraws a user's access through admin-server, which tears down the affected peers.
+// DeleteAllowedEmail does nothing when the interface has no rule with exactly that email, e.g. because a
+// domain or regex rule grants it.
+type AccessRevoker interface {
+	DeleteAllowedEmail(ctx context.Context, interfaceID string, email string) ([]string, error)
+	DeletePeer(ctx context.Context, peerID string) error
+}
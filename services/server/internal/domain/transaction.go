@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// Transactor runs fn in one database transaction. Store calls made with the context passed to fn
+// join the transaction, which commits when fn returns nil and rolls back otherwise.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
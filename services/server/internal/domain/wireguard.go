@@ -2,37 +2,103 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// PeerKeyPolicy decides who generates the key pair of peers created on an interface.
+const (
+	// PeerKeyPolicyServerGenerated lets the server generate the key pair unless the client supplies a public key.
+	PeerKeyPolicyServerGenerated = "server_generated"
+	// PeerKeyPolicyClientSupplied requires the client to supply its public key; private keys never reach the server.
+	PeerKeyPolicyClientSupplied = "client_supplied"
+)
+
+// InterfaceIsolation decides what the firewall does with traffic from peers of an interface that no peer route allows.
+const (
+	// InterfaceIsolationRoutesOnly drops it, so peers only reach their routes.
+	InterfaceIsolationRoutesOnly = "routes_only"
+	// InterfaceIsolationPeerToPeer accepts it towards other peers of the interface and drops the rest.
+	InterfaceIsolationPeerToPeer = "peer_to_peer"
+	// InterfaceIsolationOpen accepts all of it.
+	InterfaceIsolationOpen = "open"
+)
+
+// AccessProtocol limits the traffic an access rule accepts to one protocol.
+const (
+	AccessProtocolAny  = "any"
+	AccessProtocolTCP  = "tcp"
+	AccessProtocolUDP  = "udp"
+	AccessProtocolICMP = "icmp"
+)
+
+// DefaultMaxDevicesPerUser is the device limit of interfaces created without one.
+const DefaultMaxDevicesPerUser = 1
+
+// DefaultPeerDeviceName names the device of peers created without a device name.
+const DefaultPeerDeviceName = "default"
+
 type WireguardInterface struct {
-	ID         string
-	Name       string
-	Address    string
-	ListenPort uint32
-	PublicKey  string
-	MTU        uint32
+	ID                string
+	Name              string
+	Address           string
+	ListenPort        uint32
+	PublicKey         string
+	MTU               uint32
+	PeerKeyPolicy     string
+	PeerTTL           time.Duration
+	MaxDevicesPerUser uint32
 }
 
+// InterfaceConfig.PeerTTL is the lifetime given to new peers and the most a renewal can extend them by.
+// Zero means peers on the interface never expire.
+// InterfaceConfig.MaxDevicesPerUser is how many peers, each a named device, one email may own on the interface.
+// InterfaceConfig.IsolationMode is one of the InterfaceIsolation modes.
 type InterfaceConfig struct {
-	ID         string
-	Name       string
-	Address    string
-	ListenPort uint32
-	MTU        uint32
-	Endpoint   string
+	ID                string
+	Name              string
+	Address           string
+	ListenPort        uint32
+	MTU               uint32
+	Endpoint          string
+	PrivateKey        string
+	PeerKeyPolicy     string
+	PeerTTL           time.Duration
+	MaxDevicesPerUser uint32
+	IsolationMode     string
 }
 
 type AdminInterface struct {
-	ID         string
-	Name       string
-	Address    string
-	ListenPort uint32
-	PublicKey  string
-	MTU        uint32
-	Endpoint   string
+	ID                string
+	Name              string
+	Address           string
+	ListenPort        uint32
+	PublicKey         string
+	MTU               uint32
+	Endpoint          string
+	PeerKeyPolicy     string
+	PeerTTL           time.Duration
+	MaxDevicesPerUser uint32
+	IsolationMode     string
 }
 
+// PeerSpec describes a peer to be added to a wireguard interface.
+// When PublicKey is empty the repository generates a key pair and embeds the private key in the config.
+// AllowedIP holds the peer's own addresses; when empty the repository picks free addresses itself.
+type PeerSpec struct {
+	InterfaceID string
+	Endpoint    string
+	AllowedIP   string
+	AllowedIPs  []string
+	PublicKey   string
+}
+
+// WireguardPeer.AllowedIP and interface addresses hold one prefix per address family,
+// joined with ", " (e.g. "10.0.0.2/32, fd00::2/128") so they can be written to wireguard configs as is.
 type WireguardPeer struct {
 	ID          string
 	InterfaceID string
@@ -40,6 +106,124 @@ type WireguardPeer struct {
 	Config      string
 }
 
+// SplitAddresses splits a comma separated address list into trimmed, non-empty items.
+func SplitAddresses(value string) []string {
+	items := make([]string, 0, 2)
+	for _, item := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" {
+			continue
+		}
+		items = append(items, trimmed)
+	}
+	return items
+}
+
+// JoinAddresses joins addresses in the format read by SplitAddresses.
+func JoinAddresses(items []string) string {
+	return strings.Join(items, ", ")
+}
+
+// ReplacePeerConfigServerPublicKey rewrites the PublicKey line of the [Peer] section, which holds the server key.
+func ReplacePeerConfigServerPublicKey(config string, publicKey string) string {
+	if config == "" || publicKey == "" {
+		return config
+	}
+
+	lines := strings.Split(config, "\n")
+	updated := false
+	inPeerSection := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inPeerSection = trimmed == "[Peer]"
+			continue
+		}
+		if !inPeerSection || !strings.HasPrefix(trimmed, "PublicKey =") {
+			continue
+		}
+		prefixIndex := strings.Index(line, "PublicKey =")
+		lines[i] = line[:prefixIndex] + "PublicKey = " + publicKey
+		updated = true
+	}
+	if !updated {
+		return config
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AccessRule accepts traffic towards CIDR over Protocol. Ports lists the tcp or udp port ranges it accepts
+// ("22,8000-8080"); empty Ports accept every port.
+type AccessRule struct {
+	CIDR     string
+	Protocol string
+	Ports    string
+}
+
+// PortRange is an inclusive range of tcp or udp ports.
+type PortRange struct {
+	First uint16
+	Last  uint16
+}
+
+// String formats the range as "22" or "8000-8080".
+func (portRange PortRange) String() string {
+	if portRange.First == portRange.Last {
+		return strconv.Itoa(int(portRange.First))
+	}
+	return fmt.Sprintf("%d-%d", portRange.First, portRange.Last)
+}
+
+// ParsePortRanges parses a comma separated list of ports and port ranges ("22,8000-8080") into sorted ranges.
+func ParsePortRanges(value string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		firstText, lastText, isRange := strings.Cut(item, "-")
+		first, err := parsePort(firstText)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePort(lastText); err != nil {
+				return nil, err
+			}
+		}
+		if first > last {
+			return nil, fmt.Errorf("invalid port range %q", item)
+		}
+		ranges = append(ranges, PortRange{First: first, Last: last})
+	}
+	slices.SortFunc(ranges, func(a, b PortRange) int {
+		if a.First != b.First {
+			return int(a.First) - int(b.First)
+		}
+		return int(a.Last) - int(b.Last)
+	})
+	return slices.Compact(ranges), nil
+}
+
+// JoinPortRanges formats ranges in the format read by ParsePortRanges.
+func JoinPortRanges(ranges []PortRange) string {
+	items := make([]string, 0, len(ranges))
+	for _, portRange := range ranges {
+		items = append(items, portRange.String())
+	}
+	return strings.Join(items, ",")
+}
+
+func parsePort(value string) (uint16, error) {
+	port, err := strconv.ParseUint(strings.TrimSpace(value), 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return uint16(port), nil
+}
+
 type PeerStat struct {
 	PeerID          string
 	InterfaceID     string
@@ -62,23 +246,46 @@ type WireguardConfig struct {
 	Config      string
 }
 
+// PeerFirewallRules describes the firewall rules of one peer: traffic arriving on InterfaceID from the
+// peer's addresses in PeerAllowedIP is accepted as Rules allow.
+type PeerFirewallRules struct {
+	InterfaceID   string
+	PeerAllowedIP string
+	Rules         []AccessRule
+}
+
+// InterfaceFirewallRules describes the terminal firewall rules of one interface, which handle the traffic
+// of its peers that no peer rule accepts according to IsolationMode.
+type InterfaceFirewallRules struct {
+	InterfaceID   string
+	IsolationMode string
+}
+
+// ErrWireguardPeerNotFound is returned by WireguardRepository.DeletePeer when the peer is not on its interface.
+var ErrWireguardPeerNotFound = errors.New("wireguard peer not found")
+
 type WireguardRepository interface {
 	ListInterfaces(ctx context.Context) ([]WireguardInterface, error)
 	GetInterface(ctx context.Context, interfaceID string) (WireguardInterface, error)
 	CreateInterface(ctx context.Context, config InterfaceConfig) (WireguardInterface, error)
 	UpdateInterface(ctx context.Context, config InterfaceConfig) (WireguardInterface, error)
 	DeleteInterface(ctx context.Context, interfaceID string) error
-	CreatePeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (WireguardPeer, error)
+	GeneratePrivateKey(ctx context.Context) (string, error)
+	CreatePeer(ctx context.Context, spec PeerSpec) (WireguardPeer, error)
 	UpdatePeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error
 	DeletePeer(ctx context.Context, peerID string) error
 	ListPeerStats(ctx context.Context) ([]PeerStat, error)
 	ListFirewallRules(ctx context.Context) (string, error)
 	ListConfigs(ctx context.Context, interfaceID string) ([]WireguardConfig, error)
 	EnsureFirewallChain(ctx context.Context) error
-	SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, allowedIPs []string) error
+	SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []AccessRule) error
 	RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error
+	// RestoreFirewallRules replaces the rules of every interface and peer with interfaces and peers, all at once.
+	RestoreFirewallRules(ctx context.Context, interfaces []InterfaceFirewallRules, peers []PeerFirewallRules) error
 }
 
+// PeerRecord.ExpiresAt is nil for peers that never expire.
+// PeerRecord.SuspendedAt is set while the peer is removed from wireguard and the firewall but kept in the database.
 type PeerRecord struct {
 	Email       string
 	PeerID      string
@@ -86,6 +293,9 @@ type PeerRecord struct {
 	AllowedIP   string
 	Config      string
 	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	SuspendedAt *time.Time
+	DeviceName  string
 }
 
 type PeerStore interface {
@@ -98,6 +308,9 @@ type PeerStore interface {
 
 	Create(ctx context.Context, record PeerRecord) error
 	UpdateConfig(ctx context.Context, peerID string, config string) error
+	UpdateExpiry(ctx context.Context, peerID string, expiresAt *time.Time) error
+	UpdateSuspension(ctx context.Context, peerID string, suspendedAt *time.Time) error
+	ListExpired(ctx context.Context, now time.Time) ([]PeerRecord, error)
 	DeleteByPeerID(ctx context.Context, peerID string) error
 	DeleteByInterface(ctx context.Context, interfaceID string) error
 }
@@ -108,45 +321,148 @@ type InterfaceStore interface {
 	Create(ctx context.Context, config InterfaceConfig) error
 	Update(ctx context.Context, config InterfaceConfig) error
 	Delete(ctx context.Context, id string) error
+	GetPrivateKey(ctx context.Context, id string) (string, error)
+	UpdatePrivateKey(ctx context.Context, id string, privateKey string) error
 }
 
 type AllowedEmailStore interface {
 	ListByInterface(ctx context.Context, interfaceID string) ([]AllowedEmail, error)
 	ListInterfaceIDsByEmail(ctx context.Context, email string) ([]string, error)
 	Exists(ctx context.Context, interfaceID string, email string) (bool, error)
-	Create(ctx context.Context, interfaceID string, email string) error
+	// ListKnownEmails returns every email that owns a peer or is allowed by an exact rule.
+	ListKnownEmails(ctx context.Context) ([]string, error)
+	Create(ctx context.Context, interfaceID string, email string, ruleType string) error
 	Delete(ctx context.Context, interfaceID string, email string) error
 	DeleteByInterface(ctx context.Context, interfaceID string) error
 }
 
+// AllowedEmail is an access rule of an interface. Email holds the rule's pattern; see AllowedEmailRuleExact.
 type AllowedEmail struct {
 	InterfaceID string
 	Email       string
+	RuleType    string
 	CreatedAt   time.Time
 }
 
+// InterfaceRoute is an access rule every peer of an interface gets.
 type InterfaceRoute struct {
 	InterfaceID string
 	CIDR        string
+	Protocol    string
+	Ports       string
 	CreatedAt   time.Time
 }
 
+// AccessRule returns the access rule of the route.
+func (route InterfaceRoute) AccessRule() AccessRule {
+	return AccessRule{CIDR: route.CIDR, Protocol: route.Protocol, Ports: route.Ports}
+}
+
+// PeerRoute is an access rule of a single peer.
 type PeerRoute struct {
 	PeerID    string
 	CIDR      string
+	Protocol  string
+	Ports     string
 	CreatedAt time.Time
 }
 
+// AccessRule returns the access rule of the route.
+func (route PeerRoute) AccessRule() AccessRule {
+	return AccessRule{CIDR: route.CIDR, Protocol: route.Protocol, Ports: route.Ports}
+}
+
+// RouteCIDRs returns the CIDRs of the routes of an interface followed by those of a peer.
+func RouteCIDRs(interfaceRoutes []InterfaceRoute, peerRoutes []PeerRoute) []string {
+	cidrs := make([]string, 0, len(interfaceRoutes)+len(peerRoutes))
+	for _, route := range interfaceRoutes {
+		cidrs = append(cidrs, route.CIDR)
+	}
+	for _, route := range peerRoutes {
+		cidrs = append(cidrs, route.CIDR)
+	}
+	return cidrs
+}
+
+// RouteAccessRules returns the access rules of a peer: the routes of its interface followed by its own.
+func RouteAccessRules(interfaceRoutes []InterfaceRoute, peerRoutes []PeerRoute) []AccessRule {
+	rules := make([]AccessRule, 0, len(interfaceRoutes)+len(peerRoutes))
+	for _, route := range interfaceRoutes {
+		rules = append(rules, route.AccessRule())
+	}
+	for _, route := range peerRoutes {
+		rules = append(rules, route.AccessRule())
+	}
+	return rules
+}
+
+// InterfaceGroup grants every member of GroupName access to the interface, as if each member's email were allowed.
+type InterfaceGroup struct {
+	InterfaceID string
+	GroupName   string
+	CreatedAt   time.Time
+}
+
+// UserIdentity is an authenticated william-server user. Groups are the groups asserted by the identity provider.
+type UserIdentity struct {
+	Email  string
+	Groups []string
+}
+
+// GroupStore holds interface to group bindings and the locally synced group memberships.
+type GroupStore interface {
+	ListByInterface(ctx context.Context, interfaceID string) ([]InterfaceGroup, error)
+	ListInterfaceIDsByGroups(ctx context.Context, groups []string) ([]string, error)
+	Create(ctx context.Context, interfaceID string, groupName string) error
+	Delete(ctx context.Context, interfaceID string, groupName string) error
+	ListGroupsByEmail(ctx context.Context, email string) ([]string, error)
+}
+
 type InterfaceRouteStore interface {
 	ListByInterface(ctx context.Context, interfaceID string) ([]InterfaceRoute, error)
-	Create(ctx context.Context, interfaceID string, cidr string) error
-	Delete(ctx context.Context, interfaceID string, cidr string) error
+	Create(ctx context.Context, interfaceID string, rule AccessRule) error
+	Delete(ctx context.Context, interfaceID string, rule AccessRule) error
 	DeleteByInterface(ctx context.Context, interfaceID string) error
 }
 
 type PeerRouteStore interface {
 	ListByPeer(ctx context.Context, peerID string) ([]PeerRoute, error)
-	Create(ctx context.Context, peerID string, cidr string) error
-	Delete(ctx context.Context, peerID string, cidr string) error
+	Create(ctx context.Context, peerID string, rule AccessRule) error
+	Delete(ctx context.Context, peerID string, rule AccessRule) error
 	DeleteByPeer(ctx context.Context, peerID string) error
 }
+
+// IPAllocation is a peer host address (e.g. "10.0.0.2/32") handed out from an interface prefix.
+// ReleasedAt is set once the peer is gone; the address stays blocked until the reuse cooldown has passed.
+type IPAllocation struct {
+	InterfaceID string
+	Address     string
+	PeerID      string
+	ReleasedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+// IPReservation keeps a range of interface addresses out of automatic allocation.
+type IPReservation struct {
+	InterfaceID string
+	CIDR        string
+	Description string
+	CreatedAt   time.Time
+}
+
+// ErrIPAddressAllocated is returned when a requested address is already held by another allocation.
+var ErrIPAddressAllocated = errors.New("ip address is already allocated")
+
+// IPAllocationStore.Allocate takes the requested addresses as is and picks free addresses
+// for the remaining interface address families. Reserved ranges only apply to picked addresses.
+type IPAllocationStore interface {
+	ListByInterface(ctx context.Context, interfaceID string) ([]IPAllocation, error)
+	Allocate(ctx context.Context, interfaceID string, requested string) (string, error)
+	Assign(ctx context.Context, interfaceID string, addresses string, peerID string) error
+	Release(ctx context.Context, interfaceID string, addresses string) error
+	ReleaseByPeer(ctx context.Context, peerID string) error
+
+	ListReservations(ctx context.Context, interfaceID string) ([]IPReservation, error)
+	CreateReservation(ctx context.Context, reservation IPReservation) error
+	DeleteReservation(ctx context.Context, interfaceID string, cidr string) error
+}
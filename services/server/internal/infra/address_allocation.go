@@ -0,0 +1,176 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// parseInterfacePrefixes parses an interface address list holding at most one IPv4 and one IPv6 prefix.
+func parseInterfacePrefixes(address string) ([]netip.Prefix, error) {
+	items := domain.SplitAddresses(address)
+	if len(items) == 0 {
+		return nil, errors.New("interface address is empty")
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(items))
+	seenFamilies := make(map[bool]struct{}, 2)
+	for _, item := range items {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("parse interface address: %w", err)
+		}
+		if _, ok := seenFamilies[prefix.Addr().Is4()]; ok {
+			return nil, fmt.Errorf("interface address %q has more than one prefix per address family", address)
+		}
+		seenFamilies[prefix.Addr().Is4()] = struct{}{}
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes, nil
+}
+
+// allocatePeerAddresses picks a free host address from every interface prefix and
+// returns them as a single address list, e.g. "10.0.0.2/32, fd00::2/128".
+// Addresses inside a reserved prefix are never picked.
+func allocatePeerAddresses(prefixes []netip.Prefix, used map[netip.Addr]struct{}, reserved []netip.Prefix) (string, error) {
+	addresses := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		interfaceAddr := prefix.Addr()
+		used[interfaceAddr] = struct{}{}
+		used[prefix.Masked().Addr()] = struct{}{}
+
+		candidate, err := nextAvailableAddress(prefix, interfaceAddr, used, reserved)
+		if err != nil {
+			return "", err
+		}
+		used[candidate] = struct{}{}
+		addresses = append(addresses, netip.PrefixFrom(candidate, candidate.BitLen()).String())
+	}
+
+	return domain.JoinAddresses(addresses), nil
+}
+
+// allocateRequestedAddresses keeps the requested host address of each family and allocates
+// the families that were not requested. A requested address that is in use fails with
+// domain.ErrIPAddressAllocated.
+func allocateRequestedAddresses(prefixes []netip.Prefix, requested string, used map[netip.Addr]struct{}, reserved []netip.Prefix) (string, error) {
+	requestedByFamily := make(map[bool]netip.Addr, 2)
+	for _, item := range domain.SplitAddresses(requested) {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return "", fmt.Errorf("parse requested address: %w", err)
+		}
+		addr := prefix.Addr()
+		if _, exists := used[addr]; exists {
+			return "", fmt.Errorf("%w: %s", domain.ErrIPAddressAllocated, addr)
+		}
+		requestedByFamily[addr.Is4()] = addr
+	}
+
+	addresses := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		addr, ok := requestedByFamily[prefix.Addr().Is4()]
+		if !ok {
+			allocated, err := allocatePeerAddresses([]netip.Prefix{prefix}, used, reserved)
+			if err != nil {
+				return "", err
+			}
+			addresses = append(addresses, allocated)
+			continue
+		}
+		if !prefix.Contains(addr) {
+			return "", fmt.Errorf("requested address %s is outside interface prefix %s", addr, prefix.Masked())
+		}
+		used[addr] = struct{}{}
+		addresses = append(addresses, netip.PrefixFrom(addr, addr.BitLen()).String())
+	}
+
+	return domain.JoinAddresses(addresses), nil
+}
+
+// usedPeerAddresses collects the host addresses already assigned in peer address lists.
+func usedPeerAddresses(addressLists ...string) map[netip.Addr]struct{} {
+	used := make(map[netip.Addr]struct{})
+	for _, list := range addressLists {
+		for _, item := range domain.SplitAddresses(list) {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				continue
+			}
+			used[prefix.Addr()] = struct{}{}
+		}
+	}
+	return used
+}
+
+func nextAvailableAddress(prefix netip.Prefix, interfaceAddr netip.Addr, used map[netip.Addr]struct{}, reserved []netip.Prefix) (netip.Addr, error) {
+	if prefix.Bits() >= prefix.Addr().BitLen()-1 {
+		return netip.Addr{}, errors.New("prefix too small to allocate address")
+	}
+
+	networkAddr := prefix.Masked().Addr()
+	for candidate := networkAddr.Next(); candidate.IsValid() && prefix.Contains(candidate); candidate = candidate.Next() {
+		if candidate == interfaceAddr {
+			continue
+		}
+		if reservedPrefix, ok := containingPrefix(reserved, candidate); ok {
+			// Skip the whole reserved range instead of walking it address by address.
+			candidate = lastAddress(reservedPrefix)
+			continue
+		}
+		if _, exists := used[candidate]; exists {
+			continue
+		}
+		return candidate, nil
+	}
+
+	return netip.Addr{}, fmt.Errorf("no available address in prefix %s", prefix.Masked())
+}
+
+// parseReservedPrefixes parses reserved CIDRs, ignoring entries that are not valid prefixes.
+func parseReservedPrefixes(cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+func containingPrefix(prefixes []netip.Prefix, addr netip.Addr) (netip.Prefix, bool) {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return prefix, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// lastAddress returns the highest address covered by prefix.
+func lastAddress(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Masked().Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - bit%8)
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
+
+// addressFamilyMatches reports whether both CIDRs belong to the same address family.
+func addressFamilyMatches(left string, right string) bool {
+	leftPrefix, err := netip.ParsePrefix(left)
+	if err != nil {
+		return false
+	}
+	rightPrefix, err := netip.ParsePrefix(right)
+	if err != nil {
+		return false
+	}
+	return leftPrefix.Addr().Is4() == rightPrefix.Addr().Is4()
+}
@@ -0,0 +1,175 @@
+package infra
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const (
+	AdminAuthModeHeader = "header"
+	AdminAuthModeJWT    = "jwt"
+	AdminAuthModeNone   = "none"
+
+	// AdminServiceTokenHeader carries the shared token william-server uses to call admin-server.
+	AdminServiceTokenHeader = "X-William-Service-Token"
+	// adminServiceSubject is the principal of requests authenticated with the service token.
+	adminServiceSubject = "service:william-server"
+
+	defaultAdminAuthHeader      = "X-Pomerium-Claim-Email"
+	defaultAdminJWTHeader       = "Authorization"
+	defaultAdminJWTSubjectClaim = "email"
+)
+
+// AdminAuthenticator identifies callers of the admin API from request headers.
+// In header mode the identity is taken from a header set by a trusted proxy; in jwt mode
+// it is read from a claim of a JWT verified against a local JWKS file.
+type AdminAuthenticator struct {
+	mode         string
+	header       string
+	verifier     *JWTVerifier
+	subjectClaim string
+	serviceToken string
+	// insecureServiceToken allows the service token on a listener without mutual TLS.
+	insecureServiceToken bool
+}
+
+// LoadAdminAuthenticator configures admin authentication from the environment:
+//
+//	WILLIAM_ADMIN_AUTH_MODE           header, jwt or none (none disables authentication)
+//	WILLIAM_ADMIN_AUTH_HEADER         identity header for header mode
+//	WILLIAM_ADMIN_JWKS_FILE           JWKS used to verify tokens in jwt mode
+//	WILLIAM_ADMIN_JWT_HEADER          header carrying the token, "Authorization" expects a Bearer token
+//	WILLIAM_ADMIN_JWT_ISSUER          expected iss, optional
+//	WILLIAM_ADMIN_JWT_AUDIENCE        expected aud, optional
+//	WILLIAM_ADMIN_JWT_SUBJECT_CLAIM   claim used as the subject, defaults to email
+//	WILLIAM_ADMIN_SERVICE_TOKEN       shared token accepted from william-server, required unless mode is none
+//	WILLIAM_ADMIN_INSECURE_SERVICE_TOKEN=1  accept the service token without mutual TLS; for local development only
+func LoadAdminAuthenticator() (*AdminAuthenticator, error) {
+	authenticator := &AdminAuthenticator{
+		mode:                 strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_AUTH_MODE")),
+		serviceToken:         strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_SERVICE_TOKEN")),
+		insecureServiceToken: os.Getenv("WILLIAM_ADMIN_INSECURE_SERVICE_TOKEN") == "1",
+	}
+
+	switch authenticator.mode {
+	case AdminAuthModeHeader:
+		authenticator.header = envOrDefault("WILLIAM_ADMIN_AUTH_HEADER", defaultAdminAuthHeader)
+	case AdminAuthModeJWT:
+		path := os.Getenv("WILLIAM_ADMIN_JWKS_FILE")
+		if path == "" {
+			return nil, errors.New("WILLIAM_ADMIN_JWKS_FILE is required in jwt mode")
+		}
+		keySet, err := LoadJWKSFile(path)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.verifier = NewJWTVerifier(keySet, os.Getenv("WILLIAM_ADMIN_JWT_ISSUER"), os.Getenv("WILLIAM_ADMIN_JWT_AUDIENCE"))
+		authenticator.header = envOrDefault("WILLIAM_ADMIN_JWT_HEADER", defaultAdminJWTHeader)
+		authenticator.subjectClaim = envOrDefault("WILLIAM_ADMIN_JWT_SUBJECT_CLAIM", defaultAdminJWTSubjectClaim)
+	case AdminAuthModeNone:
+		log.Printf("admin authentication is disabled; every caller is treated as owner")
+	case "":
+		return nil, errors.New("WILLIAM_ADMIN_AUTH_MODE is required (header, jwt or none)")
+	default:
+		return nil, fmt.Errorf("unknown WILLIAM_ADMIN_AUTH_MODE %q", authenticator.mode)
+	}
+
+	// william-server authenticates with the service token only, so without one every call it makes is rejected.
+	if authenticator.mode != AdminAuthModeNone && authenticator.serviceToken == "" {
+		return nil, fmt.Errorf("WILLIAM_ADMIN_SERVICE_TOKEN is required in %s mode; william-server cannot call admin-server without it", authenticator.mode)
+	}
+
+	return authenticator, nil
+}
+
+// LoadAdminBootstrapOwners reads WILLIAM_ADMIN_OWNERS, a comma separated list of subjects that are always owners.
+func LoadAdminBootstrapOwners() []string {
+	return strings.FieldsFunc(os.Getenv("WILLIAM_ADMIN_OWNERS"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+}
+
+// WithoutServiceToken drops the service token from requests before they reach next. admin-server serves the
+// listener facing Pomerium through it once william-server has its own mutual TLS listener, so a leaked token
+// is useless without the client certificate.
+func WithoutServiceToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(AdminServiceTokenHeader)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckServiceTokenTransport refuses a service token when william-server has no mutual TLS listener, since the
+// token alone would then grant operator access to anyone reaching the listener facing Pomerium.
+func (authenticator *AdminAuthenticator) CheckServiceTokenTransport(mutualTLS bool) error {
+	if authenticator.serviceToken == "" || mutualTLS {
+		return nil
+	}
+	if !authenticator.insecureServiceToken {
+		return errors.New("WILLIAM_ADMIN_SERVICE_TOKEN requires mutual TLS (WILLIAM_ADMIN_TLS_CERT_FILE); set WILLIAM_ADMIN_INSECURE_SERVICE_TOKEN=1 to accept it without")
+	}
+	log.Printf("WILLIAM_ADMIN_INSECURE_SERVICE_TOKEN is set; the service token is accepted without mutual TLS")
+	return nil
+}
+
+func (authenticator *AdminAuthenticator) Authenticate(header http.Header) (domain.AdminPrincipal, error) {
+	if token := header.Get(AdminServiceTokenHeader); token != "" && authenticator.serviceToken != "" {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(authenticator.serviceToken)) != 1 {
+			return domain.AdminPrincipal{}, errors.New("invalid service token")
+		}
+		return domain.AdminPrincipal{Subject: adminServiceSubject, Role: domain.AdminRoleOperator}, nil
+	}
+
+	switch authenticator.mode {
+	case AdminAuthModeHeader:
+		subject := strings.TrimSpace(header.Get(authenticator.header))
+		if subject == "" {
+			return domain.AdminPrincipal{}, fmt.Errorf("%s header is required", authenticator.header)
+		}
+		return domain.AdminPrincipal{Subject: subject}, nil
+	case AdminAuthModeJWT:
+		return authenticator.authenticateJWT(header)
+	case AdminAuthModeNone:
+		return domain.AdminPrincipal{Subject: "anonymous", Role: domain.AdminRoleOwner}, nil
+	default:
+		return domain.AdminPrincipal{}, errors.New("admin authentication is not configured")
+	}
+}
+
+func (authenticator *AdminAuthenticator) authenticateJWT(header http.Header) (domain.AdminPrincipal, error) {
+	token := strings.TrimSpace(header.Get(authenticator.header))
+	if strings.EqualFold(authenticator.header, "Authorization") {
+		scheme, value, found := strings.Cut(token, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return domain.AdminPrincipal{}, errors.New("bearer token is required")
+		}
+		token = strings.TrimSpace(value)
+	}
+	if token == "" {
+		return domain.AdminPrincipal{}, fmt.Errorf("%s header is required", authenticator.header)
+	}
+
+	claims, err := authenticator.verifier.Verify(token)
+	if err != nil {
+		return domain.AdminPrincipal{}, err
+	}
+	subject, _ := claims[authenticator.subjectClaim].(string)
+	if subject == "" {
+		return domain.AdminPrincipal{}, fmt.Errorf("jwt has no %s claim", authenticator.subjectClaim)
+	}
+	return domain.AdminPrincipal{Subject: subject}, nil
+}
+
+func envOrDefault(key string, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
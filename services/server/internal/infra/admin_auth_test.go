@@ -0,0 +1,81 @@
+package infra
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+func TestCheckServiceTokenTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		insecure  string
+		mutualTLS bool
+		wantErr   bool
+	}{
+		{name: "token with mutual tls", token: "secret", mutualTLS: true},
+		{name: "token without mutual tls", token: "secret", wantErr: true},
+		{name: "token allowed without mutual tls", token: "secret", insecure: "1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("WILLIAM_ADMIN_AUTH_MODE", AdminAuthModeHeader)
+			t.Setenv("WILLIAM_ADMIN_SERVICE_TOKEN", test.token)
+			t.Setenv("WILLIAM_ADMIN_INSECURE_SERVICE_TOKEN", test.insecure)
+			authenticator, err := LoadAdminAuthenticator()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = authenticator.CheckServiceTokenTransport(test.mutualTLS)
+			if (err != nil) != test.wantErr {
+				t.Errorf("err = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadAdminAuthenticatorRequiresServiceToken(t *testing.T) {
+	t.Setenv("WILLIAM_ADMIN_SERVICE_TOKEN", "")
+	t.Setenv("WILLIAM_ADMIN_AUTH_MODE", AdminAuthModeHeader)
+	if _, err := LoadAdminAuthenticator(); err == nil {
+		t.Error("header mode without a service token was accepted")
+	}
+
+	t.Setenv("WILLIAM_ADMIN_AUTH_MODE", AdminAuthModeNone)
+	if _, err := LoadAdminAuthenticator(); err != nil {
+		t.Errorf("none mode without a service token: %v", err)
+	}
+}
+
+func TestWithoutServiceTokenHidesTokenFromAuthenticator(t *testing.T) {
+	t.Setenv("WILLIAM_ADMIN_AUTH_MODE", AdminAuthModeHeader)
+	t.Setenv("WILLIAM_ADMIN_SERVICE_TOKEN", "secret")
+	authenticator, err := LoadAdminAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{}
+	header.Set(AdminServiceTokenHeader, "secret")
+	principal, err := authenticator.Authenticate(header)
+	if err != nil || principal.Role != domain.AdminRoleOperator {
+		t.Fatalf("Authenticate with the service token = %+v, %v", principal, err)
+	}
+
+	var stripped http.Header
+	handler := WithoutServiceToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stripped = r.Header
+	}))
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.Header = header.Clone()
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if _, err := authenticator.Authenticate(stripped); err == nil {
+		t.Error("Authenticate accepted a request whose service token was stripped")
+	}
+}
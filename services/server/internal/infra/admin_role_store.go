@@ -0,0 +1,71 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+type SQLAdminRoleStore struct {
+	db *sql.DB
+}
+
+func NewSQLAdminRoleStore(db *sql.DB) *SQLAdminRoleStore {
+	return &SQLAdminRoleStore{db: db}
+}
+
+func (store *SQLAdminRoleStore) Get(ctx context.Context, subject string) (domain.AdminRoleAssignment, error) {
+	var assignment domain.AdminRoleAssignment
+	err := store.db.QueryRowContext(ctx, `
+		SELECT subject, role, created_at
+		FROM admin_role_assignments
+		WHERE subject = $1
+	`, subject).Scan(&assignment.Subject, &assignment.Role, &assignment.CreatedAt)
+	if err != nil {
+		return domain.AdminRoleAssignment{}, err
+	}
+	return assignment, nil
+}
+
+func (store *SQLAdminRoleStore) List(ctx context.Context) ([]domain.AdminRoleAssignment, error) {
+	rows, err := store.db.QueryContext(ctx, `
+		SELECT subject, role, created_at
+		FROM admin_role_assignments
+		ORDER BY subject
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []domain.AdminRoleAssignment
+	for rows.Next() {
+		var assignment domain.AdminRoleAssignment
+		if err := rows.Scan(&assignment.Subject, &assignment.Role, &assignment.CreatedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (store *SQLAdminRoleStore) Upsert(ctx context.Context, subject string, role string) error {
+	_, err := store.db.ExecContext(ctx, `
+		INSERT INTO admin_role_assignments (subject, role)
+		VALUES ($1, $2)
+		ON CONFLICT (subject) DO UPDATE SET role = EXCLUDED.role
+	`, subject, role)
+	return err
+}
+
+func (store *SQLAdminRoleStore) Delete(ctx context.Context, subject string) error {
+	_, err := store.db.ExecContext(ctx, `
+		DELETE FROM admin_role_assignments
+		WHERE subject = $1
+	`, subject)
+	return err
+}
@@ -0,0 +1,39 @@
+package infra
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	adminv1 "github.com/nomuken/william/services/server/gen/proto/admin/v1"
+	"github.com/nomuken/william/services/server/gen/proto/admin/v1/adminv1connect"
+)
+
+// AdminRPCAccessRevoker revokes user access through the admin-server DeleteAllowedEmail and DeletePeer RPCs,
+// so peers are torn down exactly as when an operator removes them.
+type AdminRPCAccessRevoker struct {
+	client adminv1connect.WilliamAdminServiceClient
+}
+
+func NewAdminRPCAccessRevoker(config AdminServiceClientConfig) *AdminRPCAccessRevoker {
+	return &AdminRPCAccessRevoker{client: newAdminServiceClient(config)}
+}
+
+func (revoker *AdminRPCAccessRevoker) DeleteAllowedEmail(ctx context.Context, interfaceID string, email string) ([]string, error) {
+	response, err := revoker.client.DeleteAllowedEmail(ctx, connect.NewRequest(&adminv1.DeleteAllowedEmailRequest{
+		InterfaceId: interfaceID,
+		Email:       email,
+	}))
+	if err != nil {
+		// The email is granted by a pattern rule, or the interface is gone; either way there is no rule to delete.
+		if connect.CodeOf(err) == connect.CodeNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return response.Msg.GetRemovedPeerIds(), nil
+}
+
+func (revoker *AdminRPCAccessRevoker) DeletePeer(ctx context.Context, peerID string) error {
+	_, err := revoker.client.DeletePeer(ctx, connect.NewRequest(&adminv1.DeleteAdminPeerRequest{PeerId: peerID}))
+	return err
+}
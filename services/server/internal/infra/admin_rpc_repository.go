@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"connectrpc.com/connect"
 	adminv1 "github.com/nomuken/william/services/server/gen/proto/admin/v1"
@@ -12,19 +13,52 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-const adminServiceEndpoint = "http://admin-server:8081"
-
 // AdminRPCWireguardRepository delegates wireguard operations to admin-server.
 type AdminRPCWireguardRepository struct {
 	client adminv1connect.WilliamAdminServiceClient
 }
 
-func NewAdminRPCWireguardRepository(httpClient *http.Client) *AdminRPCWireguardRepository {
+// NewAdminRPCWireguardRepository builds a client for admin-server.
+// The service token is sent in AdminServiceTokenHeader on every call when it is not empty.
+func NewAdminRPCWireguardRepository(config AdminServiceClientConfig) *AdminRPCWireguardRepository {
+	return &AdminRPCWireguardRepository{client: newAdminServiceClient(config)}
+}
+
+func newAdminServiceClient(config AdminServiceClientConfig) adminv1connect.WilliamAdminServiceClient {
+	httpClient := config.HTTPClient
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
-	client := adminv1connect.NewWilliamAdminServiceClient(httpClient, adminServiceEndpoint)
-	return &AdminRPCWireguardRepository{client: client}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = defaultAdminServiceEndpoint
+	}
+	options := []connect.ClientOption{connect.WithInterceptors(requestIDInterceptor())}
+	if config.ServiceToken != "" {
+		options = append(options, connect.WithInterceptors(serviceTokenInterceptor(config.ServiceToken)))
+	}
+	return adminv1connect.NewWilliamAdminServiceClient(httpClient, endpoint, options...)
+}
+
+func serviceTokenInterceptor(serviceToken string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			req.Header().Set(AdminServiceTokenHeader, serviceToken)
+			return next(ctx, req)
+		}
+	}
+}
+
+// requestIDInterceptor forwards the ID of the request being served so admin-server records it in its audit events.
+func requestIDInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if requestID := domain.RequestIDFromContext(ctx); requestID != "" {
+				req.Header().Set(domain.RequestIDHeader, requestID)
+			}
+			return next(ctx, req)
+		}
+	}
 }
 
 func (repo *AdminRPCWireguardRepository) ListInterfaces(ctx context.Context) ([]domain.WireguardInterface, error) {
@@ -36,12 +70,15 @@ func (repo *AdminRPCWireguardRepository) ListInterfaces(ctx context.Context) ([]
 	interfaces := make([]domain.WireguardInterface, 0, len(response.Msg.Interfaces))
 	for _, iface := range response.Msg.Interfaces {
 		interfaces = append(interfaces, domain.WireguardInterface{
-			ID:         iface.GetId(),
-			Name:       iface.GetName(),
-			Address:    iface.GetAddress(),
-			ListenPort: iface.GetListenPort(),
-			PublicKey:  iface.GetPublicKey(),
-			MTU:        iface.GetMtu(),
+			ID:                iface.GetId(),
+			Name:              iface.GetName(),
+			Address:           iface.GetAddress(),
+			ListenPort:        iface.GetListenPort(),
+			PublicKey:         iface.GetPublicKey(),
+			MTU:               iface.GetMtu(),
+			PeerKeyPolicy:     iface.GetPeerKeyPolicy(),
+			PeerTTL:           time.Duration(iface.GetPeerTtlSeconds()) * time.Second,
+			MaxDevicesPerUser: iface.GetMaxDevicesPerUser(),
 		})
 	}
 
@@ -59,22 +96,29 @@ func (repo *AdminRPCWireguardRepository) GetInterface(ctx context.Context, inter
 	}
 
 	return domain.WireguardInterface{
-		ID:         iface.GetId(),
-		Name:       iface.GetName(),
-		Address:    iface.GetAddress(),
-		ListenPort: iface.GetListenPort(),
-		PublicKey:  iface.GetPublicKey(),
-		MTU:        iface.GetMtu(),
+		ID:                iface.GetId(),
+		Name:              iface.GetName(),
+		Address:           iface.GetAddress(),
+		ListenPort:        iface.GetListenPort(),
+		PublicKey:         iface.GetPublicKey(),
+		MTU:               iface.GetMtu(),
+		PeerKeyPolicy:     iface.GetPeerKeyPolicy(),
+		PeerTTL:           time.Duration(iface.GetPeerTtlSeconds()) * time.Second,
+		MaxDevicesPerUser: iface.GetMaxDevicesPerUser(),
 	}, nil
 }
 
 func (repo *AdminRPCWireguardRepository) CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
 	response, err := repo.client.CreateInterface(ctx, connect.NewRequest(&adminv1.CreateAdminInterfaceRequest{
-		Name:       config.Name,
-		Address:    config.Address,
-		ListenPort: config.ListenPort,
-		Mtu:        config.MTU,
-		Endpoint:   config.Endpoint,
+		Name:              config.Name,
+		Address:           config.Address,
+		ListenPort:        config.ListenPort,
+		Mtu:               config.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTtlSeconds:    int64(config.PeerTTL / time.Second),
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
 	}))
 	if err != nil {
 		return domain.WireguardInterface{}, err
@@ -86,23 +130,30 @@ func (repo *AdminRPCWireguardRepository) CreateInterface(ctx context.Context, co
 	}
 
 	return domain.WireguardInterface{
-		ID:         iface.GetId(),
-		Name:       iface.GetName(),
-		Address:    iface.GetAddress(),
-		ListenPort: iface.GetListenPort(),
-		PublicKey:  iface.GetPublicKey(),
-		MTU:        iface.GetMtu(),
+		ID:                iface.GetId(),
+		Name:              iface.GetName(),
+		Address:           iface.GetAddress(),
+		ListenPort:        iface.GetListenPort(),
+		PublicKey:         iface.GetPublicKey(),
+		MTU:               iface.GetMtu(),
+		PeerKeyPolicy:     iface.GetPeerKeyPolicy(),
+		PeerTTL:           time.Duration(iface.GetPeerTtlSeconds()) * time.Second,
+		MaxDevicesPerUser: iface.GetMaxDevicesPerUser(),
 	}, nil
 }
 
 func (repo *AdminRPCWireguardRepository) UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
 	response, err := repo.client.UpdateInterface(ctx, connect.NewRequest(&adminv1.UpdateAdminInterfaceRequest{
-		Id:         config.ID,
-		Name:       config.Name,
-		Address:    config.Address,
-		ListenPort: config.ListenPort,
-		Mtu:        config.MTU,
-		Endpoint:   config.Endpoint,
+		Id:                config.ID,
+		Name:              config.Name,
+		Address:           config.Address,
+		ListenPort:        config.ListenPort,
+		Mtu:               config.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTtlSeconds:    int64(config.PeerTTL / time.Second),
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
 	}))
 	if err != nil {
 		return domain.WireguardInterface{}, err
@@ -114,12 +165,15 @@ func (repo *AdminRPCWireguardRepository) UpdateInterface(ctx context.Context, co
 	}
 
 	return domain.WireguardInterface{
-		ID:         iface.GetId(),
-		Name:       iface.GetName(),
-		Address:    iface.GetAddress(),
-		ListenPort: iface.GetListenPort(),
-		PublicKey:  iface.GetPublicKey(),
-		MTU:        iface.GetMtu(),
+		ID:                iface.GetId(),
+		Name:              iface.GetName(),
+		Address:           iface.GetAddress(),
+		ListenPort:        iface.GetListenPort(),
+		PublicKey:         iface.GetPublicKey(),
+		MTU:               iface.GetMtu(),
+		PeerKeyPolicy:     iface.GetPeerKeyPolicy(),
+		PeerTTL:           time.Duration(iface.GetPeerTtlSeconds()) * time.Second,
+		MaxDevicesPerUser: iface.GetMaxDevicesPerUser(),
 	}, nil
 }
 
@@ -128,6 +182,11 @@ func (repo *AdminRPCWireguardRepository) DeleteInterface(ctx context.Context, in
 	return err
 }
 
+// GeneratePrivateKey is not supported for RPC repository
+func (repo *AdminRPCWireguardRepository) GeneratePrivateKey(ctx context.Context) (string, error) {
+	return "", errors.New("private key generation is not supported for RPC repository")
+}
+
 func (repo *AdminRPCWireguardRepository) UpdatePeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error {
 	_, err := repo.client.UpdateWireguardPeerAllowedIPs(ctx, connect.NewRequest(&adminv1.UpdateWireguardPeerAllowedIPsRequest{
 		InterfaceId: interfaceID,
@@ -137,11 +196,13 @@ func (repo *AdminRPCWireguardRepository) UpdatePeerAllowedIPs(ctx context.Contex
 	return err
 }
 
-func (repo *AdminRPCWireguardRepository) CreatePeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (domain.WireguardPeer, error) {
+func (repo *AdminRPCWireguardRepository) CreatePeer(ctx context.Context, spec domain.PeerSpec) (domain.WireguardPeer, error) {
 	response, err := repo.client.CreateWireguardPeer(ctx, connect.NewRequest(&adminv1.CreateWireguardPeerRequest{
-		InterfaceId: interfaceID,
-		Endpoint:    endpoint,
-		AllowedIps:  allowedIPs,
+		InterfaceId: spec.InterfaceID,
+		Endpoint:    spec.Endpoint,
+		AllowedIps:  spec.AllowedIPs,
+		PublicKey:   spec.PublicKey,
+		Address:     spec.AllowedIP,
 	}))
 	if err != nil {
 		return domain.WireguardPeer{}, err
@@ -206,17 +267,32 @@ func (repo *AdminRPCWireguardRepository) ListFirewallRules(ctx context.Context)
 	return response.Msg.GetRules(), nil
 }
 
-// EnsureFirewallChain is not supported for RPC repository
 func (repo *AdminRPCWireguardRepository) EnsureFirewallChain(ctx context.Context) error {
-	return errors.New("firewall chain management is not supported for RPC repository")
+	_, err := repo.client.EnsureFirewallChain(ctx, connect.NewRequest(&emptypb.Empty{}))
+	return err
 }
 
-// SyncPeerFirewallRules is not supported for RPC repository
-func (repo *AdminRPCWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, allowedIPs []string) error {
-	return errors.New("firewall rule sync is not supported for RPC repository")
+func (repo *AdminRPCWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
+	items := make([]*adminv1.AccessRule, 0, len(rules))
+	for _, rule := range rules {
+		items = append(items, &adminv1.AccessRule{Cidr: rule.CIDR, Protocol: rule.Protocol, Ports: rule.Ports})
+	}
+	_, err := repo.client.SyncPeerFirewallRules(ctx, connect.NewRequest(&adminv1.SyncPeerFirewallRulesRequest{
+		InterfaceId:   interfaceID,
+		PeerAllowedIp: peerAllowedIP,
+		Rules:         items,
+	}))
+	return err
 }
 
-// RemovePeerFirewallRules is not supported for RPC repository
 func (repo *AdminRPCWireguardRepository) RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error {
-	return errors.New("firewall rule removal is not supported for RPC repository")
+	_, err := repo.client.RemovePeerFirewallRules(ctx, connect.NewRequest(&adminv1.RemovePeerFirewallRulesRequest{
+		PeerAllowedIp: peerAllowedIP,
+	}))
+	return err
+}
+
+// RestoreFirewallRules is not supported for RPC repository
+func (repo *AdminRPCWireguardRepository) RestoreFirewallRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	return errors.New("firewall restore is not supported for RPC repository")
 }
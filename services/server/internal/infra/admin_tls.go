@@ -0,0 +1,155 @@
+package infra
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultAdminServiceEndpoint = "http://admin-server:8081"
+	defaultAdminClientIdentity  = "william-server"
+)
+
+// AdminServiceClientConfig says how william-server reaches admin-server.
+type AdminServiceClientConfig struct {
+	Endpoint     string
+	HTTPClient   *http.Client
+	ServiceToken string
+}
+
+// LoadAdminServiceClientConfig reads WILLIAM_ADMIN_ENDPOINT, which defaults to http://admin-server:8081, and
+// WILLIAM_ADMIN_SERVICE_TOKEN. An https endpoint is verified against WILLIAM_ADMIN_CA_FILE, or the system roots
+// when it is unset, and WILLIAM_ADMIN_CLIENT_CERT_FILE and WILLIAM_ADMIN_CLIENT_KEY_FILE are presented as the
+// client certificate for mutual TLS. WILLIAM_ADMIN_TLS_SERVER_NAME overrides the name expected in the server certificate.
+func LoadAdminServiceClientConfig() (AdminServiceClientConfig, error) {
+	config := AdminServiceClientConfig{
+		Endpoint:     strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_ENDPOINT")),
+		ServiceToken: os.Getenv("WILLIAM_ADMIN_SERVICE_TOKEN"),
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = defaultAdminServiceEndpoint
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return AdminServiceClientConfig{}, fmt.Errorf("parse WILLIAM_ADMIN_ENDPOINT: %w", err)
+	}
+
+	caFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_CA_FILE"))
+	certFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_CLIENT_CERT_FILE"))
+	keyFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_CLIENT_KEY_FILE"))
+	serverName := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_TLS_SERVER_NAME"))
+
+	switch endpoint.Scheme {
+	case "http":
+		if caFile != "" || certFile != "" || keyFile != "" {
+			return AdminServiceClientConfig{}, errors.New("WILLIAM_ADMIN_CA_FILE and WILLIAM_ADMIN_CLIENT_CERT_FILE require an https WILLIAM_ADMIN_ENDPOINT")
+		}
+		config.HTTPClient = http.DefaultClient
+		return config, nil
+	case "https":
+	default:
+		return AdminServiceClientConfig{}, fmt.Errorf("WILLIAM_ADMIN_ENDPOINT must be an http or https URL, got %q", config.Endpoint)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+	if caFile != "" {
+		tlsConfig.RootCAs, err = loadCertPool(caFile)
+		if err != nil {
+			return AdminServiceClientConfig{}, fmt.Errorf("load WILLIAM_ADMIN_CA_FILE: %w", err)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return AdminServiceClientConfig{}, fmt.Errorf("load admin client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	config.HTTPClient = &http.Client{Transport: transport}
+	return config, nil
+}
+
+// LoadAdminServiceTLSConfig reads the TLS settings of the admin-server listener for william-server.
+// WILLIAM_ADMIN_TLS_CERT_FILE and WILLIAM_ADMIN_TLS_KEY_FILE are the server certificate. Clients must present a
+// certificate issued by WILLIAM_ADMIN_TLS_CLIENT_CA_FILE whose common name or a DNS or URI SAN is listed in
+// WILLIAM_ADMIN_TLS_CLIENT_NAMES (comma separated, default "william-server"); other clients fail the handshake.
+// It returns nil when no server certificate is configured.
+func LoadAdminServiceTLSConfig() (*tls.Config, error) {
+	certFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_TLS_CERT_FILE"))
+	keyFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_TLS_KEY_FILE"))
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load admin server certificate: %w", err)
+	}
+
+	caFile := strings.TrimSpace(os.Getenv("WILLIAM_ADMIN_TLS_CLIENT_CA_FILE"))
+	if caFile == "" {
+		return nil, errors.New("WILLIAM_ADMIN_TLS_CLIENT_CA_FILE is required with WILLIAM_ADMIN_TLS_CERT_FILE")
+	}
+	clientCAs, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("load WILLIAM_ADMIN_TLS_CLIENT_CA_FILE: %w", err)
+	}
+
+	identities := strings.FieldsFunc(os.Getenv("WILLIAM_ADMIN_TLS_CLIENT_NAMES"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+	if len(identities) == 0 {
+		identities = []string{defaultAdminClientIdentity}
+	}
+
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		Certificates:     []tls.Certificate{certificate},
+		ClientAuth:       tls.RequireAndVerifyClientCert,
+		ClientCAs:        clientCAs,
+		VerifyConnection: verifyClientIdentity(identities),
+	}, nil
+}
+
+// verifyClientIdentity rejects client certificates whose common name and SANs are all missing from identities.
+func verifyClientIdentity(identities []string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("client certificate is required")
+		}
+		certificate := state.PeerCertificates[0]
+
+		names := append([]string{certificate.Subject.CommonName}, certificate.DNSNames...)
+		for _, uri := range certificate.URIs {
+			names = append(names, uri.String())
+		}
+		for _, name := range names {
+			if name != "" && slices.Contains(identities, name) {
+				return nil
+			}
+		}
+		return fmt.Errorf("client certificate %q is not an allowed admin client", certificate.Subject.CommonName)
+	}
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,184 @@
+package infra
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate is a key pair signed by a testCA, written to PEM files.
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certFile    string
+	keyFile     string
+}
+
+// newTestCertificate issues a certificate from template, signed by issuer or self signed when issuer is nil.
+func newTestCertificate(t *testing.T, template *x509.Certificate, issuer *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.certificate, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	issued := &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certFile:    filepath.Join(dir, "cert.pem"),
+		keyFile:     filepath.Join(dir, "key.pem"),
+	}
+	writePEM(t, issued.certFile, "CERTIFICATE", der)
+	writePEM(t, issued.keyFile, "EC PRIVATE KEY", keyDER)
+	return issued
+}
+
+func newTestCA(t *testing.T, name string) *testCertificate {
+	return newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+func newTestClientCertificate(t *testing.T, ca *testCertificate, commonName string, dnsNames []string, uris []string) *testCertificate {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    dnsNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		uri, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.URIs = append(template.URIs, uri)
+	}
+	return newTestCertificate(t, template, ca)
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdminServiceMutualTLS(t *testing.T) {
+	ca := newTestCA(t, "william test CA")
+	server := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "admin-server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	t.Setenv("WILLIAM_ADMIN_TLS_CERT_FILE", server.certFile)
+	t.Setenv("WILLIAM_ADMIN_TLS_KEY_FILE", server.keyFile)
+	t.Setenv("WILLIAM_ADMIN_TLS_CLIENT_CA_FILE", ca.certFile)
+	t.Setenv("WILLIAM_ADMIN_TLS_CLIENT_NAMES", "william-server, william.internal, spiffe://william/server")
+	tlsConfig, err := LoadAdminServiceTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	listener.TLS = tlsConfig
+	listener.Config.ErrorLog = log.New(io.Discard, "", 0)
+	listener.StartTLS()
+	defer listener.Close()
+
+	tests := []struct {
+		name   string
+		client *testCertificate
+		accept bool
+	}{
+		{name: "allowed common name", client: newTestClientCertificate(t, ca, "william-server", nil, nil), accept: true},
+		{name: "allowed dns san", client: newTestClientCertificate(t, ca, "other", []string{"william.internal"}, nil), accept: true},
+		{name: "allowed uri san", client: newTestClientCertificate(t, ca, "other", nil, []string{"spiffe://william/server"}), accept: true},
+		{name: "wrong identity", client: newTestClientCertificate(t, ca, "intruder", []string{"intruder.internal"}, nil)},
+		{name: "untrusted issuer", client: newTestClientCertificate(t, newTestCA(t, "other CA"), "william-server", nil, nil)},
+		{name: "no client certificate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("WILLIAM_ADMIN_ENDPOINT", listener.URL)
+			t.Setenv("WILLIAM_ADMIN_CA_FILE", ca.certFile)
+			if test.client != nil {
+				t.Setenv("WILLIAM_ADMIN_CLIENT_CERT_FILE", test.client.certFile)
+				t.Setenv("WILLIAM_ADMIN_CLIENT_KEY_FILE", test.client.keyFile)
+			}
+			clientConfig, err := LoadAdminServiceClientConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response, err := clientConfig.HTTPClient.Get(clientConfig.Endpoint)
+			if err == nil {
+				response.Body.Close()
+			}
+			if test.accept && err != nil {
+				t.Errorf("handshake failed: %v", err)
+			}
+			if !test.accept && err == nil {
+				t.Errorf("handshake succeeded, status %s", response.Status)
+			}
+		})
+	}
+}
+
+func TestLoadAdminServiceTLSConfigRequiresClientCA(t *testing.T) {
+	ca := newTestCA(t, "william test CA")
+	server := newTestClientCertificate(t, ca, "admin-server", nil, nil)
+	t.Setenv("WILLIAM_ADMIN_TLS_CERT_FILE", server.certFile)
+	t.Setenv("WILLIAM_ADMIN_TLS_KEY_FILE", server.keyFile)
+	t.Setenv("WILLIAM_ADMIN_TLS_CLIENT_CA_FILE", "")
+
+	if _, err := LoadAdminServiceTLSConfig(); err == nil {
+		t.Fatal("LoadAdminServiceTLSConfig accepted a server certificate without a client CA")
+	}
+}
@@ -0,0 +1,73 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// JSONLinesAuditSink appends every audit event to a file as one JSON object per line.
+type JSONLinesAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+type auditLine struct {
+	OccurredAt string          `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
+
+// OpenJSONLinesAuditSink opens path for appending, creating it readable only by the owner.
+func OpenJSONLinesAuditSink(path string) (*JSONLinesAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesAuditSink{file: file}, nil
+}
+
+func (sink *JSONLinesAuditSink) Write(event domain.AuditEvent) error {
+	line, err := json.Marshal(auditLine{
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Actor:      event.Actor,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     event.Before,
+		After:      event.After,
+		RequestID:  event.RequestID,
+	})
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	_, err = sink.file.Write(append(line, '\n'))
+	return err
+}
+
+// LoadAuditSinks opens the audit sinks configured in the environment. WILLIAM_AUDIT_LOG_FILE, when set,
+// is a file every audit event is appended to as a JSON line, in addition to the audit_events table.
+func LoadAuditSinks() ([]domain.AuditSink, error) {
+	var sinks []domain.AuditSink
+	if path := strings.TrimSpace(os.Getenv("WILLIAM_AUDIT_LOG_FILE")); path != "" {
+		sink, err := OpenJSONLinesAuditSink(path)
+		if err != nil {
+			return nil, fmt.Errorf("open WILLIAM_AUDIT_LOG_FILE: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
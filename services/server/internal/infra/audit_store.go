@@ -0,0 +1,77 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// SQLAuditStore appends audit events to the audit_events table, which rejects updates and deletes.
+type SQLAuditStore struct {
+	db *sql.DB
+}
+
+func NewSQLAuditStore(db *sql.DB) *SQLAuditStore {
+	return &SQLAuditStore{db: db}
+}
+
+func (store *SQLAuditStore) Append(ctx context.Context, event domain.AuditEvent) error {
+	_, err := store.db.ExecContext(ctx, `
+		INSERT INTO audit_events (occurred_at, actor, action, target_type, target_id, before_value, after_value, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, event.OccurredAt, event.Actor, event.Action, event.TargetType, event.TargetID, nullJSON(event.Before), nullJSON(event.After), event.RequestID)
+	return err
+}
+
+// List returns the events matching filter, newest first.
+func (store *SQLAuditStore) List(ctx context.Context, filter domain.AuditEventFilter) ([]domain.AuditEvent, error) {
+	rows, err := store.db.QueryContext(ctx, `
+		SELECT id, occurred_at, actor, action, target_type, target_id, before_value, after_value, request_id
+		FROM audit_events
+		WHERE ($1 = '' OR actor = $1)
+			AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR target_type = $3)
+			AND ($4 = '' OR target_id = $4)
+			AND ($5::timestamp IS NULL OR occurred_at >= $5)
+			AND ($6::timestamp IS NULL OR occurred_at < $6)
+			AND ($7::bigint = 0 OR id < $7)
+		ORDER BY id DESC
+		LIMIT $8
+	`, filter.Actor, filter.Action, filter.TargetType, filter.TargetID, nullTime(filter.Since), nullTime(filter.Until), filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var event domain.AuditEvent
+		var before, after []byte
+		if err := rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.Action, &event.TargetType, &event.TargetID, &before, &after, &event.RequestID); err != nil {
+			return nil, err
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// nullJSON stores an empty value as SQL NULL.
+func nullJSON(value json.RawMessage) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
+
+// nullTime stores a zero time as SQL NULL. occurred_at holds UTC wall-clock times.
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value.UTC(), Valid: !value.IsZero()}
+}
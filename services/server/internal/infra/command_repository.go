@@ -37,12 +37,14 @@ func (execRunner) RunWithInput(ctx context.Context, input string, name string, a
 	return formatCommandOutput(name, args, output, err)
 }
 
+// CommandWireguardRepository manages wireguard with the wg and ip commands and applies firewall rules with firewall.
 type CommandWireguardRepository struct {
-	runner CommandRunner
+	runner   CommandRunner
+	firewall FirewallDriver
 }
 
-func NewCommandWireguardRepository() *CommandWireguardRepository {
-	return &CommandWireguardRepository{runner: execRunner{}}
+func NewCommandWireguardRepository(firewall FirewallDriver) *CommandWireguardRepository {
+	return &CommandWireguardRepository{runner: execRunner{}, firewall: firewall}
 }
 
 func (repo *CommandWireguardRepository) ListInterfaces(ctx context.Context) ([]domain.WireguardInterface, error) {
@@ -75,23 +77,33 @@ func (repo *CommandWireguardRepository) GetInterface(ctx context.Context, interf
 }
 
 func (repo *CommandWireguardRepository) CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
-	if _, err := repo.runner.Run(ctx, "ip", "link", "add", "dev", config.ID, "type", "wireguard"); err != nil {
+	prefixes, err := parseInterfacePrefixes(config.Address)
+	if err != nil {
 		return domain.WireguardInterface{}, err
 	}
 
-	privateKey, err := repo.runner.Run(ctx, "wg", "genkey")
-	if err != nil {
+	if _, err := repo.runner.Run(ctx, "ip", "link", "add", "dev", config.ID, "type", "wireguard"); err != nil {
 		return domain.WireguardInterface{}, err
 	}
-	privateKey = strings.TrimSpace(privateKey)
+
+	privateKey := config.PrivateKey
+	if privateKey == "" {
+		generated, err := repo.GeneratePrivateKey(ctx)
+		if err != nil {
+			return domain.WireguardInterface{}, err
+		}
+		privateKey = generated
+	}
 
 	listenPort := strconv.FormatUint(uint64(config.ListenPort), 10)
 	if _, err := repo.runner.RunWithInput(ctx, privateKey+"\n", "wg", "set", config.ID, "private-key", "/dev/fd/0", "listen-port", listenPort); err != nil {
 		return domain.WireguardInterface{}, err
 	}
 
-	if _, err := repo.runner.Run(ctx, "ip", "address", "add", config.Address, "dev", config.ID); err != nil {
-		return domain.WireguardInterface{}, err
+	for _, prefix := range prefixes {
+		if _, err := repo.runner.Run(ctx, "ip", "address", "add", prefix.String(), "dev", config.ID); err != nil {
+			return domain.WireguardInterface{}, err
+		}
 	}
 
 	if _, err := repo.runner.Run(ctx, "ip", "link", "set", "mtu", strconv.FormatUint(uint64(config.MTU), 10), "dev", config.ID); err != nil {
@@ -102,19 +114,35 @@ func (repo *CommandWireguardRepository) CreateInterface(ctx context.Context, con
 		return domain.WireguardInterface{}, err
 	}
 
+	if err := repo.firewall.SyncInterfaceRules(ctx, config.ID, config.IsolationMode); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
 	return repo.describeInterface(ctx, config.ID)
 }
 
 func (repo *CommandWireguardRepository) UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
-	if _, err := repo.runner.Run(ctx, "ip", "address", "replace", config.Address, "dev", config.ID); err != nil {
+	prefixes, err := parseInterfacePrefixes(config.Address)
+	if err != nil {
 		return domain.WireguardInterface{}, err
 	}
+	for _, prefix := range prefixes {
+		if _, err := repo.runner.Run(ctx, "ip", "address", "replace", prefix.String(), "dev", config.ID); err != nil {
+			return domain.WireguardInterface{}, err
+		}
+	}
 
 	listenPort := strconv.FormatUint(uint64(config.ListenPort), 10)
 	if _, err := repo.runner.Run(ctx, "wg", "set", config.ID, "listen-port", listenPort); err != nil {
 		return domain.WireguardInterface{}, err
 	}
 
+	if config.PrivateKey != "" {
+		if _, err := repo.runner.RunWithInput(ctx, config.PrivateKey+"\n", "wg", "set", config.ID, "private-key", "/dev/fd/0"); err != nil {
+			return domain.WireguardInterface{}, err
+		}
+	}
+
 	if _, err := repo.runner.Run(ctx, "ip", "link", "set", "mtu", strconv.FormatUint(uint64(config.MTU), 10), "dev", config.ID); err != nil {
 		return domain.WireguardInterface{}, err
 	}
@@ -123,15 +151,31 @@ func (repo *CommandWireguardRepository) UpdateInterface(ctx context.Context, con
 		return domain.WireguardInterface{}, err
 	}
 
+	if err := repo.firewall.SyncInterfaceRules(ctx, config.ID, config.IsolationMode); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
 	return repo.describeInterface(ctx, config.ID)
 }
 
+func (repo *CommandWireguardRepository) GeneratePrivateKey(ctx context.Context) (string, error) {
+	// wg genkey
+	privateKey, err := repo.runner.Run(ctx, "wg", "genkey")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(privateKey), nil
+}
+
 func (repo *CommandWireguardRepository) DeleteInterface(ctx context.Context, interfaceID string) error {
-	_, err := repo.runner.Run(ctx, "ip", "link", "delete", "dev", interfaceID)
-	return err
+	if _, err := repo.runner.Run(ctx, "ip", "link", "delete", "dev", interfaceID); err != nil {
+		return err
+	}
+	return repo.firewall.RemoveInterfaceRules(ctx, interfaceID)
 }
 
-func (repo *CommandWireguardRepository) CreatePeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (domain.WireguardPeer, error) {
+func (repo *CommandWireguardRepository) CreatePeer(ctx context.Context, spec domain.PeerSpec) (domain.WireguardPeer, error) {
+	interfaceID := spec.InterfaceID
 	interfaceInfo, err := repo.describeInterface(ctx, interfaceID)
 	if err != nil {
 		if errors.Is(err, ErrInterfaceNotFound) {
@@ -140,47 +184,38 @@ func (repo *CommandWireguardRepository) CreatePeer(ctx context.Context, interfac
 		return domain.WireguardPeer{}, err
 	}
 
-	prefix, interfaceAddr, err := repo.interfacePrefix(ctx, interfaceID)
-	if err != nil {
-		return domain.WireguardPeer{}, err
-	}
-
-	usedAddrs, err := repo.listAllowedIPs(ctx, interfaceID)
-	if err != nil {
-		return domain.WireguardPeer{}, err
-	}
-	usedAddrs[interfaceAddr] = struct{}{}
-	usedAddrs[prefix.Masked().Addr()] = struct{}{}
+	allowedIP := spec.AllowedIP
+	if allowedIP == "" {
+		prefixes, err := repo.interfacePrefixes(ctx, interfaceID)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
 
-	peerAddr, err := nextAvailableIPv4(prefix, interfaceAddr, usedAddrs)
-	if err != nil {
-		return domain.WireguardPeer{}, err
-	}
-	allowedIP := fmt.Sprintf("%s/32", peerAddr.String())
+		usedAddrs, err := repo.listAllowedIPs(ctx, interfaceID)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
 
-	// wg genkey
-	privateKey, err := repo.runner.Run(ctx, "wg", "genkey")
-	if err != nil {
-		return domain.WireguardPeer{}, err
+		allowedIP, err = allocatePeerAddresses(prefixes, usedAddrs, nil)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
 	}
-	privateKey = strings.TrimSpace(privateKey)
 
-	// wg pubkey
-	publicKey, err := repo.runner.RunWithInput(ctx, privateKey+"\n", "wg", "pubkey")
+	privateKey, publicKey, err := repo.peerKeyPair(ctx, spec.PublicKey)
 	if err != nil {
 		return domain.WireguardPeer{}, err
 	}
-	publicKey = strings.TrimSpace(publicKey)
 
-	allowedIPs = normalizeAllowedIPs(allowedIP, allowedIPs)
+	allowedIPs := normalizeAllowedIPs(allowedIP, spec.AllowedIPs)
 	if _, err := repo.runner.Run(ctx, "wg", "set", interfaceID, "peer", publicKey, "allowed-ips", strings.Join(allowedIPs, ",")); err != nil {
 		return domain.WireguardPeer{}, err
 	}
 
-	if endpoint == "" {
+	if spec.Endpoint == "" {
 		return domain.WireguardPeer{}, errors.New("endpoint is required")
 	}
-	config, err := buildPeerConfig(privateKey, allowedIP, interfaceInfo.PublicKey, interfaceInfo.ListenPort, endpoint, allowedIPs)
+	config, err := buildPeerConfig(privateKey, allowedIP, interfaceInfo.PublicKey, interfaceInfo.ListenPort, spec.Endpoint, allowedIPs)
 	if err != nil {
 		return domain.WireguardPeer{}, err
 	}
@@ -194,6 +229,27 @@ func (repo *CommandWireguardRepository) CreatePeer(ctx context.Context, interfac
 	}, nil
 }
 
+// peerKeyPair returns the key pair for a new peer.
+// A client supplied public key is used as is and the private key is left empty.
+func (repo *CommandWireguardRepository) peerKeyPair(ctx context.Context, clientPublicKey string) (string, string, error) {
+	if clientPublicKey != "" {
+		return "", clientPublicKey, nil
+	}
+
+	// wg genkey
+	privateKey, err := repo.GeneratePrivateKey(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	// wg pubkey
+	publicKey, err := repo.runner.RunWithInput(ctx, privateKey+"\n", "wg", "pubkey")
+	if err != nil {
+		return "", "", err
+	}
+	return privateKey, strings.TrimSpace(publicKey), nil
+}
+
 func (repo *CommandWireguardRepository) UpdatePeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error {
 	if len(allowedIPs) == 0 {
 		return errors.New("allowed IPs are required")
@@ -303,11 +359,7 @@ func (repo *CommandWireguardRepository) ListConfigs(ctx context.Context, interfa
 }
 
 func (repo *CommandWireguardRepository) ListFirewallRules(ctx context.Context) (string, error) {
-	rules, err := repo.runner.Run(ctx, "iptables", "-S", "WILLIAM_FWD")
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(rules), nil
+	return repo.firewall.ListRules(ctx)
 }
 
 type peerTransfer struct {
@@ -381,10 +433,14 @@ func (repo *CommandWireguardRepository) describeInterface(ctx context.Context, n
 		return domain.WireguardInterface{}, fmt.Errorf("parse listen port: %w", err)
 	}
 
-	prefix, _, err := repo.interfacePrefix(ctx, name)
+	prefixes, err := repo.interfacePrefixes(ctx, name)
 	if err != nil {
 		return domain.WireguardInterface{}, err
 	}
+	addresses := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		addresses = append(addresses, prefix.String())
+	}
 
 	mtu, err := repo.interfaceMTU(ctx, name)
 	if err != nil {
@@ -394,42 +450,60 @@ func (repo *CommandWireguardRepository) describeInterface(ctx context.Context, n
 	return domain.WireguardInterface{
 		ID:         name,
 		Name:       name,
-		Address:    prefix.String(),
+		Address:    domain.JoinAddresses(addresses),
 		ListenPort: uint32(listenPort),
 		PublicKey:  publicKey,
 		MTU:        uint32(mtu),
 	}, nil
 }
 
-func (repo *CommandWireguardRepository) interfacePrefix(ctx context.Context, name string) (netip.Prefix, netip.Addr, error) {
-	// ip -4 addr show dev <interface>
-	output, err := repo.runner.Run(ctx, "ip", "-4", "addr", "show", "dev", name)
+// interfacePrefixes returns the first IPv4 and the first global IPv6 prefix of the interface.
+func (repo *CommandWireguardRepository) interfacePrefixes(ctx context.Context, name string) ([]netip.Prefix, error) {
+	// ip addr show dev <interface>
+	output, err := repo.runner.Run(ctx, "ip", "addr", "show", "dev", name)
 	if err != nil {
 		if strings.Contains(output, "does not exist") {
-			return netip.Prefix{}, netip.Addr{}, ErrInterfaceNotFound
+			return nil, ErrInterfaceNotFound
 		}
-		return netip.Prefix{}, netip.Addr{}, err
+		return nil, err
 	}
 
+	var ipv4, ipv6 netip.Prefix
 	scanner := bufio.NewScanner(strings.NewReader(output))
 	for scanner.Scan() {
 		fields := strings.Fields(scanner.Text())
-		for index, field := range fields {
-			if field == "inet" && index+1 < len(fields) {
-				prefix, err := netip.ParsePrefix(fields[index+1])
-				if err != nil {
-					return netip.Prefix{}, netip.Addr{}, fmt.Errorf("parse interface address: %w", err)
-				}
-				return prefix, prefix.Addr(), nil
-			}
+		if len(fields) < 2 || (fields[0] != "inet" && fields[0] != "inet6") {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse interface address: %w", err)
+		}
+		if prefix.Addr().IsLinkLocalUnicast() {
+			continue
+		}
+		if prefix.Addr().Is4() && !ipv4.IsValid() {
+			ipv4 = prefix
+		}
+		if prefix.Addr().Is6() && !ipv6.IsValid() {
+			ipv6 = prefix
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return netip.Prefix{}, netip.Addr{}, err
+		return nil, err
 	}
 
-	return netip.Prefix{}, netip.Addr{}, ErrInterfaceNotFound
+	prefixes := make([]netip.Prefix, 0, 2)
+	for _, prefix := range []netip.Prefix{ipv4, ipv6} {
+		if prefix.IsValid() {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, ErrInterfaceNotFound
+	}
+	return prefixes, nil
 }
 
 func (repo *CommandWireguardRepository) interfaceMTU(ctx context.Context, name string) (int, error) {
@@ -460,51 +534,21 @@ func (repo *CommandWireguardRepository) listAllowedIPs(ctx context.Context, inte
 		return nil, err
 	}
 
-	used := make(map[netip.Addr]struct{})
+	lists := make([]string, 0)
 	scanner := bufio.NewScanner(strings.NewReader(output))
 	for scanner.Scan() {
 		fields := strings.Fields(scanner.Text())
 		if len(fields) < 2 {
 			continue
 		}
-		for _, item := range strings.Split(fields[1], ",") {
-			prefix, err := netip.ParsePrefix(strings.TrimSpace(item))
-			if err != nil {
-				continue
-			}
-			if prefix.Addr().Is4() {
-				used[prefix.Addr()] = struct{}{}
-			}
-		}
+		lists = append(lists, strings.Join(fields[1:], ","))
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 
-	return used, nil
-}
-
-func nextAvailableIPv4(prefix netip.Prefix, interfaceAddr netip.Addr, used map[netip.Addr]struct{}) (netip.Addr, error) {
-	if !prefix.Addr().Is4() {
-		return netip.Addr{}, errors.New("interface address is not IPv4")
-	}
-	if prefix.Bits() >= 31 {
-		return netip.Addr{}, errors.New("prefix too small to allocate address")
-	}
-
-	networkAddr := prefix.Masked().Addr()
-	for candidate := networkAddr.Next(); prefix.Contains(candidate); candidate = candidate.Next() {
-		if candidate == interfaceAddr {
-			continue
-		}
-		if _, exists := used[candidate]; exists {
-			continue
-		}
-		return candidate, nil
-	}
-
-	return netip.Addr{}, errors.New("no available address in prefix")
+	return usedPeerAddresses(lists...), nil
 }
 
 //go:embed templates/peer.conf.tmpl
@@ -512,6 +556,9 @@ var peerTemplateFS embed.FS
 
 var peerTemplate = template.Must(template.ParseFS(peerTemplateFS, "templates/peer.conf.tmpl"))
 
+// peerPrivateKeyPlaceholder is rendered when the client keeps its private key to itself.
+const peerPrivateKeyPlaceholder = "<client-private-key>"
+
 type peerTemplateData struct {
 	PrivateKey      string
 	Address         string
@@ -522,6 +569,9 @@ type peerTemplateData struct {
 }
 
 func buildPeerConfig(privateKey, address, serverPublicKey string, listenPort uint32, endpoint string, allowedIPs []string) (string, error) {
+	if privateKey == "" {
+		privateKey = peerPrivateKeyPlaceholder
+	}
 	data := peerTemplateData{
 		PrivateKey:      privateKey,
 		Address:         address,
@@ -540,12 +590,12 @@ func buildPeerConfig(privateKey, address, serverPublicKey string, listenPort uin
 }
 
 func normalizeAllowedIPs(peerAllowedIP string, allowedIPs []string) []string {
-	items := make([]string, 0, len(allowedIPs)+1)
-	seen := make(map[string]struct{}, len(allowedIPs)+1)
+	items := make([]string, 0, len(allowedIPs)+2)
+	seen := make(map[string]struct{}, len(allowedIPs)+2)
 
-	if peerAllowedIP != "" {
-		items = append(items, peerAllowedIP)
-		seen[peerAllowedIP] = struct{}{}
+	for _, address := range domain.SplitAddresses(peerAllowedIP) {
+		items = append(items, address)
+		seen[address] = struct{}{}
 	}
 
 	for _, item := range allowedIPs {
@@ -572,104 +622,18 @@ func formatCommandOutput(name string, args []string, output []byte, err error) (
 	return trimmed, nil
 }
 
-// EnsureFirewallChain creates the WILLIAM_FWD chain if it doesn't exist and ensures it's called from FORWARD chain
 func (repo *CommandWireguardRepository) EnsureFirewallChain(ctx context.Context) error {
-	// Check if WILLIAM_FWD chain exists
-	if _, err := repo.runner.Run(ctx, "iptables", "-L", "WILLIAM_FWD", "-n"); err != nil {
-		// Chain doesn't exist, create it
-		if _, err := repo.runner.Run(ctx, "iptables", "-N", "WILLIAM_FWD"); err != nil {
-			return fmt.Errorf("create WILLIAM_FWD chain: %w", err)
-		}
-	}
-
-	// Check if FORWARD chain calls WILLIAM_FWD
-	output, err := repo.runner.Run(ctx, "iptables", "-S", "FORWARD")
-	if err != nil {
-		return fmt.Errorf("check FORWARD chain: %w", err)
-	}
-
-	if !strings.Contains(output, "-A FORWARD -j WILLIAM_FWD") {
-		// Add jump to WILLIAM_FWD at the beginning of FORWARD chain
-		if _, err := repo.runner.Run(ctx, "iptables", "-I", "FORWARD", "1", "-j", "WILLIAM_FWD"); err != nil {
-			return fmt.Errorf("add WILLIAM_FWD to FORWARD chain: %w", err)
-		}
-	}
-
-	return nil
+	return repo.firewall.EnsureChain(ctx)
 }
 
-// SyncPeerFirewallRules synchronizes iptables rules for a specific peer
-func (repo *CommandWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, allowedIPs []string) error {
-	// First, ensure the firewall chain exists
-	if err := repo.EnsureFirewallChain(ctx); err != nil {
-		return err
-	}
-
-	// Remove old rules for this peer
-	if err := repo.RemovePeerFirewallRules(ctx, peerAllowedIP); err != nil {
-		return err
-	}
-
-	// Extract source IP from CIDR (e.g., "10.0.0.2/32" -> "10.0.0.2")
-	sourceIP := strings.TrimSuffix(peerAllowedIP, "/32")
-
-	// Add new rules for each allowed destination
-	for _, destCIDR := range allowedIPs {
-		// Skip the peer's own IP
-		if destCIDR == peerAllowedIP {
-			continue
-		}
-
-		// Add rule: allow traffic from peer to destination
-		args := []string{
-			"-A", "WILLIAM_FWD",
-			"-i", interfaceID,
-			"-s", sourceIP,
-			"-d", destCIDR,
-			"-j", "ACCEPT",
-		}
-		if _, err := repo.runner.Run(ctx, "iptables", args...); err != nil {
-			return fmt.Errorf("add firewall rule for %s -> %s: %w", sourceIP, destCIDR, err)
-		}
-	}
-
-	return nil
+func (repo *CommandWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
+	return repo.firewall.SyncPeerRules(ctx, interfaceID, peerAllowedIP, rules)
 }
 
-// RemovePeerFirewallRules removes all iptables rules associated with a peer's IP
 func (repo *CommandWireguardRepository) RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error {
-	// Extract source IP from CIDR
-	sourceIP := strings.TrimSuffix(peerAllowedIP, "/32")
-
-	// Get current rules
-	output, err := repo.runner.Run(ctx, "iptables", "-S", "WILLIAM_FWD")
-	if err != nil {
-		// Chain might not exist yet
-		return nil
-	}
-
-	// Parse rules and delete matching ones
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Look for rules with this source IP
-		if !strings.Contains(line, "-s "+sourceIP) {
-			continue
-		}
-
-		// Convert "-A WILLIAM_FWD ..." to "-D WILLIAM_FWD ..."
-		if !strings.HasPrefix(line, "-A ") {
-			continue
-		}
-		deleteRule := "-D " + strings.TrimPrefix(line, "-A ")
-
-		// Split into args and execute
-		args := strings.Fields(deleteRule)
-		if _, err := repo.runner.Run(ctx, "iptables", args...); err != nil {
-			// Rule might have been already deleted, continue
-			continue
-		}
-	}
+	return repo.firewall.RemovePeerRules(ctx, peerAllowedIP)
+}
 
-	return scanner.Err()
+func (repo *CommandWireguardRepository) RestoreFirewallRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	return repo.firewall.RestoreRules(ctx, interfaces, peers)
 }
@@ -1,6 +1,10 @@
 package infra
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
 
 var ErrInterfaceNotFound = errors.New("wireguard interface not found")
-var ErrPeerNotFound = errors.New("wireguard peer not found")
+var ErrPeerNotFound = domain.ErrWireguardPeerNotFound
@@ -0,0 +1,185 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// FirewallDriver applies the firewall rules that limit where wireguard peers may send traffic.
+type FirewallDriver interface {
+	// EnsureChain prepares the firewall for peer rules.
+	EnsureChain(ctx context.Context) error
+	// SyncPeerRules replaces the rules of the peer at peerAllowedIP with rules implementing accessRules.
+	SyncPeerRules(ctx context.Context, interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) error
+	// RemovePeerRules removes every rule whose source is one of the addresses in peerAllowedIP.
+	RemovePeerRules(ctx context.Context, peerAllowedIP string) error
+	// SyncInterfaceRules replaces the terminal rules of an interface with the ones of isolationMode.
+	SyncInterfaceRules(ctx context.Context, interfaceID string, isolationMode string) error
+	// RemoveInterfaceRules removes every rule of traffic arriving on an interface.
+	RemoveInterfaceRules(ctx context.Context, interfaceID string) error
+	// RestoreRules replaces the rules of every interface and peer with interfaces and peers.
+	RestoreRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error
+	// ListRules returns the live rules in the backend's own format, for people to read.
+	ListRules(ctx context.Context) (string, error)
+	// PeerRules returns the live peer rules with canonical source and destination prefixes, protocols and ports.
+	PeerRules(ctx context.Context) ([]FirewallRule, error)
+	// IsolationModes returns the isolation mode the live terminal rules of each interface implement,
+	// or "" for interfaces whose rules match no mode.
+	IsolationModes(ctx context.Context) (map[string]string, error)
+}
+
+// NewFirewallDriverFromEnv selects the firewall backend from WILLIAM_FIREWALL_BACKEND: "iptables" (default) or "nftables".
+func NewFirewallDriverFromEnv() (FirewallDriver, error) {
+	switch backend := strings.TrimSpace(os.Getenv("WILLIAM_FIREWALL_BACKEND")); backend {
+	case "", "iptables":
+		return NewIptablesFirewall(), nil
+	case "nftables":
+		return NewNftablesFirewall(), nil
+	default:
+		return nil, fmt.Errorf("unknown WILLIAM_FIREWALL_BACKEND %q", backend)
+	}
+}
+
+// isolationModes lists the interface isolation modes, with the default first.
+var isolationModes = []string{domain.InterfaceIsolationRoutesOnly, domain.InterfaceIsolationPeerToPeer, domain.InterfaceIsolationOpen}
+
+// FirewallRule accepts traffic arriving on InterfaceID from Source towards Destination over Protocol, one of the
+// domain.AccessProtocol values. Ports is one tcp or udp port range ("22" or "8000-8080"), empty for every port.
+type FirewallRule struct {
+	InterfaceID string
+	Source      string
+	Destination string
+	Protocol    string
+	Ports       string
+}
+
+// peerFirewallRules returns the rules of a peer, one per access rule destination and port range. Each peer address
+// only gets rules towards destinations of its own address family, and none towards the peer's own addresses or
+// covered by a wider rule.
+func peerFirewallRules(interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) []FirewallRule {
+	peerAddresses := domain.SplitAddresses(peerAllowedIP)
+	for index, address := range peerAddresses {
+		peerAddresses[index] = canonicalPrefix(address)
+	}
+	candidates := make([]FirewallRule, 0, len(accessRules))
+	for _, accessRule := range accessRules {
+		candidates = append(candidates, accessFirewallRules(accessRule)...)
+	}
+	destinations := make([]FirewallRule, 0, len(candidates))
+	for _, candidate := range candidates {
+		if slices.Contains(peerAddresses, candidate.Destination) || coveredRule(candidate, candidates, destinations) {
+			continue
+		}
+		destinations = append(destinations, candidate)
+	}
+
+	rules := make([]FirewallRule, 0, len(peerAddresses)*len(destinations))
+	for _, sourceCIDR := range peerAddresses {
+		for _, destination := range destinations {
+			if !addressFamilyMatches(sourceCIDR, destination.Destination) {
+				continue
+			}
+			destination.InterfaceID = interfaceID
+			destination.Source = sourceCIDR
+			rules = append(rules, destination)
+		}
+	}
+	return rules
+}
+
+// accessFirewallRules splits an access rule into destination-only rules, one per port range. Unknown protocols
+// are treated as any, like routes were before they had one, and ports that do not parse as every port.
+func accessFirewallRules(accessRule domain.AccessRule) []FirewallRule {
+	rule := FirewallRule{Destination: canonicalPrefix(accessRule.CIDR), Protocol: accessRule.Protocol}
+	switch rule.Protocol {
+	case domain.AccessProtocolTCP, domain.AccessProtocolUDP:
+	case domain.AccessProtocolICMP:
+		return []FirewallRule{rule}
+	default:
+		rule.Protocol = domain.AccessProtocolAny
+		return []FirewallRule{rule}
+	}
+	portRanges, err := domain.ParsePortRanges(accessRule.Ports)
+	if err != nil || len(portRanges) == 0 {
+		return []FirewallRule{rule}
+	}
+	rules := make([]FirewallRule, 0, len(portRanges))
+	for _, portRange := range portRanges {
+		rule.Ports = portRange.String()
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// coveredRule reports whether a wider rule of candidates accepts all traffic rule accepts, or rule equals one
+// already kept. Rules are compared by destination, protocol and ports only.
+func coveredRule(rule FirewallRule, candidates []FirewallRule, kept []FirewallRule) bool {
+	for _, candidate := range candidates {
+		if candidate != rule && firewallRuleCovers(candidate, rule) {
+			return true
+		}
+	}
+	return slices.Contains(kept, rule)
+}
+
+// firewallRuleCovers reports whether wider accepts all traffic rule accepts.
+func firewallRuleCovers(wider FirewallRule, rule FirewallRule) bool {
+	widerPrefix, err := netip.ParsePrefix(wider.Destination)
+	if err != nil {
+		return false
+	}
+	prefix, err := netip.ParsePrefix(rule.Destination)
+	if err != nil {
+		return false
+	}
+	if widerPrefix.Bits() > prefix.Bits() || !widerPrefix.Masked().Contains(prefix.Addr()) {
+		return false
+	}
+	if wider.Protocol == domain.AccessProtocolAny {
+		return true
+	}
+	if wider.Protocol != rule.Protocol {
+		return false
+	}
+	if wider.Ports == "" {
+		return true
+	}
+	if rule.Ports == "" {
+		return false
+	}
+	widerRanges, widerErr := domain.ParsePortRanges(wider.Ports)
+	ranges, err := domain.ParsePortRanges(rule.Ports)
+	if widerErr != nil || err != nil || len(widerRanges) != 1 || len(ranges) != 1 {
+		return false
+	}
+	return widerRanges[0].First <= ranges[0].First && ranges[0].Last <= widerRanges[0].Last
+}
+
+// canonicalPrefix masks value to its network prefix ("10.0.0.5/24" -> "10.0.0.0/24", "10.0.0.2" -> "10.0.0.2/32"),
+// the form wireguard and the firewall print it in. Values that are not prefixes are returned trimmed.
+func canonicalPrefix(value string) string {
+	value = strings.TrimSpace(value)
+	prefix, err := netip.ParsePrefix(hostCIDR(value))
+	if err != nil {
+		return value
+	}
+	return prefix.Masked().String()
+}
+
+// hostCIDR adds the host prefix length to bare addresses ("10.0.0.2" -> "10.0.0.2/32").
+func hostCIDR(value string) string {
+	if strings.Contains(value, "/") {
+		return value
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return value
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()).String()
+}
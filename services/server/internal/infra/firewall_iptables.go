@@ -0,0 +1,406 @@
+package infra
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// IptablesFirewall keeps peer rules in the WILLIAM_FWD chains of iptables and ip6tables and the terminal rules
+// of each interface in the WILLIAM_ISOLATION chains. FORWARD jumps to WILLIAM_FWD first and to WILLIAM_ISOLATION
+// right after it, so the isolation mode decides about traffic no peer rule accepts. Traffic of interfaces
+// without terminal rules falls back to the FORWARD chain.
+type IptablesFirewall struct {
+	runner CommandRunner
+}
+
+func NewIptablesFirewall() *IptablesFirewall {
+	return &IptablesFirewall{runner: execRunner{}}
+}
+
+func (firewall *IptablesFirewall) ListRules(ctx context.Context) (string, error) {
+	rules, err := firewall.runner.Run(ctx, "iptables", "-S", "WILLIAM_FWD")
+	if err != nil {
+		return "", err
+	}
+
+	listings := []string{strings.TrimSpace(rules)}
+	// The other chains only exist once the firewall has been prepared for them, e.g. by syncing an IPv6 peer.
+	for _, chain := range [][2]string{{"iptables", "WILLIAM_ISOLATION"}, {"ip6tables", "WILLIAM_FWD"}, {"ip6tables", "WILLIAM_ISOLATION"}} {
+		if output, err := firewall.runner.Run(ctx, chain[0], "-S", chain[1]); err == nil && strings.TrimSpace(output) != "" {
+			listings = append(listings, strings.TrimSpace(output))
+		}
+	}
+	return strings.Join(listings, "\n"), nil
+}
+
+func (firewall *IptablesFirewall) PeerRules(ctx context.Context) ([]FirewallRule, error) {
+	rules, err := firewall.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]FirewallRule, 0)
+	for _, line := range strings.Split(rules, "\n") {
+		if rule, ok := parseIptablesRule(line); ok {
+			parsed = append(parsed, rule)
+		}
+	}
+	return parsed, nil
+}
+
+// IsolationModes reads the modes from the WILLIAM_ISOLATION chains. An interface whose rules differ between
+// the address families matches no mode.
+func (firewall *IptablesFirewall) IsolationModes(ctx context.Context) (map[string]string, error) {
+	var modes map[string]string
+	for _, iptables := range []string{"iptables", "ip6tables"} {
+		// Like in ListRules, a chain that has not been created yet holds no rules.
+		output, err := firewall.runner.Run(ctx, iptables, "-S", "WILLIAM_ISOLATION")
+		if err != nil {
+			output = ""
+		}
+		familyModes := parseIptablesIsolationModes(output)
+		if modes == nil {
+			modes = familyModes
+			continue
+		}
+		for interfaceID, mode := range familyModes {
+			if current, ok := modes[interfaceID]; !ok || current != mode {
+				modes[interfaceID] = ""
+			}
+		}
+		for interfaceID := range modes {
+			if _, ok := familyModes[interfaceID]; !ok {
+				modes[interfaceID] = ""
+			}
+		}
+	}
+	return modes, nil
+}
+
+// EnsureChain creates the WILLIAM_FWD and WILLIAM_ISOLATION chains if they don't exist and ensures they're called from FORWARD chain
+func (firewall *IptablesFirewall) EnsureChain(ctx context.Context) error {
+	if err := firewall.ensureChain(ctx, "iptables"); err != nil {
+		return err
+	}
+	return firewall.ensureChain(ctx, "ip6tables")
+}
+
+func (firewall *IptablesFirewall) ensureChain(ctx context.Context, iptables string) error {
+	for _, chain := range []string{"WILLIAM_FWD", "WILLIAM_ISOLATION"} {
+		// Check if the chain exists
+		if _, err := firewall.runner.Run(ctx, iptables, "-L", chain, "-n"); err != nil {
+			// Chain doesn't exist, create it
+			if _, err := firewall.runner.Run(ctx, iptables, "-N", chain); err != nil {
+				return fmt.Errorf("create %s chain: %w", chain, err)
+			}
+		}
+	}
+
+	// Check if FORWARD chain calls WILLIAM_FWD and WILLIAM_ISOLATION
+	output, err := firewall.runner.Run(ctx, iptables, "-S", "FORWARD")
+	if err != nil {
+		return fmt.Errorf("check FORWARD chain: %w", err)
+	}
+
+	peerJump := forwardRulePosition(output, "-A FORWARD -j WILLIAM_FWD")
+	if peerJump == 0 {
+		// Add jump to WILLIAM_FWD at the beginning of FORWARD chain
+		if _, err := firewall.runner.Run(ctx, iptables, "-I", "FORWARD", "1", "-j", "WILLIAM_FWD"); err != nil {
+			return fmt.Errorf("add WILLIAM_FWD to FORWARD chain: %w", err)
+		}
+		peerJump = 1
+	}
+
+	if forwardRulePosition(output, "-A FORWARD -j WILLIAM_ISOLATION") == 0 {
+		// Add jump to WILLIAM_ISOLATION right after the jump to WILLIAM_FWD
+		position := strconv.Itoa(peerJump + 1)
+		if _, err := firewall.runner.Run(ctx, iptables, "-I", "FORWARD", position, "-j", "WILLIAM_ISOLATION"); err != nil {
+			return fmt.Errorf("add WILLIAM_ISOLATION to FORWARD chain: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// forwardRulePosition returns the 1-based position of rule in the output of `iptables -S FORWARD`, or 0 when it is missing.
+func forwardRulePosition(output string, rule string) int {
+	position := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-A FORWARD") {
+			continue
+		}
+		position++
+		if line == rule {
+			return position
+		}
+	}
+	return 0
+}
+
+// SyncPeerRules synchronizes iptables/ip6tables rules for a specific peer.
+func (firewall *IptablesFirewall) SyncPeerRules(ctx context.Context, interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) error {
+	// Remove old rules for this peer
+	if err := firewall.RemovePeerRules(ctx, peerAllowedIP); err != nil {
+		return err
+	}
+
+	for _, sourceCIDR := range domain.SplitAddresses(peerAllowedIP) {
+		// Ensure the firewall chain exists for this address family
+		if err := firewall.ensureChain(ctx, iptablesCommandFor(sourceCIDR)); err != nil {
+			return err
+		}
+	}
+
+	// Add rule: allow traffic from peer to destination
+	for _, rule := range peerFirewallRules(interfaceID, peerAllowedIP, accessRules) {
+		if _, err := firewall.runner.Run(ctx, iptablesCommandFor(rule.Source), iptablesRuleArgs(rule)...); err != nil {
+			return fmt.Errorf("add firewall rule for %s -> %s: %w", rule.Source, rule.Destination, err)
+		}
+	}
+
+	return nil
+}
+
+// SyncInterfaceRules replaces the WILLIAM_ISOLATION rules of an interface in both address families.
+func (firewall *IptablesFirewall) SyncInterfaceRules(ctx context.Context, interfaceID string, isolationMode string) error {
+	for _, iptables := range []string{"iptables", "ip6tables"} {
+		if err := firewall.ensureChain(ctx, iptables); err != nil {
+			return err
+		}
+		if err := firewall.removeInterfaceRules(ctx, iptables, "WILLIAM_ISOLATION", interfaceID); err != nil {
+			return err
+		}
+		for _, args := range iptablesIsolationRules(interfaceID, isolationMode) {
+			if _, err := firewall.runner.Run(ctx, iptables, args...); err != nil {
+				return fmt.Errorf("add %s isolation rule for %s: %w", isolationMode, interfaceID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveInterfaceRules removes the peer and isolation rules of an interface in both address families.
+func (firewall *IptablesFirewall) RemoveInterfaceRules(ctx context.Context, interfaceID string) error {
+	for _, iptables := range []string{"iptables", "ip6tables"} {
+		for _, chain := range []string{"WILLIAM_FWD", "WILLIAM_ISOLATION"} {
+			if err := firewall.removeInterfaceRules(ctx, iptables, chain, interfaceID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreRules replaces the WILLIAM_FWD and WILLIAM_ISOLATION chains with the rules of interfaces and peers.
+// Each address family is rebuilt in one iptables-restore transaction, so traffic never sees a half rebuilt chain.
+func (firewall *IptablesFirewall) RestoreRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	if err := firewall.EnsureChain(ctx); err != nil {
+		return err
+	}
+
+	rulesByCommand := map[string][]FirewallRule{}
+	for _, peer := range peers {
+		for _, rule := range peerFirewallRules(peer.InterfaceID, peer.PeerAllowedIP, peer.Rules) {
+			iptables := iptablesCommandFor(rule.Source)
+			rulesByCommand[iptables] = append(rulesByCommand[iptables], rule)
+		}
+	}
+
+	for _, iptables := range []string{"iptables", "ip6tables"} {
+		// Declaring the chains flushes them; --noflush keeps every other chain as is.
+		var input strings.Builder
+		input.WriteString("*filter\n:WILLIAM_FWD - [0:0]\n:WILLIAM_ISOLATION - [0:0]\n")
+		for _, rule := range rulesByCommand[iptables] {
+			input.WriteString(strings.Join(iptablesRuleArgs(rule), " ") + "\n")
+		}
+		for _, iface := range interfaces {
+			for _, args := range iptablesIsolationRules(iface.InterfaceID, iface.IsolationMode) {
+				input.WriteString(strings.Join(args, " ") + "\n")
+			}
+		}
+		input.WriteString("COMMIT\n")
+
+		if _, err := firewall.runner.RunWithInput(ctx, input.String(), iptables+"-restore", "--noflush"); err != nil {
+			return fmt.Errorf("restore %s rules: %w", iptables, err)
+		}
+	}
+
+	return nil
+}
+
+// RemovePeerRules removes all iptables/ip6tables rules associated with a peer's addresses
+func (firewall *IptablesFirewall) RemovePeerRules(ctx context.Context, peerAllowedIP string) error {
+	for _, sourceCIDR := range domain.SplitAddresses(peerAllowedIP) {
+		source := canonicalPrefix(sourceCIDR)
+		err := firewall.removeRules(ctx, iptablesCommandFor(sourceCIDR), "WILLIAM_FWD", func(line string) bool {
+			rule, ok := parseIptablesRule(line)
+			return ok && rule.Source == source
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (firewall *IptablesFirewall) removeInterfaceRules(ctx context.Context, iptables string, chain string, interfaceID string) error {
+	return firewall.removeRules(ctx, iptables, chain, func(line string) bool {
+		return iptablesRuleInterface(line) == interfaceID
+	})
+}
+
+// removeRules deletes the rules of chain that remove reports true for.
+func (firewall *IptablesFirewall) removeRules(ctx context.Context, iptables string, chain string, remove func(line string) bool) error {
+	// Get current rules
+	output, err := firewall.runner.Run(ctx, iptables, "-S", chain)
+	if err != nil {
+		// Chain might not exist yet
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "-A "+chain+" ") || !remove(line) {
+			continue
+		}
+
+		// Convert "-A <chain> ..." to "-D <chain> ..."
+		args := strings.Fields("-D " + strings.TrimPrefix(line, "-A "))
+		if _, err := firewall.runner.Run(ctx, iptables, args...); err != nil {
+			// Rule might have been already deleted, continue
+			continue
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseIptablesRule reads a WILLIAM_FWD rule from a line of `iptables -S WILLIAM_FWD`.
+// Rules without a source address are not peer rules and are skipped.
+func parseIptablesRule(line string) (FirewallRule, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "-A" || fields[1] != "WILLIAM_FWD" {
+		return FirewallRule{}, false
+	}
+
+	rule := FirewallRule{Protocol: domain.AccessProtocolAny}
+	for index := 2; index+1 < len(fields); index++ {
+		switch fields[index] {
+		case "-i":
+			rule.InterfaceID = fields[index+1]
+		case "-s":
+			rule.Source = canonicalPrefix(fields[index+1])
+		case "-d":
+			rule.Destination = canonicalPrefix(fields[index+1])
+		case "-p":
+			rule.Protocol = fields[index+1]
+			if rule.Protocol == "ipv6-icmp" {
+				rule.Protocol = domain.AccessProtocolICMP
+			}
+		case "--dport":
+			rule.Ports = strings.ReplaceAll(fields[index+1], ":", "-")
+		}
+	}
+	return rule, rule.Source != ""
+}
+
+// iptablesRuleInterface returns the input interface of a line of `iptables -S`, or "" when it matches any.
+func iptablesRuleInterface(line string) string {
+	fields := strings.Fields(line)
+	for index := 0; index+1 < len(fields); index++ {
+		if fields[index] == "-i" {
+			return fields[index+1]
+		}
+	}
+	return ""
+}
+
+// parseIptablesIsolationModes matches the rules of each interface in the output of `iptables -S WILLIAM_ISOLATION`
+// against the rules of every isolation mode.
+func parseIptablesIsolationModes(output string) map[string]string {
+	lines := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-A WILLIAM_ISOLATION ") {
+			continue
+		}
+		interfaceID := iptablesRuleInterface(line)
+		lines[interfaceID] = append(lines[interfaceID], line)
+	}
+
+	modes := make(map[string]string, len(lines))
+	for interfaceID, rules := range lines {
+		modes[interfaceID] = ""
+		for _, mode := range isolationModes {
+			wanted := make([]string, 0, 3)
+			for _, args := range iptablesIsolationRules(interfaceID, mode) {
+				wanted = append(wanted, strings.Join(args, " "))
+			}
+			if slices.Equal(rules, wanted) {
+				modes[interfaceID] = mode
+				break
+			}
+		}
+	}
+	return modes
+}
+
+// iptablesRuleArgs returns the WILLIAM_FWD rule of a peer rule, in the form `iptables -S` prints it.
+func iptablesRuleArgs(rule FirewallRule) []string {
+	args := []string{
+		"-A", "WILLIAM_FWD",
+		"-i", rule.InterfaceID,
+		"-s", rule.Source,
+		"-d", rule.Destination,
+	}
+	switch rule.Protocol {
+	case domain.AccessProtocolTCP, domain.AccessProtocolUDP:
+		args = append(args, "-p", rule.Protocol)
+		if rule.Ports != "" {
+			args = append(args, "-m", rule.Protocol, "--dport", strings.ReplaceAll(rule.Ports, "-", ":"))
+		}
+	case domain.AccessProtocolICMP:
+		if iptablesCommandFor(rule.Source) == "ip6tables" {
+			args = append(args, "-p", "ipv6-icmp")
+		} else {
+			args = append(args, "-p", "icmp")
+		}
+	}
+	return append(args, "-j", "ACCEPT")
+}
+
+// iptablesIsolationRules returns the WILLIAM_ISOLATION rules of an interface, in the form `iptables -S` prints them.
+func iptablesIsolationRules(interfaceID string, isolationMode string) [][]string {
+	rule := func(args ...string) []string {
+		return append([]string{"-A", "WILLIAM_ISOLATION", "-i", interfaceID}, args...)
+	}
+	established := rule("-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT")
+
+	switch isolationMode {
+	case domain.InterfaceIsolationOpen:
+		return [][]string{rule("-j", "ACCEPT")}
+	case domain.InterfaceIsolationPeerToPeer:
+		return [][]string{established, rule("-o", interfaceID, "-j", "ACCEPT"), rule("-j", "DROP")}
+	default:
+		return [][]string{established, rule("-j", "DROP")}
+	}
+}
+
+// iptablesCommandFor returns ip6tables for IPv6 CIDRs and iptables otherwise.
+func iptablesCommandFor(cidr string) string {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err == nil && prefix.Addr().Is6() {
+		return "ip6tables"
+	}
+	return "iptables"
+}
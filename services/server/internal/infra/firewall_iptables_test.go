@@ -0,0 +1,79 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// fakeIptablesRunner answers `-S` with the rules of the chains it holds, keyed by command and chain, and fails
+// for chains it does not hold like iptables does.
+type fakeIptablesRunner struct {
+	chains map[string]string
+}
+
+func (runner *fakeIptablesRunner) Run(ctx context.Context, name string, args ...string) (string, error) {
+	rules, ok := runner.chains[name+" "+args[len(args)-1]]
+	if !ok {
+		return "", errors.New("iptables: No chain/target/match by that name.")
+	}
+	return rules, nil
+}
+
+func (runner *fakeIptablesRunner) RunWithInput(ctx context.Context, input string, name string, args ...string) (string, error) {
+	return runner.Run(ctx, name, args...)
+}
+
+// isolationChain renders the WILLIAM_ISOLATION chain holding the rules of each interface's isolation mode.
+func isolationChain(modes map[string]string) string {
+	lines := []string{"-N WILLIAM_ISOLATION"}
+	for interfaceID, mode := range modes {
+		for _, args := range iptablesIsolationRules(interfaceID, mode) {
+			lines = append(lines, strings.Join(args, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestIptablesFirewallIsolationModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		chains map[string]string
+		want   map[string]string
+	}{
+		{
+			name: "both families",
+			chains: map[string]string{
+				"iptables WILLIAM_ISOLATION":  isolationChain(map[string]string{"wg0": domain.InterfaceIsolationOpen, "wg1": domain.InterfaceIsolationPeerToPeer}),
+				"ip6tables WILLIAM_ISOLATION": isolationChain(map[string]string{"wg0": domain.InterfaceIsolationOpen, "wg1": domain.InterfaceIsolationRoutesOnly}),
+			},
+			want: map[string]string{"wg0": domain.InterfaceIsolationOpen, "wg1": ""},
+		},
+		{
+			name:   "missing ip6tables chain",
+			chains: map[string]string{"iptables WILLIAM_ISOLATION": isolationChain(map[string]string{"wg0": domain.InterfaceIsolationOpen})},
+			want:   map[string]string{"wg0": ""},
+		},
+		{
+			name: "no chains",
+			want: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firewall := &IptablesFirewall{runner: &fakeIptablesRunner{chains: test.chains}}
+			modes, err := firewall.IsolationModes(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(modes, test.want) {
+				t.Errorf("modes = %v, want %v", modes, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,601 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const nftablesTablePrefix = "william_"
+
+// NftablesFirewall keeps the rules of each wireguard interface in an inet table of its own, william_<interface>.
+// Every change renders the tables from scratch and replaces them all in one `nft -f` transaction. Each peer gets
+// a set of its allowed destinations per address and protocol and port range, and a rule with a counter per set; the isolation mode of the
+// interface decides about other new traffic arriving on it, dropping it unless the mode is open.
+// Accepted traffic still passes the forward chains of other tables.
+//
+// The driver keeps the rules it applied in memory, so RestoreRules must run before the first peer change,
+// as the wireguard bootstrap does. Until then SyncInterfaceRules and RemoveInterfaceRules only record the mode.
+type NftablesFirewall struct {
+	runner CommandRunner
+
+	mu         sync.Mutex
+	interfaces map[string]string
+	peers      map[string]domain.PeerFirewallRules
+}
+
+func NewNftablesFirewall() *NftablesFirewall {
+	return &NftablesFirewall{runner: execRunner{}, interfaces: make(map[string]string)}
+}
+
+// EnsureChain does nothing: the tables are created together with their rules.
+func (firewall *NftablesFirewall) EnsureChain(ctx context.Context) error {
+	return nil
+}
+
+func (firewall *NftablesFirewall) SyncPeerRules(ctx context.Context, interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) error {
+	firewall.mu.Lock()
+	defer firewall.mu.Unlock()
+	if firewall.peers == nil {
+		return errors.New("nftables firewall rules have not been restored")
+	}
+
+	peers := firewall.withoutSources(peerAllowedIP)
+	peers[peerAllowedIP] = domain.PeerFirewallRules{
+		InterfaceID:   interfaceID,
+		PeerAllowedIP: peerAllowedIP,
+		Rules:         slices.Clone(accessRules),
+	}
+	return firewall.apply(ctx, firewall.interfaces, peers)
+}
+
+func (firewall *NftablesFirewall) RemovePeerRules(ctx context.Context, peerAllowedIP string) error {
+	firewall.mu.Lock()
+	defer firewall.mu.Unlock()
+	if firewall.peers == nil {
+		return errors.New("nftables firewall rules have not been restored")
+	}
+
+	return firewall.apply(ctx, firewall.interfaces, firewall.withoutSources(peerAllowedIP))
+}
+
+func (firewall *NftablesFirewall) SyncInterfaceRules(ctx context.Context, interfaceID string, isolationMode string) error {
+	firewall.mu.Lock()
+	defer firewall.mu.Unlock()
+
+	interfaces := maps.Clone(firewall.interfaces)
+	interfaces[interfaceID] = isolationMode
+	if firewall.peers == nil {
+		firewall.interfaces = interfaces
+		return nil
+	}
+	return firewall.apply(ctx, interfaces, firewall.peers)
+}
+
+func (firewall *NftablesFirewall) RemoveInterfaceRules(ctx context.Context, interfaceID string) error {
+	firewall.mu.Lock()
+	defer firewall.mu.Unlock()
+
+	interfaces := maps.Clone(firewall.interfaces)
+	delete(interfaces, interfaceID)
+	if firewall.peers == nil {
+		firewall.interfaces = interfaces
+		return nil
+	}
+
+	peers := maps.Clone(firewall.peers)
+	maps.DeleteFunc(peers, func(_ string, peer domain.PeerFirewallRules) bool {
+		return peer.InterfaceID == interfaceID
+	})
+	return firewall.apply(ctx, interfaces, peers)
+}
+
+func (firewall *NftablesFirewall) RestoreRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	firewall.mu.Lock()
+	defer firewall.mu.Unlock()
+
+	modes := make(map[string]string, len(interfaces))
+	for _, iface := range interfaces {
+		modes[iface.InterfaceID] = iface.IsolationMode
+	}
+	peersByAddress := make(map[string]domain.PeerFirewallRules, len(peers))
+	for _, peer := range peers {
+		peersByAddress[peer.PeerAllowedIP] = peer
+	}
+	return firewall.apply(ctx, modes, peersByAddress)
+}
+
+// withoutSources returns a copy of the applied peers without the ones holding any address of peerAllowedIP.
+func (firewall *NftablesFirewall) withoutSources(peerAllowedIP string) map[string]domain.PeerFirewallRules {
+	sources := make(map[string]struct{})
+	for _, address := range domain.SplitAddresses(peerAllowedIP) {
+		sources[canonicalPrefix(address)] = struct{}{}
+	}
+
+	peers := make(map[string]domain.PeerFirewallRules, len(firewall.peers))
+	for key, peer := range firewall.peers {
+		owned := slices.ContainsFunc(domain.SplitAddresses(peer.PeerAllowedIP), func(address string) bool {
+			_, ok := sources[canonicalPrefix(address)]
+			return ok
+		})
+		if !owned {
+			peers[key] = peer
+		}
+	}
+	return peers
+}
+
+// apply replaces the live william tables with the tables of interfaces and peers and remembers both once nft accepted them.
+func (firewall *NftablesFirewall) apply(ctx context.Context, interfaces map[string]string, peers map[string]domain.PeerFirewallRules) error {
+	liveTables, err := firewall.tables(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]domain.PeerFirewallRules, 0, len(peers))
+	for _, peer := range peers {
+		rules = append(rules, peer)
+	}
+	if _, err := firewall.runner.RunWithInput(ctx, renderNftablesRuleset(interfaces, rules, liveTables), "nft", "-f", "-"); err != nil {
+		return fmt.Errorf("apply nftables ruleset: %w", err)
+	}
+
+	firewall.interfaces = interfaces
+	firewall.peers = peers
+	return nil
+}
+
+// tables returns the names of the live william tables.
+func (firewall *NftablesFirewall) tables(ctx context.Context) ([]string, error) {
+	output, err := firewall.runner.Run(ctx, "nft", "list", "tables", "inet")
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		// table inet william_wg0
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "table" && strings.HasPrefix(fields[2], nftablesTablePrefix) {
+			tables = append(tables, fields[2])
+		}
+	}
+	slices.Sort(tables)
+	return tables, nil
+}
+
+func (firewall *NftablesFirewall) ListRules(ctx context.Context) (string, error) {
+	tables, err := firewall.tables(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	listings := make([]string, 0, len(tables))
+	for _, table := range tables {
+		listing, err := firewall.runner.Run(ctx, "nft", "list", "table", "inet", table)
+		if err != nil {
+			return "", err
+		}
+		listings = append(listings, strings.TrimSpace(listing))
+	}
+	return strings.Join(listings, "\n\n"), nil
+}
+
+func (firewall *NftablesFirewall) PeerRules(ctx context.Context) ([]FirewallRule, error) {
+	output, err := firewall.runner.Run(ctx, "nft", "-j", "list", "ruleset", "inet")
+	if err != nil {
+		return nil, err
+	}
+	return parseNftablesRules(output)
+}
+
+func (firewall *NftablesFirewall) IsolationModes(ctx context.Context) (map[string]string, error) {
+	output, err := firewall.runner.Run(ctx, "nft", "-j", "list", "ruleset", "inet")
+	if err != nil {
+		return nil, err
+	}
+	return parseNftablesIsolationModes(output)
+}
+
+// renderNftablesRuleset renders an `nft -f` script that deletes the liveTables no interface needs any more and
+// replaces the table of every interface with an isolation mode or peers. Declaring a table before deleting it
+// lets the script run whether or not the table exists.
+func renderNftablesRuleset(interfaces map[string]string, peers []domain.PeerFirewallRules, liveTables []string) string {
+	peersByInterface := make(map[string][]domain.PeerFirewallRules)
+	for interfaceID := range interfaces {
+		peersByInterface[interfaceID] = nil
+	}
+	for _, peer := range peers {
+		peersByInterface[peer.InterfaceID] = append(peersByInterface[peer.InterfaceID], peer)
+	}
+	interfaceIDs := make([]string, 0, len(peersByInterface))
+	wanted := make(map[string]struct{}, len(peersByInterface))
+	for interfaceID := range peersByInterface {
+		interfaceIDs = append(interfaceIDs, interfaceID)
+		wanted[nftablesTableName(interfaceID)] = struct{}{}
+	}
+	slices.Sort(interfaceIDs)
+
+	var script strings.Builder
+	for _, table := range liveTables {
+		if _, ok := wanted[table]; !ok {
+			fmt.Fprintf(&script, "delete table inet %s\n", table)
+		}
+	}
+	for _, interfaceID := range interfaceIDs {
+		table := nftablesTableName(interfaceID)
+		fmt.Fprintf(&script, "table inet %s\ndelete table inet %s\n", table, table)
+		script.WriteString(renderNftablesTable(interfaceID, interfaces[interfaceID], peersByInterface[interfaceID]))
+	}
+	return script.String()
+}
+
+// renderNftablesTable renders the table of one interface, with the peers sorted by address. Interfaces without
+// an isolation mode get the rules of the default, routes only.
+func renderNftablesTable(interfaceID string, isolationMode string, peers []domain.PeerFirewallRules) string {
+	peers = slices.Clone(peers)
+	slices.SortFunc(peers, func(left, right domain.PeerFirewallRules) int {
+		return strings.Compare(left.PeerAllowedIP, right.PeerAllowedIP)
+	})
+
+	var sets, rules strings.Builder
+	for _, peer := range peers {
+		// Destinations share a set when they share the source, protocol and ports, in the order peerFirewallRules returns them.
+		var groups []FirewallRule
+		destinations := make(map[FirewallRule][]string)
+		for _, rule := range peerFirewallRules(interfaceID, peer.PeerAllowedIP, peer.Rules) {
+			group := FirewallRule{Source: rule.Source, Protocol: rule.Protocol, Ports: rule.Ports}
+			if _, ok := destinations[group]; !ok {
+				groups = append(groups, group)
+			}
+			destinations[group] = append(destinations[group], rule.Destination)
+		}
+
+		for _, group := range groups {
+			prefix, err := netip.ParsePrefix(group.Source)
+			if err != nil {
+				continue
+			}
+			family, addressType := "ip", "ipv4_addr"
+			if prefix.Addr().Is6() {
+				family, addressType = "ip6", "ipv6_addr"
+			}
+			set := nftablesSetName(prefix, group.Protocol, group.Ports)
+
+			fmt.Fprintf(&sets, "\tset %s {\n\t\ttype %s\n\t\tflags interval\n\t\telements = { %s }\n\t}\n\n",
+				set, addressType, strings.Join(destinations[group], ", "))
+			fmt.Fprintf(&rules, "\t\tiifname %s %s saddr %s %s daddr @%s %scounter accept\n",
+				strconv.Quote(interfaceID), family, nftablesAddress(prefix), family, set, nftablesProtocolMatch(family, group.Protocol, group.Ports))
+		}
+	}
+
+	iifname := "iifname " + strconv.Quote(interfaceID)
+	var table strings.Builder
+	fmt.Fprintf(&table, "table inet %s {\n", nftablesTableName(interfaceID))
+	table.WriteString(sets.String())
+	table.WriteString("\tchain forward {\n")
+	table.WriteString("\t\ttype filter hook forward priority filter; policy accept;\n")
+	switch isolationMode {
+	case domain.InterfaceIsolationOpen:
+		table.WriteString(rules.String())
+		fmt.Fprintf(&table, "\t\t%s accept\n", iifname)
+	case domain.InterfaceIsolationPeerToPeer:
+		fmt.Fprintf(&table, "\t\t%s ct state established,related accept\n", iifname)
+		table.WriteString(rules.String())
+		fmt.Fprintf(&table, "\t\t%s oifname %s accept\n", iifname, strconv.Quote(interfaceID))
+		fmt.Fprintf(&table, "\t\t%s counter drop\n", iifname)
+	default:
+		fmt.Fprintf(&table, "\t\t%s ct state established,related accept\n", iifname)
+		table.WriteString(rules.String())
+		fmt.Fprintf(&table, "\t\t%s counter drop\n", iifname)
+	}
+	table.WriteString("\t}\n}\n")
+	return table.String()
+}
+
+// nftablesIsolationRules describes the terminal rules renderNftablesTable renders for an isolation mode,
+// in the form nftablesRule.terminal reads them back.
+func nftablesIsolationRules(isolationMode string) []string {
+	switch isolationMode {
+	case domain.InterfaceIsolationOpen:
+		return []string{"accept"}
+	case domain.InterfaceIsolationPeerToPeer:
+		return []string{"ct accept", "oifname accept", "drop"}
+	default:
+		return []string{"ct accept", "drop"}
+	}
+}
+
+// nftablesTableName names the table of an interface; characters nft does not allow in names become "_".
+func nftablesTableName(interfaceID string) string {
+	return nftablesTablePrefix + nftablesIdentifier(interfaceID)
+}
+
+// nftablesSetName names the destination set of a peer address and protocol, e.g. peer_10_0_0_2_32 for any
+// protocol or peer_10_0_0_2_32_tcp_8000_8080.
+func nftablesSetName(source netip.Prefix, protocol string, ports string) string {
+	name := "peer_" + nftablesIdentifier(source.Masked().String())
+	if protocol != domain.AccessProtocolAny {
+		name += "_" + protocol
+	}
+	if ports != "" {
+		name += "_" + nftablesIdentifier(ports)
+	}
+	return name
+}
+
+// nftablesProtocolMatch renders the matches of a protocol and port range followed by a space, e.g. "tcp dport 22 ",
+// or "" for any protocol.
+func nftablesProtocolMatch(family string, protocol string, ports string) string {
+	switch protocol {
+	case domain.AccessProtocolTCP, domain.AccessProtocolUDP:
+		if ports != "" {
+			return fmt.Sprintf("%s dport %s ", protocol, ports)
+		}
+		return "meta l4proto " + protocol + " "
+	case domain.AccessProtocolICMP:
+		if family == "ip6" {
+			return "meta l4proto ipv6-icmp "
+		}
+		return "meta l4proto icmp "
+	}
+	return ""
+}
+
+func nftablesIdentifier(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+// nftablesAddress prints host prefixes as bare addresses, the way nft lists them.
+func nftablesAddress(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.Masked().String()
+}
+
+// nftablesJSON is the part of `nft -j list ruleset` that holds william rules.
+type nftablesJSON struct {
+	Nftables []struct {
+		Set *struct {
+			Table string            `json:"table"`
+			Name  string            `json:"name"`
+			Elem  []json.RawMessage `json:"elem"`
+		} `json:"set"`
+		Rule *struct {
+			Table string            `json:"table"`
+			Expr  []json.RawMessage `json:"expr"`
+		} `json:"rule"`
+	} `json:"nftables"`
+}
+
+type nftablesMatch struct {
+	Left struct {
+		Meta *struct {
+			Key string `json:"key"`
+		} `json:"meta"`
+		Payload *struct {
+			Protocol string `json:"protocol"`
+			Field    string `json:"field"`
+		} `json:"payload"`
+		Ct *struct {
+			Key string `json:"key"`
+		} `json:"ct"`
+	} `json:"left"`
+	Right json.RawMessage `json:"right"`
+}
+
+// nftablesRule is what a rule of a william table matches and its verdict.
+type nftablesRule struct {
+	table           string
+	interfaceID     string
+	outInterfaceID  string
+	source          string
+	destination     string
+	protocol        string
+	ports           string
+	connectionState bool
+	verdict         string
+}
+
+// terminal describes a rule that matches no source address, e.g. "ct accept" or "drop". Only traffic
+// towards the interface it arrives on is described as "oifname".
+func (rule nftablesRule) terminal() string {
+	parts := make([]string, 0, 3)
+	if rule.connectionState {
+		parts = append(parts, "ct")
+	}
+	switch rule.outInterfaceID {
+	case "":
+	case rule.interfaceID:
+		parts = append(parts, "oifname")
+	default:
+		parts = append(parts, "oifname "+rule.outInterfaceID)
+	}
+	return strings.Join(append(parts, rule.verdict), " ")
+}
+
+// parseNftablesRuleset reads the rules and sets of the william tables from the output of `nft -j list ruleset`.
+// Sets are keyed by "<table>/<set>".
+func parseNftablesRuleset(output string) ([]nftablesRule, map[string][]string, error) {
+	var ruleset nftablesJSON
+	if err := json.Unmarshal([]byte(output), &ruleset); err != nil {
+		return nil, nil, fmt.Errorf("parse nftables ruleset: %w", err)
+	}
+
+	sets := make(map[string][]string)
+	rules := make([]nftablesRule, 0)
+	for _, item := range ruleset.Nftables {
+		if item.Set != nil && strings.HasPrefix(item.Set.Table, nftablesTablePrefix) {
+			elements := make([]string, 0, len(item.Set.Elem))
+			for _, elem := range item.Set.Elem {
+				elements = append(elements, nftablesValue(elem))
+			}
+			sets[item.Set.Table+"/"+item.Set.Name] = elements
+		}
+		if item.Rule == nil || !strings.HasPrefix(item.Rule.Table, nftablesTablePrefix) {
+			continue
+		}
+
+		rule := nftablesRule{table: item.Rule.Table}
+		for _, raw := range item.Rule.Expr {
+			// Each expression is an object with a single key naming its kind, e.g. {"accept": null}.
+			var expr map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &expr); err != nil {
+				continue
+			}
+			for _, verdict := range []string{"accept", "drop"} {
+				if _, ok := expr[verdict]; ok {
+					rule.verdict = verdict
+				}
+			}
+			var match nftablesMatch
+			if err := json.Unmarshal(expr["match"], &match); err != nil {
+				continue
+			}
+			switch {
+			case match.Left.Meta != nil && match.Left.Meta.Key == "iifname":
+				rule.interfaceID = nftablesValue(match.Right)
+			case match.Left.Meta != nil && match.Left.Meta.Key == "oifname":
+				rule.outInterfaceID = nftablesValue(match.Right)
+			case match.Left.Payload != nil && match.Left.Payload.Field == "saddr":
+				rule.source = nftablesValue(match.Right)
+			case match.Left.Payload != nil && match.Left.Payload.Field == "daddr":
+				rule.destination = nftablesValue(match.Right)
+			case match.Left.Payload != nil && match.Left.Payload.Field == "dport":
+				rule.protocol = match.Left.Payload.Protocol
+				rule.ports = nftablesPorts(match.Right)
+			case match.Left.Meta != nil && match.Left.Meta.Key == "l4proto":
+				rule.protocol = nftablesValue(match.Right)
+				if rule.protocol == "ipv6-icmp" {
+					rule.protocol = domain.AccessProtocolICMP
+				}
+			case match.Left.Ct != nil && match.Left.Ct.Key == "state":
+				rule.connectionState = true
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, sets, nil
+}
+
+// parseNftablesRules reads the peer rules of the william tables from the output of `nft -j list ruleset`.
+// A rule whose destination is a set yields one rule per set element.
+func parseNftablesRules(output string) ([]FirewallRule, error) {
+	rules, sets, err := parseNftablesRuleset(output)
+	if err != nil {
+		return nil, err
+	}
+
+	peerRules := make([]FirewallRule, 0)
+	for _, rule := range rules {
+		if rule.verdict != "accept" || rule.source == "" || rule.destination == "" {
+			continue
+		}
+
+		protocol := rule.protocol
+		if protocol == "" {
+			protocol = domain.AccessProtocolAny
+		}
+		destinations := []string{rule.destination}
+		if set, ok := strings.CutPrefix(rule.destination, "@"); ok {
+			destinations = sets[rule.table+"/"+set]
+		}
+		for _, destination := range destinations {
+			peerRules = append(peerRules, FirewallRule{
+				InterfaceID: rule.interfaceID,
+				Source:      canonicalPrefix(rule.source),
+				Destination: canonicalPrefix(destination),
+				Protocol:    protocol,
+				Ports:       rule.ports,
+			})
+		}
+	}
+	return peerRules, nil
+}
+
+// parseNftablesIsolationModes matches the terminal rules of each interface in the output of `nft -j list ruleset`
+// against the rules of every isolation mode.
+func parseNftablesIsolationModes(output string) (map[string]string, error) {
+	rules, _, err := parseNftablesRuleset(output)
+	if err != nil {
+		return nil, err
+	}
+
+	terminals := make(map[string][]string)
+	for _, rule := range rules {
+		if rule.source != "" || rule.interfaceID == "" {
+			continue
+		}
+		terminals[rule.interfaceID] = append(terminals[rule.interfaceID], rule.terminal())
+	}
+
+	modes := make(map[string]string, len(terminals))
+	for interfaceID, terminal := range terminals {
+		modes[interfaceID] = ""
+		for _, mode := range isolationModes {
+			if slices.Equal(terminal, nftablesIsolationRules(mode)) {
+				modes[interfaceID] = mode
+				break
+			}
+		}
+	}
+	return modes, nil
+}
+
+// nftablesValue reads an address, a set reference or a prefix ({"prefix": {"addr": ..., "len": ...}}) of nft JSON.
+// Ranges are returned as "<first>-<last>" and never equal a prefix.
+func nftablesValue(raw json.RawMessage) string {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+
+	var compound struct {
+		Prefix *struct {
+			Addr string `json:"addr"`
+			Len  int    `json:"len"`
+		} `json:"prefix"`
+		Range []string `json:"range"`
+	}
+	if err := json.Unmarshal(raw, &compound); err != nil {
+		return ""
+	}
+	switch {
+	case compound.Prefix != nil:
+		return compound.Prefix.Addr + "/" + strconv.Itoa(compound.Prefix.Len)
+	case len(compound.Range) == 2:
+		return compound.Range[0] + "-" + compound.Range[1]
+	}
+	return ""
+}
+
+// nftablesPorts reads a port (22) or a port range ({"range": [8000, 8080]}) of nft JSON as "22" or "8000-8080".
+func nftablesPorts(raw json.RawMessage) string {
+	var port int
+	if err := json.Unmarshal(raw, &port); err == nil {
+		return strconv.Itoa(port)
+	}
+
+	var compound struct {
+		Range []int `json:"range"`
+	}
+	if err := json.Unmarshal(raw, &compound); err != nil || len(compound.Range) != 2 {
+		return ""
+	}
+	return domain.PortRange{First: uint16(compound.Range[0]), Last: uint16(compound.Range[1])}.String()
+}
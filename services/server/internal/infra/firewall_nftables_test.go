@@ -0,0 +1,162 @@
+package infra
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites the file when the test runs with -update.
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the rendered ruleset:\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
+
+// fakeCommandRunner answers `nft list tables inet` with the live tables and records every script fed to nft.
+type fakeCommandRunner struct {
+	liveTables []string
+	scripts    []string
+}
+
+func (runner *fakeCommandRunner) Run(ctx context.Context, name string, args ...string) (string, error) {
+	var output strings.Builder
+	for _, table := range runner.liveTables {
+		output.WriteString("table inet " + table + "\n")
+	}
+	return output.String(), nil
+}
+
+func (runner *fakeCommandRunner) RunWithInput(ctx context.Context, input string, name string, args ...string) (string, error) {
+	runner.scripts = append(runner.scripts, input)
+	return "", nil
+}
+
+func TestRenderNftablesRuleset(t *testing.T) {
+	routes := []domain.AccessRule{
+		{CIDR: "10.10.0.0/16", Protocol: domain.AccessProtocolAny},
+		{CIDR: "192.168.1.0/24", Protocol: domain.AccessProtocolAny},
+	}
+	twoPeers := []domain.PeerFirewallRules{
+		{InterfaceID: "wg0", PeerAllowedIP: "10.0.0.3/32", Rules: routes},
+		{InterfaceID: "wg0", PeerAllowedIP: "10.0.0.2/32", Rules: routes},
+	}
+
+	tests := []struct {
+		name       string
+		interfaces map[string]string
+		peers      []domain.PeerFirewallRules
+		liveTables []string
+	}{
+		{
+			name:       "routes_only",
+			interfaces: map[string]string{"wg0": domain.InterfaceIsolationRoutesOnly},
+			peers:      twoPeers,
+		},
+		{
+			name:       "peer_to_peer",
+			interfaces: map[string]string{"wg0": domain.InterfaceIsolationPeerToPeer},
+			peers:      twoPeers,
+		},
+		{
+			name:       "open",
+			interfaces: map[string]string{"wg0": domain.InterfaceIsolationOpen},
+			peers:      twoPeers,
+		},
+		{
+			name:       "ports",
+			interfaces: map[string]string{"wg0": domain.InterfaceIsolationRoutesOnly},
+			peers: []domain.PeerFirewallRules{{
+				InterfaceID:   "wg0",
+				PeerAllowedIP: "10.0.0.2/32, fd00::2/128",
+				Rules: []domain.AccessRule{
+					{CIDR: "10.10.0.5/32", Protocol: domain.AccessProtocolTCP, Ports: "22"},
+					{CIDR: "10.10.0.0/16", Protocol: domain.AccessProtocolTCP, Ports: "8000-8080"},
+					{CIDR: "10.20.0.0/16", Protocol: domain.AccessProtocolTCP, Ports: "8000-8080"},
+					{CIDR: "10.53.0.53/32", Protocol: domain.AccessProtocolUDP, Ports: "53"},
+					{CIDR: "10.30.0.0/16", Protocol: domain.AccessProtocolUDP},
+					{CIDR: "10.0.0.0/8", Protocol: domain.AccessProtocolICMP},
+					{CIDR: "fd10::/64", Protocol: domain.AccessProtocolAny},
+					{CIDR: "fd20::/64", Protocol: domain.AccessProtocolICMP},
+				},
+			}},
+		},
+		{
+			// Tables of removed interfaces are deleted, and an interface without a mode gets the default.
+			name: "replace_tables",
+			interfaces: map[string]string{
+				"wg0":     domain.InterfaceIsolationPeerToPeer,
+				"wg-lab":  domain.InterfaceIsolationOpen,
+				"wg-idle": domain.InterfaceIsolationRoutesOnly,
+			},
+			peers: []domain.PeerFirewallRules{
+				{InterfaceID: "wg0", PeerAllowedIP: "10.0.0.2/32", Rules: routes},
+				{InterfaceID: "wg-lab", PeerAllowedIP: "10.1.0.2/32", Rules: routes[:1]},
+				{InterfaceID: "wg-new", PeerAllowedIP: "10.2.0.2/32", Rules: routes[1:]},
+			},
+			liveTables: []string{"william_wg0", "william_wg_gone", "william_wg_lab"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkGolden(t, test.name+".nft", renderNftablesRuleset(test.interfaces, test.peers, test.liveTables))
+		})
+	}
+}
+
+func TestNftablesFirewallAppliesRenderedRuleset(t *testing.T) {
+	ctx := context.Background()
+	runner := &fakeCommandRunner{liveTables: []string{"william_wg0"}}
+	firewall := &NftablesFirewall{runner: runner, interfaces: make(map[string]string)}
+
+	routes := []domain.AccessRule{{CIDR: "10.10.0.0/16", Protocol: domain.AccessProtocolAny}}
+	if err := firewall.SyncPeerRules(ctx, "wg0", "10.0.0.2/32", routes); err == nil {
+		t.Fatal("SyncPeerRules before RestoreRules succeeded")
+	}
+	if err := firewall.SyncInterfaceRules(ctx, "wg0", domain.InterfaceIsolationPeerToPeer); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.scripts) != 0 {
+		t.Fatalf("applied %d scripts before RestoreRules", len(runner.scripts))
+	}
+
+	err := firewall.RestoreRules(ctx, []domain.InterfaceFirewallRules{{InterfaceID: "wg0", IsolationMode: domain.InterfaceIsolationRoutesOnly}},
+		[]domain.PeerFirewallRules{{InterfaceID: "wg0", PeerAllowedIP: "10.0.0.3/32", Rules: routes}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := firewall.SyncPeerRules(ctx, "wg0", "10.0.0.2/32", routes); err != nil {
+		t.Fatal(err)
+	}
+	if err := firewall.RemovePeerRules(ctx, "10.0.0.3/32"); err != nil {
+		t.Fatal(err)
+	}
+	if err := firewall.RemoveInterfaceRules(ctx, "wg0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runner.scripts) != 4 {
+		t.Fatalf("applied %d scripts, want 4", len(runner.scripts))
+	}
+	checkGolden(t, "apply.nft", strings.Join(runner.scripts, "# next script\n"))
+}
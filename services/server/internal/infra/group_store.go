@@ -0,0 +1,90 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// SQLGroupStore persists interface to group bindings and group memberships.
+type SQLGroupStore struct {
+	db *sql.DB
+}
+
+func NewSQLGroupStore(db *sql.DB) *SQLGroupStore {
+	return &SQLGroupStore{db: db}
+}
+
+func (store *SQLGroupStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.InterfaceGroup, error) {
+	rows, err := conn(ctx, store.db).QueryContext(ctx, `
+		SELECT interface_id, group_name, created_at
+		FROM interface_groups
+		WHERE interface_id = $1
+		ORDER BY group_name
+	`, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []domain.InterfaceGroup
+	for rows.Next() {
+		var group domain.InterfaceGroup
+		if err := rows.Scan(&group.InterfaceID, &group.GroupName, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (store *SQLGroupStore) ListInterfaceIDsByGroups(ctx context.Context, groups []string) ([]string, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, conn(ctx, store.db), `
+		SELECT DISTINCT interface_id
+		FROM interface_groups
+		WHERE group_name = ANY($1)
+		ORDER BY interface_id
+	`, pq.Array(groups))
+}
+
+func (store *SQLGroupStore) Create(ctx context.Context, interfaceID string, groupName string) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		INSERT INTO interface_groups (interface_id, group_name)
+		VALUES ($1, $2)
+		ON CONFLICT (interface_id, group_name) DO NOTHING
+	`, interfaceID, groupName)
+	return err
+}
+
+func (store *SQLGroupStore) Delete(ctx context.Context, interfaceID string, groupName string) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		DELETE FROM interface_groups
+		WHERE interface_id = $1 AND group_name = $2
+	`, interfaceID, groupName)
+	return err
+}
+
+// ListGroupsByEmail returns the groups of email from group_members and from SCIM provisioned groups.
+// Users deactivated over SCIM are not members of any SCIM group.
+func (store *SQLGroupStore) ListGroupsByEmail(ctx context.Context, email string) ([]string, error) {
+	return queryStrings(ctx, conn(ctx, store.db), `
+		SELECT group_name
+		FROM group_members
+		WHERE email = $1
+		UNION
+		SELECT scim_groups.display_name
+		FROM scim_group_members
+		JOIN scim_groups ON scim_groups.id = scim_group_members.group_id
+		JOIN scim_users ON scim_users.id = scim_group_members.user_id
+		WHERE lower(scim_users.user_name) = lower($1) AND scim_users.active
+		ORDER BY 1
+	`, email)
+}
@@ -0,0 +1,257 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// SQLIPAllocationStore hands out peer addresses from the ip_allocations table.
+// Released addresses are kept for reuseCooldown before they can be allocated again.
+type SQLIPAllocationStore struct {
+	db            *sql.DB
+	reuseCooldown time.Duration
+}
+
+func NewSQLIPAllocationStore(db *sql.DB, reuseCooldown time.Duration) *SQLIPAllocationStore {
+	return &SQLIPAllocationStore{db: db, reuseCooldown: reuseCooldown}
+}
+
+// LoadIPReuseCooldown reads WILLIAM_IP_REUSE_COOLDOWN (a Go duration such as "24h").
+// Addresses are reusable immediately when it is unset.
+func LoadIPReuseCooldown() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("WILLIAM_IP_REUSE_COOLDOWN"))
+	if value == "" {
+		return 0, nil
+	}
+	cooldown, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse WILLIAM_IP_REUSE_COOLDOWN: %w", err)
+	}
+	if cooldown < 0 {
+		return 0, fmt.Errorf("WILLIAM_IP_REUSE_COOLDOWN must not be negative")
+	}
+	return cooldown, nil
+}
+
+func (store *SQLIPAllocationStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.IPAllocation, error) {
+	rows, err := conn(ctx, store.db).QueryContext(ctx, `
+		SELECT interface_id, address, COALESCE(peer_id, ''), released_at, created_at
+		FROM ip_allocations
+		WHERE interface_id = $1
+		ORDER BY created_at, address
+	`, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allocations []domain.IPAllocation
+	for rows.Next() {
+		var allocation domain.IPAllocation
+		var releasedAt sql.NullTime
+		if err := rows.Scan(&allocation.InterfaceID, &allocation.Address, &allocation.PeerID, &releasedAt, &allocation.CreatedAt); err != nil {
+			return nil, err
+		}
+		if releasedAt.Valid {
+			allocation.ReleasedAt = &releasedAt.Time
+		}
+		allocations = append(allocations, allocation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// Allocate records one address per interface address family without a peer. Requested addresses
+// are used when they are free; the other families get the next free, unreserved address.
+// The interface row is locked for the duration of the transaction so concurrent allocations
+// on the same interface are serialized; the primary key guards against duplicates regardless.
+// Inside a transaction carried by ctx the allocation joins it and is only visible once it commits.
+func (store *SQLIPAllocationStore) Allocate(ctx context.Context, interfaceID string, requested string) (string, error) {
+	var allowedIP string
+	err := withinTransaction(ctx, store.db, func(ctx context.Context) error {
+		tx := conn(ctx, store.db)
+
+		var interfaceAddress string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT address
+			FROM interfaces
+			WHERE id = $1
+			FOR UPDATE
+		`, interfaceID).Scan(&interfaceAddress); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM ip_allocations
+			WHERE interface_id = $1
+			  AND released_at IS NOT NULL
+			  AND released_at <= CURRENT_TIMESTAMP - make_interval(secs => $2)
+		`, interfaceID, store.reuseCooldown.Seconds()); err != nil {
+			return err
+		}
+
+		addresses, err := queryStrings(ctx, tx, `
+			SELECT address
+			FROM ip_allocations
+			WHERE interface_id = $1
+		`, interfaceID)
+		if err != nil {
+			return err
+		}
+
+		reservations, err := queryStrings(ctx, tx, `
+			SELECT cidr
+			FROM ip_reservations
+			WHERE interface_id = $1
+		`, interfaceID)
+		if err != nil {
+			return err
+		}
+
+		prefixes, err := parseInterfacePrefixes(interfaceAddress)
+		if err != nil {
+			return err
+		}
+
+		allowedIP, err = allocateRequestedAddresses(prefixes, requested, usedPeerAddresses(addresses...), parseReservedPrefixes(reservations))
+		if err != nil {
+			return err
+		}
+
+		for _, address := range domain.SplitAddresses(allowedIP) {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO ip_allocations (interface_id, address)
+				VALUES ($1, $2)
+			`, interfaceID, address); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return allowedIP, nil
+}
+
+// Assign links allocated addresses to the peer that now uses them.
+func (store *SQLIPAllocationStore) Assign(ctx context.Context, interfaceID string, addresses string, peerID string) error {
+	for _, address := range domain.SplitAddresses(addresses) {
+		if _, err := conn(ctx, store.db).ExecContext(ctx, `
+			UPDATE ip_allocations
+			SET peer_id = $3
+			WHERE interface_id = $1 AND address = $2
+		`, interfaceID, address, peerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release frees addresses immediately, e.g. when creating the peer failed after allocation.
+func (store *SQLIPAllocationStore) Release(ctx context.Context, interfaceID string, addresses string) error {
+	for _, address := range domain.SplitAddresses(addresses) {
+		if _, err := conn(ctx, store.db).ExecContext(ctx, `
+			DELETE FROM ip_allocations
+			WHERE interface_id = $1 AND address = $2
+		`, interfaceID, address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseByPeer frees the addresses of a deleted peer, honouring the reuse cooldown.
+func (store *SQLIPAllocationStore) ReleaseByPeer(ctx context.Context, peerID string) error {
+	if store.reuseCooldown <= 0 {
+		_, err := conn(ctx, store.db).ExecContext(ctx, `
+			DELETE FROM ip_allocations
+			WHERE peer_id = $1
+		`, peerID)
+		return err
+	}
+
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		UPDATE ip_allocations
+		SET released_at = CURRENT_TIMESTAMP
+		WHERE peer_id = $1 AND released_at IS NULL
+	`, peerID)
+	return err
+}
+
+func (store *SQLIPAllocationStore) ListReservations(ctx context.Context, interfaceID string) ([]domain.IPReservation, error) {
+	rows, err := conn(ctx, store.db).QueryContext(ctx, `
+		SELECT interface_id, cidr, description, created_at
+		FROM ip_reservations
+		WHERE interface_id = $1
+		ORDER BY cidr
+	`, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []domain.IPReservation
+	for rows.Next() {
+		var reservation domain.IPReservation
+		if err := rows.Scan(&reservation.InterfaceID, &reservation.CIDR, &reservation.Description, &reservation.CreatedAt); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (store *SQLIPAllocationStore) CreateReservation(ctx context.Context, reservation domain.IPReservation) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		INSERT INTO ip_reservations (interface_id, cidr, description)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (interface_id, cidr) DO UPDATE SET description = EXCLUDED.description
+	`, reservation.InterfaceID, reservation.CIDR, reservation.Description)
+	return err
+}
+
+func (store *SQLIPAllocationStore) DeleteReservation(ctx context.Context, interfaceID string, cidr string) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		DELETE FROM ip_reservations
+		WHERE interface_id = $1 AND cidr = $2
+	`, interfaceID, cidr)
+	return err
+}
+
+// stringQueryer is satisfied by both *sql.DB and *sql.Tx.
+type stringQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryStrings(ctx context.Context, queryer stringQueryer, query string, args ...any) ([]string, error) {
+	rows, err := queryer.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,178 @@
+package infra
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	jwtClockLeeway = time.Minute
+	// jwksRefreshInterval limits how often a remote JWKS is re-fetched for unknown key IDs.
+	jwksRefreshInterval = time.Minute
+	maxJWKSSize         = 1 << 20
+)
+
+var supportedJWTAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// JWTVerifier checks JWT signatures against a JWKS and validates issuer, audience and expiry.
+type JWTVerifier struct {
+	keys     jwksProvider
+	issuer   string
+	audience string
+}
+
+type jwksProvider interface {
+	keys(keyID string) ([]jose.JSONWebKey, error)
+}
+
+// NewJWTVerifier builds a verifier for a fixed key set. Empty issuer or audience are not checked.
+func NewJWTVerifier(keySet jose.JSONWebKeySet, issuer string, audience string) *JWTVerifier {
+	return &JWTVerifier{keys: staticJWKS{keySet: keySet}, issuer: issuer, audience: audience}
+}
+
+// NewRemoteJWTVerifier builds a verifier that fetches the key set from url and
+// re-fetches it when a token is signed with a key it does not know yet.
+func NewRemoteJWTVerifier(url string, httpClient *http.Client, issuer string, audience string) *JWTVerifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWTVerifier{keys: &remoteJWKS{url: url, client: httpClient}, issuer: issuer, audience: audience}
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from path.
+func LoadJWKSFile(path string) (jose.JSONWebKeySet, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("read jwks file: %w", err)
+	}
+	return parseJWKS(content)
+}
+
+func parseJWKS(content []byte) (jose.JSONWebKeySet, error) {
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(content, &keySet); err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("parse jwks: %w", err)
+	}
+	if len(keySet.Keys) == 0 {
+		return jose.JSONWebKeySet{}, errors.New("jwks has no keys")
+	}
+	return keySet, nil
+}
+
+// Verify validates token and returns all of its claims.
+func (verifier *JWTVerifier) Verify(token string) (map[string]any, error) {
+	parsed, err := jwt.ParseSigned(token, supportedJWTAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwt: %w", err)
+	}
+
+	keyID := ""
+	if len(parsed.Headers) > 0 {
+		keyID = parsed.Headers[0].KeyID
+	}
+	keys, err := verifier.keys.keys(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwt is signed with an unknown key")
+	}
+
+	for _, key := range keys {
+		var registered jwt.Claims
+		claims := make(map[string]any)
+		if err := parsed.Claims(key, &registered, &claims); err != nil {
+			continue
+		}
+
+		if registered.Expiry == nil {
+			return nil, errors.New("jwt has no expiry")
+		}
+		expected := jwt.Expected{Issuer: verifier.issuer, Time: time.Now()}
+		if verifier.audience != "" {
+			expected.AnyAudience = jwt.Audience{verifier.audience}
+		}
+		if err := registered.ValidateWithLeeway(expected, jwtClockLeeway); err != nil {
+			return nil, fmt.Errorf("validate jwt: %w", err)
+		}
+		return claims, nil
+	}
+
+	return nil, errors.New("jwt signature is invalid")
+}
+
+type staticJWKS struct {
+	keySet jose.JSONWebKeySet
+}
+
+func (provider staticJWKS) keys(keyID string) ([]jose.JSONWebKey, error) {
+	return selectJWKSKeys(provider.keySet, keyID), nil
+}
+
+type remoteJWKS struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keySet    jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func (provider *remoteJWKS) keys(keyID string) ([]jose.JSONWebKey, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if keys := selectJWKSKeys(provider.keySet, keyID); len(keys) > 0 {
+		return keys, nil
+	}
+	if !provider.fetchedAt.IsZero() && time.Since(provider.fetchedAt) < jwksRefreshInterval {
+		return nil, nil
+	}
+
+	keySet, err := provider.fetch()
+	if err != nil {
+		return nil, err
+	}
+	provider.keySet = keySet
+	provider.fetchedAt = time.Now()
+	return selectJWKSKeys(provider.keySet, keyID), nil
+}
+
+func (provider *remoteJWKS) fetch() (jose.JSONWebKeySet, error) {
+	response, err := provider.client.Get(provider.url)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: unexpected status %s", response.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxJWKSSize))
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return parseJWKS(content)
+}
+
+// selectJWKSKeys returns the keys matching keyID, or every key when the token has no key ID.
+func selectJWKSKeys(keySet jose.JSONWebKeySet, keyID string) []jose.JSONWebKey {
+	if keyID == "" {
+		return keySet.Keys
+	}
+	return keySet.Key(keyID)
+}
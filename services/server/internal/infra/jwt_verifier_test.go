@@ -0,0 +1,191 @@
+package infra
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// testSigningKey is an ES256 key whose public half can be served in a JWKS.
+type testSigningKey struct {
+	keyID      string
+	privateKey *ecdsa.PrivateKey
+}
+
+func newTestSigningKey(t *testing.T, keyID string) testSigningKey {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigningKey{keyID: keyID, privateKey: privateKey}
+}
+
+func (key testSigningKey) public() jose.JSONWebKey {
+	return jose.JSONWebKey{Key: &key.privateKey.PublicKey, KeyID: key.keyID, Algorithm: string(jose.ES256), Use: "sig"}
+}
+
+func (key testSigningKey) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: key.privateKey, KeyID: key.keyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// jwksServer serves a JWKS over HTTP and counts how often it was fetched.
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    []jose.JSONWebKey
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, keys ...jose.JSONWebKey) *jwksServer {
+	t.Helper()
+	server := &jwksServer{keys: keys}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		server.fetches++
+		if err := json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: server.keys}); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (server *jwksServer) setKeys(keys ...jose.JSONWebKey) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.keys = keys
+}
+
+func TestJWTVerifierVerify(t *testing.T) {
+	signing := newTestSigningKey(t, "pomerium-1")
+	other := newTestSigningKey(t, "pomerium-1")
+	server := newJWKSServer(t, signing.public())
+	verifier := NewRemoteJWTVerifier(server.URL, server.Client(), "wireguard.example.com", "wireguard.example.com")
+
+	now := time.Now()
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   "wireguard.example.com",
+			"aud":   "wireguard.example.com",
+			"exp":   now.Add(time.Hour).Unix(),
+			"email": "alice@example.com",
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "valid", token: signing.sign(t, valid())},
+		{name: "audience list", token: signing.sign(t, with("aud", []string{"other.example.com", "wireguard.example.com"}))},
+		{name: "expired within leeway", token: signing.sign(t, with("exp", now.Add(-30*time.Second).Unix()))},
+		{name: "wrong issuer", token: signing.sign(t, with("iss", "evil.example.com")), wantErr: "validate jwt"},
+		{name: "wrong audience", token: signing.sign(t, with("aud", "admin.example.com")), wantErr: "validate jwt"},
+		{name: "expired", token: signing.sign(t, with("exp", now.Add(-time.Hour).Unix())), wantErr: "validate jwt"},
+		{name: "no expiry", token: signing.sign(t, with("exp", nil)), wantErr: "no expiry"},
+		{name: "not yet valid", token: signing.sign(t, with("nbf", now.Add(time.Hour).Unix())), wantErr: "validate jwt"},
+		{name: "bad signature", token: other.sign(t, valid()), wantErr: "signature is invalid"},
+		{name: "malformed", token: "not.a.jwt", wantErr: "parse jwt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := verifier.Verify(test.token)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if claims["email"] != "alice@example.com" {
+					t.Errorf("email claim = %v", claims["email"])
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+
+	if server.fetches != 1 {
+		t.Errorf("fetched the JWKS %d times, want once for the known key", server.fetches)
+	}
+}
+
+func TestJWTVerifierRefreshesUnknownKeyID(t *testing.T) {
+	current := newTestSigningKey(t, "pomerium-1")
+	next := newTestSigningKey(t, "pomerium-2")
+	server := newJWKSServer(t, current.public())
+	verifier := NewRemoteJWTVerifier(server.URL, server.Client(), "", "")
+	claims := map[string]any{"exp": time.Now().Add(time.Hour).Unix(), "email": "alice@example.com"}
+
+	if _, err := verifier.Verify(current.sign(t, claims)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The identity provider rotates its key; tokens signed with it name a key ID the verifier has not seen.
+	server.setKeys(current.public(), next.public())
+	if _, err := verifier.Verify(next.sign(t, claims)); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Fatalf("err = %v, want the unknown key rejected until the refresh interval passed", err)
+	}
+	if server.fetches != 1 {
+		t.Fatalf("fetched the JWKS %d times within the refresh interval, want 1", server.fetches)
+	}
+
+	verifier.keys.(*remoteJWKS).fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	if _, err := verifier.Verify(next.sign(t, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if server.fetches != 2 {
+		t.Errorf("fetched the JWKS %d times, want a refetch for the unknown key", server.fetches)
+	}
+	if _, err := verifier.Verify(current.sign(t, claims)); err != nil {
+		t.Errorf("the previous key stopped verifying: %v", err)
+	}
+}
+
+func TestNewJWTVerifierWithStaticKeySet(t *testing.T) {
+	signing := newTestSigningKey(t, "")
+	verifier := NewJWTVerifier(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{signing.public()}}, "admin.example.com", "")
+
+	claims := map[string]any{"iss": "admin.example.com", "exp": time.Now().Add(time.Hour).Unix(), "email": "owner@example.com"}
+	if _, err := verifier.Verify(signing.sign(t, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(newTestSigningKey(t, "").sign(t, claims)); err == nil {
+		t.Error("a token signed with a key outside the key set was accepted")
+	}
+}
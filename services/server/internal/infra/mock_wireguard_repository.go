@@ -7,7 +7,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"net/netip"
 	"strings"
 
 	"github.com/nomuken/william/services/server/internal/domain"
@@ -34,7 +33,11 @@ func (repo *MockWireguardRepository) ListInterfaces(ctx context.Context) ([]doma
 
 	items := make([]domain.WireguardInterface, 0, len(configs))
 	for _, config := range configs {
-		items = append(items, interfaceFromConfig(config))
+		item, err := repo.describeInterface(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 	}
 	return items, nil
 }
@@ -44,7 +47,7 @@ func (repo *MockWireguardRepository) GetInterface(ctx context.Context, interface
 	if err != nil {
 		return domain.WireguardInterface{}, err
 	}
-	return interfaceFromConfig(config), nil
+	return repo.describeInterface(ctx, config)
 }
 
 func (repo *MockWireguardRepository) CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
@@ -52,18 +55,23 @@ func (repo *MockWireguardRepository) CreateInterface(ctx context.Context, config
 }
 
 func (repo *MockWireguardRepository) UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
-	return interfaceFromConfig(config), nil
+	return repo.describeInterface(ctx, config)
 }
 
 func (repo *MockWireguardRepository) DeleteInterface(ctx context.Context, interfaceID string) error {
 	return nil
 }
 
-func (repo *MockWireguardRepository) CreatePeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (domain.WireguardPeer, error) {
-	config, err := repo.interfaceStore.Get(ctx, interfaceID)
+func (repo *MockWireguardRepository) GeneratePrivateKey(ctx context.Context) (string, error) {
+	return randomKey()
+}
+
+func (repo *MockWireguardRepository) CreatePeer(ctx context.Context, spec domain.PeerSpec) (domain.WireguardPeer, error) {
+	config, err := repo.interfaceStore.Get(ctx, spec.InterfaceID)
 	if err != nil {
 		return domain.WireguardPeer{}, err
 	}
+	endpoint := spec.Endpoint
 	if endpoint == "" {
 		endpoint = config.Endpoint
 	}
@@ -71,26 +79,33 @@ func (repo *MockWireguardRepository) CreatePeer(ctx context.Context, interfaceID
 		return domain.WireguardPeer{}, errors.New("endpoint is required")
 	}
 
-	allowedIP, err := repo.nextAllowedIP(ctx, config)
-	if err != nil {
-		return domain.WireguardPeer{}, err
+	allowedIP := spec.AllowedIP
+	if allowedIP == "" {
+		allowedIP, err = repo.nextAllowedIP(ctx, config)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
 	}
 
-	privateKey, err := randomKey()
-	if err != nil {
-		return domain.WireguardPeer{}, err
-	}
-	publicKey, err := randomKey()
-	if err != nil {
-		return domain.WireguardPeer{}, err
+	privateKey := peerPrivateKeyPlaceholder
+	publicKey := spec.PublicKey
+	if publicKey == "" {
+		privateKey, err = randomKey()
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
+		publicKey, err = randomKey()
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
 	}
 
-	allowedIPs = normalizeAllowedIPs(allowedIP, allowedIPs)
+	allowedIPs := normalizeAllowedIPs(allowedIP, spec.AllowedIPs)
 	configText := fmt.Sprintf("[Interface]\nPrivateKey = %s\nAddress = %s\n\n[Peer]\nPublicKey = %s\nAllowedIPs = %s\nEndpoint = %s\n", privateKey, allowedIP, publicKey, strings.Join(allowedIPs, ", "), endpoint)
 
 	return domain.WireguardPeer{
 		ID:          publicKey,
-		InterfaceID: interfaceID,
+		InterfaceID: spec.InterfaceID,
 		AllowedIP:   allowedIP,
 		Config:      configText,
 	}, nil
@@ -129,7 +144,7 @@ func (repo *MockWireguardRepository) EnsureFirewallChain(ctx context.Context) er
 	return nil
 }
 
-func (repo *MockWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, allowedIPs []string) error {
+func (repo *MockWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
 	return nil
 }
 
@@ -137,6 +152,10 @@ func (repo *MockWireguardRepository) RemovePeerFirewallRules(ctx context.Context
 	return nil
 }
 
+func (repo *MockWireguardRepository) RestoreFirewallRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	return nil
+}
+
 func (repo *MockWireguardRepository) ListConfigs(ctx context.Context, interfaceID string) ([]domain.WireguardConfig, error) {
 	configs, err := repo.interfaceStore.List(ctx)
 	if err != nil {
@@ -158,45 +177,47 @@ func (repo *MockWireguardRepository) ListConfigs(ctx context.Context, interfaceI
 }
 
 func (repo *MockWireguardRepository) nextAllowedIP(ctx context.Context, config domain.InterfaceConfig) (string, error) {
-	prefix, err := netip.ParsePrefix(config.Address)
+	prefixes, err := parseInterfacePrefixes(config.Address)
 	if err != nil {
-		return "", fmt.Errorf("parse interface address: %w", err)
+		return "", err
 	}
-	interfaceAddr := prefix.Addr()
-
-	used := make(map[netip.Addr]struct{})
-	used[interfaceAddr] = struct{}{}
-	used[prefix.Masked().Addr()] = struct{}{}
 
 	peers, err := repo.peerStore.ListByInterface(ctx, config.ID)
 	if err != nil {
 		return "", err
 	}
+	assigned := make([]string, 0, len(peers))
 	for _, peer := range peers {
-		peerPrefix, err := netip.ParsePrefix(peer.AllowedIP)
-		if err != nil {
-			continue
-		}
-		if peerPrefix.Addr().Is4() {
-			used[peerPrefix.Addr()] = struct{}{}
-		}
+		assigned = append(assigned, peer.AllowedIP)
 	}
 
-	candidate, err := nextAvailableIPv4Mock(prefix, interfaceAddr, used)
-	if err != nil {
-		return "", err
-	}
+	return allocatePeerAddresses(prefixes, usedPeerAddresses(assigned...), nil)
+}
 
-	return candidate.String() + "/32", nil
+func (repo *MockWireguardRepository) describeInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
+	if config.PrivateKey == "" {
+		privateKey, err := repo.interfaceStore.GetPrivateKey(ctx, config.ID)
+		if err != nil {
+			return domain.WireguardInterface{}, err
+		}
+		config.PrivateKey = privateKey
+	}
+	return interfaceFromConfig(config), nil
 }
 
 func interfaceFromConfig(config domain.InterfaceConfig) domain.WireguardInterface {
+	// Derive the fake public key from the stored private key so key rotation is visible in dev.
+	seed := config.ID
+	if config.PrivateKey != "" {
+		seed = config.PrivateKey
+	}
+
 	return domain.WireguardInterface{
 		ID:         config.ID,
 		Name:       config.Name,
 		Address:    config.Address,
 		ListenPort: config.ListenPort,
-		PublicKey:  deterministicKey(config.ID),
+		PublicKey:  deterministicKey(seed),
 		MTU:        config.MTU,
 	}
 }
@@ -213,25 +234,3 @@ func randomKey() (string, error) {
 	}
 	return base64.StdEncoding.EncodeToString(buffer), nil
 }
-
-func nextAvailableIPv4Mock(prefix netip.Prefix, interfaceAddr netip.Addr, used map[netip.Addr]struct{}) (netip.Addr, error) {
-	if !prefix.Addr().Is4() {
-		return netip.Addr{}, errors.New("interface address is not IPv4")
-	}
-	if prefix.Bits() >= 31 {
-		return netip.Addr{}, errors.New("prefix too small to allocate address")
-	}
-
-	networkAddr := prefix.Masked().Addr()
-	for candidate := networkAddr.Next(); prefix.Contains(candidate); candidate = candidate.Next() {
-		if candidate == interfaceAddr {
-			continue
-		}
-		if _, exists := used[candidate]; exists {
-			continue
-		}
-		return candidate, nil
-	}
-
-	return netip.Addr{}, errors.New("no available address in prefix")
-}
@@ -0,0 +1,513 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// WireguardClient is the subset of wgctrl.Client used by NetlinkWireguardRepository.
+type WireguardClient interface {
+	Devices() ([]*wgtypes.Device, error)
+	Device(name string) (*wgtypes.Device, error)
+	ConfigureDevice(name string, cfg wgtypes.Config) error
+}
+
+// LinkHandle is the subset of netlink.Handle used by NetlinkWireguardRepository.
+type LinkHandle interface {
+	LinkByName(name string) (netlink.Link, error)
+	LinkAdd(link netlink.Link) error
+	LinkDel(link netlink.Link) error
+	LinkSetUp(link netlink.Link) error
+	LinkSetMTU(link netlink.Link, mtu int) error
+	AddrList(link netlink.Link, family int) ([]netlink.Addr, error)
+	AddrAdd(link netlink.Link, addr *netlink.Addr) error
+	AddrReplace(link netlink.Link, addr *netlink.Addr) error
+}
+
+// NetlinkWireguardRepository manages wireguard through rtnetlink and the wireguard generic netlink API.
+// Firewall rules are applied by the firewall driver.
+type NetlinkWireguardRepository struct {
+	links    LinkHandle
+	client   WireguardClient
+	firewall FirewallDriver
+}
+
+// NewWireguardRepositoryFromEnv selects the wireguard backend from WILLIAM_WG_BACKEND: "command" (default) or "netlink".
+func NewWireguardRepositoryFromEnv(firewall FirewallDriver) (domain.WireguardRepository, error) {
+	switch backend := strings.TrimSpace(os.Getenv("WILLIAM_WG_BACKEND")); backend {
+	case "", "command":
+		return NewCommandWireguardRepository(firewall), nil
+	case "netlink":
+		return NewNetlinkWireguardRepository(firewall)
+	default:
+		return nil, fmt.Errorf("unknown WILLIAM_WG_BACKEND %q", backend)
+	}
+}
+
+// NewNetlinkWireguardRepository opens netlink sockets in the current network namespace.
+func NewNetlinkWireguardRepository(firewall FirewallDriver) (*NetlinkWireguardRepository, error) {
+	links, err := netlink.NewHandle()
+	if err != nil {
+		return nil, fmt.Errorf("open rtnetlink: %w", err)
+	}
+
+	client, err := wgctrl.New()
+	if err != nil {
+		links.Close()
+		return nil, fmt.Errorf("open wireguard netlink: %w", err)
+	}
+
+	return NewNetlinkWireguardRepositoryWith(links, client, firewall), nil
+}
+
+// NewNetlinkWireguardRepositoryWith wires the repository with explicit netlink handles, e.g. ones bound to another namespace.
+func NewNetlinkWireguardRepositoryWith(links LinkHandle, client WireguardClient, firewall FirewallDriver) *NetlinkWireguardRepository {
+	return &NetlinkWireguardRepository{links: links, client: client, firewall: firewall}
+}
+
+func (repo *NetlinkWireguardRepository) ListInterfaces(ctx context.Context) ([]domain.WireguardInterface, error) {
+	devices, err := repo.client.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make([]domain.WireguardInterface, 0, len(devices))
+	for _, device := range devices {
+		item, err := repo.describeDevice(device)
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, item)
+	}
+
+	return interfaces, nil
+}
+
+func (repo *NetlinkWireguardRepository) GetInterface(ctx context.Context, interfaceID string) (domain.WireguardInterface, error) {
+	device, err := repo.device(interfaceID)
+	if err != nil {
+		return domain.WireguardInterface{}, err
+	}
+	return repo.describeDevice(device)
+}
+
+func (repo *NetlinkWireguardRepository) CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
+	link := &netlink.Wireguard{LinkAttrs: netlink.LinkAttrs{Name: config.ID}}
+	if err := repo.links.LinkAdd(link); err != nil {
+		return domain.WireguardInterface{}, fmt.Errorf("add link %s: %w", config.ID, err)
+	}
+
+	privateKey := config.PrivateKey
+	if privateKey == "" {
+		generated, err := repo.GeneratePrivateKey(ctx)
+		if err != nil {
+			return domain.WireguardInterface{}, err
+		}
+		privateKey = generated
+	}
+
+	if err := repo.configureInterface(config, privateKey, repo.links.AddrAdd); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
+	if err := repo.firewall.SyncInterfaceRules(ctx, config.ID, config.IsolationMode); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
+	return repo.GetInterface(ctx, config.ID)
+}
+
+func (repo *NetlinkWireguardRepository) UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
+	if err := repo.configureInterface(config, config.PrivateKey, repo.links.AddrReplace); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
+	if err := repo.firewall.SyncInterfaceRules(ctx, config.ID, config.IsolationMode); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
+	return repo.GetInterface(ctx, config.ID)
+}
+
+func (repo *NetlinkWireguardRepository) configureInterface(config domain.InterfaceConfig, privateKey string, setAddr func(netlink.Link, *netlink.Addr) error) error {
+	link, err := repo.link(config.ID)
+	if err != nil {
+		return err
+	}
+
+	listenPort := int(config.ListenPort)
+	deviceConfig := wgtypes.Config{ListenPort: &listenPort}
+	if privateKey != "" {
+		key, err := wgtypes.ParseKey(privateKey)
+		if err != nil {
+			return fmt.Errorf("parse private key: %w", err)
+		}
+		deviceConfig.PrivateKey = &key
+	}
+	if err := repo.client.ConfigureDevice(config.ID, deviceConfig); err != nil {
+		return fmt.Errorf("configure device %s: %w", config.ID, err)
+	}
+
+	prefixes, err := parseInterfacePrefixes(config.Address)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		addr, err := netlink.ParseAddr(prefix.String())
+		if err != nil {
+			return fmt.Errorf("parse interface address: %w", err)
+		}
+		if err := setAddr(link, addr); err != nil {
+			return fmt.Errorf("set address %s on %s: %w", prefix, config.ID, err)
+		}
+	}
+
+	if err := repo.links.LinkSetMTU(link, int(config.MTU)); err != nil {
+		return fmt.Errorf("set mtu on %s: %w", config.ID, err)
+	}
+
+	if err := repo.links.LinkSetUp(link); err != nil {
+		return fmt.Errorf("set %s up: %w", config.ID, err)
+	}
+
+	return nil
+}
+
+func (repo *NetlinkWireguardRepository) DeleteInterface(ctx context.Context, interfaceID string) error {
+	link, err := repo.link(interfaceID)
+	if err != nil {
+		return err
+	}
+	if err := repo.links.LinkDel(link); err != nil {
+		return err
+	}
+	return repo.firewall.RemoveInterfaceRules(ctx, interfaceID)
+}
+
+func (repo *NetlinkWireguardRepository) GeneratePrivateKey(ctx context.Context) (string, error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+func (repo *NetlinkWireguardRepository) CreatePeer(ctx context.Context, spec domain.PeerSpec) (domain.WireguardPeer, error) {
+	if spec.Endpoint == "" {
+		return domain.WireguardPeer{}, errors.New("endpoint is required")
+	}
+
+	interfaceID := spec.InterfaceID
+	device, err := repo.device(interfaceID)
+	if err != nil {
+		return domain.WireguardPeer{}, err
+	}
+
+	allowedIP := spec.AllowedIP
+	if allowedIP == "" {
+		prefixes, err := repo.interfacePrefixes(interfaceID)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
+
+		allowedIP, err = allocatePeerAddresses(prefixes, devicePeerAddresses(device), nil)
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
+	}
+
+	privateKey := ""
+	var publicKey wgtypes.Key
+	if spec.PublicKey != "" {
+		publicKey, err = wgtypes.ParseKey(spec.PublicKey)
+		if err != nil {
+			return domain.WireguardPeer{}, fmt.Errorf("parse peer public key: %w", err)
+		}
+	} else {
+		generated, err := wgtypes.GeneratePrivateKey()
+		if err != nil {
+			return domain.WireguardPeer{}, err
+		}
+		privateKey = generated.String()
+		publicKey = generated.PublicKey()
+	}
+
+	allowedIPs := normalizeAllowedIPs(allowedIP, spec.AllowedIPs)
+	if err := repo.setPeerAllowedIPs(interfaceID, publicKey, allowedIPs); err != nil {
+		return domain.WireguardPeer{}, err
+	}
+
+	config, err := buildPeerConfig(privateKey, allowedIP, device.PublicKey.String(), uint32(device.ListenPort), spec.Endpoint, allowedIPs)
+	if err != nil {
+		return domain.WireguardPeer{}, err
+	}
+
+	log.Printf("wireguard peer created: interface=%s peer=%s ip=%s", interfaceID, publicKey.String(), allowedIP)
+	return domain.WireguardPeer{
+		ID:          publicKey.String(),
+		InterfaceID: interfaceID,
+		AllowedIP:   allowedIP,
+		Config:      config,
+	}, nil
+}
+
+func (repo *NetlinkWireguardRepository) UpdatePeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error {
+	if len(allowedIPs) == 0 {
+		return errors.New("allowed IPs are required")
+	}
+	publicKey, err := wgtypes.ParseKey(peerID)
+	if err != nil {
+		return fmt.Errorf("parse peer public key: %w", err)
+	}
+	return repo.setPeerAllowedIPs(interfaceID, publicKey, allowedIPs)
+}
+
+func (repo *NetlinkWireguardRepository) setPeerAllowedIPs(interfaceID string, publicKey wgtypes.Key, allowedIPs []string) error {
+	networks := make([]net.IPNet, 0, len(allowedIPs))
+	for _, item := range allowedIPs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(item))
+		if err != nil {
+			return fmt.Errorf("parse allowed ip %q: %w", item, err)
+		}
+		networks = append(networks, *network)
+	}
+
+	return repo.client.ConfigureDevice(interfaceID, wgtypes.Config{
+		Peers: []wgtypes.PeerConfig{{
+			PublicKey:         publicKey,
+			ReplaceAllowedIPs: true,
+			AllowedIPs:        networks,
+		}},
+	})
+}
+
+func (repo *NetlinkWireguardRepository) DeletePeer(ctx context.Context, peerID string) error {
+	publicKey, err := wgtypes.ParseKey(peerID)
+	if err != nil {
+		return fmt.Errorf("parse peer public key: %w", err)
+	}
+
+	devices, err := repo.client.Devices()
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		for _, peer := range device.Peers {
+			if peer.PublicKey != publicKey {
+				continue
+			}
+			return repo.client.ConfigureDevice(device.Name, wgtypes.Config{
+				Peers: []wgtypes.PeerConfig{{PublicKey: publicKey, Remove: true}},
+			})
+		}
+	}
+
+	return ErrPeerNotFound
+}
+
+func (repo *NetlinkWireguardRepository) ListPeerStats(ctx context.Context) ([]domain.PeerStat, error) {
+	devices, err := repo.client.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]domain.PeerStat, 0)
+	for _, device := range devices {
+		for _, peer := range device.Peers {
+			var lastHandshakeAt int64
+			if !peer.LastHandshakeTime.IsZero() {
+				lastHandshakeAt = peer.LastHandshakeTime.Unix()
+			}
+			stats = append(stats, domain.PeerStat{
+				PeerID:          peer.PublicKey.String(),
+				InterfaceID:     device.Name,
+				RxBytes:         uint64(peer.ReceiveBytes),
+				TxBytes:         uint64(peer.TransmitBytes),
+				LastHandshakeAt: lastHandshakeAt,
+			})
+		}
+	}
+
+	return stats, nil
+}
+
+func (repo *NetlinkWireguardRepository) ListConfigs(ctx context.Context, interfaceID string) ([]domain.WireguardConfig, error) {
+	devices, err := repo.client.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]domain.WireguardConfig, 0, len(devices))
+	for _, device := range devices {
+		if interfaceID != "" && device.Name != interfaceID {
+			continue
+		}
+		configs = append(configs, domain.WireguardConfig{
+			InterfaceID: device.Name,
+			Config:      renderDeviceConfig(device),
+		})
+	}
+
+	if interfaceID != "" && len(configs) == 0 {
+		return nil, ErrInterfaceNotFound
+	}
+
+	return configs, nil
+}
+
+func (repo *NetlinkWireguardRepository) ListFirewallRules(ctx context.Context) (string, error) {
+	return repo.firewall.ListRules(ctx)
+}
+
+func (repo *NetlinkWireguardRepository) EnsureFirewallChain(ctx context.Context) error {
+	return repo.firewall.EnsureChain(ctx)
+}
+
+func (repo *NetlinkWireguardRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
+	return repo.firewall.SyncPeerRules(ctx, interfaceID, peerAllowedIP, rules)
+}
+
+func (repo *NetlinkWireguardRepository) RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error {
+	return repo.firewall.RemovePeerRules(ctx, peerAllowedIP)
+}
+
+func (repo *NetlinkWireguardRepository) RestoreFirewallRules(ctx context.Context, interfaces []domain.InterfaceFirewallRules, peers []domain.PeerFirewallRules) error {
+	return repo.firewall.RestoreRules(ctx, interfaces, peers)
+}
+
+func (repo *NetlinkWireguardRepository) device(name string) (*wgtypes.Device, error) {
+	device, err := repo.client.Device(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrInterfaceNotFound
+		}
+		return nil, err
+	}
+	return device, nil
+}
+
+func (repo *NetlinkWireguardRepository) link(name string) (netlink.Link, error) {
+	link, err := repo.links.LinkByName(name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, ErrInterfaceNotFound
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (repo *NetlinkWireguardRepository) describeDevice(device *wgtypes.Device) (domain.WireguardInterface, error) {
+	link, err := repo.link(device.Name)
+	if err != nil {
+		return domain.WireguardInterface{}, err
+	}
+
+	prefixes, err := repo.linkPrefixes(link)
+	if err != nil {
+		return domain.WireguardInterface{}, err
+	}
+	addresses := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		addresses = append(addresses, prefix.String())
+	}
+
+	return domain.WireguardInterface{
+		ID:         device.Name,
+		Name:       device.Name,
+		Address:    domain.JoinAddresses(addresses),
+		ListenPort: uint32(device.ListenPort),
+		PublicKey:  device.PublicKey.String(),
+		MTU:        uint32(link.Attrs().MTU),
+	}, nil
+}
+
+func (repo *NetlinkWireguardRepository) interfacePrefixes(name string) ([]netip.Prefix, error) {
+	link, err := repo.link(name)
+	if err != nil {
+		return nil, err
+	}
+	return repo.linkPrefixes(link)
+}
+
+// linkPrefixes returns the first IPv4 and the first global IPv6 prefix of the link.
+func (repo *NetlinkWireguardRepository) linkPrefixes(link netlink.Link) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, 2)
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		addrs, err := repo.links.AddrList(link, family)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if addr.IPNet == nil {
+				continue
+			}
+			ip, ok := netip.AddrFromSlice(addr.IP)
+			if !ok || ip.Unmap().IsLinkLocalUnicast() {
+				continue
+			}
+			bits, _ := addr.Mask.Size()
+			prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), bits))
+			break
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, ErrInterfaceNotFound
+	}
+	return prefixes, nil
+}
+
+func devicePeerAddresses(device *wgtypes.Device) map[netip.Addr]struct{} {
+	used := make(map[netip.Addr]struct{})
+	for _, peer := range device.Peers {
+		for _, network := range peer.AllowedIPs {
+			ip, ok := netip.AddrFromSlice(network.IP)
+			if ok {
+				used[ip.Unmap()] = struct{}{}
+			}
+		}
+	}
+	return used
+}
+
+// renderDeviceConfig renders the device in the format of `wg showconf`.
+func renderDeviceConfig(device *wgtypes.Device) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("[Interface]\n")
+	fmt.Fprintf(&buffer, "ListenPort = %d\n", device.ListenPort)
+	fmt.Fprintf(&buffer, "PrivateKey = %s\n", device.PrivateKey.String())
+
+	for _, peer := range device.Peers {
+		buffer.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&buffer, "PublicKey = %s\n", peer.PublicKey.String())
+		if len(peer.AllowedIPs) > 0 {
+			allowedIPs := make([]string, 0, len(peer.AllowedIPs))
+			for _, network := range peer.AllowedIPs {
+				allowedIPs = append(allowedIPs, network.String())
+			}
+			fmt.Fprintf(&buffer, "AllowedIPs = %s\n", strings.Join(allowedIPs, ", "))
+		}
+		if peer.Endpoint != nil {
+			fmt.Fprintf(&buffer, "Endpoint = %s\n", peer.Endpoint.String())
+		}
+		if peer.PersistentKeepaliveInterval > 0 {
+			fmt.Fprintf(&buffer, "PersistentKeepalive = %d\n", int(peer.PersistentKeepaliveInterval.Seconds()))
+		}
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,371 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// fakeNetlink is a kernel with wireguard links, serving both LinkHandle and WireguardClient.
+type fakeNetlink struct {
+	links   map[string]*netlink.Wireguard
+	addrs   map[string][]netlink.Addr
+	devices map[string]*wgtypes.Device
+}
+
+func newFakeNetlink() *fakeNetlink {
+	return &fakeNetlink{
+		links:   make(map[string]*netlink.Wireguard),
+		addrs:   make(map[string][]netlink.Addr),
+		devices: make(map[string]*wgtypes.Device),
+	}
+}
+
+func (kernel *fakeNetlink) LinkByName(name string) (netlink.Link, error) {
+	link, ok := kernel.links[name]
+	if !ok {
+		return nil, netlink.LinkNotFoundError{}
+	}
+	return link, nil
+}
+
+func (kernel *fakeNetlink) LinkAdd(link netlink.Link) error {
+	wireguard, ok := link.(*netlink.Wireguard)
+	if !ok {
+		return errors.New("only wireguard links are supported")
+	}
+	name := wireguard.Attrs().Name
+	if _, ok := kernel.links[name]; ok {
+		return os.ErrExist
+	}
+	kernel.links[name] = wireguard
+	kernel.devices[name] = &wgtypes.Device{Name: name, Type: wgtypes.LinuxKernel}
+	return nil
+}
+
+func (kernel *fakeNetlink) LinkDel(link netlink.Link) error {
+	name := link.Attrs().Name
+	delete(kernel.links, name)
+	delete(kernel.addrs, name)
+	delete(kernel.devices, name)
+	return nil
+}
+
+func (kernel *fakeNetlink) LinkSetUp(link netlink.Link) error {
+	link.Attrs().Flags |= net.FlagUp
+	return nil
+}
+
+func (kernel *fakeNetlink) LinkSetMTU(link netlink.Link, mtu int) error {
+	link.Attrs().MTU = mtu
+	return nil
+}
+
+func (kernel *fakeNetlink) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
+	var addrs []netlink.Addr
+	for _, addr := range kernel.addrs[link.Attrs().Name] {
+		if (addr.IP.To4() != nil) == (family == netlink.FAMILY_V4) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+func (kernel *fakeNetlink) AddrAdd(link netlink.Link, addr *netlink.Addr) error {
+	name := link.Attrs().Name
+	for _, existing := range kernel.addrs[name] {
+		if existing.Equal(*addr) {
+			return os.ErrExist
+		}
+	}
+	kernel.addrs[name] = append(kernel.addrs[name], *addr)
+	return nil
+}
+
+func (kernel *fakeNetlink) AddrReplace(link netlink.Link, addr *netlink.Addr) error {
+	name := link.Attrs().Name
+	kernel.addrs[name] = slices.DeleteFunc(kernel.addrs[name], func(existing netlink.Addr) bool {
+		return existing.Equal(*addr)
+	})
+	kernel.addrs[name] = append(kernel.addrs[name], *addr)
+	return nil
+}
+
+func (kernel *fakeNetlink) Devices() ([]*wgtypes.Device, error) {
+	devices := make([]*wgtypes.Device, 0, len(kernel.devices))
+	for _, device := range kernel.devices {
+		devices = append(devices, device)
+	}
+	slices.SortFunc(devices, func(left, right *wgtypes.Device) int { return strings.Compare(left.Name, right.Name) })
+	return devices, nil
+}
+
+func (kernel *fakeNetlink) Device(name string) (*wgtypes.Device, error) {
+	device, ok := kernel.devices[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return device, nil
+}
+
+func (kernel *fakeNetlink) ConfigureDevice(name string, config wgtypes.Config) error {
+	device, ok := kernel.devices[name]
+	if !ok {
+		return os.ErrNotExist
+	}
+	if config.PrivateKey != nil {
+		device.PrivateKey = *config.PrivateKey
+		device.PublicKey = config.PrivateKey.PublicKey()
+	}
+	if config.ListenPort != nil {
+		device.ListenPort = *config.ListenPort
+	}
+	for _, peerConfig := range config.Peers {
+		index := slices.IndexFunc(device.Peers, func(peer wgtypes.Peer) bool { return peer.PublicKey == peerConfig.PublicKey })
+		switch {
+		case peerConfig.Remove:
+			if index >= 0 {
+				device.Peers = slices.Delete(device.Peers, index, index+1)
+			}
+		case index < 0:
+			device.Peers = append(device.Peers, wgtypes.Peer{PublicKey: peerConfig.PublicKey, AllowedIPs: peerConfig.AllowedIPs})
+		case peerConfig.ReplaceAllowedIPs:
+			device.Peers[index].AllowedIPs = peerConfig.AllowedIPs
+		default:
+			device.Peers[index].AllowedIPs = append(device.Peers[index].AllowedIPs, peerConfig.AllowedIPs...)
+		}
+	}
+	return nil
+}
+
+// fakeFirewall records the interface and peer rules it was asked to apply.
+type fakeFirewall struct {
+	FirewallDriver
+	interfaces map[string]string
+	peers      map[string]string
+}
+
+func newFakeFirewall() *fakeFirewall {
+	return &fakeFirewall{interfaces: make(map[string]string), peers: make(map[string]string)}
+}
+
+func (firewall *fakeFirewall) SyncInterfaceRules(ctx context.Context, interfaceID string, isolationMode string) error {
+	firewall.interfaces[interfaceID] = isolationMode
+	return nil
+}
+
+func (firewall *fakeFirewall) RemoveInterfaceRules(ctx context.Context, interfaceID string) error {
+	delete(firewall.interfaces, interfaceID)
+	return nil
+}
+
+func (firewall *fakeFirewall) SyncPeerRules(ctx context.Context, interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) error {
+	firewall.peers[peerAllowedIP] = interfaceID
+	return nil
+}
+
+func (firewall *fakeFirewall) RemovePeerRules(ctx context.Context, peerAllowedIP string) error {
+	delete(firewall.peers, peerAllowedIP)
+	return nil
+}
+
+func newFakeNetlinkRepository(t *testing.T) (*NetlinkWireguardRepository, *fakeNetlink, *fakeFirewall) {
+	t.Helper()
+	kernel := newFakeNetlink()
+	firewall := newFakeFirewall()
+	repo := NewNetlinkWireguardRepositoryWith(kernel, kernel, firewall)
+
+	_, err := repo.CreateInterface(context.Background(), domain.InterfaceConfig{
+		ID:            "wg0",
+		Address:       "10.0.0.1/24, fd00::1/64",
+		ListenPort:    51820,
+		MTU:           1420,
+		IsolationMode: domain.InterfaceIsolationRoutesOnly,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, kernel, firewall
+}
+
+func TestNetlinkRepositoryCreateInterface(t *testing.T) {
+	repo, kernel, firewall := newFakeNetlinkRepository(t)
+
+	iface, err := repo.GetInterface(context.Background(), "wg0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := kernel.devices["wg0"]
+	want := domain.WireguardInterface{
+		ID:         "wg0",
+		Name:       "wg0",
+		Address:    "10.0.0.1/24, fd00::1/64",
+		ListenPort: 51820,
+		PublicKey:  device.PublicKey.String(),
+		MTU:        1420,
+	}
+	if iface != want {
+		t.Errorf("interface = %+v, want %+v", iface, want)
+	}
+	if device.PrivateKey == (wgtypes.Key{}) {
+		t.Error("no private key was generated")
+	}
+	if kernel.links["wg0"].Flags&net.FlagUp == 0 {
+		t.Error("link is not up")
+	}
+	if mode := firewall.interfaces["wg0"]; mode != domain.InterfaceIsolationRoutesOnly {
+		t.Errorf("isolation mode = %q", mode)
+	}
+
+	if _, err := repo.CreateInterface(context.Background(), domain.InterfaceConfig{ID: "wg0", Address: "10.0.0.1/24"}); err == nil {
+		t.Error("creating an existing interface succeeded")
+	}
+}
+
+func TestNetlinkRepositoryUpdateInterface(t *testing.T) {
+	repo, kernel, firewall := newFakeNetlinkRepository(t)
+	privateKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iface, err := repo.UpdateInterface(context.Background(), domain.InterfaceConfig{
+		ID:            "wg0",
+		Address:       "10.0.0.1/24",
+		ListenPort:    51821,
+		MTU:           1380,
+		PrivateKey:    privateKey.String(),
+		IsolationMode: domain.InterfaceIsolationOpen,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if iface.ListenPort != 51821 || iface.MTU != 1380 || iface.PublicKey != privateKey.PublicKey().String() {
+		t.Errorf("interface = %+v", iface)
+	}
+	if got := len(kernel.addrs["wg0"]); got != 2 {
+		t.Errorf("wg0 has %d addresses, want the replaced IPv4 and the kept IPv6 one", got)
+	}
+	if mode := firewall.interfaces["wg0"]; mode != domain.InterfaceIsolationOpen {
+		t.Errorf("isolation mode = %q", mode)
+	}
+
+	_, err = repo.UpdateInterface(context.Background(), domain.InterfaceConfig{ID: "wg9", Address: "10.9.0.1/24"})
+	if !errors.Is(err, ErrInterfaceNotFound) {
+		t.Errorf("updating a missing interface: err = %v, want ErrInterfaceNotFound", err)
+	}
+}
+
+func TestNetlinkRepositoryDeleteInterface(t *testing.T) {
+	repo, kernel, firewall := newFakeNetlinkRepository(t)
+
+	if err := repo.DeleteInterface(context.Background(), "wg0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kernel.links["wg0"]; ok {
+		t.Error("link wg0 still exists")
+	}
+	if _, ok := firewall.interfaces["wg0"]; ok {
+		t.Error("firewall rules of wg0 were not removed")
+	}
+	if err := repo.DeleteInterface(context.Background(), "wg0"); !errors.Is(err, ErrInterfaceNotFound) {
+		t.Errorf("deleting it again: err = %v, want ErrInterfaceNotFound", err)
+	}
+}
+
+func TestNetlinkRepositoryCreateAndDeletePeer(t *testing.T) {
+	repo, kernel, _ := newFakeNetlinkRepository(t)
+	ctx := context.Background()
+
+	generated, err := repo.CreatePeer(ctx, domain.PeerSpec{
+		InterfaceID: "wg0",
+		Endpoint:    "vpn.example.com:51820",
+		AllowedIPs:  []string{"10.10.0.0/16"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated.AllowedIP != "10.0.0.2/32, fd00::2/128" {
+		t.Errorf("allocated %q", generated.AllowedIP)
+	}
+	if !strings.Contains(generated.Config, "PrivateKey = ") || strings.Contains(generated.Config, peerPrivateKeyPlaceholder) {
+		t.Errorf("config of a server generated peer has no private key:\n%s", generated.Config)
+	}
+
+	clientKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	supplied, err := repo.CreatePeer(ctx, domain.PeerSpec{
+		InterfaceID: "wg0",
+		Endpoint:    "vpn.example.com:51820",
+		PublicKey:   clientKey.PublicKey().String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if supplied.ID != clientKey.PublicKey().String() || supplied.AllowedIP != "10.0.0.3/32, fd00::3/128" {
+		t.Errorf("peer = %+v", supplied)
+	}
+
+	peers := kernel.devices["wg0"].Peers
+	if len(peers) != 2 {
+		t.Fatalf("device has %d peers, want 2", len(peers))
+	}
+	if got := len(peers[0].AllowedIPs); got != 3 {
+		t.Errorf("first peer has %d allowed IPs, want its two addresses and the route", got)
+	}
+
+	if err := repo.DeletePeer(ctx, generated.ID); err != nil {
+		t.Fatal(err)
+	}
+	if peers := kernel.devices["wg0"].Peers; len(peers) != 1 || peers[0].PublicKey.String() != supplied.ID {
+		t.Errorf("peers after removal = %v", peers)
+	}
+	if err := repo.DeletePeer(ctx, generated.ID); !errors.Is(err, ErrPeerNotFound) {
+		t.Errorf("removing it again: err = %v, want ErrPeerNotFound", err)
+	}
+
+	_, err = repo.CreatePeer(ctx, domain.PeerSpec{InterfaceID: "wg9", Endpoint: "vpn.example.com:51820"})
+	if !errors.Is(err, ErrInterfaceNotFound) {
+		t.Errorf("peer on a missing interface: err = %v, want ErrInterfaceNotFound", err)
+	}
+}
+
+func TestNewWireguardRepositoryFromEnv(t *testing.T) {
+	firewall := newFakeFirewall()
+
+	for _, backend := range []string{"", "command", " command "} {
+		t.Setenv("WILLIAM_WG_BACKEND", backend)
+		repo, err := NewWireguardRepositoryFromEnv(firewall)
+		if err != nil {
+			t.Fatalf("backend %q: %v", backend, err)
+		}
+		if _, ok := repo.(*CommandWireguardRepository); !ok {
+			t.Errorf("backend %q = %T, want *CommandWireguardRepository", backend, repo)
+		}
+	}
+
+	t.Setenv("WILLIAM_WG_BACKEND", "userspace")
+	if _, err := NewWireguardRepositoryFromEnv(firewall); err == nil {
+		t.Error("unknown backend was accepted")
+	}
+
+	t.Setenv("WILLIAM_WG_BACKEND", "netlink")
+	repo, err := NewWireguardRepositoryFromEnv(firewall)
+	if err != nil {
+		t.Skipf("netlink sockets are unavailable: %v", err)
+	}
+	if _, ok := repo.(*NetlinkWireguardRepository); !ok {
+		t.Errorf("backend netlink = %T, want *NetlinkWireguardRepository", repo)
+	}
+}
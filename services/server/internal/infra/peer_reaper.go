@@ -0,0 +1,27 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultPeerReaperInterval = time.Minute
+
+// LoadPeerReaperInterval reads WILLIAM_PEER_REAPER_INTERVAL, how often admin-server removes expired peers.
+// It defaults to one minute; "0" disables the reaper.
+func LoadPeerReaperInterval() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("WILLIAM_PEER_REAPER_INTERVAL"))
+	if value == "" {
+		return defaultPeerReaperInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse WILLIAM_PEER_REAPER_INTERVAL: %w", err)
+	}
+	if interval < 0 {
+		return 0, fmt.Errorf("WILLIAM_PEER_REAPER_INTERVAL must not be negative")
+	}
+	return interval, nil
+}
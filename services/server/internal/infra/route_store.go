@@ -24,11 +24,11 @@ func NewSQLPeerRouteStore(db *sql.DB) *SQLPeerRouteStore {
 }
 
 func (store *SQLInterfaceRouteStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.InterfaceRoute, error) {
-	rows, err := store.db.QueryContext(ctx, `
-		SELECT interface_id, cidr, created_at
+	rows, err := conn(ctx, store.db).QueryContext(ctx, `
+		SELECT interface_id, cidr, protocol, ports, created_at
 		FROM interface_allowed_routes
 		WHERE interface_id = $1
-		ORDER BY cidr
+		ORDER BY cidr, protocol, ports
 	`, interfaceID)
 	if err != nil {
 		return nil, err
@@ -38,7 +38,7 @@ func (store *SQLInterfaceRouteStore) ListByInterface(ctx context.Context, interf
 	var routes []domain.InterfaceRoute
 	for rows.Next() {
 		var route domain.InterfaceRoute
-		if err := rows.Scan(&route.InterfaceID, &route.CIDR, &route.CreatedAt); err != nil {
+		if err := rows.Scan(&route.InterfaceID, &route.CIDR, &route.Protocol, &route.Ports, &route.CreatedAt); err != nil {
 			return nil, err
 		}
 		routes = append(routes, route)
@@ -49,25 +49,25 @@ func (store *SQLInterfaceRouteStore) ListByInterface(ctx context.Context, interf
 	return routes, nil
 }
 
-func (store *SQLInterfaceRouteStore) Create(ctx context.Context, interfaceID string, cidr string) error {
-	_, err := store.db.ExecContext(ctx, `
-		INSERT INTO interface_allowed_routes (interface_id, cidr)
-		VALUES ($1, $2)
-		ON CONFLICT (interface_id, cidr) DO NOTHING
-	`, interfaceID, cidr)
+func (store *SQLInterfaceRouteStore) Create(ctx context.Context, interfaceID string, rule domain.AccessRule) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		INSERT INTO interface_allowed_routes (interface_id, cidr, protocol, ports)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (interface_id, cidr, protocol, ports) DO NOTHING
+	`, interfaceID, rule.CIDR, rule.Protocol, rule.Ports)
 	return err
 }
 
-func (store *SQLInterfaceRouteStore) Delete(ctx context.Context, interfaceID string, cidr string) error {
-	_, err := store.db.ExecContext(ctx, `
+func (store *SQLInterfaceRouteStore) Delete(ctx context.Context, interfaceID string, rule domain.AccessRule) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
 		DELETE FROM interface_allowed_routes
-		WHERE interface_id = $1 AND cidr = $2
-	`, interfaceID, cidr)
+		WHERE interface_id = $1 AND cidr = $2 AND protocol = $3 AND ports = $4
+	`, interfaceID, rule.CIDR, rule.Protocol, rule.Ports)
 	return err
 }
 
 func (store *SQLInterfaceRouteStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
-	_, err := store.db.ExecContext(ctx, `
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
 		DELETE FROM interface_allowed_routes
 		WHERE interface_id = $1
 	`, interfaceID)
@@ -75,11 +75,11 @@ func (store *SQLInterfaceRouteStore) DeleteByInterface(ctx context.Context, inte
 }
 
 func (store *SQLPeerRouteStore) ListByPeer(ctx context.Context, peerID string) ([]domain.PeerRoute, error) {
-	rows, err := store.db.QueryContext(ctx, `
-		SELECT peer_id, cidr, created_at
+	rows, err := conn(ctx, store.db).QueryContext(ctx, `
+		SELECT peer_id, cidr, protocol, ports, created_at
 		FROM peer_allowed_routes
 		WHERE peer_id = $1
-		ORDER BY cidr
+		ORDER BY cidr, protocol, ports
 	`, peerID)
 	if err != nil {
 		return nil, err
@@ -89,7 +89,7 @@ func (store *SQLPeerRouteStore) ListByPeer(ctx context.Context, peerID string) (
 	var routes []domain.PeerRoute
 	for rows.Next() {
 		var route domain.PeerRoute
-		if err := rows.Scan(&route.PeerID, &route.CIDR, &route.CreatedAt); err != nil {
+		if err := rows.Scan(&route.PeerID, &route.CIDR, &route.Protocol, &route.Ports, &route.CreatedAt); err != nil {
 			return nil, err
 		}
 		routes = append(routes, route)
@@ -100,25 +100,25 @@ func (store *SQLPeerRouteStore) ListByPeer(ctx context.Context, peerID string) (
 	return routes, nil
 }
 
-func (store *SQLPeerRouteStore) Create(ctx context.Context, peerID string, cidr string) error {
-	_, err := store.db.ExecContext(ctx, `
-		INSERT INTO peer_allowed_routes (peer_id, cidr)
-		VALUES ($1, $2)
-		ON CONFLICT (peer_id, cidr) DO NOTHING
-	`, peerID, cidr)
+func (store *SQLPeerRouteStore) Create(ctx context.Context, peerID string, rule domain.AccessRule) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
+		INSERT INTO peer_allowed_routes (peer_id, cidr, protocol, ports)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (peer_id, cidr, protocol, ports) DO NOTHING
+	`, peerID, rule.CIDR, rule.Protocol, rule.Ports)
 	return err
 }
 
-func (store *SQLPeerRouteStore) Delete(ctx context.Context, peerID string, cidr string) error {
-	_, err := store.db.ExecContext(ctx, `
+func (store *SQLPeerRouteStore) Delete(ctx context.Context, peerID string, rule domain.AccessRule) error {
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
 		DELETE FROM peer_allowed_routes
-		WHERE peer_id = $1 AND cidr = $2
-	`, peerID, cidr)
+		WHERE peer_id = $1 AND cidr = $2 AND protocol = $3 AND ports = $4
+	`, peerID, rule.CIDR, rule.Protocol, rule.Ports)
 	return err
 }
 
 func (store *SQLPeerRouteStore) DeleteByPeer(ctx context.Context, peerID string) error {
-	_, err := store.db.ExecContext(ctx, `
+	_, err := conn(ctx, store.db).ExecContext(ctx, `
 		DELETE FROM peer_allowed_routes
 		WHERE peer_id = $1
 	`, peerID)
@@ -0,0 +1,262 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// SQLScimStore persists users and groups provisioned over SCIM.
+type SQLScimStore struct {
+	db *sql.DB
+}
+
+func NewSQLScimStore(db *sql.DB) *SQLScimStore {
+	return &SQLScimStore{db: db}
+}
+
+// ListUsers returns every user, or only the user whose user name matches userName case-insensitively.
+func (store *SQLScimStore) ListUsers(ctx context.Context, userName string) ([]domain.ScimUser, error) {
+	rows, err := store.db.QueryContext(ctx, `
+		SELECT id, user_name, external_id, display_name, active, created_at, updated_at
+		FROM scim_users
+		WHERE $1 = '' OR lower(user_name) = lower($1)
+		ORDER BY created_at, id
+	`, userName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.ScimUser
+	for rows.Next() {
+		var user domain.ScimUser
+		if err := rows.Scan(&user.ID, &user.UserName, &user.ExternalID, &user.DisplayName, &user.Active, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (store *SQLScimStore) GetUser(ctx context.Context, id string) (domain.ScimUser, error) {
+	var user domain.ScimUser
+	err := store.db.QueryRowContext(ctx, `
+		SELECT id, user_name, external_id, display_name, active, created_at, updated_at
+		FROM scim_users
+		WHERE id = $1
+	`, id).Scan(&user.ID, &user.UserName, &user.ExternalID, &user.DisplayName, &user.Active, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return domain.ScimUser{}, err
+	}
+	return user, nil
+}
+
+func (store *SQLScimStore) CreateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	err := store.db.QueryRowContext(ctx, `
+		INSERT INTO scim_users (user_name, external_id, display_name, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, user.UserName, user.ExternalID, user.DisplayName, user.Active).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return domain.ScimUser{}, scimStoreError(err)
+	}
+	return user, nil
+}
+
+func (store *SQLScimStore) UpdateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	err := store.db.QueryRowContext(ctx, `
+		UPDATE scim_users
+		SET user_name = $2, external_id = $3, display_name = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, user.ID, user.UserName, user.ExternalID, user.DisplayName, user.Active).Scan(&user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return domain.ScimUser{}, scimStoreError(err)
+	}
+	return user, nil
+}
+
+func (store *SQLScimStore) DeleteUser(ctx context.Context, id string) error {
+	result, err := store.db.ExecContext(ctx, `
+		DELETE FROM scim_users
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffectedRow(result)
+}
+
+// ListGroups returns every group, or only the group named displayName, with their members.
+func (store *SQLScimStore) ListGroups(ctx context.Context, displayName string) ([]domain.ScimGroup, error) {
+	rows, err := store.db.QueryContext(ctx, `
+		SELECT id, display_name, external_id, created_at, updated_at
+		FROM scim_groups
+		WHERE $1 = '' OR display_name = $1
+		ORDER BY created_at, id
+	`, displayName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []domain.ScimGroup
+	for rows.Next() {
+		var group domain.ScimGroup
+		if err := rows.Scan(&group.ID, &group.DisplayName, &group.ExternalID, &group.CreatedAt, &group.UpdatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for index := range groups {
+		memberIDs, err := queryStrings(ctx, store.db, `
+			SELECT user_id
+			FROM scim_group_members
+			WHERE group_id = $1
+			ORDER BY user_id
+		`, groups[index].ID)
+		if err != nil {
+			return nil, err
+		}
+		groups[index].MemberIDs = memberIDs
+	}
+	return groups, nil
+}
+
+func (store *SQLScimStore) GetGroup(ctx context.Context, id string) (domain.ScimGroup, error) {
+	var group domain.ScimGroup
+	err := store.db.QueryRowContext(ctx, `
+		SELECT id, display_name, external_id, created_at, updated_at
+		FROM scim_groups
+		WHERE id = $1
+	`, id).Scan(&group.ID, &group.DisplayName, &group.ExternalID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return domain.ScimGroup{}, err
+	}
+
+	memberIDs, err := queryStrings(ctx, store.db, `
+		SELECT user_id
+		FROM scim_group_members
+		WHERE group_id = $1
+		ORDER BY user_id
+	`, id)
+	if err != nil {
+		return domain.ScimGroup{}, err
+	}
+	group.MemberIDs = memberIDs
+	return group, nil
+}
+
+func (store *SQLScimStore) CreateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.ScimGroup{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO scim_groups (display_name, external_id)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, group.DisplayName, group.ExternalID).Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return domain.ScimGroup{}, scimStoreError(err)
+	}
+	if err := replaceScimGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+		return domain.ScimGroup{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.ScimGroup{}, err
+	}
+	return group, nil
+}
+
+func (store *SQLScimStore) UpdateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.ScimGroup{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE scim_groups
+		SET display_name = $2, external_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, group.ID, group.DisplayName, group.ExternalID).Scan(&group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return domain.ScimGroup{}, scimStoreError(err)
+	}
+	if err := replaceScimGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+		return domain.ScimGroup{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.ScimGroup{}, err
+	}
+	return group, nil
+}
+
+func (store *SQLScimStore) DeleteGroup(ctx context.Context, id string) error {
+	result, err := store.db.ExecContext(ctx, `
+		DELETE FROM scim_groups
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffectedRow(result)
+}
+
+// replaceScimGroupMembers sets the members of a group, ignoring IDs of users that do not exist.
+func replaceScimGroupMembers(ctx context.Context, tx *sql.Tx, groupID string, memberIDs []string) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM scim_group_members
+		WHERE group_id = $1
+	`, groupID); err != nil {
+		return err
+	}
+	if len(memberIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO scim_group_members (group_id, user_id)
+		SELECT $1, id
+		FROM scim_users
+		WHERE id = ANY($2)
+		ON CONFLICT (group_id, user_id) DO NOTHING
+	`, groupID, pq.Array(memberIDs))
+	return err
+}
+
+func requireAffectedRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// scimStoreError maps unique violations to domain.ErrScimConflict.
+func scimStoreError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrScimConflict
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package infra
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// sealedSecretPrefix marks values encrypted directly with a master key.
+	sealedSecretPrefix = "sealed:v1:"
+	// envelopeSecretPrefix marks values encrypted with a data key wrapped by a versioned master key.
+	envelopeSecretPrefix = "sealed:v2:"
+
+	defaultMasterKeyID = "default"
+)
+
+var ErrSecretBoxNotConfigured = errors.New("secret box is not configured")
+
+// SecretBox encrypts secrets before they are written to the database.
+// Every value gets its own data key which is wrapped by the active master key,
+// so master keys can be rotated by re-wrapping without touching other rows.
+type SecretBox struct {
+	masterKeys  map[string]cipher.AEAD
+	activeKeyID string
+}
+
+// NewSecretBox builds an AES-256-GCM secret box from a single 32 byte master key.
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	return NewSecretBoxWithKeys(map[string][]byte{defaultMasterKeyID: key}, defaultMasterKeyID)
+}
+
+// NewSecretBoxWithKeys builds a secret box from versioned 32 byte master keys.
+// New values are sealed with activeKeyID; the other keys are only used to open older values.
+func NewSecretBoxWithKeys(keys map[string][]byte, activeKeyID string) (*SecretBox, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active master key %q is not configured", activeKeyID)
+	}
+
+	masterKeys := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		aead, err := newSecretAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		masterKeys[id] = aead
+	}
+
+	return &SecretBox{masterKeys: masterKeys, activeKeyID: activeKeyID}, nil
+}
+
+// LoadSecretBox reads master keys from WILLIAM_MASTER_KEY or WILLIAM_MASTER_KEY_FILE.
+// The value is either a single base64 key or a comma/newline separated list of <id>:<base64 key> entries.
+// WILLIAM_MASTER_KEY_ID selects the key used for new values and defaults to the last entry.
+func LoadSecretBox() (*SecretBox, error) {
+	value := strings.TrimSpace(os.Getenv("WILLIAM_MASTER_KEY"))
+	if path := os.Getenv("WILLIAM_MASTER_KEY_FILE"); value == "" && path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read master key file: %w", err)
+		}
+		value = strings.TrimSpace(string(content))
+	}
+	if value == "" {
+		return nil, errors.New("WILLIAM_MASTER_KEY or WILLIAM_MASTER_KEY_FILE is required")
+	}
+
+	keys, lastKeyID, err := parseMasterKeys(value)
+	if err != nil {
+		return nil, err
+	}
+
+	activeKeyID := strings.TrimSpace(os.Getenv("WILLIAM_MASTER_KEY_ID"))
+	if activeKeyID == "" {
+		activeKeyID = lastKeyID
+	}
+
+	return NewSecretBoxWithKeys(keys, activeKeyID)
+}
+
+func parseMasterKeys(value string) (map[string][]byte, string, error) {
+	entries := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+
+	keys := make(map[string][]byte, len(entries))
+	lastKeyID := ""
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		// base64 never contains ':' so an entry without one is an unnamed key.
+		id, encoded, found := strings.Cut(entry, ":")
+		if !found {
+			id, encoded = defaultMasterKeyID, entry
+		}
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, "", errors.New("master key id must not be empty")
+		}
+		if _, exists := keys[id]; exists {
+			return nil, "", fmt.Errorf("master key %q is configured twice", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, "", fmt.Errorf("decode master key %q: %w", id, err)
+		}
+		keys[id] = key
+		lastKeyID = id
+	}
+
+	if len(keys) == 0 {
+		return nil, "", errors.New("no master key configured")
+	}
+	return keys, lastKeyID, nil
+}
+
+func newSecretAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts plaintext and returns a printable value suitable for TEXT columns.
+// The format is sealed:v2:<master key id>:<wrapped data key>:<ciphertext>.
+func (box *SecretBox) Seal(plaintext string) (string, error) {
+	if box == nil {
+		return "", ErrSecretBoxNotConfigured
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newSecretAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := sealWithAEAD(box.masterKeys[box.activeKeyID], dataKey, []byte(box.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := sealWithAEAD(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return envelopeSecretPrefix + box.activeKeyID + ":" +
+		base64.StdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value produced by Seal.
+func (box *SecretBox) Open(value string) (string, error) {
+	if box == nil {
+		return "", ErrSecretBoxNotConfigured
+	}
+
+	if strings.HasPrefix(value, sealedSecretPrefix) {
+		return box.openLegacy(strings.TrimPrefix(value, sealedSecretPrefix))
+	}
+	if !strings.HasPrefix(value, envelopeSecretPrefix) {
+		return "", errors.New("value is not sealed")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, envelopeSecretPrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("sealed value is malformed")
+	}
+	keyID := parts[0]
+	masterKey, ok := box.masterKeys[keyID]
+	if !ok {
+		return "", fmt.Errorf("master key %q is not configured", keyID)
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("decode wrapped data key: %w", err)
+	}
+	dataKey, err := openWithAEAD(masterKey, wrappedKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key: %w", err)
+	}
+	dataAEAD, err := newSecretAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("decode sealed value: %w", err)
+	}
+	plaintext, err := openWithAEAD(dataAEAD, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("open sealed value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsSealed reports whether value was produced by Seal, with any master key version.
+func (box *SecretBox) IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedSecretPrefix) || strings.HasPrefix(value, envelopeSecretPrefix)
+}
+
+// IsCurrent reports whether value is sealed with the active master key.
+func (box *SecretBox) IsCurrent(value string) bool {
+	if box == nil {
+		return false
+	}
+	return strings.HasPrefix(value, envelopeSecretPrefix+box.activeKeyID+":")
+}
+
+// openLegacy opens values sealed directly with a master key before envelope encryption was introduced.
+func (box *SecretBox) openLegacy(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode sealed value: %w", err)
+	}
+
+	for _, masterKey := range box.masterKeys {
+		plaintext, err := openWithAEAD(masterKey, sealed, nil)
+		if err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", errors.New("open sealed value: no configured master key matches")
+}
+
+func sealWithAEAD(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openWithAEAD(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("sealed value is too short")
+	}
+	return aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], additionalData)
+}
@@ -0,0 +1,139 @@
+package infra
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// newTestSecretBox builds a secret box whose master keys are derived from their IDs, so the same ID always
+// names the same key.
+func newTestSecretBox(t *testing.T, activeKeyID string, keyIDs ...string) *SecretBox {
+	t.Helper()
+	keys := make(map[string][]byte, len(keyIDs))
+	for _, id := range keyIDs {
+		key := sha256.Sum256([]byte(id))
+		keys[id] = key[:]
+	}
+	box, err := NewSecretBoxWithKeys(keys, activeKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestSecretBoxSealOpen(t *testing.T) {
+	box := newTestSecretBox(t, "2024", "2023", "2024")
+
+	sealed, err := box.Seal("PrivateKey = secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "sealed:v2:2024:") || !box.IsSealed(sealed) || !box.IsCurrent(sealed) {
+		t.Fatalf("sealed value %q is not sealed with the active key", sealed)
+	}
+	if strings.Contains(sealed, "secret") {
+		t.Fatalf("sealed value %q contains the plaintext", sealed)
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != "PrivateKey = secret" {
+		t.Errorf("opened %q", opened)
+	}
+
+	// Values sealed with an older key still open once the active key changes.
+	rotated := newTestSecretBox(t, "2025", "2023", "2024", "2025")
+	if rotated.IsCurrent(sealed) {
+		t.Error("a value sealed with 2024 is current under 2025")
+	}
+	if opened, err := rotated.Open(sealed); err != nil || opened != "PrivateKey = secret" {
+		t.Errorf("open after rotation = %q, %v", opened, err)
+	}
+}
+
+func TestSecretBoxOpenV1(t *testing.T) {
+	box := newTestSecretBox(t, "2024", "2023", "2024")
+
+	// v1 values are sealed directly with a master key, without a key ID.
+	sealed, err := sealWithAEAD(box.masterKeys["2023"], []byte("legacy"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := sealedSecretPrefix + base64.StdEncoding.EncodeToString(sealed)
+	if !box.IsSealed(value) || box.IsCurrent(value) {
+		t.Fatalf("v1 value: sealed = %v, current = %v", box.IsSealed(value), box.IsCurrent(value))
+	}
+
+	opened, err := box.Open(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != "legacy" {
+		t.Errorf("opened %q", opened)
+	}
+
+	if _, err := newTestSecretBox(t, "2024", "2024").Open(value); err == nil {
+		t.Error("opened a v1 value without its master key")
+	}
+}
+
+func TestSecretBoxOpenErrors(t *testing.T) {
+	box := newTestSecretBox(t, "2024", "2024")
+	sealed, err := box.Seal("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(sealed, ":")
+
+	tamper := func(encoded string) string {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw[len(raw)-1] ^= 0xff
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plaintext", value: "secret", want: "not sealed"},
+		{name: "malformed", value: "sealed:v2:2024:abc", want: "malformed"},
+		{name: "unknown key id", value: strings.Replace(sealed, ":2024:", ":2019:", 1), want: `master key "2019" is not configured`},
+		{name: "tampered data key", value: strings.Join([]string{parts[0], parts[1], parts[2], tamper(parts[3]), parts[4]}, ":"), want: "unwrap data key"},
+		{name: "tampered ciphertext", value: strings.Join([]string{parts[0], parts[1], parts[2], parts[3], tamper(parts[4])}, ":"), want: "open sealed value"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := box.Open(test.value)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMasterKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	keys, lastKeyID, err := parseMasterKeys(key)
+	if err != nil || len(keys) != 1 || lastKeyID != defaultMasterKeyID {
+		t.Errorf("single key: keys = %d, last = %q, err = %v", len(keys), lastKeyID, err)
+	}
+
+	keys, lastKeyID, err = parseMasterKeys("# rotated yearly\n2023:" + key + "\n2024:" + key)
+	if err != nil || len(keys) != 2 || lastKeyID != "2024" {
+		t.Errorf("versioned keys: keys = %d, last = %q, err = %v", len(keys), lastKeyID, err)
+	}
+
+	if _, _, err := parseMasterKeys("2024:" + key + ",2024:" + key); err == nil {
+		t.Error("a duplicate key id was accepted")
+	}
+}
@@ -0,0 +1,107 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nomuken/william/services/server/internal/db"
+)
+
+// SecretMigrationResult counts the rows rewritten by EncryptStoredSecrets.
+type SecretMigrationResult struct {
+	PeerConfigs   int
+	InterfaceKeys int
+}
+
+// EncryptStoredSecrets seals plaintext peer configs left over from before encryption was enabled.
+// With reencrypt set, values sealed with an older master key are also re-sealed with the active key.
+func EncryptStoredSecrets(ctx context.Context, database *sql.DB, secretBox *SecretBox, reencrypt bool) (SecretMigrationResult, error) {
+	if secretBox == nil {
+		return SecretMigrationResult{}, ErrSecretBoxNotConfigured
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return SecretMigrationResult{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := encryptStoredSecrets(ctx, db.New(tx), secretBox, reencrypt)
+	if err != nil {
+		return SecretMigrationResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SecretMigrationResult{}, err
+	}
+	return result, nil
+}
+
+// secretQueries are the queries EncryptStoredSecrets runs inside its transaction.
+type secretQueries interface {
+	ListPeers(ctx context.Context) ([]db.Peer, error)
+	UpdatePeerConfig(ctx context.Context, arg db.UpdatePeerConfigParams) error
+	ListInterfaces(ctx context.Context) ([]db.Interface, error)
+	UpdateInterfacePrivateKey(ctx context.Context, arg db.UpdateInterfacePrivateKeyParams) error
+}
+
+func encryptStoredSecrets(ctx context.Context, queries secretQueries, secretBox *SecretBox, reencrypt bool) (SecretMigrationResult, error) {
+	var result SecretMigrationResult
+
+	peers, err := queries.ListPeers(ctx)
+	if err != nil {
+		return SecretMigrationResult{}, err
+	}
+	for _, peer := range peers {
+		config, changed, err := resealSecret(secretBox, peer.Config, reencrypt)
+		if err != nil {
+			return SecretMigrationResult{}, fmt.Errorf("peer %s: %w", peer.PeerID, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := queries.UpdatePeerConfig(ctx, db.UpdatePeerConfigParams{Config: config, PeerID: peer.PeerID}); err != nil {
+			return SecretMigrationResult{}, err
+		}
+		result.PeerConfigs++
+	}
+
+	interfaces, err := queries.ListInterfaces(ctx)
+	if err != nil {
+		return SecretMigrationResult{}, err
+	}
+	for _, iface := range interfaces {
+		if iface.PrivateKey == "" {
+			continue
+		}
+		privateKey, changed, err := resealSecret(secretBox, iface.PrivateKey, reencrypt)
+		if err != nil {
+			return SecretMigrationResult{}, fmt.Errorf("interface %s: %w", iface.ID, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := queries.UpdateInterfacePrivateKey(ctx, db.UpdateInterfacePrivateKeyParams{PrivateKey: privateKey, ID: iface.ID}); err != nil {
+			return SecretMigrationResult{}, err
+		}
+		result.InterfaceKeys++
+	}
+	return result, nil
+}
+
+func resealSecret(secretBox *SecretBox, value string, reencrypt bool) (string, bool, error) {
+	if !secretBox.IsSealed(value) {
+		sealed, err := secretBox.Seal(value)
+		return sealed, err == nil, err
+	}
+	if !reencrypt || secretBox.IsCurrent(value) {
+		return value, false, nil
+	}
+
+	plaintext, err := secretBox.Open(value)
+	if err != nil {
+		return "", false, err
+	}
+	sealed, err := secretBox.Seal(plaintext)
+	return sealed, err == nil, err
+}
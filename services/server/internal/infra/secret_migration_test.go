@@ -0,0 +1,102 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/db"
+)
+
+// fakeSecretQueries holds peer and interface rows in memory.
+type fakeSecretQueries struct {
+	peers      []db.Peer
+	interfaces []db.Interface
+}
+
+func (queries *fakeSecretQueries) ListPeers(ctx context.Context) ([]db.Peer, error) {
+	return queries.peers, nil
+}
+
+func (queries *fakeSecretQueries) UpdatePeerConfig(ctx context.Context, arg db.UpdatePeerConfigParams) error {
+	for i := range queries.peers {
+		if queries.peers[i].PeerID == arg.PeerID {
+			queries.peers[i].Config = arg.Config
+		}
+	}
+	return nil
+}
+
+func (queries *fakeSecretQueries) ListInterfaces(ctx context.Context) ([]db.Interface, error) {
+	return queries.interfaces, nil
+}
+
+func (queries *fakeSecretQueries) UpdateInterfacePrivateKey(ctx context.Context, arg db.UpdateInterfacePrivateKeyParams) error {
+	for i := range queries.interfaces {
+		if queries.interfaces[i].ID == arg.ID {
+			queries.interfaces[i].PrivateKey = arg.PrivateKey
+		}
+	}
+	return nil
+}
+
+func TestEncryptStoredSecrets(t *testing.T) {
+	ctx := context.Background()
+	oldBox := newTestSecretBox(t, "2023", "2023")
+	oldConfig, err := oldBox.Seal("old config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	box := newTestSecretBox(t, "2024", "2023", "2024")
+	currentConfig, err := box.Seal("current config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := &fakeSecretQueries{
+		peers: []db.Peer{
+			{PeerID: "plaintext", Config: "plaintext config"},
+			{PeerID: "old", Config: oldConfig},
+			{PeerID: "current", Config: currentConfig},
+		},
+		interfaces: []db.Interface{
+			{ID: "wg0", PrivateKey: "plaintext key"},
+			{ID: "wg1"},
+		},
+	}
+
+	result, err := encryptStoredSecrets(ctx, queries, box, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PeerConfigs != 1 || result.InterfaceKeys != 1 {
+		t.Errorf("sealed %+v, want the plaintext config and key", result)
+	}
+	if queries.peers[1].Config != oldConfig || queries.peers[2].Config != currentConfig {
+		t.Error("sealed values were rewritten without reencrypt")
+	}
+	if queries.interfaces[1].PrivateKey != "" {
+		t.Error("an interface without a key got one")
+	}
+
+	result, err = encryptStoredSecrets(ctx, queries, box, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PeerConfigs != 1 || result.InterfaceKeys != 0 {
+		t.Errorf("re-sealed %+v, want the config sealed with 2023 only", result)
+	}
+
+	want := map[string]string{"plaintext": "plaintext config", "old": "old config", "current": "current config"}
+	for _, peer := range queries.peers {
+		if !box.IsCurrent(peer.Config) {
+			t.Errorf("peer %s config is not sealed with the active key", peer.PeerID)
+		}
+		if config, err := box.Open(peer.Config); err != nil || config != want[peer.PeerID] {
+			t.Errorf("peer %s config = %q, %v", peer.PeerID, config, err)
+		}
+	}
+	if key, err := box.Open(queries.interfaces[0].PrivateKey); err != nil || key != "plaintext key" {
+		t.Errorf("wg0 key = %q, %v", key, err)
+	}
+}
@@ -3,6 +3,9 @@ package infra
 import (
 	"context"
 	"database/sql"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/nomuken/william/services/server/internal/db"
 	"github.com/nomuken/william/services/server/internal/domain"
@@ -10,7 +13,8 @@ import (
 
 // SQLInterfaceStore persists wireguard interface metadata.
 type SQLInterfaceStore struct {
-	queries *db.Queries
+	queries   *db.Queries
+	secretBox *SecretBox
 }
 
 // SQLAllowedEmailStore tracks allowed emails per interface.
@@ -19,8 +23,9 @@ type SQLAllowedEmailStore struct {
 }
 
 // NewSQLInterfaceStore wires interface storage using sqlc queries.
-func NewSQLInterfaceStore(database *sql.DB) *SQLInterfaceStore {
-	return &SQLInterfaceStore{queries: db.New(database)}
+// The secret box encrypts interface private keys; it may be nil when private keys are never accessed.
+func NewSQLInterfaceStore(database *sql.DB, secretBox *SecretBox) *SQLInterfaceStore {
+	return &SQLInterfaceStore{queries: db.New(database), secretBox: secretBox}
 }
 
 // NewSQLAllowedEmailStore wires allowed email storage using sqlc queries.
@@ -29,23 +34,27 @@ func NewSQLAllowedEmailStore(database *sql.DB) *SQLAllowedEmailStore {
 }
 
 func (store *SQLInterfaceStore) Get(ctx context.Context, id string) (domain.InterfaceConfig, error) {
-	row, err := store.queries.GetInterface(ctx, id)
+	row, err := queriesFor(ctx, store.queries).GetInterface(ctx, id)
 	if err != nil {
 		return domain.InterfaceConfig{}, err
 	}
 
 	return domain.InterfaceConfig{
-		ID:         row.ID,
-		Name:       row.Name,
-		Address:    row.Address,
-		ListenPort: uint32(row.ListenPort),
-		MTU:        uint32(row.Mtu),
-		Endpoint:   row.Endpoint,
+		ID:                row.ID,
+		Name:              row.Name,
+		Address:           row.Address,
+		ListenPort:        uint32(row.ListenPort),
+		MTU:               uint32(row.Mtu),
+		Endpoint:          row.Endpoint,
+		PeerKeyPolicy:     row.PeerKeyPolicy,
+		PeerTTL:           time.Duration(row.PeerTtlSeconds) * time.Second,
+		MaxDevicesPerUser: uint32(row.MaxDevicesPerUser),
+		IsolationMode:     row.IsolationMode,
 	}, nil
 }
 
 func (store *SQLInterfaceStore) List(ctx context.Context) ([]domain.InterfaceConfig, error) {
-	rows, err := store.queries.ListInterfaces(ctx)
+	rows, err := queriesFor(ctx, store.queries).ListInterfaces(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,12 +62,16 @@ func (store *SQLInterfaceStore) List(ctx context.Context) ([]domain.InterfaceCon
 	items := make([]domain.InterfaceConfig, 0, len(rows))
 	for _, row := range rows {
 		items = append(items, domain.InterfaceConfig{
-			ID:         row.ID,
-			Name:       row.Name,
-			Address:    row.Address,
-			ListenPort: uint32(row.ListenPort),
-			MTU:        uint32(row.Mtu),
-			Endpoint:   row.Endpoint,
+			ID:                row.ID,
+			Name:              row.Name,
+			Address:           row.Address,
+			ListenPort:        uint32(row.ListenPort),
+			MTU:               uint32(row.Mtu),
+			Endpoint:          row.Endpoint,
+			PeerKeyPolicy:     row.PeerKeyPolicy,
+			PeerTTL:           time.Duration(row.PeerTtlSeconds) * time.Second,
+			MaxDevicesPerUser: uint32(row.MaxDevicesPerUser),
+			IsolationMode:     row.IsolationMode,
 		})
 	}
 
@@ -66,37 +79,81 @@ func (store *SQLInterfaceStore) List(ctx context.Context) ([]domain.InterfaceCon
 }
 
 func (store *SQLInterfaceStore) Create(ctx context.Context, config domain.InterfaceConfig) error {
+	sealedKey := ""
+	if config.PrivateKey != "" {
+		sealed, err := store.secretBox.Seal(config.PrivateKey)
+		if err != nil {
+			return err
+		}
+		sealedKey = sealed
+	}
+
 	params := db.CreateInterfaceParams{
-		ID:         config.ID,
-		Name:       config.Name,
-		Address:    config.Address,
-		ListenPort: int64(config.ListenPort),
-		Mtu:        int64(config.MTU),
-		Endpoint:   config.Endpoint,
+		ID:                config.ID,
+		Name:              config.Name,
+		Address:           config.Address,
+		ListenPort:        int64(config.ListenPort),
+		Mtu:               int64(config.MTU),
+		Endpoint:          config.Endpoint,
+		PrivateKey:        sealedKey,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTtlSeconds:    int64(config.PeerTTL / time.Second),
+		MaxDevicesPerUser: int64(config.MaxDevicesPerUser),
+		IsolationMode:     config.IsolationMode,
 	}
 
-	return store.queries.CreateInterface(ctx, params)
+	return queriesFor(ctx, store.queries).CreateInterface(ctx, params)
 }
 
 func (store *SQLInterfaceStore) Update(ctx context.Context, config domain.InterfaceConfig) error {
 	params := db.UpdateInterfaceParams{
-		Name:       config.Name,
-		Address:    config.Address,
-		ListenPort: int64(config.ListenPort),
-		Mtu:        int64(config.MTU),
-		Endpoint:   config.Endpoint,
-		ID:         config.ID,
+		Name:              config.Name,
+		Address:           config.Address,
+		ListenPort:        int64(config.ListenPort),
+		Mtu:               int64(config.MTU),
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTtlSeconds:    int64(config.PeerTTL / time.Second),
+		MaxDevicesPerUser: int64(config.MaxDevicesPerUser),
+		IsolationMode:     config.IsolationMode,
+		ID:                config.ID,
 	}
 
-	return store.queries.UpdateInterface(ctx, params)
+	return queriesFor(ctx, store.queries).UpdateInterface(ctx, params)
 }
 
 func (store *SQLInterfaceStore) Delete(ctx context.Context, id string) error {
-	return store.queries.DeleteInterface(ctx, id)
+	return queriesFor(ctx, store.queries).DeleteInterface(ctx, id)
+}
+
+// GetPrivateKey returns the decrypted private key, or an empty string when none has been stored yet.
+func (store *SQLInterfaceStore) GetPrivateKey(ctx context.Context, id string) (string, error) {
+	row, err := queriesFor(ctx, store.queries).GetInterface(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if row.PrivateKey == "" {
+		return "", nil
+	}
+
+	return store.secretBox.Open(row.PrivateKey)
+}
+
+func (store *SQLInterfaceStore) UpdatePrivateKey(ctx context.Context, id string, privateKey string) error {
+	sealedKey, err := store.secretBox.Seal(privateKey)
+	if err != nil {
+		return err
+	}
+
+	params := db.UpdateInterfacePrivateKeyParams{
+		PrivateKey: sealedKey,
+		ID:         id,
+	}
+	return queriesFor(ctx, store.queries).UpdateInterfacePrivateKey(ctx, params)
 }
 
 func (store *SQLAllowedEmailStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.AllowedEmail, error) {
-	emails, err := store.queries.ListAllowedEmails(ctx, interfaceID)
+	emails, err := queriesFor(ctx, store.queries).ListAllowedEmails(ctx, interfaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +163,7 @@ func (store *SQLAllowedEmailStore) ListByInterface(ctx context.Context, interfac
 		items = append(items, domain.AllowedEmail{
 			InterfaceID: email.InterfaceID,
 			Email:       email.Email,
+			RuleType:    email.RuleType,
 			CreatedAt:   email.CreatedAt,
 		})
 	}
@@ -113,38 +171,84 @@ func (store *SQLAllowedEmailStore) ListByInterface(ctx context.Context, interfac
 	return items, nil
 }
 
+// ListInterfaceIDsByEmail returns the interfaces whose exact, domain or regex rules allow email.
 func (store *SQLAllowedEmailStore) ListInterfaceIDsByEmail(ctx context.Context, email string) ([]string, error) {
-	return store.queries.ListAllowedInterfacesByEmail(ctx, email)
+	interfaceIDs, err := queriesFor(ctx, store.queries).ListAllowedInterfacesByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := queriesFor(ctx, store.queries).ListAllowedEmailPatterns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range patterns {
+		if !domain.AllowedEmailRuleMatches(pattern.RuleType, pattern.Email, email) || slices.Contains(interfaceIDs, pattern.InterfaceID) {
+			continue
+		}
+		interfaceIDs = append(interfaceIDs, pattern.InterfaceID)
+	}
+	sort.Strings(interfaceIDs)
+
+	return interfaceIDs, nil
 }
 
+// Exists reports whether any rule of the interface allows email.
 func (store *SQLAllowedEmailStore) Exists(ctx context.Context, interfaceID string, email string) (bool, error) {
-	count, err := store.queries.AllowedEmailExists(ctx, db.AllowedEmailExistsParams{
+	count, err := queriesFor(ctx, store.queries).AllowedEmailExists(ctx, db.AllowedEmailExistsParams{
 		InterfaceID: interfaceID,
 		Email:       email,
 	})
 	if err != nil {
 		return false, err
 	}
+	if count > 0 {
+		return true, nil
+	}
 
-	return count > 0, nil
+	patterns, err := queriesFor(ctx, store.queries).ListAllowedEmailPatternsByInterface(ctx, interfaceID)
+	if err != nil {
+		return false, err
+	}
+	for _, pattern := range patterns {
+		if domain.AllowedEmailRuleMatches(pattern.RuleType, pattern.Email, email) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (store *SQLAllowedEmailStore) ListKnownEmails(ctx context.Context) ([]string, error) {
+	return queriesFor(ctx, store.queries).ListKnownEmails(ctx)
 }
 
-func (store *SQLAllowedEmailStore) Create(ctx context.Context, interfaceID string, email string) error {
+func (store *SQLAllowedEmailStore) Create(ctx context.Context, interfaceID string, email string, ruleType string) error {
 	params := db.CreateAllowedEmailParams{
 		InterfaceID: interfaceID,
 		Email:       email,
+		RuleType:    ruleType,
 	}
-	return store.queries.CreateAllowedEmail(ctx, params)
+	return queriesFor(ctx, store.queries).CreateAllowedEmail(ctx, params)
 }
 
+// Delete deletes the rule of the interface with pattern email, whatever its type. It returns sql.ErrNoRows
+// when the interface has no such rule.
 func (store *SQLAllowedEmailStore) Delete(ctx context.Context, interfaceID string, email string) error {
 	params := db.DeleteAllowedEmailParams{
 		InterfaceID: interfaceID,
 		Email:       email,
 	}
-	return store.queries.DeleteAllowedEmail(ctx, params)
+	affected, err := queriesFor(ctx, store.queries).DeleteAllowedEmail(ctx, params)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
 func (store *SQLAllowedEmailStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
-	return store.queries.DeleteAllowedEmailsByInterface(ctx, interfaceID)
+	return queriesFor(ctx, store.queries).DeleteAllowedEmailsByInterface(ctx, interfaceID)
 }
@@ -3,6 +3,9 @@ package infra
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/nomuken/william/services/server/internal/db"
 	"github.com/nomuken/william/services/server/internal/domain"
@@ -10,69 +13,45 @@ import (
 
 // SQLPeerStore provides peer persistence backed by SQL.
 type SQLPeerStore struct {
-	queries *db.Queries
+	queries   *db.Queries
+	secretBox *SecretBox
 }
 
 // NewSQLPeerStore wires a peer store using sqlc queries.
-func NewSQLPeerStore(database *sql.DB) *SQLPeerStore {
-	return &SQLPeerStore{queries: db.New(database)}
+// Peer configs embed private keys, so they are sealed with the secret box before being written.
+func NewSQLPeerStore(database *sql.DB, secretBox *SecretBox) *SQLPeerStore {
+	return &SQLPeerStore{queries: db.New(database), secretBox: secretBox}
 }
 
 func (store *SQLPeerStore) GetByEmail(ctx context.Context, email string) (domain.PeerRecord, error) {
-	peer, err := store.queries.GetPeerByEmail(ctx, email)
+	peer, err := queriesFor(ctx, store.queries).GetPeerByEmail(ctx, email)
 	if err != nil {
 		return domain.PeerRecord{}, err
 	}
 
-	return domain.PeerRecord{
-		Email:       peer.Email,
-		PeerID:      peer.PeerID,
-		InterfaceID: peer.InterfaceID,
-		AllowedIP:   peer.AllowedIp,
-		Config:      peer.Config,
-		CreatedAt:   peer.CreatedAt,
-	}, nil
+	return store.toRecord(peer)
 }
 
 func (store *SQLPeerStore) ListByEmail(ctx context.Context, email string) ([]domain.PeerRecord, error) {
-	peers, err := store.queries.ListPeersByEmail(ctx, email)
+	peers, err := queriesFor(ctx, store.queries).ListPeersByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]domain.PeerRecord, 0, len(peers))
-	for _, peer := range peers {
-		items = append(items, domain.PeerRecord{
-			Email:       peer.Email,
-			PeerID:      peer.PeerID,
-			InterfaceID: peer.InterfaceID,
-			AllowedIP:   peer.AllowedIp,
-			Config:      peer.Config,
-			CreatedAt:   peer.CreatedAt,
-		})
-	}
-
-	return items, nil
+	return store.toRecords(peers)
 }
 
 func (store *SQLPeerStore) GetByPeerID(ctx context.Context, peerID string) (domain.PeerRecord, error) {
-	peer, err := store.queries.GetPeerByID(ctx, peerID)
+	peer, err := queriesFor(ctx, store.queries).GetPeerByID(ctx, peerID)
 	if err != nil {
 		return domain.PeerRecord{}, err
 	}
 
-	return domain.PeerRecord{
-		Email:       peer.Email,
-		PeerID:      peer.PeerID,
-		InterfaceID: peer.InterfaceID,
-		AllowedIP:   peer.AllowedIp,
-		Config:      peer.Config,
-		CreatedAt:   peer.CreatedAt,
-	}, nil
+	return store.toRecord(peer)
 }
 
 func (store *SQLPeerStore) GetByEmailAndInterface(ctx context.Context, email string, interfaceID string) (domain.PeerRecord, error) {
-	peer, err := store.queries.GetPeerByEmailAndInterface(ctx, db.GetPeerByEmailAndInterfaceParams{
+	peer, err := queriesFor(ctx, store.queries).GetPeerByEmailAndInterface(ctx, db.GetPeerByEmailAndInterfaceParams{
 		Email:       email,
 		InterfaceID: interfaceID,
 	})
@@ -80,87 +59,151 @@ func (store *SQLPeerStore) GetByEmailAndInterface(ctx context.Context, email str
 		return domain.PeerRecord{}, err
 	}
 
-	return domain.PeerRecord{
-		Email:       peer.Email,
-		PeerID:      peer.PeerID,
-		InterfaceID: peer.InterfaceID,
-		AllowedIP:   peer.AllowedIp,
-		Config:      peer.Config,
-		CreatedAt:   peer.CreatedAt,
-	}, nil
+	return store.toRecord(peer)
 }
 
 func (store *SQLPeerStore) List(ctx context.Context) ([]domain.PeerRecord, error) {
-	peers, err := store.queries.ListPeers(ctx)
+	peers, err := queriesFor(ctx, store.queries).ListPeers(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]domain.PeerRecord, 0, len(peers))
-	for _, peer := range peers {
-		items = append(items, domain.PeerRecord{
-			Email:       peer.Email,
-			PeerID:      peer.PeerID,
-			InterfaceID: peer.InterfaceID,
-			AllowedIP:   peer.AllowedIp,
-			Config:      peer.Config,
-			CreatedAt:   peer.CreatedAt,
-		})
-	}
-
-	return items, nil
+	return store.toRecords(peers)
 }
 
 func (store *SQLPeerStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.PeerRecord, error) {
-	peers, err := store.queries.ListPeersByInterface(ctx, interfaceID)
+	peers, err := queriesFor(ctx, store.queries).ListPeersByInterface(ctx, interfaceID)
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]domain.PeerRecord, 0, len(peers))
-	for _, peer := range peers {
-		items = append(items, domain.PeerRecord{
-			Email:       peer.Email,
-			PeerID:      peer.PeerID,
-			InterfaceID: peer.InterfaceID,
-			AllowedIP:   peer.AllowedIp,
-			Config:      peer.Config,
-			CreatedAt:   peer.CreatedAt,
-		})
-	}
-
-	return items, nil
+	return store.toRecords(peers)
 }
 
 func (store *SQLPeerStore) Create(ctx context.Context, record domain.PeerRecord) error {
+	sealedConfig, err := store.secretBox.Seal(record.Config)
+	if err != nil {
+		return err
+	}
+
 	params := db.CreatePeerParams{
 		Email:       record.Email,
 		PeerID:      record.PeerID,
 		InterfaceID: record.InterfaceID,
 		AllowedIp:   record.AllowedIP,
-		Config:      record.Config,
+		Config:      sealedConfig,
+		ExpiresAt:   toNullTime(record.ExpiresAt),
+		DeviceName:  record.DeviceName,
 	}
 
-	return store.queries.CreatePeer(ctx, params)
+	return queriesFor(ctx, store.queries).CreatePeer(ctx, params)
 }
 
 func (store *SQLPeerStore) UpdateConfig(ctx context.Context, peerID string, config string) error {
+	sealedConfig, err := store.secretBox.Seal(config)
+	if err != nil {
+		return err
+	}
+
 	params := db.UpdatePeerConfigParams{
-		Config: config,
+		Config: sealedConfig,
 		PeerID: peerID,
 	}
 
-	return store.queries.UpdatePeerConfig(ctx, params)
+	return queriesFor(ctx, store.queries).UpdatePeerConfig(ctx, params)
+}
+
+func (store *SQLPeerStore) UpdateExpiry(ctx context.Context, peerID string, expiresAt *time.Time) error {
+	return queriesFor(ctx, store.queries).UpdatePeerExpiry(ctx, db.UpdatePeerExpiryParams{
+		ExpiresAt: toNullTime(expiresAt),
+		PeerID:    peerID,
+	})
+}
+
+func (store *SQLPeerStore) UpdateSuspension(ctx context.Context, peerID string, suspendedAt *time.Time) error {
+	return queriesFor(ctx, store.queries).UpdatePeerSuspension(ctx, db.UpdatePeerSuspensionParams{
+		SuspendedAt: toNullTime(suspendedAt),
+		PeerID:      peerID,
+	})
+}
+
+func (store *SQLPeerStore) ListExpired(ctx context.Context, now time.Time) ([]domain.PeerRecord, error) {
+	peers, err := queriesFor(ctx, store.queries).ListExpiredPeers(ctx, sql.NullTime{Time: now, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return store.toRecords(peers)
 }
 
 func (store *SQLPeerStore) DeleteByPeerID(ctx context.Context, peerID string) error {
-	if _, err := store.queries.GetPeerByID(ctx, peerID); err != nil {
+	if _, err := queriesFor(ctx, store.queries).GetPeerByID(ctx, peerID); err != nil {
 		return err
 	}
 
-	return store.queries.DeletePeerByID(ctx, peerID)
+	return queriesFor(ctx, store.queries).DeletePeerByID(ctx, peerID)
 }
 
 func (store *SQLPeerStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
-	return store.queries.DeletePeersByInterface(ctx, interfaceID)
+	return queriesFor(ctx, store.queries).DeletePeersByInterface(ctx, interfaceID)
+}
+
+func (store *SQLPeerStore) toRecords(peers []db.Peer) ([]domain.PeerRecord, error) {
+	items := make([]domain.PeerRecord, 0, len(peers))
+	for _, peer := range peers {
+		item, err := store.toRecord(peer)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (store *SQLPeerStore) toRecord(peer db.Peer) (domain.PeerRecord, error) {
+	config, err := store.openConfig(peer.PeerID, peer.Config)
+	if err != nil {
+		return domain.PeerRecord{}, fmt.Errorf("peer %s: %w", peer.PeerID, err)
+	}
+
+	return domain.PeerRecord{
+		Email:       peer.Email,
+		PeerID:      peer.PeerID,
+		InterfaceID: peer.InterfaceID,
+		AllowedIP:   peer.AllowedIp,
+		Config:      config,
+		CreatedAt:   peer.CreatedAt,
+		ExpiresAt:   fromNullTime(peer.ExpiresAt),
+		SuspendedAt: fromNullTime(peer.SuspendedAt),
+		DeviceName:  peer.DeviceName,
+	}, nil
+}
+
+// openConfig decrypts a stored config.
+// Plaintext rows written before encryption was enabled are returned as is until EncryptStoredSecrets seals them.
+// admin-server runs it at startup, so a plaintext row is logged: it is either not migrated yet or was not written by william.
+func (store *SQLPeerStore) openConfig(peerID string, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	if !store.secretBox.IsSealed(value) {
+		log.Printf("peer %s: config is stored in plaintext; it is sealed the next time admin-server starts", peerID)
+		return value, nil
+	}
+	return store.secretBox.Open(value)
+}
+
+func toNullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func fromNullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
 }
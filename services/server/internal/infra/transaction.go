@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nomuken/william/services/server/internal/db"
+)
+
+type sqlTxKey struct{}
+
+// SQLTransactor groups store calls in one database transaction. The transaction travels in the context,
+// so every SQL store called with that context reads and writes through it.
+type SQLTransactor struct {
+	db *sql.DB
+}
+
+func NewSQLTransactor(db *sql.DB) *SQLTransactor {
+	return &SQLTransactor{db: db}
+}
+
+// WithinTransaction runs fn in a transaction that commits when fn returns nil and rolls back otherwise.
+// When ctx already carries a transaction, fn joins it and the outermost call decides the outcome.
+func (transactor *SQLTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTransaction(ctx, transactor.db, fn)
+}
+
+func withinTransaction(ctx context.Context, database *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, sqlTxKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns the transaction carried by ctx, or database outside a transaction.
+func conn(ctx context.Context, database *sql.DB) db.DBTX {
+	if tx, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return database
+}
+
+// queriesFor returns queries bound to the transaction carried by ctx, or queries itself outside a transaction.
+func queriesFor(ctx context.Context, queries *db.Queries) *db.Queries {
+	if tx, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return queries.WithTx(tx)
+	}
+	return queries
+}
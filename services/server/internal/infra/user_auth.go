@@ -0,0 +1,131 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const (
+	defaultUserJWTHeader      = "X-Pomerium-Jwt-Assertion"
+	defaultUserJWTEmailClaim  = "email"
+	defaultUserJWTGroupsClaim = "groups"
+	insecureUserEmailHeader   = "X-Email"
+	insecureUserGroupsHeader  = "X-Groups"
+)
+
+// UserAuthenticator identifies william-server users from a signed identity assertion,
+// such as Pomerium's X-Pomerium-Jwt-Assertion or an OIDC ID token sent as a Bearer token.
+type UserAuthenticator struct {
+	verifier    *JWTVerifier
+	header      string
+	emailClaim  string
+	groupsClaim string
+	insecure    bool
+}
+
+// LoadUserAuthenticator configures user authentication from the environment:
+//
+//	WILLIAM_AUTH_JWKS_URL or WILLIAM_AUTH_JWKS_FILE   key set used to verify assertions
+//	WILLIAM_AUTH_JWT_ISSUER                            expected iss
+//	WILLIAM_AUTH_JWT_AUDIENCE                          expected aud
+//	WILLIAM_AUTH_JWT_HEADER                            header carrying the assertion, "Authorization" expects a Bearer token
+//	WILLIAM_AUTH_EMAIL_CLAIM                           claim holding the email, defaults to email
+//	WILLIAM_AUTH_GROUPS_CLAIM                          claim holding the group names, defaults to groups
+//	WILLIAM_INSECURE_EMAIL_HEADER=1                    trust the raw X-Email and X-Groups headers instead; for local development only
+func LoadUserAuthenticator() (*UserAuthenticator, error) {
+	if os.Getenv("WILLIAM_INSECURE_EMAIL_HEADER") == "1" {
+		log.Printf("WILLIAM_INSECURE_EMAIL_HEADER is set; the %s header is trusted without verification", insecureUserEmailHeader)
+		return &UserAuthenticator{header: insecureUserEmailHeader, insecure: true}, nil
+	}
+
+	issuer := strings.TrimSpace(os.Getenv("WILLIAM_AUTH_JWT_ISSUER"))
+	audience := strings.TrimSpace(os.Getenv("WILLIAM_AUTH_JWT_AUDIENCE"))
+	if issuer == "" || audience == "" {
+		return nil, errors.New("WILLIAM_AUTH_JWT_ISSUER and WILLIAM_AUTH_JWT_AUDIENCE are required")
+	}
+
+	var verifier *JWTVerifier
+	if url := strings.TrimSpace(os.Getenv("WILLIAM_AUTH_JWKS_URL")); url != "" {
+		verifier = NewRemoteJWTVerifier(url, nil, issuer, audience)
+	} else if path := os.Getenv("WILLIAM_AUTH_JWKS_FILE"); path != "" {
+		keySet, err := LoadJWKSFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verifier = NewJWTVerifier(keySet, issuer, audience)
+	} else {
+		return nil, errors.New("WILLIAM_AUTH_JWKS_URL or WILLIAM_AUTH_JWKS_FILE is required")
+	}
+
+	return &UserAuthenticator{
+		verifier:    verifier,
+		header:      envOrDefault("WILLIAM_AUTH_JWT_HEADER", defaultUserJWTHeader),
+		emailClaim:  envOrDefault("WILLIAM_AUTH_EMAIL_CLAIM", defaultUserJWTEmailClaim),
+		groupsClaim: envOrDefault("WILLIAM_AUTH_GROUPS_CLAIM", defaultUserJWTGroupsClaim),
+	}, nil
+}
+
+// Identify returns the verified email and groups of the caller.
+func (authenticator *UserAuthenticator) Identify(header http.Header) (domain.UserIdentity, error) {
+	value := strings.TrimSpace(header.Get(authenticator.header))
+	if authenticator.insecure {
+		if value == "" {
+			return domain.UserIdentity{}, fmt.Errorf("%s header is required", authenticator.header)
+		}
+		groups := strings.FieldsFunc(header.Get(insecureUserGroupsHeader), func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		return domain.UserIdentity{Email: value, Groups: groups}, nil
+	}
+
+	if strings.EqualFold(authenticator.header, "Authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return domain.UserIdentity{}, errors.New("bearer token is required")
+		}
+		value = strings.TrimSpace(token)
+	}
+	if value == "" {
+		return domain.UserIdentity{}, fmt.Errorf("%s header is required", authenticator.header)
+	}
+
+	claims, err := authenticator.verifier.Verify(value)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return domain.UserIdentity{}, errors.New("email is not verified")
+	}
+	email, _ := claims[authenticator.emailClaim].(string)
+	if email == "" {
+		return domain.UserIdentity{}, fmt.Errorf("identity assertion has no %s claim", authenticator.emailClaim)
+	}
+	return domain.UserIdentity{Email: email, Groups: claimStrings(claims[authenticator.groupsClaim])}, nil
+}
+
+// claimStrings reads a claim that is either a single string or a list of strings.
+func claimStrings(value any) []string {
+	switch claim := value.(type) {
+	case string:
+		if claim == "" {
+			return nil
+		}
+		return []string{claim}
+	case []any:
+		items := make([]string, 0, len(claim))
+		for _, item := range claim {
+			if text, ok := item.(string); ok && text != "" {
+				items = append(items, text)
+			}
+		}
+		return items
+	default:
+		return nil
+	}
+}
@@ -3,6 +3,7 @@ package infra
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
@@ -10,7 +11,7 @@ import (
 	"github.com/nomuken/william/services/server/internal/domain"
 )
 
-// BootstrapWireguard resets and restores wireguard state from the database.
+// BootstrapWireguard resets and restores wireguard and firewall state from the database and logs what it restored.
 func BootstrapWireguard(ctx context.Context, repository domain.WireguardRepository, interfaceStore domain.InterfaceStore, peerStore domain.PeerStore, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore, runner CommandRunner) error {
 	if runner == nil {
 		runner = execRunner{}
@@ -37,10 +38,30 @@ func BootstrapWireguard(ctx context.Context, repository domain.WireguardReposito
 		return err
 	}
 
+	interfaceRules := make([]domain.InterfaceFirewallRules, 0, len(configs))
+	firewallRules := make([]domain.PeerFirewallRules, 0)
+	summaries := make([]string, 0, len(configs))
+	var totalPeers, totalRules int
 	for _, config := range configs {
-		if _, err := repository.CreateInterface(ctx, config); err != nil {
+		privateKey, generated, err := ensureInterfacePrivateKey(ctx, repository, interfaceStore, config.ID)
+		if err != nil {
+			return err
+		}
+		config.PrivateKey = privateKey
+
+		iface, err := repository.CreateInterface(ctx, config)
+		if err != nil {
 			return err
 		}
+		if generated {
+			if err := reissuePeerConfigs(ctx, peerStore, config.ID, iface.PublicKey); err != nil {
+				return err
+			}
+		}
+		interfaceRules = append(interfaceRules, domain.InterfaceFirewallRules{
+			InterfaceID:   config.ID,
+			IsolationMode: config.IsolationMode,
+		})
 
 		interfaceRoutes, err := interfaceRouteStore.ListByInterface(ctx, config.ID)
 		if err != nil {
@@ -51,18 +72,127 @@ func BootstrapWireguard(ctx context.Context, repository domain.WireguardReposito
 		if err != nil {
 			return err
 		}
+		var restoredPeers, suspendedPeers, restoredPeerRoutes, restoredRules int
 		for _, peer := range peers {
+			if peer.SuspendedAt != nil {
+				suspendedPeers++
+				continue
+			}
 			peerRoutes, err := peerRouteStore.ListByPeer(ctx, peer.PeerID)
 			if err != nil {
 				return err
 			}
-			allowedIPs := normalizeAllowedIPs(peer.AllowedIP, append(routeCIDRs(interfaceRoutes), peerRouteCIDRs(peerRoutes)...))
+			allowedIPs := normalizeAllowedIPs(peer.AllowedIP, domain.RouteCIDRs(interfaceRoutes, peerRoutes))
 			if err := repository.UpdatePeerAllowedIPs(ctx, config.ID, peer.PeerID, allowedIPs); err != nil {
 				return err
 			}
+
+			accessRules := domain.RouteAccessRules(interfaceRoutes, peerRoutes)
+			firewallRules = append(firewallRules, domain.PeerFirewallRules{
+				InterfaceID:   config.ID,
+				PeerAllowedIP: peer.AllowedIP,
+				Rules:         accessRules,
+			})
+			restoredPeers++
+			restoredPeerRoutes += len(peerRoutes)
+			restoredRules += len(peerFirewallRules(config.ID, peer.AllowedIP, accessRules))
+		}
+
+		summaries = append(summaries, fmt.Sprintf("interface=%s isolation=%s peers=%d suspended=%d interface_routes=%d peer_routes=%d firewall_rules=%d",
+			config.ID, config.IsolationMode, restoredPeers, suspendedPeers, len(interfaceRoutes), restoredPeerRoutes, restoredRules))
+		totalPeers += restoredPeers
+		totalRules += restoredRules
+	}
+
+	// The firewall is rebuilt last and in one step, so it never holds the rules of only some peers.
+	if err := repository.RestoreFirewallRules(ctx, interfaceRules, firewallRules); err != nil {
+		return err
+	}
+
+	for _, summary := range summaries {
+		log.Printf("wireguard bootstrap: restored %s", summary)
+	}
+	log.Printf("wireguard bootstrap: restored interfaces=%d peers=%d firewall_rules=%d", len(configs), totalPeers, totalRules)
+	return nil
+}
+
+// ensureInterfacePrivateKey returns the stored private key of an interface. Interfaces created before keys were
+// stored adopt the key of their live device, so the peer configs issued for it stay valid. Only when the device
+// is gone too is a new key generated; generated then tells the caller to re-render the peer configs with reissuePeerConfigs.
+func ensureInterfacePrivateKey(ctx context.Context, repository domain.WireguardRepository, interfaceStore domain.InterfaceStore, interfaceID string) (privateKey string, generated bool, err error) {
+	privateKey, err = interfaceStore.GetPrivateKey(ctx, interfaceID)
+	if err != nil {
+		return "", false, err
+	}
+	if privateKey != "" {
+		return privateKey, false, nil
+	}
+
+	privateKey, err = livePrivateKey(ctx, repository, interfaceID)
+	if err != nil {
+		return "", false, err
+	}
+	if privateKey == "" {
+		privateKey, err = repository.GeneratePrivateKey(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		generated = true
+	}
+	if err := interfaceStore.UpdatePrivateKey(ctx, interfaceID, privateKey); err != nil {
+		return "", false, err
+	}
+
+	if generated {
+		log.Printf("wireguard bootstrap: generated private key for interface=%s", interfaceID)
+	} else {
+		log.Printf("wireguard bootstrap: stored the live private key of interface=%s", interfaceID)
+	}
+	return privateKey, generated, nil
+}
+
+// livePrivateKey returns the private key of the live interfaceID, or "" when the interface does not exist.
+func livePrivateKey(ctx context.Context, repository domain.WireguardRepository, interfaceID string) (string, error) {
+	configs, err := repository.ListConfigs(ctx, interfaceID)
+	if err != nil {
+		if errors.Is(err, ErrInterfaceNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	for _, config := range configs {
+		for _, line := range strings.Split(config.Config, "\n") {
+			key, value, ok := strings.Cut(line, "=")
+			if ok && strings.TrimSpace(key) == "PrivateKey" {
+				return strings.TrimSpace(value), nil
+			}
 		}
 	}
+	return "", nil
+}
 
+// reissuePeerConfigs re-renders the stored config of every peer on interfaceID with the server publicKey,
+// as RotateInterfaceKey does. Clients still have to fetch their config again.
+func reissuePeerConfigs(ctx context.Context, peerStore domain.PeerStore, interfaceID string, publicKey string) error {
+	peers, err := peerStore.ListByInterface(ctx, interfaceID)
+	if err != nil {
+		return err
+	}
+
+	var updated int
+	for _, peer := range peers {
+		config := domain.ReplacePeerConfigServerPublicKey(peer.Config, publicKey)
+		if config == peer.Config {
+			continue
+		}
+		if err := peerStore.UpdateConfig(ctx, peer.PeerID, config); err != nil {
+			return err
+		}
+		updated++
+	}
+
+	log.Printf("wireguard bootstrap: re-rendered peer configs of interface=%s peers=%d; clients must fetch them again", interfaceID, updated)
 	return nil
 }
 
@@ -107,19 +237,3 @@ func BootstrapWireguardOrFatal(ctx context.Context, repository domain.WireguardR
 		log.Fatalf("wireguard bootstrap failed: %v", err)
 	}
 }
-
-func routeCIDRs(routes []domain.InterfaceRoute) []string {
-	cidrs := make([]string, 0, len(routes))
-	for _, route := range routes {
-		cidrs = append(cidrs, route.CIDR)
-	}
-	return cidrs
-}
-
-func peerRouteCIDRs(routes []domain.PeerRoute) []string {
-	cidrs := make([]string, 0, len(routes))
-	for _, route := range routes {
-		cidrs = append(cidrs, route.CIDR)
-	}
-	return cidrs
-}
@@ -0,0 +1,89 @@
+package infra
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// fakeKeyStore holds the private keys of interfaces stored before keys were.
+type fakeKeyStore struct {
+	domain.InterfaceStore
+	privateKeys map[string]string
+}
+
+func (store *fakeKeyStore) GetPrivateKey(ctx context.Context, id string) (string, error) {
+	return store.privateKeys[id], nil
+}
+
+func (store *fakeKeyStore) UpdatePrivateKey(ctx context.Context, id string, privateKey string) error {
+	store.privateKeys[id] = privateKey
+	return nil
+}
+
+// fakeConfigStore holds the peer configs of one interface.
+type fakeConfigStore struct {
+	domain.PeerStore
+	peers []domain.PeerRecord
+}
+
+func (store *fakeConfigStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.PeerRecord, error) {
+	return store.peers, nil
+}
+
+func (store *fakeConfigStore) UpdateConfig(ctx context.Context, peerID string, config string) error {
+	for i := range store.peers {
+		if store.peers[i].PeerID == peerID {
+			store.peers[i].Config = config
+		}
+	}
+	return nil
+}
+
+func TestEnsureInterfacePrivateKeyAdoptsLiveKey(t *testing.T) {
+	repo, kernel, _ := newFakeNetlinkRepository(t)
+	store := &fakeKeyStore{privateKeys: map[string]string{"wg0": ""}}
+
+	privateKey, generated, err := ensureInterfacePrivateKey(context.Background(), repo, store, "wg0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated {
+		t.Error("generated a key for an interface that is up")
+	}
+	if want := kernel.devices["wg0"].PrivateKey.String(); privateKey != want || store.privateKeys["wg0"] != want {
+		t.Errorf("key = %q, stored %q, want the live key %q", privateKey, store.privateKeys["wg0"], want)
+	}
+}
+
+func TestEnsureInterfacePrivateKeyReissuesPeerConfigs(t *testing.T) {
+	kernel := newFakeNetlink()
+	repo := NewNetlinkWireguardRepositoryWith(kernel, kernel, newFakeFirewall())
+	store := &fakeKeyStore{privateKeys: map[string]string{"wg0": ""}}
+
+	privateKey, generated, err := ensureInterfacePrivateKey(context.Background(), repo, store, "wg0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !generated || privateKey == "" || store.privateKeys["wg0"] != privateKey {
+		t.Fatalf("key = %q, generated = %v, stored %q", privateKey, generated, store.privateKeys["wg0"])
+	}
+
+	key, err := wgtypes.ParseKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := key.PublicKey().String()
+	peers := &fakeConfigStore{peers: []domain.PeerRecord{
+		{PeerID: "peer-a", Config: "[Interface]\nPrivateKey = client\n\n[Peer]\nPublicKey = old-server-key\nAllowedIPs = 0.0.0.0/0\n"},
+	}}
+	if err := reissuePeerConfigs(context.Background(), peers, "wg0", publicKey); err != nil {
+		t.Fatal(err)
+	}
+	if config := peers.peers[0].Config; !strings.Contains(config, "PublicKey = "+publicKey) || strings.Contains(config, "old-server-key") {
+		t.Errorf("config was not re-rendered with the new server key:\n%s", config)
+	}
+}
@@ -0,0 +1,437 @@
+package infra
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const defaultReconcileInterval = time.Minute
+
+// LoadReconcileInterval reads WILLIAM_RECONCILE_INTERVAL, how often admin-server reconciles live wireguard
+// and firewall state with the database. It defaults to one minute; "0" disables the reconciler.
+func LoadReconcileInterval() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("WILLIAM_RECONCILE_INTERVAL"))
+	if value == "" {
+		return defaultReconcileInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse WILLIAM_RECONCILE_INTERVAL: %w", err)
+	}
+	if interval < 0 {
+		return 0, fmt.Errorf("WILLIAM_RECONCILE_INTERVAL must not be negative")
+	}
+	return interval, nil
+}
+
+// WireguardReconciler restores the interfaces, peers and firewall rules stored in the database when live state
+// drifts from them, e.g. after a manual `wg set ... remove` or an iptables flush. Peers that are live but not
+// stored, or suspended, are removed, as are firewall rules of addresses no active peer owns. Live interfaces
+// missing from the database are left alone.
+//
+// Peers are added and removed in several steps by admin-server and william-server, so live state is briefly
+// out of line while they do. Apply therefore only makes a change once two passes in a row have planned it.
+type WireguardReconciler struct {
+	repository          domain.WireguardRepository
+	firewall            FirewallDriver
+	interfaceStore      domain.InterfaceStore
+	peerStore           domain.PeerStore
+	interfaceRouteStore domain.InterfaceRouteStore
+	peerRouteStore      domain.PeerRouteStore
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+func NewWireguardReconciler(repository domain.WireguardRepository, firewall FirewallDriver, interfaceStore domain.InterfaceStore, peerStore domain.PeerStore, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore) *WireguardReconciler {
+	return &WireguardReconciler{
+		repository:          repository,
+		firewall:            firewall,
+		interfaceStore:      interfaceStore,
+		peerStore:           peerStore,
+		interfaceRouteStore: interfaceRouteStore,
+		peerRouteStore:      peerRouteStore,
+	}
+}
+
+type reconcileStep struct {
+	change domain.ReconcileChange
+	apply  func(ctx context.Context) error
+}
+
+func (reconciler *WireguardReconciler) Plan(ctx context.Context) ([]domain.ReconcileChange, error) {
+	steps, err := reconciler.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]domain.ReconcileChange, 0, len(steps))
+	for _, step := range steps {
+		changes = append(changes, step.change)
+	}
+	return changes, nil
+}
+
+// Apply makes the planned changes that the previous pass planned as well. A change that fails is skipped
+// so the rest are still made; its error is returned with the others.
+func (reconciler *WireguardReconciler) Apply(ctx context.Context) ([]domain.ReconcileChange, error) {
+	reconciler.mu.Lock()
+	defer reconciler.mu.Unlock()
+
+	if err := reconciler.repository.EnsureFirewallChain(ctx); err != nil {
+		return nil, err
+	}
+	steps, err := reconciler.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make(map[string]struct{}, len(steps))
+	applied := make([]domain.ReconcileChange, 0)
+	var errs []error
+	for _, step := range steps {
+		key := step.change.Kind + "|" + step.change.InterfaceID + "|" + step.change.Target
+		planned[key] = struct{}{}
+		if _, ok := reconciler.pending[key]; !ok {
+			continue
+		}
+		if err := step.apply(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s %s: %w", step.change.Kind, step.change.InterfaceID, step.change.Target, err))
+			continue
+		}
+		applied = append(applied, step.change)
+	}
+	reconciler.pending = planned
+
+	return applied, errors.Join(errs...)
+}
+
+func (reconciler *WireguardReconciler) plan(ctx context.Context) ([]reconcileStep, error) {
+	configs, err := reconciler.interfaceStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	liveInterfaces, err := reconciler.repository.ListInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	live := make(map[string]domain.WireguardInterface, len(liveInterfaces))
+	for _, iface := range liveInterfaces {
+		live[iface.ID] = iface
+	}
+
+	rules, err := reconciler.firewall.PeerRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list firewall rules: %w", err)
+	}
+	liveRules := make(map[string]map[FirewallRule]struct{})
+	for _, rule := range rules {
+		if liveRules[rule.Source] == nil {
+			liveRules[rule.Source] = make(map[FirewallRule]struct{})
+		}
+		liveRules[rule.Source][rule] = struct{}{}
+	}
+	liveModes, err := reconciler.firewall.IsolationModes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list isolation modes: %w", err)
+	}
+
+	steps := make([]reconcileStep, 0)
+	claimed := make(map[string]struct{})
+	for _, config := range configs {
+		interfaceSteps, err := reconciler.planInterface(ctx, config, live, liveRules, liveModes, claimed)
+		if err != nil {
+			return nil, fmt.Errorf("plan interface %s: %w", config.ID, err)
+		}
+		steps = append(steps, interfaceSteps...)
+	}
+
+	sources := make([]string, 0, len(liveRules))
+	for source := range liveRules {
+		if _, ok := claimed[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	slices.Sort(sources)
+	for _, source := range sources {
+		var interfaceID string
+		for rule := range liveRules[source] {
+			interfaceID = rule.InterfaceID
+			break
+		}
+		steps = append(steps, reconcileStep{
+			change: domain.ReconcileChange{
+				Kind:        domain.ReconcileRemoveFirewallRules,
+				InterfaceID: interfaceID,
+				Target:      source,
+				Detail:      fmt.Sprintf("%d rules of an address no active peer owns", len(liveRules[source])),
+			},
+			apply: func(ctx context.Context) error {
+				return reconciler.repository.RemovePeerFirewallRules(ctx, source)
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+// planInterface plans the changes of one stored interface and its peers and marks the firewall sources of its
+// active peers as claimed.
+func (reconciler *WireguardReconciler) planInterface(ctx context.Context, config domain.InterfaceConfig, live map[string]domain.WireguardInterface, liveRules map[string]map[FirewallRule]struct{}, liveModes map[string]string, claimed map[string]struct{}) ([]reconcileStep, error) {
+	steps := make([]reconcileStep, 0)
+
+	liveInterface, exists := live[config.ID]
+	if !exists {
+		steps = append(steps, reconcileStep{
+			change: domain.ReconcileChange{Kind: domain.ReconcileCreateInterface, InterfaceID: config.ID, Detail: "interface is missing"},
+			apply: func(ctx context.Context) error {
+				privateKey, generated, err := ensureInterfacePrivateKey(ctx, reconciler.repository, reconciler.interfaceStore, config.ID)
+				if err != nil {
+					return err
+				}
+				config.PrivateKey = privateKey
+				iface, err := reconciler.repository.CreateInterface(ctx, config)
+				if err != nil {
+					return err
+				}
+				if generated {
+					return reissuePeerConfigs(ctx, reconciler.peerStore, config.ID, iface.PublicKey)
+				}
+				return nil
+			},
+		})
+	} else if drift := interfaceDrift(config, liveInterface); drift != "" {
+		steps = append(steps, reconcileStep{
+			change: domain.ReconcileChange{Kind: domain.ReconcileUpdateInterface, InterfaceID: config.ID, Detail: drift},
+			apply: func(ctx context.Context) error {
+				// An empty private key keeps the live one.
+				config.PrivateKey = ""
+				_, err := reconciler.repository.UpdateInterface(ctx, config)
+				return err
+			},
+		})
+	}
+	// Creating the interface syncs its isolation rules as well.
+	if liveMode := liveModes[config.ID]; exists && liveMode != config.IsolationMode {
+		if liveMode == "" {
+			liveMode = "none"
+		}
+		steps = append(steps, reconcileStep{
+			change: domain.ReconcileChange{Kind: domain.ReconcileSyncIsolationRules, InterfaceID: config.ID, Detail: fmt.Sprintf("isolation %s -> %s", liveMode, config.IsolationMode)},
+			apply: func(ctx context.Context) error {
+				return reconciler.firewall.SyncInterfaceRules(ctx, config.ID, config.IsolationMode)
+			},
+		})
+	}
+
+	livePeers := make(map[string][]string)
+	if exists {
+		wireguardConfigs, err := reconciler.repository.ListConfigs(ctx, config.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, wireguardConfig := range wireguardConfigs {
+			for peerID, allowedIPs := range parseConfigPeers(wireguardConfig.Config) {
+				livePeers[peerID] = allowedIPs
+			}
+		}
+	}
+
+	interfaceRoutes, err := reconciler.interfaceRouteStore.ListByInterface(ctx, config.ID)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := reconciler.peerStore.ListByInterface(ctx, config.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]struct{}, len(peers))
+	suspended := make(map[string]struct{})
+	for _, peer := range peers {
+		if peer.SuspendedAt != nil {
+			suspended[peer.PeerID] = struct{}{}
+			continue
+		}
+		active[peer.PeerID] = struct{}{}
+
+		peerRoutes, err := reconciler.peerRouteStore.ListByPeer(ctx, peer.PeerID)
+		if err != nil {
+			return nil, err
+		}
+		allowedIPs := normalizeAllowedIPs(peer.AllowedIP, domain.RouteCIDRs(interfaceRoutes, peerRoutes))
+
+		liveAllowedIPs, ok := livePeers[peer.PeerID]
+		switch {
+		case !ok:
+			steps = append(steps, reconcileStep{
+				change: domain.ReconcileChange{Kind: domain.ReconcileAddPeer, InterfaceID: config.ID, Target: peer.PeerID, Detail: "peer is missing"},
+				apply: func(ctx context.Context) error {
+					return reconciler.repository.UpdatePeerAllowedIPs(ctx, config.ID, peer.PeerID, allowedIPs)
+				},
+			})
+		case !slices.Equal(canonicalPrefixes(liveAllowedIPs), canonicalPrefixes(allowedIPs)):
+			steps = append(steps, reconcileStep{
+				change: domain.ReconcileChange{
+					Kind:        domain.ReconcileUpdatePeerAllowedIPs,
+					InterfaceID: config.ID,
+					Target:      peer.PeerID,
+					Detail:      fmt.Sprintf("allowed ips %s -> %s", strings.Join(canonicalPrefixes(liveAllowedIPs), ", "), strings.Join(canonicalPrefixes(allowedIPs), ", ")),
+				},
+				apply: func(ctx context.Context) error {
+					return reconciler.repository.UpdatePeerAllowedIPs(ctx, config.ID, peer.PeerID, allowedIPs)
+				},
+			})
+		}
+
+		accessRules := domain.RouteAccessRules(interfaceRoutes, peerRoutes)
+		wanted := canonicalFirewallRules(config.ID, peer.AllowedIP, accessRules)
+		have := make(map[FirewallRule]struct{})
+		for _, address := range domain.SplitAddresses(peer.AllowedIP) {
+			source := canonicalPrefix(address)
+			claimed[source] = struct{}{}
+			for rule := range liveRules[source] {
+				have[rule] = struct{}{}
+			}
+		}
+		if !sameFirewallRules(have, wanted) {
+			steps = append(steps, reconcileStep{
+				change: domain.ReconcileChange{
+					Kind:        domain.ReconcileSyncFirewallRules,
+					InterfaceID: config.ID,
+					Target:      peer.PeerID,
+					Detail:      fmt.Sprintf("%s has %d rules, %d of the %d wanted", peer.AllowedIP, len(have), countFirewallRules(have, wanted), len(wanted)),
+				},
+				apply: func(ctx context.Context) error {
+					return reconciler.repository.SyncPeerFirewallRules(ctx, config.ID, peer.AllowedIP, accessRules)
+				},
+			})
+		}
+	}
+
+	extraPeers := make([]string, 0)
+	for peerID := range livePeers {
+		if _, ok := active[peerID]; !ok {
+			extraPeers = append(extraPeers, peerID)
+		}
+	}
+	slices.Sort(extraPeers)
+	for _, peerID := range extraPeers {
+		detail := "peer is not in the database"
+		if _, ok := suspended[peerID]; ok {
+			detail = "peer is suspended"
+		}
+		steps = append(steps, reconcileStep{
+			change: domain.ReconcileChange{Kind: domain.ReconcileRemovePeer, InterfaceID: config.ID, Target: peerID, Detail: detail},
+			apply: func(ctx context.Context) error {
+				return reconciler.repository.DeletePeer(ctx, peerID)
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+// interfaceDrift describes how the live interface differs from its stored config, or returns "" when it does not.
+func interfaceDrift(config domain.InterfaceConfig, live domain.WireguardInterface) string {
+	drifts := make([]string, 0, 3)
+	if live.ListenPort != config.ListenPort {
+		drifts = append(drifts, fmt.Sprintf("listen port %d -> %d", live.ListenPort, config.ListenPort))
+	}
+	if config.MTU != 0 && live.MTU != config.MTU {
+		drifts = append(drifts, fmt.Sprintf("mtu %d -> %d", live.MTU, config.MTU))
+	}
+	liveAddresses := interfaceAddresses(live.Address)
+	storedAddresses := interfaceAddresses(config.Address)
+	if !slices.Equal(liveAddresses, storedAddresses) {
+		drifts = append(drifts, fmt.Sprintf("address %s -> %s", domain.JoinAddresses(liveAddresses), domain.JoinAddresses(storedAddresses)))
+	}
+	return strings.Join(drifts, ", ")
+}
+
+// interfaceAddresses returns the sorted interface addresses of value, keeping their host bits.
+func interfaceAddresses(value string) []string {
+	addresses := make([]string, 0, 2)
+	for _, item := range domain.SplitAddresses(value) {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			item = prefix.String()
+		}
+		addresses = append(addresses, item)
+	}
+	slices.Sort(addresses)
+	return addresses
+}
+
+// parseConfigPeers returns the allowed IPs of every peer in a config in the format of `wg showconf`.
+func parseConfigPeers(config string) map[string][]string {
+	peers := make(map[string][]string)
+	var peerID string
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			peerID = ""
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "PublicKey":
+			peerID = strings.TrimSpace(value)
+			if _, ok := peers[peerID]; !ok {
+				peers[peerID] = nil
+			}
+		case "AllowedIPs":
+			if peerID != "" {
+				peers[peerID] = append(peers[peerID], domain.SplitAddresses(value)...)
+			}
+		}
+	}
+	return peers
+}
+
+// canonicalFirewallRules returns the rules of a peer as a set; peerFirewallRules already returns canonical prefixes.
+func canonicalFirewallRules(interfaceID string, peerAllowedIP string, accessRules []domain.AccessRule) map[FirewallRule]struct{} {
+	rules := make(map[FirewallRule]struct{})
+	for _, rule := range peerFirewallRules(interfaceID, peerAllowedIP, accessRules) {
+		rules[rule] = struct{}{}
+	}
+	return rules
+}
+
+func sameFirewallRules(have map[FirewallRule]struct{}, wanted map[FirewallRule]struct{}) bool {
+	return len(have) == len(wanted) && countFirewallRules(have, wanted) == len(wanted)
+}
+
+// countFirewallRules counts the wanted rules that are in have.
+func countFirewallRules(have map[FirewallRule]struct{}, wanted map[FirewallRule]struct{}) int {
+	count := 0
+	for rule := range wanted {
+		if _, ok := have[rule]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// canonicalPrefixes returns the sorted, deduplicated canonical forms of values.
+func canonicalPrefixes(values []string) []string {
+	prefixes := make([]string, 0, len(values))
+	for _, value := range values {
+		prefixes = append(prefixes, canonicalPrefix(value))
+	}
+	slices.Sort(prefixes)
+	return slices.Compact(prefixes)
+}
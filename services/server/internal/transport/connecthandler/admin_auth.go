@@ -0,0 +1,100 @@
+package connecthandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/nomuken/william/services/server/gen/proto/admin/v1/adminv1connect"
+	"github.com/nomuken/william/services/server/internal/domain"
+	"github.com/nomuken/william/services/server/internal/usecase"
+)
+
+// AdminAuthenticator resolves the caller of an admin RPC from the request headers.
+type AdminAuthenticator interface {
+	Authenticate(header http.Header) (domain.AdminPrincipal, error)
+}
+
+// adminProcedureRoles is the minimum role needed for each admin RPC.
+// Procedures missing from the map require the owner role.
+var adminProcedureRoles = map[string]string{
+	adminv1connect.WilliamAdminServiceListInterfacesProcedure:          domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceGetInterfaceProcedure:            domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListAllowedEmailsProcedure:       domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServicePreviewAllowedEmailRuleProcedure: domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListInterfaceGroupsProcedure:     domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListPeersProcedure:               domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListInterfaceRoutesProcedure:     domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListPeerRoutesProcedure:          domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListIpAllocationsProcedure:       domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListPeerStatsProcedure:           domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceGetFirewallRulesProcedure:        domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServiceListAuditEventsProcedure:         domain.AdminRoleViewer,
+	adminv1connect.WilliamAdminServicePlanWireguardReconcileProcedure:  domain.AdminRoleViewer,
+
+	adminv1connect.WilliamAdminServiceCreateAllowedEmailProcedure:            domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeleteAllowedEmailProcedure:            domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceCreateInterfaceGroupProcedure:          domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeleteInterfaceGroupProcedure:          domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeletePeerProcedure:                    domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceSuspendPeerProcedure:                   domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceResumePeerProcedure:                    domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceCreateWireguardPeerProcedure:           domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceUpdateWireguardPeerAllowedIPsProcedure: domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeleteWireguardPeerProcedure:           domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceSyncPeerFirewallRulesProcedure:         domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceRemovePeerFirewallRulesProcedure:       domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceEnsureFirewallChainProcedure:           domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceCreateInterfaceRouteProcedure:          domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeleteInterfaceRouteProcedure:          domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceCreatePeerRouteProcedure:               domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeletePeerRouteProcedure:               domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceCreateIpReservationProcedure:           domain.AdminRoleOperator,
+	adminv1connect.WilliamAdminServiceDeleteIpReservationProcedure:           domain.AdminRoleOperator,
+
+	// ListWireguardConfigs exposes interface private keys, so it stays owner only.
+	adminv1connect.WilliamAdminServiceCreateInterfaceProcedure:           domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceUpdateInterfaceProcedure:           domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceDeleteInterfaceProcedure:           domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceRotateInterfaceKeyProcedure:        domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceListWireguardConfigsProcedure:      domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceListAdminRoleAssignmentsProcedure:  domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceSetAdminRoleAssignmentProcedure:    domain.AdminRoleOwner,
+	adminv1connect.WilliamAdminServiceDeleteAdminRoleAssignmentProcedure: domain.AdminRoleOwner,
+}
+
+type adminPrincipalKey struct{}
+
+// NewAdminAuthInterceptor authenticates every admin RPC and checks the caller's role against adminProcedureRoles.
+// The caller's subject is recorded as the actor of the audit events the RPC produces.
+func NewAdminAuthInterceptor(authenticator AdminAuthenticator, adminAccessUsecase usecase.AdminAccessUsecase) connect.Interceptor {
+	return connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			principal, err := authenticator.Authenticate(req.Header())
+			if err != nil {
+				return nil, connect.NewError(connect.CodeUnauthenticated, err)
+			}
+
+			requiredRole, ok := adminProcedureRoles[req.Spec().Procedure]
+			if !ok {
+				requiredRole = domain.AdminRoleOwner
+			}
+			if err := adminAccessUsecase.Authorize(ctx, principal, requiredRole); err != nil {
+				if errors.Is(err, usecase.ErrAdminForbidden) {
+					return nil, connect.NewError(connect.CodePermissionDenied, err)
+				}
+				return nil, err
+			}
+
+			ctx = domain.WithAuditActor(ctx, principal.Subject)
+			return next(context.WithValue(ctx, adminPrincipalKey{}, principal), req)
+		}
+	})
+}
+
+// AdminPrincipalFromContext returns the principal stored by NewAdminAuthInterceptor.
+func AdminPrincipalFromContext(ctx context.Context) (domain.AdminPrincipal, bool) {
+	principal, ok := ctx.Value(adminPrincipalKey{}).(domain.AdminPrincipal)
+	return principal, ok
+}
@@ -3,6 +3,8 @@ package connecthandler
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"connectrpc.com/connect"
 	adminv1 "github.com/nomuken/william/services/server/gen/proto/admin/v1"
@@ -13,11 +15,12 @@ import (
 )
 
 type AdminHandler struct {
-	adminUsecase usecase.AdminUsecase
+	adminUsecase       usecase.AdminUsecase
+	adminAccessUsecase usecase.AdminAccessUsecase
 }
 
-func NewAdminHandler(adminUsecase usecase.AdminUsecase) *AdminHandler {
-	return &AdminHandler{adminUsecase: adminUsecase}
+func NewAdminHandler(adminUsecase usecase.AdminUsecase, adminAccessUsecase usecase.AdminAccessUsecase) *AdminHandler {
+	return &AdminHandler{adminUsecase: adminUsecase, adminAccessUsecase: adminAccessUsecase}
 }
 
 func (handler *AdminHandler) ListInterfaces(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[adminv1.ListAdminInterfacesResponse], error) {
@@ -29,13 +32,17 @@ func (handler *AdminHandler) ListInterfaces(ctx context.Context, _ *connect.Requ
 	items := make([]*adminv1.AdminWireguardInterface, 0, len(interfaces))
 	for _, item := range interfaces {
 		items = append(items, &adminv1.AdminWireguardInterface{
-			Id:         item.ID,
-			Name:       item.Name,
-			Address:    item.Address,
-			ListenPort: item.ListenPort,
-			PublicKey:  item.PublicKey,
-			Mtu:        item.MTU,
-			Endpoint:   item.Endpoint,
+			Id:                item.ID,
+			Name:              item.Name,
+			Address:           item.Address,
+			ListenPort:        item.ListenPort,
+			PublicKey:         item.PublicKey,
+			Mtu:               item.MTU,
+			Endpoint:          item.Endpoint,
+			PeerKeyPolicy:     item.PeerKeyPolicy,
+			PeerTtlSeconds:    int64(item.PeerTTL / time.Second),
+			MaxDevicesPerUser: item.MaxDevicesPerUser,
+			IsolationMode:     item.IsolationMode,
 		})
 	}
 
@@ -57,15 +64,22 @@ func (handler *AdminHandler) GetInterface(ctx context.Context, req *connect.Requ
 
 func (handler *AdminHandler) CreateInterface(ctx context.Context, req *connect.Request[adminv1.CreateAdminInterfaceRequest]) (*connect.Response[adminv1.CreateAdminInterfaceResponse], error) {
 	config := domain.InterfaceConfig{
-		ID:         req.Msg.GetName(),
-		Name:       req.Msg.GetName(),
-		Address:    req.Msg.GetAddress(),
-		ListenPort: req.Msg.GetListenPort(),
-		MTU:        req.Msg.GetMtu(),
-		Endpoint:   req.Msg.GetEndpoint(),
+		ID:                req.Msg.GetName(),
+		Name:              req.Msg.GetName(),
+		Address:           req.Msg.GetAddress(),
+		ListenPort:        req.Msg.GetListenPort(),
+		MTU:               req.Msg.GetMtu(),
+		Endpoint:          req.Msg.GetEndpoint(),
+		PeerKeyPolicy:     req.Msg.GetPeerKeyPolicy(),
+		PeerTTL:           time.Duration(req.Msg.GetPeerTtlSeconds()) * time.Second,
+		MaxDevicesPerUser: req.Msg.GetMaxDevicesPerUser(),
+		IsolationMode:     req.Msg.GetIsolationMode(),
 	}
 	iface, err := handler.adminUsecase.CreateInterface(ctx, config)
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidPeerKeyPolicy) || errors.Is(err, usecase.ErrInvalidIsolationMode) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 
@@ -75,18 +89,25 @@ func (handler *AdminHandler) CreateInterface(ctx context.Context, req *connect.R
 
 func (handler *AdminHandler) UpdateInterface(ctx context.Context, req *connect.Request[adminv1.UpdateAdminInterfaceRequest]) (*connect.Response[adminv1.UpdateAdminInterfaceResponse], error) {
 	config := domain.InterfaceConfig{
-		ID:         req.Msg.GetId(),
-		Name:       req.Msg.GetName(),
-		Address:    req.Msg.GetAddress(),
-		ListenPort: req.Msg.GetListenPort(),
-		MTU:        req.Msg.GetMtu(),
-		Endpoint:   req.Msg.GetEndpoint(),
+		ID:                req.Msg.GetId(),
+		Name:              req.Msg.GetName(),
+		Address:           req.Msg.GetAddress(),
+		ListenPort:        req.Msg.GetListenPort(),
+		MTU:               req.Msg.GetMtu(),
+		Endpoint:          req.Msg.GetEndpoint(),
+		PeerKeyPolicy:     req.Msg.GetPeerKeyPolicy(),
+		PeerTTL:           time.Duration(req.Msg.GetPeerTtlSeconds()) * time.Second,
+		MaxDevicesPerUser: req.Msg.GetMaxDevicesPerUser(),
+		IsolationMode:     req.Msg.GetIsolationMode(),
 	}
 	iface, err := handler.adminUsecase.UpdateInterface(ctx, config)
 	if err != nil {
 		if errors.Is(err, usecase.ErrInterfaceNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
 		}
+		if errors.Is(err, usecase.ErrInvalidPeerKeyPolicy) || errors.Is(err, usecase.ErrInvalidIsolationMode) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 
@@ -104,6 +125,22 @@ func (handler *AdminHandler) DeleteInterface(ctx context.Context, req *connect.R
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (handler *AdminHandler) RotateInterfaceKey(ctx context.Context, req *connect.Request[adminv1.RotateInterfaceKeyRequest]) (*connect.Response[adminv1.RotateInterfaceKeyResponse], error) {
+	iface, updatedPeerIDs, err := handler.adminUsecase.RotateInterfaceKey(ctx, req.Msg.GetId())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	response := &adminv1.RotateInterfaceKeyResponse{
+		Interface:      adminInterfaceToProto(iface),
+		UpdatedPeerIds: updatedPeerIDs,
+	}
+	return connect.NewResponse(response), nil
+}
+
 func (handler *AdminHandler) ListAllowedEmails(ctx context.Context, req *connect.Request[adminv1.ListAllowedEmailsRequest]) (*connect.Response[adminv1.ListAllowedEmailsResponse], error) {
 	emails, err := handler.adminUsecase.ListAllowedEmails(ctx, req.Msg.GetInterfaceId())
 	if err != nil {
@@ -119,6 +156,7 @@ func (handler *AdminHandler) ListAllowedEmails(ctx context.Context, req *connect
 			InterfaceId: email.InterfaceID,
 			Email:       email.Email,
 			CreatedAt:   timestamppb.New(email.CreatedAt),
+			RuleType:    email.RuleType,
 		})
 	}
 
@@ -126,17 +164,70 @@ func (handler *AdminHandler) ListAllowedEmails(ctx context.Context, req *connect
 }
 
 func (handler *AdminHandler) CreateAllowedEmail(ctx context.Context, req *connect.Request[adminv1.CreateAllowedEmailRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.CreateAllowedEmail(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEmail()); err != nil {
+	if err := handler.adminUsecase.CreateAllowedEmail(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEmail(), req.Msg.GetRuleType()); err != nil {
 		if errors.Is(err, usecase.ErrInterfaceNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
 		}
+		if errors.Is(err, usecase.ErrInvalidAllowedEmailRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
-func (handler *AdminHandler) DeleteAllowedEmail(ctx context.Context, req *connect.Request[adminv1.DeleteAllowedEmailRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.DeleteAllowedEmail(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEmail()); err != nil {
+func (handler *AdminHandler) PreviewAllowedEmailRule(ctx context.Context, req *connect.Request[adminv1.PreviewAllowedEmailRuleRequest]) (*connect.Response[adminv1.PreviewAllowedEmailRuleResponse], error) {
+	emails, err := handler.adminUsecase.PreviewAllowedEmailRule(ctx, req.Msg.GetRuleType(), req.Msg.GetPattern())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAllowedEmailRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&adminv1.PreviewAllowedEmailRuleResponse{Emails: emails}), nil
+}
+
+func (handler *AdminHandler) DeleteAllowedEmail(ctx context.Context, req *connect.Request[adminv1.DeleteAllowedEmailRequest]) (*connect.Response[adminv1.DeleteAllowedEmailResponse], error) {
+	affectedPeerIDs, err := handler.adminUsecase.DeleteAllowedEmail(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEmail(), req.Msg.GetSuspendPeers())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) || errors.Is(err, usecase.ErrAllowedEmailNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	response := &adminv1.DeleteAllowedEmailResponse{}
+	if req.Msg.GetSuspendPeers() {
+		response.SuspendedPeerIds = affectedPeerIDs
+	} else {
+		response.RemovedPeerIds = affectedPeerIDs
+	}
+	return connect.NewResponse(response), nil
+}
+
+func (handler *AdminHandler) ListInterfaceGroups(ctx context.Context, req *connect.Request[adminv1.ListInterfaceGroupsRequest]) (*connect.Response[adminv1.ListInterfaceGroupsResponse], error) {
+	groups, err := handler.adminUsecase.ListInterfaceGroups(ctx, req.Msg.GetInterfaceId())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	items := make([]*adminv1.InterfaceGroup, 0, len(groups))
+	for _, group := range groups {
+		items = append(items, &adminv1.InterfaceGroup{
+			InterfaceId: group.InterfaceID,
+			GroupName:   group.GroupName,
+			CreatedAt:   timestamppb.New(group.CreatedAt),
+		})
+	}
+
+	return connect.NewResponse(&adminv1.ListInterfaceGroupsResponse{Groups: items}), nil
+}
+
+func (handler *AdminHandler) CreateInterfaceGroup(ctx context.Context, req *connect.Request[adminv1.CreateInterfaceGroupRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.CreateInterfaceGroup(ctx, req.Msg.GetInterfaceId(), req.Msg.GetGroupName()); err != nil {
 		if errors.Is(err, usecase.ErrInterfaceNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
 		}
@@ -145,6 +236,24 @@ func (handler *AdminHandler) DeleteAllowedEmail(ctx context.Context, req *connec
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (handler *AdminHandler) DeleteInterfaceGroup(ctx context.Context, req *connect.Request[adminv1.DeleteInterfaceGroupRequest]) (*connect.Response[adminv1.DeleteInterfaceGroupResponse], error) {
+	affectedPeerIDs, err := handler.adminUsecase.DeleteInterfaceGroup(ctx, req.Msg.GetInterfaceId(), req.Msg.GetGroupName(), req.Msg.GetSuspendPeers())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	response := &adminv1.DeleteInterfaceGroupResponse{}
+	if req.Msg.GetSuspendPeers() {
+		response.SuspendedPeerIds = affectedPeerIDs
+	} else {
+		response.RemovedPeerIds = affectedPeerIDs
+	}
+	return connect.NewResponse(response), nil
+}
+
 func (handler *AdminHandler) ListPeers(ctx context.Context, req *connect.Request[adminv1.ListAdminPeersRequest]) (*connect.Response[adminv1.ListAdminPeersResponse], error) {
 	peers, err := handler.adminUsecase.ListPeers(ctx, req.Msg.GetInterfaceId())
 	if err != nil {
@@ -153,13 +262,21 @@ func (handler *AdminHandler) ListPeers(ctx context.Context, req *connect.Request
 
 	items := make([]*adminv1.AdminPeer, 0, len(peers))
 	for _, peer := range peers {
-		items = append(items, &adminv1.AdminPeer{
+		item := &adminv1.AdminPeer{
 			PeerId:      peer.PeerID,
 			Email:       peer.Email,
 			InterfaceId: peer.InterfaceID,
 			AllowedIp:   peer.AllowedIP,
 			CreatedAt:   timestamppb.New(peer.CreatedAt),
-		})
+			DeviceName:  peer.DeviceName,
+		}
+		if peer.ExpiresAt != nil {
+			item.ExpiresAt = timestamppb.New(*peer.ExpiresAt)
+		}
+		if peer.SuspendedAt != nil {
+			item.SuspendedAt = timestamppb.New(*peer.SuspendedAt)
+		}
+		items = append(items, item)
 	}
 
 	return connect.NewResponse(&adminv1.ListAdminPeersResponse{Peers: items}), nil
@@ -175,12 +292,38 @@ func (handler *AdminHandler) DeletePeer(ctx context.Context, req *connect.Reques
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (handler *AdminHandler) SuspendPeer(ctx context.Context, req *connect.Request[adminv1.SuspendAdminPeerRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.SuspendPeer(ctx, req.Msg.GetPeerId()); err != nil {
+		if errors.Is(err, usecase.ErrPeerNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) ResumePeer(ctx context.Context, req *connect.Request[adminv1.ResumeAdminPeerRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.ResumePeer(ctx, req.Msg.GetPeerId()); err != nil {
+		if errors.Is(err, usecase.ErrPeerNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
 func (handler *AdminHandler) CreateWireguardPeer(ctx context.Context, req *connect.Request[adminv1.CreateWireguardPeerRequest]) (*connect.Response[adminv1.CreateWireguardPeerResponse], error) {
-	peer, err := handler.adminUsecase.CreateWireguardPeer(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEndpoint(), req.Msg.GetAllowedIps())
+	peer, err := handler.adminUsecase.CreateWireguardPeer(ctx, req.Msg.GetInterfaceId(), req.Msg.GetEndpoint(), req.Msg.GetAllowedIps(), req.Msg.GetPublicKey(), req.Msg.GetAddress())
 	if err != nil {
 		if errors.Is(err, usecase.ErrInterfaceNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
 		}
+		if errors.Is(err, usecase.ErrPeerAlreadyExists) || errors.Is(err, usecase.ErrPeerAddressInUse) {
+			return nil, connect.NewError(connect.CodeAlreadyExists, err)
+		}
+		if errors.Is(err, usecase.ErrInvalidPublicKey) || errors.Is(err, usecase.ErrPublicKeyRequired) || errors.Is(err, usecase.ErrInvalidPeerAddress) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 
@@ -207,6 +350,44 @@ func (handler *AdminHandler) UpdateWireguardPeerAllowedIPs(ctx context.Context,
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (handler *AdminHandler) SyncPeerFirewallRules(ctx context.Context, req *connect.Request[adminv1.SyncPeerFirewallRulesRequest]) (*connect.Response[emptypb.Empty], error) {
+	// allowed_ips predates access rules and allows any protocol.
+	rules := make([]domain.AccessRule, 0, len(req.Msg.GetAllowedIps())+len(req.Msg.GetRules()))
+	for _, cidr := range req.Msg.GetAllowedIps() {
+		rules = append(rules, domain.AccessRule{CIDR: cidr, Protocol: domain.AccessProtocolAny})
+	}
+	for _, rule := range req.Msg.GetRules() {
+		rules = append(rules, domain.AccessRule{CIDR: rule.GetCidr(), Protocol: rule.GetProtocol(), Ports: rule.GetPorts()})
+	}
+	if err := handler.adminUsecase.SyncPeerFirewallRules(ctx, req.Msg.GetInterfaceId(), req.Msg.GetPeerAllowedIp(), rules); err != nil {
+		if errors.Is(err, usecase.ErrInvalidFirewallCIDR) || errors.Is(err, usecase.ErrInvalidAccessRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) RemovePeerFirewallRules(ctx context.Context, req *connect.Request[adminv1.RemovePeerFirewallRulesRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.RemovePeerFirewallRules(ctx, req.Msg.GetPeerAllowedIp()); err != nil {
+		if errors.Is(err, usecase.ErrInvalidFirewallCIDR) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) EnsureFirewallChain(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.EnsureFirewallChain(ctx); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
 func (handler *AdminHandler) ListInterfaceRoutes(ctx context.Context, req *connect.Request[adminv1.ListInterfaceRoutesRequest]) (*connect.Response[adminv1.ListInterfaceRoutesResponse], error) {
 	routes, err := handler.adminUsecase.ListInterfaceRoutes(ctx, req.Msg.GetInterfaceId())
 	if err != nil {
@@ -218,20 +399,30 @@ func (handler *AdminHandler) ListInterfaceRoutes(ctx context.Context, req *conne
 			InterfaceId: route.InterfaceID,
 			Cidr:        route.CIDR,
 			CreatedAt:   timestamppb.New(route.CreatedAt),
+			Protocol:    route.Protocol,
+			Ports:       route.Ports,
 		})
 	}
 	return connect.NewResponse(&adminv1.ListInterfaceRoutesResponse{Routes: items}), nil
 }
 
 func (handler *AdminHandler) CreateInterfaceRoute(ctx context.Context, req *connect.Request[adminv1.CreateInterfaceRouteRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.CreateInterfaceRoute(ctx, req.Msg.GetInterfaceId(), req.Msg.GetCidr()); err != nil {
+	rule := domain.AccessRule{CIDR: req.Msg.GetCidr(), Protocol: req.Msg.GetProtocol(), Ports: req.Msg.GetPorts()}
+	if err := handler.adminUsecase.CreateInterfaceRoute(ctx, req.Msg.GetInterfaceId(), rule); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAccessRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
 func (handler *AdminHandler) DeleteInterfaceRoute(ctx context.Context, req *connect.Request[adminv1.DeleteInterfaceRouteRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.DeleteInterfaceRoute(ctx, req.Msg.GetInterfaceId(), req.Msg.GetCidr()); err != nil {
+	rule := domain.AccessRule{CIDR: req.Msg.GetCidr(), Protocol: req.Msg.GetProtocol(), Ports: req.Msg.GetPorts()}
+	if err := handler.adminUsecase.DeleteInterfaceRoute(ctx, req.Msg.GetInterfaceId(), rule); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAccessRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 	return connect.NewResponse(&emptypb.Empty{}), nil
@@ -248,20 +439,120 @@ func (handler *AdminHandler) ListPeerRoutes(ctx context.Context, req *connect.Re
 			PeerId:    route.PeerID,
 			Cidr:      route.CIDR,
 			CreatedAt: timestamppb.New(route.CreatedAt),
+			Protocol:  route.Protocol,
+			Ports:     route.Ports,
 		})
 	}
 	return connect.NewResponse(&adminv1.ListPeerRoutesResponse{Routes: items}), nil
 }
 
 func (handler *AdminHandler) CreatePeerRoute(ctx context.Context, req *connect.Request[adminv1.CreatePeerRouteRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.CreatePeerRoute(ctx, req.Msg.GetPeerId(), req.Msg.GetCidr()); err != nil {
+	rule := domain.AccessRule{CIDR: req.Msg.GetCidr(), Protocol: req.Msg.GetProtocol(), Ports: req.Msg.GetPorts()}
+	if err := handler.adminUsecase.CreatePeerRoute(ctx, req.Msg.GetPeerId(), rule); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAccessRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, err
 	}
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
 func (handler *AdminHandler) DeletePeerRoute(ctx context.Context, req *connect.Request[adminv1.DeletePeerRouteRequest]) (*connect.Response[emptypb.Empty], error) {
-	if err := handler.adminUsecase.DeletePeerRoute(ctx, req.Msg.GetPeerId(), req.Msg.GetCidr()); err != nil {
+	rule := domain.AccessRule{CIDR: req.Msg.GetCidr(), Protocol: req.Msg.GetProtocol(), Ports: req.Msg.GetPorts()}
+	if err := handler.adminUsecase.DeletePeerRoute(ctx, req.Msg.GetPeerId(), rule); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAccessRule) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) ListIpAllocations(ctx context.Context, req *connect.Request[adminv1.ListIpAllocationsRequest]) (*connect.Response[adminv1.ListIpAllocationsResponse], error) {
+	allocations, reservations, err := handler.adminUsecase.ListIPAllocations(ctx, req.Msg.GetInterfaceId())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	allocationItems := make([]*adminv1.IpAllocation, 0, len(allocations))
+	for _, allocation := range allocations {
+		item := &adminv1.IpAllocation{
+			InterfaceId: allocation.InterfaceID,
+			Address:     allocation.Address,
+			PeerId:      allocation.PeerID,
+			CreatedAt:   timestamppb.New(allocation.CreatedAt),
+		}
+		if allocation.ReleasedAt != nil {
+			item.ReleasedAt = timestamppb.New(*allocation.ReleasedAt)
+		}
+		allocationItems = append(allocationItems, item)
+	}
+
+	reservationItems := make([]*adminv1.IpReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		reservationItems = append(reservationItems, &adminv1.IpReservation{
+			InterfaceId: reservation.InterfaceID,
+			Cidr:        reservation.CIDR,
+			Description: reservation.Description,
+			CreatedAt:   timestamppb.New(reservation.CreatedAt),
+		})
+	}
+
+	return connect.NewResponse(&adminv1.ListIpAllocationsResponse{
+		Allocations:  allocationItems,
+		Reservations: reservationItems,
+	}), nil
+}
+
+func (handler *AdminHandler) CreateIpReservation(ctx context.Context, req *connect.Request[adminv1.CreateIpReservationRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.CreateIPReservation(ctx, req.Msg.GetInterfaceId(), req.Msg.GetCidr(), req.Msg.GetDescription()); err != nil {
+		if errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) DeleteIpReservation(ctx context.Context, req *connect.Request[adminv1.DeleteIpReservationRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminUsecase.DeleteIPReservation(ctx, req.Msg.GetInterfaceId(), req.Msg.GetCidr()); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) ListAdminRoleAssignments(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[adminv1.ListAdminRoleAssignmentsResponse], error) {
+	assignments, err := handler.adminAccessUsecase.ListRoleAssignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*adminv1.AdminRoleAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		items = append(items, &adminv1.AdminRoleAssignment{
+			Subject:   assignment.Subject,
+			Role:      assignment.Role,
+			CreatedAt: timestamppb.New(assignment.CreatedAt),
+		})
+	}
+	return connect.NewResponse(&adminv1.ListAdminRoleAssignmentsResponse{Assignments: items}), nil
+}
+
+func (handler *AdminHandler) SetAdminRoleAssignment(ctx context.Context, req *connect.Request[adminv1.SetAdminRoleAssignmentRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminAccessUsecase.SetRoleAssignment(ctx, req.Msg.GetSubject(), req.Msg.GetRole()); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAdminRole) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, err
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (handler *AdminHandler) DeleteAdminRoleAssignment(ctx context.Context, req *connect.Request[adminv1.DeleteAdminRoleAssignmentRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := handler.adminAccessUsecase.DeleteRoleAssignment(ctx, req.Msg.GetSubject()); err != nil {
 		return nil, err
 	}
 	return connect.NewResponse(&emptypb.Empty{}), nil
@@ -310,14 +601,88 @@ func (handler *AdminHandler) GetFirewallRules(ctx context.Context, _ *connect.Re
 	return connect.NewResponse(&adminv1.GetFirewallRulesResponse{Rules: rules}), nil
 }
 
+// PlanWireguardReconcile returns the changes that would bring live wireguard and firewall state back in line
+// with the database, without making them.
+func (handler *AdminHandler) PlanWireguardReconcile(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[adminv1.PlanWireguardReconcileResponse], error) {
+	changes, err := handler.adminUsecase.PlanWireguardReconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*adminv1.ReconcileChange, 0, len(changes))
+	for _, change := range changes {
+		items = append(items, &adminv1.ReconcileChange{
+			Kind:        change.Kind,
+			InterfaceId: change.InterfaceID,
+			Target:      change.Target,
+			Detail:      change.Detail,
+		})
+	}
+	return connect.NewResponse(&adminv1.PlanWireguardReconcileResponse{Changes: items}), nil
+}
+
+// ListAuditEvents returns audit events newest first. The page token is the opaque cursor from the previous page.
+func (handler *AdminHandler) ListAuditEvents(ctx context.Context, req *connect.Request[adminv1.ListAuditEventsRequest]) (*connect.Response[adminv1.ListAuditEventsResponse], error) {
+	filter := domain.AuditEventFilter{
+		Actor:      req.Msg.GetActor(),
+		Action:     req.Msg.GetAction(),
+		TargetType: req.Msg.GetTargetType(),
+		TargetID:   req.Msg.GetTargetId(),
+		Limit:      int(req.Msg.GetPageSize()),
+	}
+	if req.Msg.GetSince() != nil {
+		filter.Since = req.Msg.GetSince().AsTime()
+	}
+	if req.Msg.GetUntil() != nil {
+		filter.Until = req.Msg.GetUntil().AsTime()
+	}
+	if token := req.Msg.GetPageToken(); token != "" {
+		beforeID, err := strconv.ParseInt(token, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("invalid page token"))
+		}
+		filter.BeforeID = beforeID
+	}
+
+	events, nextBeforeID, err := handler.adminUsecase.ListAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*adminv1.AuditEvent, 0, len(events))
+	for _, event := range events {
+		items = append(items, &adminv1.AuditEvent{
+			Id:         strconv.FormatInt(event.ID, 10),
+			OccurredAt: timestamppb.New(event.OccurredAt),
+			Actor:      event.Actor,
+			Action:     event.Action,
+			TargetType: event.TargetType,
+			TargetId:   event.TargetID,
+			BeforeJson: string(event.Before),
+			AfterJson:  string(event.After),
+			RequestId:  event.RequestID,
+		})
+	}
+
+	response := &adminv1.ListAuditEventsResponse{Events: items}
+	if nextBeforeID > 0 {
+		response.NextPageToken = strconv.FormatInt(nextBeforeID, 10)
+	}
+	return connect.NewResponse(response), nil
+}
+
 func adminInterfaceToProto(item domain.AdminInterface) *adminv1.AdminWireguardInterface {
 	return &adminv1.AdminWireguardInterface{
-		Id:         item.ID,
-		Name:       item.Name,
-		Address:    item.Address,
-		ListenPort: item.ListenPort,
-		PublicKey:  item.PublicKey,
-		Mtu:        item.MTU,
-		Endpoint:   item.Endpoint,
+		Id:                item.ID,
+		Name:              item.Name,
+		Address:           item.Address,
+		ListenPort:        item.ListenPort,
+		PublicKey:         item.PublicKey,
+		Mtu:               item.MTU,
+		Endpoint:          item.Endpoint,
+		PeerKeyPolicy:     item.PeerKeyPolicy,
+		PeerTtlSeconds:    int64(item.PeerTTL / time.Second),
+		MaxDevicesPerUser: item.MaxDevicesPerUser,
+		IsolationMode:     item.IsolationMode,
 	}
 }
@@ -0,0 +1,52 @@
+package connecthandler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"connectrpc.com/connect"
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const maxRequestIDLength = 128
+
+// NewRequestIDInterceptor stores the caller's X-Request-Id, or a new random ID when it is missing or malformed,
+// in the context of every RPC and echoes it in the response header.
+func NewRequestIDInterceptor() connect.Interceptor {
+	return connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			requestID := req.Header().Get(domain.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+
+			response, err := next(domain.WithRequestID(ctx, requestID), req)
+			if response != nil {
+				response.Header().Set(domain.RequestIDHeader, requestID)
+			}
+			return response, err
+		}
+	})
+}
+
+// validRequestID accepts IDs of printable ASCII characters so they can be logged and stored as is.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
@@ -4,28 +4,37 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"connectrpc.com/connect"
 	williamv1 "github.com/nomuken/william/services/server/gen/proto/server/v1"
+	"github.com/nomuken/william/services/server/internal/domain"
 	"github.com/nomuken/william/services/server/internal/usecase"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// UserAuthenticator resolves the email and groups of the caller from verified request headers.
+type UserAuthenticator interface {
+	Identify(header http.Header) (domain.UserIdentity, error)
+}
+
 type WilliamHandler struct {
 	wireguardUsecase usecase.WireguardUsecase
+	authenticator    UserAuthenticator
 }
 
-func NewWilliamHandler(wireguardUsecase usecase.WireguardUsecase) *WilliamHandler {
-	return &WilliamHandler{wireguardUsecase: wireguardUsecase}
+func NewWilliamHandler(wireguardUsecase usecase.WireguardUsecase, authenticator UserAuthenticator) *WilliamHandler {
+	return &WilliamHandler{wireguardUsecase: wireguardUsecase, authenticator: authenticator}
 }
 
 func (handler *WilliamHandler) ListWireguardInterfaces(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[williamv1.ListWireguardInterfacesResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	interfaces, err := handler.wireguardUsecase.ListInterfaces(ctx, email)
+	interfaces, err := handler.wireguardUsecase.ListInterfaces(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -33,12 +42,15 @@ func (handler *WilliamHandler) ListWireguardInterfaces(ctx context.Context, req
 	items := make([]*williamv1.WireguardInterface, 0, len(interfaces))
 	for _, item := range interfaces {
 		items = append(items, &williamv1.WireguardInterface{
-			Id:         item.ID,
-			Name:       item.Name,
-			Address:    item.Address,
-			ListenPort: item.ListenPort,
-			PublicKey:  item.PublicKey,
-			Mtu:        item.MTU,
+			Id:                item.ID,
+			Name:              item.Name,
+			Address:           item.Address,
+			ListenPort:        item.ListenPort,
+			PublicKey:         item.PublicKey,
+			Mtu:               item.MTU,
+			PeerKeyPolicy:     item.PeerKeyPolicy,
+			PeerTtlSeconds:    int64(item.PeerTTL / time.Second),
+			MaxDevicesPerUser: item.MaxDevicesPerUser,
 		})
 	}
 
@@ -47,16 +59,22 @@ func (handler *WilliamHandler) ListWireguardInterfaces(ctx context.Context, req
 }
 
 func (handler *WilliamHandler) CreateWireguardPeer(ctx context.Context, req *connect.Request[williamv1.CreateWireguardPeerRequest]) (*connect.Response[williamv1.CreateWireguardPeerResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	peer, err := handler.wireguardUsecase.CreatePeer(ctx, email, req.Msg.GetWireguardInterfaceId())
+	record, err := handler.wireguardUsecase.CreatePeer(ctx, user, req.Msg.GetWireguardInterfaceId(), req.Msg.GetPublicKey(), req.Msg.GetDeviceName())
 	if err != nil {
 		if errors.Is(err, usecase.ErrPeerAlreadyExists) {
 			return nil, connect.NewError(connect.CodeAlreadyExists, err)
 		}
+		if errors.Is(err, usecase.ErrDeviceLimitReached) {
+			return nil, connect.NewError(connect.CodeResourceExhausted, err)
+		}
+		if errors.Is(err, usecase.ErrInvalidPublicKey) || errors.Is(err, usecase.ErrPublicKeyRequired) || errors.Is(err, usecase.ErrInvalidDeviceName) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		if errors.Is(err, usecase.ErrEmailNotAllowed) {
 			return nil, connect.NewError(connect.CodePermissionDenied, err)
 		}
@@ -67,19 +85,20 @@ func (handler *WilliamHandler) CreateWireguardPeer(ctx context.Context, req *con
 	}
 
 	response := &williamv1.CreateWireguardPeerResponse{
-		PeerId:     peer.ID,
-		PeerConfig: peer.Config,
+		PeerId:     record.PeerID,
+		PeerConfig: record.Config,
+		DeviceName: record.DeviceName,
 	}
 	return connect.NewResponse(response), nil
 }
 
 func (handler *WilliamHandler) GetMyWireguardPeer(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[williamv1.GetMyWireguardPeerResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	record, err := handler.wireguardUsecase.GetPeerByEmail(ctx, email)
+	record, err := handler.wireguardUsecase.GetPeerByEmail(ctx, user)
 	if err != nil {
 		if errors.Is(err, usecase.ErrPeerNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
@@ -93,17 +112,22 @@ func (handler *WilliamHandler) GetMyWireguardPeer(ctx context.Context, req *conn
 	response := &williamv1.GetMyWireguardPeerResponse{
 		PeerId:     record.PeerID,
 		PeerConfig: record.Config,
+		Suspended:  record.SuspendedAt != nil,
+		DeviceName: record.DeviceName,
+	}
+	if record.ExpiresAt != nil {
+		response.ExpiresAt = timestamppb.New(*record.ExpiresAt)
 	}
 	return connect.NewResponse(response), nil
 }
 
 func (handler *WilliamHandler) GetMyWireguardPeerByInterface(ctx context.Context, req *connect.Request[williamv1.GetMyWireguardPeerByInterfaceRequest]) (*connect.Response[williamv1.GetMyWireguardPeerByInterfaceResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	record, err := handler.wireguardUsecase.GetPeerByEmailAndInterface(ctx, email, req.Msg.GetInterfaceId())
+	record, err := handler.wireguardUsecase.GetPeerByEmailAndInterface(ctx, user, req.Msg.GetInterfaceId())
 	if err != nil {
 		if errors.Is(err, usecase.ErrPeerNotFound) {
 			return nil, connect.NewError(connect.CodeNotFound, err)
@@ -120,17 +144,53 @@ func (handler *WilliamHandler) GetMyWireguardPeerByInterface(ctx context.Context
 	response := &williamv1.GetMyWireguardPeerByInterfaceResponse{
 		PeerId:     record.PeerID,
 		PeerConfig: record.Config,
+		Suspended:  record.SuspendedAt != nil,
+		DeviceName: record.DeviceName,
+	}
+	if record.ExpiresAt != nil {
+		response.ExpiresAt = timestamppb.New(*record.ExpiresAt)
 	}
 	return connect.NewResponse(response), nil
 }
 
+func (handler *WilliamHandler) ListMyPeers(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[williamv1.ListMyPeersResponse], error) {
+	user, err := handler.userFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := handler.wireguardUsecase.ListPeers(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]*williamv1.MyWireguardPeer, 0, len(records))
+	for _, record := range records {
+		peer := &williamv1.MyWireguardPeer{
+			PeerId:      record.PeerID,
+			InterfaceId: record.InterfaceID,
+			DeviceName:  record.DeviceName,
+			AllowedIp:   record.AllowedIP,
+			PeerConfig:  record.Config,
+			CreatedAt:   timestamppb.New(record.CreatedAt),
+			Suspended:   record.SuspendedAt != nil,
+		}
+		if record.ExpiresAt != nil {
+			peer.ExpiresAt = timestamppb.New(*record.ExpiresAt)
+		}
+		peers = append(peers, peer)
+	}
+
+	return connect.NewResponse(&williamv1.ListMyPeersResponse{Peers: peers}), nil
+}
+
 func (handler *WilliamHandler) DeleteWireguardPeer(ctx context.Context, req *connect.Request[williamv1.DeleteWireguardPeerRequest]) (*connect.Response[williamv1.DeleteWireguardPeerResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := handler.wireguardUsecase.DeletePeer(ctx, email, req.Msg.GetPeerId()); err != nil {
+	if err := handler.wireguardUsecase.DeletePeer(ctx, user, req.Msg.GetPeerId()); err != nil {
 		if errors.Is(err, usecase.ErrPeerForbidden) {
 			return nil, connect.NewError(connect.CodePermissionDenied, err)
 		}
@@ -143,12 +203,40 @@ func (handler *WilliamHandler) DeleteWireguardPeer(ctx context.Context, req *con
 	return connect.NewResponse(&williamv1.DeleteWireguardPeerResponse{}), nil
 }
 
+func (handler *WilliamHandler) RenewMyWireguardPeer(ctx context.Context, req *connect.Request[williamv1.RenewMyWireguardPeerRequest]) (*connect.Response[williamv1.RenewMyWireguardPeerResponse], error) {
+	user, err := handler.userFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(req.Msg.GetDurationSeconds()) * time.Second
+	record, err := handler.wireguardUsecase.RenewPeer(ctx, user, req.Msg.GetPeerId(), duration)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPeerForbidden) || errors.Is(err, usecase.ErrEmailNotAllowed) {
+			return nil, connect.NewError(connect.CodePermissionDenied, err)
+		}
+		if errors.Is(err, usecase.ErrPeerNotFound) || errors.Is(err, usecase.ErrInterfaceNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, err)
+		}
+		if errors.Is(err, usecase.ErrPeerDoesNotExpire) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		if errors.Is(err, usecase.ErrInvalidRenewDuration) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, err
+	}
+
+	response := &williamv1.RenewMyWireguardPeerResponse{ExpiresAt: timestamppb.New(*record.ExpiresAt)}
+	return connect.NewResponse(response), nil
+}
+
 func (handler *WilliamHandler) ListPeerStatuses(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[williamv1.ListPeerStatusesResponse], error) {
-	email, err := emailFromHeader(req)
+	user, err := handler.userFromRequest(req)
 	if err != nil {
 		return nil, err
 	}
-	statuses, err := handler.wireguardUsecase.ListPeerStatuses(ctx, email)
+	statuses, err := handler.wireguardUsecase.ListPeerStatuses(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -168,10 +256,10 @@ func (handler *WilliamHandler) ListPeerStatuses(ctx context.Context, req *connec
 	return connect.NewResponse(&williamv1.ListPeerStatusesResponse{Statuses: items}), nil
 }
 
-func emailFromHeader(request interface{ Header() http.Header }) (string, error) {
-	email := request.Header().Get("X-Email")
-	if email == "" {
-		return "", connect.NewError(connect.CodeUnauthenticated, errors.New("X-Email header is required"))
+func (handler *WilliamHandler) userFromRequest(request interface{ Header() http.Header }) (domain.UserIdentity, error) {
+	user, err := handler.authenticator.Identify(request.Header())
+	if err != nil {
+		return domain.UserIdentity{}, connect.NewError(connect.CodeUnauthenticated, err)
 	}
-	return email, nil
+	return user, nil
 }
@@ -0,0 +1,507 @@
+package scimhandler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+	"github.com/nomuken/william/services/server/internal/usecase"
+)
+
+const (
+	// PathPrefix is where the SCIM endpoints are mounted.
+	PathPrefix = "/scim/v2/"
+
+	scimContentType  = "application/scim+json"
+	maxScimBodySize  = 1 << 20
+	defaultPageCount = 100
+
+	userSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	listResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	patchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	errorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	serviceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// eqFilterPattern matches the only filter form supported: attribute eq "value".
+var eqFilterPattern = regexp.MustCompile(`(?i)^\s*([a-z.]+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// ScimHandler serves the SCIM 2.0 Users and Groups endpoints used by the identity provider
+// to provision users. Every request must carry the shared bearer token.
+type ScimHandler struct {
+	scimUsecase usecase.ScimUsecase
+	token       string
+	mux         *http.ServeMux
+}
+
+func NewScimHandler(scimUsecase usecase.ScimUsecase, token string) *ScimHandler {
+	handler := &ScimHandler{scimUsecase: scimUsecase, token: token, mux: http.NewServeMux()}
+
+	handler.mux.HandleFunc("GET "+PathPrefix+"ServiceProviderConfig", handler.getServiceProviderConfig)
+
+	handler.mux.HandleFunc("GET "+PathPrefix+"Users", handler.listUsers)
+	handler.mux.HandleFunc("POST "+PathPrefix+"Users", handler.createUser)
+	handler.mux.HandleFunc("GET "+PathPrefix+"Users/{id}", handler.getUser)
+	handler.mux.HandleFunc("PUT "+PathPrefix+"Users/{id}", handler.replaceUser)
+	handler.mux.HandleFunc("PATCH "+PathPrefix+"Users/{id}", handler.patchUser)
+	handler.mux.HandleFunc("DELETE "+PathPrefix+"Users/{id}", handler.deleteUser)
+
+	handler.mux.HandleFunc("GET "+PathPrefix+"Groups", handler.listGroups)
+	handler.mux.HandleFunc("POST "+PathPrefix+"Groups", handler.createGroup)
+	handler.mux.HandleFunc("GET "+PathPrefix+"Groups/{id}", handler.getGroup)
+	handler.mux.HandleFunc("PUT "+PathPrefix+"Groups/{id}", handler.replaceGroup)
+	handler.mux.HandleFunc("PATCH "+PathPrefix+"Groups/{id}", handler.patchGroup)
+	handler.mux.HandleFunc("DELETE "+PathPrefix+"Groups/{id}", handler.deleteGroup)
+
+	return handler
+}
+
+func (handler *ScimHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(handler.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+		writeError(w, http.StatusUnauthorized, "", "bearer token is invalid")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxScimBodySize)
+	handler.mux.ServeHTTP(w, r)
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUserResource struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Meta        *scimMeta   `json:"meta,omitempty"`
+}
+
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimGroupResource struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members"`
+	Meta        *scimMeta    `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func (handler *ScimHandler) getServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(value bool) map[string]any { return map[string]any{"supported": value} }
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{serviceProviderConfigSchema},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": defaultPageCount},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Shared bearer token configured with WILLIAM_SCIM_TOKEN",
+		}},
+	})
+}
+
+func (handler *ScimHandler) listUsers(w http.ResponseWriter, r *http.Request) {
+	userName, err := parseEqFilter(r.URL.Query().Get("filter"), "userName")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+
+	users, err := handler.scimUsecase.ListUsers(r.Context(), userName)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	resources := make([]any, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, toUserResource(r, user))
+	}
+	writeList(w, r, resources)
+}
+
+func (handler *ScimHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	user, err := handler.scimUsecase.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResource(r, user))
+}
+
+func (handler *ScimHandler) createUser(w http.ResponseWriter, r *http.Request) {
+	var resource scimUserResource
+	if err := decodeJSON(r, &resource); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	user, err := handler.scimUsecase.CreateUser(r.Context(), fromUserResource(resource))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	created := toUserResource(r, user)
+	w.Header().Set("Location", created.Meta.Location)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (handler *ScimHandler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	var resource scimUserResource
+	if err := decodeJSON(r, &resource); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	user := fromUserResource(resource)
+	user.ID = r.PathValue("id")
+	user, err := handler.scimUsecase.ReplaceUser(r.Context(), user)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResource(r, user))
+}
+
+func (handler *ScimHandler) patchUser(w http.ResponseWriter, r *http.Request) {
+	var request scimPatchRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	user, err := handler.scimUsecase.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	for _, operation := range request.Operations {
+		if err := applyUserPatch(&user, operation); err != nil {
+			writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+	}
+
+	user, err = handler.scimUsecase.ReplaceUser(r.Context(), user)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResource(r, user))
+}
+
+func (handler *ScimHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := handler.scimUsecase.DeleteUser(r.Context(), r.PathValue("id")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *ScimHandler) listGroups(w http.ResponseWriter, r *http.Request) {
+	displayName, err := parseEqFilter(r.URL.Query().Get("filter"), "displayName")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+
+	groups, err := handler.scimUsecase.ListGroups(r.Context(), displayName)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	excludeMembers := strings.EqualFold(r.URL.Query().Get("excludedAttributes"), "members")
+	resources := make([]any, 0, len(groups))
+	for _, group := range groups {
+		if excludeMembers {
+			group.MemberIDs = nil
+		}
+		resources = append(resources, toGroupResource(r, group))
+	}
+	writeList(w, r, resources)
+}
+
+func (handler *ScimHandler) getGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := handler.scimUsecase.GetGroup(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toGroupResource(r, group))
+}
+
+func (handler *ScimHandler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var resource scimGroupResource
+	if err := decodeJSON(r, &resource); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	group, err := handler.scimUsecase.CreateGroup(r.Context(), fromGroupResource(resource))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	created := toGroupResource(r, group)
+	w.Header().Set("Location", created.Meta.Location)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (handler *ScimHandler) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	var resource scimGroupResource
+	if err := decodeJSON(r, &resource); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	group := fromGroupResource(resource)
+	group.ID = r.PathValue("id")
+	group, err := handler.scimUsecase.ReplaceGroup(r.Context(), group)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toGroupResource(r, group))
+}
+
+func (handler *ScimHandler) patchGroup(w http.ResponseWriter, r *http.Request) {
+	var request scimPatchRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	group, err := handler.scimUsecase.GetGroup(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	for _, operation := range request.Operations {
+		if err := applyGroupPatch(&group, operation); err != nil {
+			writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+	}
+
+	group, err = handler.scimUsecase.ReplaceGroup(r.Context(), group)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toGroupResource(r, group))
+}
+
+func (handler *ScimHandler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := handler.scimUsecase.DeleteGroup(r.Context(), r.PathValue("id")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toUserResource(r *http.Request, user domain.ScimUser) scimUserResource {
+	active := user.Active
+	return scimUserResource{
+		Schemas:     []string{userSchema},
+		ID:          user.ID,
+		ExternalID:  user.ExternalID,
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		Active:      &active,
+		Emails:      []scimEmail{{Value: user.UserName, Type: "work", Primary: true}},
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: user.UpdatedAt.UTC().Format(time.RFC3339),
+			Location:     resourceLocation(r, "Users", user.ID),
+		},
+	}
+}
+
+// fromUserResource reads a user from a request body. A user without an active attribute is active.
+func fromUserResource(resource scimUserResource) domain.ScimUser {
+	user := domain.ScimUser{
+		UserName:    resource.UserName,
+		ExternalID:  resource.ExternalID,
+		DisplayName: resource.DisplayName,
+		Active:      resource.Active == nil || *resource.Active,
+	}
+	if user.UserName == "" {
+		for _, email := range resource.Emails {
+			if email.Primary || user.UserName == "" {
+				user.UserName = email.Value
+			}
+		}
+	}
+	return user
+}
+
+func toGroupResource(r *http.Request, group domain.ScimGroup) scimGroupResource {
+	members := make([]scimMember, 0, len(group.MemberIDs))
+	for _, memberID := range group.MemberIDs {
+		members = append(members, scimMember{Value: memberID})
+	}
+	return scimGroupResource{
+		Schemas:     []string{groupSchema},
+		ID:          group.ID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     members,
+		Meta: &scimMeta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: group.UpdatedAt.UTC().Format(time.RFC3339),
+			Location:     resourceLocation(r, "Groups", group.ID),
+		},
+	}
+}
+
+func fromGroupResource(resource scimGroupResource) domain.ScimGroup {
+	group := domain.ScimGroup{
+		DisplayName: resource.DisplayName,
+		ExternalID:  resource.ExternalID,
+	}
+	for _, member := range resource.Members {
+		group.MemberIDs = append(group.MemberIDs, member.Value)
+	}
+	return group
+}
+
+// resourceLocation builds the absolute URL of a resource, honouring X-Forwarded-Proto set by the proxy.
+func resourceLocation(r *http.Request, resourceType string, id string) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s%s/%s", scheme, r.Host, PathPrefix, resourceType, id)
+}
+
+// parseEqFilter returns the value compared to attribute by filter, or "" when filter is empty.
+func parseEqFilter(filter string, attribute string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+	match := eqFilterPattern.FindStringSubmatch(filter)
+	if match == nil || !strings.EqualFold(match[1], attribute) {
+		return "", fmt.Errorf("only %s eq \"value\" filters are supported", attribute)
+	}
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		return "", fmt.Errorf("invalid filter value: %w", err)
+	}
+	if value == "" {
+		return "", errors.New("filter value must not be empty")
+	}
+	return value, nil
+}
+
+// writeList pages resources with the 1-based startIndex and count query parameters.
+func writeList(w http.ResponseWriter, r *http.Request, resources []any) {
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = defaultPageCount
+	}
+
+	page := []any{}
+	if start := startIndex - 1; start < len(resources) {
+		page = resources[start:min(start+count, len(resources))]
+	}
+	writeJSON(w, http.StatusOK, scimListResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	})
+}
+
+func decodeJSON(r *http.Request, target any) error {
+	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("scim: write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, scimType string, detail string) {
+	writeJSON(w, status, scimError{
+		Schemas:  []string{errorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func writeUsecaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrScimUserNotFound), errors.Is(err, usecase.ErrScimGroupNotFound):
+		writeError(w, http.StatusNotFound, "", err.Error())
+	case errors.Is(err, domain.ErrScimConflict):
+		writeError(w, http.StatusConflict, "uniqueness", err.Error())
+	case errors.Is(err, usecase.ErrInvalidScimResource):
+		writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		log.Printf("scim: %v", err)
+		writeError(w, http.StatusInternalServerError, "", "internal error")
+	}
+}
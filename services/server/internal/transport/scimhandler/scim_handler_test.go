@@ -0,0 +1,512 @@
+package scimhandler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+	"github.com/nomuken/william/services/server/internal/usecase"
+)
+
+const testToken = "scim-secret"
+
+// memoryScimStore keeps SCIM users and groups in memory, with the uniqueness rules of the SQL store.
+type memoryScimStore struct {
+	nextID int
+	users  []domain.ScimUser
+	groups []domain.ScimGroup
+}
+
+func (store *memoryScimStore) id() string {
+	store.nextID++
+	return fmt.Sprintf("id-%d", store.nextID)
+}
+
+func (store *memoryScimStore) ListUsers(ctx context.Context, userName string) ([]domain.ScimUser, error) {
+	var users []domain.ScimUser
+	for _, user := range store.users {
+		if userName == "" || strings.EqualFold(user.UserName, userName) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (store *memoryScimStore) GetUser(ctx context.Context, id string) (domain.ScimUser, error) {
+	index := slices.IndexFunc(store.users, func(user domain.ScimUser) bool { return user.ID == id })
+	if index < 0 {
+		return domain.ScimUser{}, sql.ErrNoRows
+	}
+	return store.users[index], nil
+}
+
+func (store *memoryScimStore) CreateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	if slices.ContainsFunc(store.users, func(existing domain.ScimUser) bool { return strings.EqualFold(existing.UserName, user.UserName) }) {
+		return domain.ScimUser{}, domain.ErrScimConflict
+	}
+	user.ID = store.id()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	store.users = append(store.users, user)
+	return user, nil
+}
+
+func (store *memoryScimStore) UpdateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	index := slices.IndexFunc(store.users, func(existing domain.ScimUser) bool { return existing.ID == user.ID })
+	if index < 0 {
+		return domain.ScimUser{}, sql.ErrNoRows
+	}
+	user.CreatedAt = store.users[index].CreatedAt
+	user.UpdatedAt = time.Now()
+	store.users[index] = user
+	return user, nil
+}
+
+func (store *memoryScimStore) DeleteUser(ctx context.Context, id string) error {
+	index := slices.IndexFunc(store.users, func(user domain.ScimUser) bool { return user.ID == id })
+	if index < 0 {
+		return sql.ErrNoRows
+	}
+	store.users = slices.Delete(store.users, index, index+1)
+	return nil
+}
+
+func (store *memoryScimStore) ListGroups(ctx context.Context, displayName string) ([]domain.ScimGroup, error) {
+	var groups []domain.ScimGroup
+	for _, group := range store.groups {
+		if displayName == "" || group.DisplayName == displayName {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+func (store *memoryScimStore) GetGroup(ctx context.Context, id string) (domain.ScimGroup, error) {
+	index := slices.IndexFunc(store.groups, func(group domain.ScimGroup) bool { return group.ID == id })
+	if index < 0 {
+		return domain.ScimGroup{}, sql.ErrNoRows
+	}
+	group := store.groups[index]
+	group.MemberIDs = slices.Clone(group.MemberIDs)
+	return group, nil
+}
+
+func (store *memoryScimStore) CreateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	if slices.ContainsFunc(store.groups, func(existing domain.ScimGroup) bool { return existing.DisplayName == group.DisplayName }) {
+		return domain.ScimGroup{}, domain.ErrScimConflict
+	}
+	group.ID = store.id()
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = group.CreatedAt
+	store.groups = append(store.groups, group)
+	return group, nil
+}
+
+func (store *memoryScimStore) UpdateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	index := slices.IndexFunc(store.groups, func(existing domain.ScimGroup) bool { return existing.ID == group.ID })
+	if index < 0 {
+		return domain.ScimGroup{}, sql.ErrNoRows
+	}
+	group.CreatedAt = store.groups[index].CreatedAt
+	group.UpdatedAt = time.Now()
+	store.groups[index] = group
+	return group, nil
+}
+
+func (store *memoryScimStore) DeleteGroup(ctx context.Context, id string) error {
+	index := slices.IndexFunc(store.groups, func(group domain.ScimGroup) bool { return group.ID == id })
+	if index < 0 {
+		return sql.ErrNoRows
+	}
+	store.groups = slices.Delete(store.groups, index, index+1)
+	return nil
+}
+
+// fakeAccess answers which interfaces and peers an email has, and records what the revoker is asked to revoke.
+type fakeAccess struct {
+	interfaceIDs map[string][]string
+	peerIDs      map[string][]string
+	failRevoke   bool
+	revoked      []string
+}
+
+type fakeAllowedEmailStore struct {
+	domain.AllowedEmailStore
+	access *fakeAccess
+}
+
+func (store fakeAllowedEmailStore) ListInterfaceIDsByEmail(ctx context.Context, email string) ([]string, error) {
+	return store.access.interfaceIDs[email], nil
+}
+
+type fakePeerStore struct {
+	domain.PeerStore
+	access *fakeAccess
+}
+
+func (store fakePeerStore) ListByEmail(ctx context.Context, email string) ([]domain.PeerRecord, error) {
+	var records []domain.PeerRecord
+	for _, peerID := range store.access.peerIDs[email] {
+		records = append(records, domain.PeerRecord{Email: email, PeerID: peerID})
+	}
+	return records, nil
+}
+
+func (access *fakeAccess) DeleteAllowedEmail(ctx context.Context, interfaceID string, email string) ([]string, error) {
+	if access.failRevoke {
+		return nil, errors.New("admin-server is unavailable")
+	}
+	access.revoked = append(access.revoked, "allowed email "+interfaceID+" "+email)
+	return nil, nil
+}
+
+func (access *fakeAccess) DeletePeer(ctx context.Context, peerID string) error {
+	access.revoked = append(access.revoked, "peer "+peerID)
+	return nil
+}
+
+// scimClient is a minimal SCIM client for the handler under test.
+type scimClient struct {
+	t       *testing.T
+	baseURL string
+	token   string
+}
+
+func newScimTest(t *testing.T) (*scimClient, *memoryScimStore, *fakeAccess) {
+	t.Helper()
+	store := &memoryScimStore{}
+	access := &fakeAccess{interfaceIDs: make(map[string][]string), peerIDs: make(map[string][]string)}
+	service := usecase.NewScimService(store, fakeAllowedEmailStore{access: access}, fakePeerStore{access: access}, access)
+
+	server := httptest.NewServer(NewScimHandler(service, testToken))
+	t.Cleanup(server.Close)
+	return &scimClient{t: t, baseURL: server.URL + strings.TrimSuffix(PathPrefix, "/"), token: testToken}, store, access
+}
+
+// do sends body as JSON and decodes the JSON response into result when it is not nil.
+func (client *scimClient) do(method string, path string, body any, result any) int {
+	client.t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			client.t.Fatal(err)
+		}
+	}
+	request, err := http.NewRequest(method, client.baseURL+path, &reader)
+	if err != nil {
+		client.t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", scimContentType)
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		client.t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if result != nil && response.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			client.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return response.StatusCode
+}
+
+func (client *scimClient) createUser(userName string) scimUserResource {
+	client.t.Helper()
+	var created scimUserResource
+	status := client.do(http.MethodPost, "/Users", scimUserResource{Schemas: []string{userSchema}, UserName: userName}, &created)
+	if status != http.StatusCreated {
+		client.t.Fatalf("create user %s: status %d", userName, status)
+	}
+	return created
+}
+
+type scimListUsers struct {
+	TotalResults int                `json:"totalResults"`
+	Resources    []scimUserResource `json:"Resources"`
+}
+
+type scimListGroups struct {
+	TotalResults int                 `json:"totalResults"`
+	Resources    []scimGroupResource `json:"Resources"`
+}
+
+func TestScimHandlerRejectsInvalidBearerToken(t *testing.T) {
+	client, _, _ := newScimTest(t)
+
+	for _, authorization := range []string{"", "Bearer wrong", "Basic " + testToken, testToken} {
+		request, err := http.NewRequest(http.MethodGet, client.baseURL+"/Users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", authorization, response.StatusCode)
+		}
+		if response.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: no WWW-Authenticate challenge", authorization)
+		}
+	}
+
+	if status := client.do(http.MethodGet, "/ServiceProviderConfig", nil, nil); status != http.StatusOK {
+		t.Errorf("valid token: status %d, want 200", status)
+	}
+}
+
+func TestScimHandlerUsers(t *testing.T) {
+	client, _, access := newScimTest(t)
+
+	created := client.createUser("alice@example.com")
+	if created.ID == "" || created.Active == nil || !*created.Active || !strings.HasSuffix(created.Meta.Location, "/Users/"+created.ID) {
+		t.Errorf("created user = %+v", created)
+	}
+	if status := client.do(http.MethodPost, "/Users", scimUserResource{UserName: "Alice@example.com"}, nil); status != http.StatusConflict {
+		t.Errorf("duplicate user name: status %d, want 409", status)
+	}
+	if status := client.do(http.MethodPost, "/Users", scimUserResource{}, nil); status != http.StatusBadRequest {
+		t.Errorf("user without name: status %d, want 400", status)
+	}
+	client.createUser("bob@example.com")
+
+	var fetched scimUserResource
+	if status := client.do(http.MethodGet, "/Users/"+created.ID, nil, &fetched); status != http.StatusOK || fetched.UserName != "alice@example.com" {
+		t.Errorf("get user: status %d, user %+v", status, fetched)
+	}
+
+	var list scimListUsers
+	client.do(http.MethodGet, "/Users", nil, &list)
+	if list.TotalResults != 2 {
+		t.Errorf("listed %d users, want 2", list.TotalResults)
+	}
+
+	var replaced scimUserResource
+	status := client.do(http.MethodPut, "/Users/"+created.ID, scimUserResource{UserName: "alice@example.com", DisplayName: "Alice"}, &replaced)
+	if status != http.StatusOK || replaced.DisplayName != "Alice" || !*replaced.Active {
+		t.Errorf("replace user: status %d, user %+v", status, replaced)
+	}
+	if status := client.do(http.MethodPut, "/Users/missing", scimUserResource{UserName: "carol@example.com"}, nil); status != http.StatusNotFound {
+		t.Errorf("replace missing user: status %d, want 404", status)
+	}
+
+	access.interfaceIDs["alice@example.com"] = []string{"wg0"}
+	access.peerIDs["alice@example.com"] = []string{"peer1"}
+	if status := client.do(http.MethodDelete, "/Users/"+created.ID, nil, nil); status != http.StatusNoContent {
+		t.Errorf("delete user: status %d, want 204", status)
+	}
+	if want := []string{"allowed email wg0 alice@example.com", "peer peer1"}; !slices.Equal(access.revoked, want) {
+		t.Errorf("revoked %v, want %v", access.revoked, want)
+	}
+	if status := client.do(http.MethodGet, "/Users/"+created.ID, nil, nil); status != http.StatusNotFound {
+		t.Errorf("get deleted user: status %d, want 404", status)
+	}
+	if status := client.do(http.MethodDelete, "/Users/"+created.ID, nil, nil); status != http.StatusNotFound {
+		t.Errorf("delete deleted user: status %d, want 404", status)
+	}
+}
+
+func TestScimHandlerPatchDeactivationRevokesAccess(t *testing.T) {
+	client, store, access := newScimTest(t)
+	user := client.createUser("alice@example.com")
+	access.interfaceIDs["alice@example.com"] = []string{"wg0", "wg1"}
+	access.peerIDs["alice@example.com"] = []string{"peer1", "peer2"}
+
+	deactivate := scimPatchRequest{
+		Schemas:    []string{patchOpSchema},
+		Operations: []scimPatchOperation{{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)}},
+	}
+
+	access.failRevoke = true
+	if status := client.do(http.MethodPatch, "/Users/"+user.ID, deactivate, nil); status != http.StatusInternalServerError {
+		t.Errorf("patch with a failing revoker: status %d, want 500", status)
+	}
+	if stored, _ := store.GetUser(context.Background(), user.ID); !stored.Active {
+		t.Error("user was deactivated although revoking failed")
+	}
+
+	access.failRevoke = false
+	var patched scimUserResource
+	if status := client.do(http.MethodPatch, "/Users/"+user.ID, deactivate, &patched); status != http.StatusOK || *patched.Active {
+		t.Fatalf("patch: status %d, user %+v", status, patched)
+	}
+	want := []string{
+		"allowed email wg0 alice@example.com",
+		"allowed email wg1 alice@example.com",
+		"peer peer1",
+		"peer peer2",
+	}
+	if !slices.Equal(access.revoked, want) {
+		t.Errorf("revoked %v, want %v", access.revoked, want)
+	}
+
+	// Deactivating an inactive user or reactivating it revokes nothing.
+	access.revoked = nil
+	client.do(http.MethodPatch, "/Users/"+user.ID, deactivate, nil)
+	reactivate := scimPatchRequest{Operations: []scimPatchOperation{{Op: "replace", Value: json.RawMessage(`{"active":true}`)}}}
+	if status := client.do(http.MethodPatch, "/Users/"+user.ID, reactivate, &patched); status != http.StatusOK || !*patched.Active {
+		t.Errorf("reactivate: status %d, user %+v", status, patched)
+	}
+	if len(access.revoked) != 0 {
+		t.Errorf("revoked %v, want nothing", access.revoked)
+	}
+
+	unsupported := scimPatchRequest{Operations: []scimPatchOperation{{Op: "replace", Path: "title", Value: json.RawMessage(`"x"`)}}}
+	if status := client.do(http.MethodPatch, "/Users/"+user.ID, unsupported, nil); status != http.StatusBadRequest {
+		t.Errorf("patch of an unsupported attribute: status %d, want 400", status)
+	}
+}
+
+func TestScimHandlerGroupMembership(t *testing.T) {
+	client, _, _ := newScimTest(t)
+	alice := client.createUser("alice@example.com")
+	bob := client.createUser("bob@example.com")
+	carol := client.createUser("carol@example.com")
+
+	var group scimGroupResource
+	status := client.do(http.MethodPost, "/Groups", scimGroupResource{
+		Schemas:     []string{groupSchema},
+		DisplayName: "engineering",
+		Members:     []scimMember{{Value: alice.ID}},
+	}, &group)
+	if status != http.StatusCreated {
+		t.Fatalf("create group: status %d", status)
+	}
+	if status := client.do(http.MethodPost, "/Groups", scimGroupResource{DisplayName: "engineering"}, nil); status != http.StatusConflict {
+		t.Errorf("duplicate group: status %d, want 409", status)
+	}
+
+	members := func() []string {
+		t.Helper()
+		var fetched scimGroupResource
+		if status := client.do(http.MethodGet, "/Groups/"+group.ID, nil, &fetched); status != http.StatusOK {
+			t.Fatalf("get group: status %d", status)
+		}
+		ids := make([]string, 0, len(fetched.Members))
+		for _, member := range fetched.Members {
+			ids = append(ids, member.Value)
+		}
+		return ids
+	}
+	patch := func(operations ...scimPatchOperation) {
+		t.Helper()
+		request := scimPatchRequest{Schemas: []string{patchOpSchema}, Operations: operations}
+		if status := client.do(http.MethodPatch, "/Groups/"+group.ID, request, nil); status != http.StatusOK {
+			t.Fatalf("patch group: status %d", status)
+		}
+	}
+	memberValues := func(ids ...string) json.RawMessage {
+		values := make([]scimMember, 0, len(ids))
+		for _, id := range ids {
+			values = append(values, scimMember{Value: id})
+		}
+		raw, _ := json.Marshal(values)
+		return raw
+	}
+
+	patch(scimPatchOperation{Op: "add", Path: "members", Value: memberValues(bob.ID, alice.ID)})
+	if got, want := members(), []string{alice.ID, bob.ID}; !slices.Equal(got, want) {
+		t.Errorf("after add: members %v, want %v", got, want)
+	}
+
+	patch(scimPatchOperation{Op: "remove", Path: fmt.Sprintf(`members[value eq "%s"]`, alice.ID)})
+	if got, want := members(), []string{bob.ID}; !slices.Equal(got, want) {
+		t.Errorf("after filtered remove: members %v, want %v", got, want)
+	}
+
+	patch(scimPatchOperation{Op: "replace", Path: "members", Value: memberValues(carol.ID, alice.ID)})
+	if got, want := members(), []string{carol.ID, alice.ID}; !slices.Equal(got, want) {
+		t.Errorf("after replace: members %v, want %v", got, want)
+	}
+
+	patch(scimPatchOperation{Op: "remove", Path: "members", Value: memberValues(carol.ID)})
+	if got, want := members(), []string{alice.ID}; !slices.Equal(got, want) {
+		t.Errorf("after remove: members %v, want %v", got, want)
+	}
+
+	var replaced scimGroupResource
+	status = client.do(http.MethodPut, "/Groups/"+group.ID, scimGroupResource{DisplayName: "platform", Members: []scimMember{{Value: bob.ID}}}, &replaced)
+	if status != http.StatusOK || replaced.DisplayName != "platform" {
+		t.Errorf("replace group: status %d, group %+v", status, replaced)
+	}
+	if got, want := members(), []string{bob.ID}; !slices.Equal(got, want) {
+		t.Errorf("after put: members %v, want %v", got, want)
+	}
+
+	var list scimListGroups
+	client.do(http.MethodGet, `/Groups?excludedAttributes=members&filter=displayName+eq+%22platform%22`, nil, &list)
+	if list.TotalResults != 1 || len(list.Resources[0].Members) != 0 {
+		t.Errorf("list groups without members = %+v", list)
+	}
+
+	if status := client.do(http.MethodDelete, "/Groups/"+group.ID, nil, nil); status != http.StatusNoContent {
+		t.Errorf("delete group: status %d, want 204", status)
+	}
+	if status := client.do(http.MethodGet, "/Groups/"+group.ID, nil, nil); status != http.StatusNotFound {
+		t.Errorf("get deleted group: status %d, want 404", status)
+	}
+}
+
+func TestScimHandlerFiltersUsersByUserName(t *testing.T) {
+	client, _, _ := newScimTest(t)
+	client.createUser("alice@example.com")
+	client.createUser("bob@example.com")
+
+	var list scimListUsers
+	status := client.do(http.MethodGet, `/Users?filter=userName+eq+%22bob%40example.com%22`, nil, &list)
+	if status != http.StatusOK || list.TotalResults != 1 || list.Resources[0].UserName != "bob@example.com" {
+		t.Errorf("filtered list: status %d, %+v", status, list)
+	}
+
+	var scimErr scimError
+	status = client.do(http.MethodGet, `/Users?filter=userName+co+%22bob%22`, nil, &scimErr)
+	if status != http.StatusBadRequest || scimErr.ScimType != "invalidFilter" {
+		t.Errorf("unsupported filter: status %d, error %+v", status, scimErr)
+	}
+}
+
+func TestParseEqFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    string
+		wantErr bool
+	}{
+		{filter: "", want: ""},
+		{filter: "   ", want: ""},
+		{filter: `userName eq "alice@example.com"`, want: "alice@example.com"},
+		{filter: `  USERNAME EQ "alice@example.com"  `, want: "alice@example.com"},
+		{filter: `userName eq "quote\"d"`, want: `quote"d`},
+		{filter: `userName eq "back\\slash"`, want: `back\slash`},
+		{filter: `displayName eq "alice"`, wantErr: true},
+		{filter: `userName co "alice"`, wantErr: true},
+		{filter: `userName eq alice`, wantErr: true},
+		{filter: `userName eq ""`, wantErr: true},
+		{filter: `userName eq "a" and userName eq "b"`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseEqFilter(test.filter, "userName")
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("parseEqFilter(%q) = %q, %v; want %q, error %v", test.filter, got, err, test.want, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package scimhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+// memberFilterPattern matches paths that select one member, such as members[value eq "id"].
+var memberFilterPattern = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyUserPatch applies an add, replace or remove operation on the userName, displayName,
+// externalId or active attribute. Without a path, value is an object of attributes to replace.
+func applyUserPatch(user *domain.ScimUser, operation scimPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("unsupported patch op %q", operation.Op)
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return errors.New("remove requires a path")
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return fmt.Errorf("patch value must be an object: %w", err)
+		}
+		for path, value := range attributes {
+			if err := setUserAttribute(user, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if op == "remove" {
+		switch strings.ToLower(operation.Path) {
+		case "displayname":
+			user.DisplayName = ""
+		case "externalid":
+			user.ExternalID = ""
+		default:
+			return fmt.Errorf("attribute %q cannot be removed", operation.Path)
+		}
+		return nil
+	}
+	return setUserAttribute(user, operation.Path, operation.Value)
+}
+
+func setUserAttribute(user *domain.ScimUser, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "username":
+		return json.Unmarshal(value, &user.UserName)
+	case "displayname":
+		return json.Unmarshal(value, &user.DisplayName)
+	case "externalid":
+		return json.Unmarshal(value, &user.ExternalID)
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		user.Active = active
+		return nil
+	default:
+		return fmt.Errorf("attribute %q is not supported", path)
+	}
+}
+
+// parseBool accepts JSON booleans as well as the "True" and "False" strings some identity providers send.
+func parseBool(value json.RawMessage) (bool, error) {
+	var result bool
+	if err := json.Unmarshal(value, &result); err == nil {
+		return result, nil
+	}
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return false, errors.New("active must be a boolean")
+	}
+	result, err := strconv.ParseBool(text)
+	if err != nil {
+		return false, errors.New("active must be a boolean")
+	}
+	return result, nil
+}
+
+// applyGroupPatch applies an operation on the displayName, externalId or members of a group.
+// Members are added, removed or replaced by value; a members[value eq "id"] path removes one member.
+func applyGroupPatch(group *domain.ScimGroup, operation scimPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	path := strings.ToLower(operation.Path)
+
+	if match := memberFilterPattern.FindStringSubmatch(operation.Path); match != nil {
+		if op != "remove" {
+			return fmt.Errorf("unsupported patch op %q for %s", operation.Op, operation.Path)
+		}
+		group.MemberIDs = slices.DeleteFunc(group.MemberIDs, func(memberID string) bool {
+			return memberID == match[1]
+		})
+		return nil
+	}
+
+	switch {
+	case path == "" && (op == "add" || op == "replace"):
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return fmt.Errorf("patch value must be an object: %w", err)
+		}
+		for attribute, value := range attributes {
+			if err := applyGroupPatch(group, scimPatchOperation{Op: op, Path: attribute, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case path == "displayname" && (op == "add" || op == "replace"):
+		return json.Unmarshal(operation.Value, &group.DisplayName)
+	case path == "externalid" && (op == "add" || op == "replace"):
+		return json.Unmarshal(operation.Value, &group.ExternalID)
+	case path == "externalid" && op == "remove":
+		group.ExternalID = ""
+		return nil
+	case path == "members":
+		var members []scimMember
+		if len(operation.Value) > 0 {
+			if err := json.Unmarshal(operation.Value, &members); err != nil {
+				return fmt.Errorf("members must be a list: %w", err)
+			}
+		}
+		switch op {
+		case "add":
+			for _, member := range members {
+				if !slices.Contains(group.MemberIDs, member.Value) {
+					group.MemberIDs = append(group.MemberIDs, member.Value)
+				}
+			}
+		case "replace":
+			group.MemberIDs = nil
+			for _, member := range members {
+				group.MemberIDs = append(group.MemberIDs, member.Value)
+			}
+		case "remove":
+			if len(members) == 0 {
+				group.MemberIDs = nil
+				return nil
+			}
+			group.MemberIDs = slices.DeleteFunc(group.MemberIDs, func(memberID string) bool {
+				return slices.ContainsFunc(members, func(member scimMember) bool { return member.Value == memberID })
+			})
+		default:
+			return fmt.Errorf("unsupported patch op %q", operation.Op)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported patch op %q for path %q", operation.Op, operation.Path)
+	}
+}
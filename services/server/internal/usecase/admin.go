@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/netip"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/nomuken/william/services/server/internal/domain"
 )
@@ -17,23 +20,38 @@ type AdminUsecase interface {
 	CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.AdminInterface, error)
 	UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.AdminInterface, error)
 	DeleteInterface(ctx context.Context, interfaceID string) error
+	RotateInterfaceKey(ctx context.Context, interfaceID string) (domain.AdminInterface, []string, error)
 	ListAllowedEmails(ctx context.Context, interfaceID string) ([]domain.AllowedEmail, error)
-	CreateAllowedEmail(ctx context.Context, interfaceID string, email string) error
-	DeleteAllowedEmail(ctx context.Context, interfaceID string, email string) error
+	CreateAllowedEmail(ctx context.Context, interfaceID string, email string, ruleType string) error
+	PreviewAllowedEmailRule(ctx context.Context, ruleType string, pattern string) ([]string, error)
+	ListInterfaceGroups(ctx context.Context, interfaceID string) ([]domain.InterfaceGroup, error)
+	CreateInterfaceGroup(ctx context.Context, interfaceID string, groupName string) error
+	DeleteInterfaceGroup(ctx context.Context, interfaceID string, groupName string, suspendPeers bool) ([]string, error)
+	DeleteAllowedEmail(ctx context.Context, interfaceID string, email string, suspendPeers bool) ([]string, error)
 	ListPeers(ctx context.Context, interfaceID string) ([]domain.PeerRecord, error)
 	DeletePeer(ctx context.Context, peerID string) error
-	CreateWireguardPeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (domain.WireguardPeer, error)
+	SuspendPeer(ctx context.Context, peerID string) error
+	ResumePeer(ctx context.Context, peerID string) error
+	CreateWireguardPeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string, publicKey string, address string) (domain.WireguardPeer, error)
 	DeleteWireguardPeer(ctx context.Context, peerID string) error
 	UpdateWireguardPeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error
+	SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error
+	RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error
+	EnsureFirewallChain(ctx context.Context) error
 	ListInterfaceRoutes(ctx context.Context, interfaceID string) ([]domain.InterfaceRoute, error)
-	CreateInterfaceRoute(ctx context.Context, interfaceID string, cidr string) error
-	DeleteInterfaceRoute(ctx context.Context, interfaceID string, cidr string) error
+	CreateInterfaceRoute(ctx context.Context, interfaceID string, rule domain.AccessRule) error
+	DeleteInterfaceRoute(ctx context.Context, interfaceID string, rule domain.AccessRule) error
 	ListPeerRoutes(ctx context.Context, peerID string) ([]domain.PeerRoute, error)
-	CreatePeerRoute(ctx context.Context, peerID string, cidr string) error
-	DeletePeerRoute(ctx context.Context, peerID string, cidr string) error
+	CreatePeerRoute(ctx context.Context, peerID string, rule domain.AccessRule) error
+	DeletePeerRoute(ctx context.Context, peerID string, rule domain.AccessRule) error
 	ListPeerStats(ctx context.Context) ([]domain.PeerStat, error)
 	GetFirewallRules(ctx context.Context) (string, error)
 	ListWireguardConfigs(ctx context.Context, interfaceID string) ([]domain.WireguardConfig, error)
+	ListIPAllocations(ctx context.Context, interfaceID string) ([]domain.IPAllocation, []domain.IPReservation, error)
+	CreateIPReservation(ctx context.Context, interfaceID string, cidr string, description string) error
+	DeleteIPReservation(ctx context.Context, interfaceID string, cidr string) error
+	ListAuditEvents(ctx context.Context, filter domain.AuditEventFilter) ([]domain.AuditEvent, int64, error)
+	PlanWireguardReconcile(ctx context.Context) ([]domain.ReconcileChange, error)
 }
 
 type AdminService struct {
@@ -43,9 +61,14 @@ type AdminService struct {
 	allowedEmailStore   domain.AllowedEmailStore
 	interfaceRouteStore domain.InterfaceRouteStore
 	peerRouteStore      domain.PeerRouteStore
+	ipAllocationStore   domain.IPAllocationStore
+	groupStore          domain.GroupStore
+	transactor          domain.Transactor
+	reconciler          domain.WireguardReconciler
+	auditor             *Auditor
 }
 
-func NewAdminService(repository domain.WireguardRepository, peerStore domain.PeerStore, interfaceStore domain.InterfaceStore, allowedEmailStore domain.AllowedEmailStore, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore) *AdminService {
+func NewAdminService(repository domain.WireguardRepository, peerStore domain.PeerStore, interfaceStore domain.InterfaceStore, allowedEmailStore domain.AllowedEmailStore, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore, ipAllocationStore domain.IPAllocationStore, groupStore domain.GroupStore, transactor domain.Transactor, reconciler domain.WireguardReconciler, auditor *Auditor) *AdminService {
 	return &AdminService{
 		repository:          repository,
 		peerStore:           peerStore,
@@ -53,6 +76,11 @@ func NewAdminService(repository domain.WireguardRepository, peerStore domain.Pee
 		allowedEmailStore:   allowedEmailStore,
 		interfaceRouteStore: interfaceRouteStore,
 		peerRouteStore:      peerRouteStore,
+		ipAllocationStore:   ipAllocationStore,
+		groupStore:          groupStore,
+		transactor:          transactor,
+		reconciler:          reconciler,
+		auditor:             auditor,
 	}
 }
 
@@ -69,13 +97,17 @@ func (service *AdminService) ListInterfaces(ctx context.Context) ([]domain.Admin
 			return nil, err
 		}
 		items = append(items, domain.AdminInterface{
-			ID:         iface.ID,
-			Name:       config.Name,
-			Address:    iface.Address,
-			ListenPort: iface.ListenPort,
-			PublicKey:  iface.PublicKey,
-			MTU:        iface.MTU,
-			Endpoint:   config.Endpoint,
+			ID:                iface.ID,
+			Name:              config.Name,
+			Address:           iface.Address,
+			ListenPort:        iface.ListenPort,
+			PublicKey:         iface.PublicKey,
+			MTU:               iface.MTU,
+			Endpoint:          config.Endpoint,
+			PeerKeyPolicy:     config.PeerKeyPolicy,
+			PeerTTL:           config.PeerTTL,
+			MaxDevicesPerUser: config.MaxDevicesPerUser,
+			IsolationMode:     config.IsolationMode,
 		})
 	}
 
@@ -97,13 +129,17 @@ func (service *AdminService) GetInterface(ctx context.Context, interfaceID strin
 	}
 
 	return domain.AdminInterface{
-		ID:         iface.ID,
-		Name:       config.Name,
-		Address:    iface.Address,
-		ListenPort: iface.ListenPort,
-		PublicKey:  iface.PublicKey,
-		MTU:        iface.MTU,
-		Endpoint:   config.Endpoint,
+		ID:                iface.ID,
+		Name:              config.Name,
+		Address:           iface.Address,
+		ListenPort:        iface.ListenPort,
+		PublicKey:         iface.PublicKey,
+		MTU:               iface.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTTL:           config.PeerTTL,
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
 	}, nil
 }
 
@@ -112,23 +148,60 @@ func (service *AdminService) CreateInterface(ctx context.Context, config domain.
 		return domain.AdminInterface{}, err
 	}
 
+	policy, err := normalizePeerKeyPolicy(config.PeerKeyPolicy)
+	if err != nil {
+		return domain.AdminInterface{}, err
+	}
+	config.PeerKeyPolicy = policy
+	if config.MaxDevicesPerUser == 0 {
+		config.MaxDevicesPerUser = domain.DefaultMaxDevicesPerUser
+	}
+	mode, err := normalizeIsolationMode(config.IsolationMode)
+	if err != nil {
+		return domain.AdminInterface{}, err
+	}
+	config.IsolationMode = mode
+
+	if config.PrivateKey == "" {
+		privateKey, err := service.repository.GeneratePrivateKey(ctx)
+		if err != nil {
+			return domain.AdminInterface{}, err
+		}
+		config.PrivateKey = privateKey
+	}
+
+	var steps saga
 	iface, err := service.repository.CreateInterface(ctx, config)
 	if err != nil {
 		return domain.AdminInterface{}, err
 	}
+	// The reconciler never removes live interfaces it has no row for, so a failed insert must not leave one behind.
+	steps.onRollback("wireguard interface", func(ctx context.Context) error {
+		return service.repository.DeleteInterface(ctx, config.ID)
+	})
 
 	if err := service.interfaceStore.Create(ctx, config); err != nil {
-		return domain.AdminInterface{}, err
+		return domain.AdminInterface{}, steps.fail(ctx, err)
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceCreate,
+		TargetType: domain.AuditTargetInterface,
+		TargetID:   config.ID,
+		After:      auditValue(auditInterfaceValue(config)),
+	})
 
 	return domain.AdminInterface{
-		ID:         iface.ID,
-		Name:       config.Name,
-		Address:    iface.Address,
-		ListenPort: iface.ListenPort,
-		PublicKey:  iface.PublicKey,
-		MTU:        iface.MTU,
-		Endpoint:   config.Endpoint,
+		ID:                iface.ID,
+		Name:              config.Name,
+		Address:           iface.Address,
+		ListenPort:        iface.ListenPort,
+		PublicKey:         iface.PublicKey,
+		MTU:               iface.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTTL:           config.PeerTTL,
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
 	}, nil
 }
 
@@ -156,70 +229,212 @@ func (service *AdminService) UpdateInterface(ctx context.Context, config domain.
 	if config.Endpoint == "" {
 		config.Endpoint = currentConfig.Endpoint
 	}
+	if config.PeerKeyPolicy == "" {
+		config.PeerKeyPolicy = currentConfig.PeerKeyPolicy
+	}
+	// A zero TTL keeps the current policy; a negative TTL removes it.
+	if config.PeerTTL == 0 {
+		config.PeerTTL = currentConfig.PeerTTL
+	} else if config.PeerTTL < 0 {
+		config.PeerTTL = 0
+	}
+	if config.MaxDevicesPerUser == 0 {
+		config.MaxDevicesPerUser = currentConfig.MaxDevicesPerUser
+	}
+	if config.IsolationMode == "" {
+		config.IsolationMode = currentConfig.IsolationMode
+	}
+	policy, err := normalizePeerKeyPolicy(config.PeerKeyPolicy)
+	if err != nil {
+		return domain.AdminInterface{}, err
+	}
+	config.PeerKeyPolicy = policy
+	mode, err := normalizeIsolationMode(config.IsolationMode)
+	if err != nil {
+		return domain.AdminInterface{}, err
+	}
+	config.IsolationMode = mode
 
 	if err := validateInterfaceConfig(config); err != nil {
 		return domain.AdminInterface{}, err
 	}
 
+	var steps saga
 	iface, err := service.repository.UpdateInterface(ctx, config)
 	if err != nil {
 		return domain.AdminInterface{}, err
 	}
+	// The stored config still matches the interface as it was before the update.
+	steps.onRollback("wireguard interface", func(ctx context.Context) error {
+		_, err := service.repository.UpdateInterface(ctx, currentConfig)
+		return err
+	})
 
 	if err := service.interfaceStore.Update(ctx, config); err != nil {
-		return domain.AdminInterface{}, err
+		return domain.AdminInterface{}, steps.fail(ctx, err)
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceUpdate,
+		TargetType: domain.AuditTargetInterface,
+		TargetID:   config.ID,
+		Before:     auditValue(auditInterfaceValue(currentConfig)),
+		After:      auditValue(auditInterfaceValue(config)),
+	})
 
 	return domain.AdminInterface{
-		ID:         iface.ID,
-		Name:       config.Name,
-		Address:    iface.Address,
-		ListenPort: iface.ListenPort,
-		PublicKey:  iface.PublicKey,
-		MTU:        iface.MTU,
-		Endpoint:   config.Endpoint,
+		ID:                iface.ID,
+		Name:              config.Name,
+		Address:           iface.Address,
+		ListenPort:        iface.ListenPort,
+		PublicKey:         iface.PublicKey,
+		MTU:               iface.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTTL:           config.PeerTTL,
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
 	}, nil
 }
 
 func (service *AdminService) DeleteInterface(ctx context.Context, interfaceID string) error {
-	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+	currentConfig, err := service.interfaceStore.Get(ctx, interfaceID)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrInterfaceNotFound
 		}
 		return err
 	}
 
-	if err := service.repository.DeleteInterface(ctx, interfaceID); err != nil {
-		return err
-	}
+	// The rows are deleted in one transaction and the interface is only torn down once it has committed,
+	// so a failed deletion leaves both the database and wireguard untouched. If the teardown itself fails,
+	// the rows are already gone and the error is returned for an operator to remove the device.
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		peers, err := service.peerStore.ListByInterface(ctx, interfaceID)
+		if err != nil {
+			return err
+		}
+		for _, peer := range peers {
+			if err := service.peerRouteStore.DeleteByPeer(ctx, peer.PeerID); err != nil {
+				return err
+			}
+		}
 
-	peers, err := service.peerStore.ListByInterface(ctx, interfaceID)
+		if err := service.interfaceRouteStore.DeleteByInterface(ctx, interfaceID); err != nil {
+			return err
+		}
+
+		if err := service.peerStore.DeleteByInterface(ctx, interfaceID); err != nil {
+			return err
+		}
+
+		if err := service.allowedEmailStore.DeleteByInterface(ctx, interfaceID); err != nil {
+			return err
+		}
+
+		return service.interfaceStore.Delete(ctx, interfaceID)
+	})
 	if err != nil {
 		return err
 	}
-	for _, peer := range peers {
-		if err := service.peerRouteStore.DeleteByPeer(ctx, peer.PeerID); err != nil {
-			return err
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceDelete,
+		TargetType: domain.AuditTargetInterface,
+		TargetID:   interfaceID,
+		Before:     auditValue(auditInterfaceValue(currentConfig)),
+	})
+
+	if err := service.repository.DeleteInterface(ctx, interfaceID); err != nil {
+		return fmt.Errorf("interface %s was deleted from the database but not from wireguard: %w", interfaceID, err)
+	}
+	return nil
+}
+
+// RotateInterfaceKey replaces the interface private key and re-renders the config of every peer on it.
+// It returns the updated interface and the IDs of the peers whose config changed.
+func (service *AdminService) RotateInterfaceKey(ctx context.Context, interfaceID string) (domain.AdminInterface, []string, error) {
+	if interfaceID == "" {
+		return domain.AdminInterface{}, nil, errors.New("interface id is required")
+	}
+
+	config, err := service.interfaceStore.Get(ctx, interfaceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.AdminInterface{}, nil, ErrInterfaceNotFound
 		}
+		return domain.AdminInterface{}, nil, err
 	}
 
-	if err := service.interfaceRouteStore.DeleteByInterface(ctx, interfaceID); err != nil {
-		return err
+	previousKey, err := service.interfaceStore.GetPrivateKey(ctx, interfaceID)
+	if err != nil {
+		return domain.AdminInterface{}, nil, err
 	}
 
-	if err := service.peerStore.DeleteByInterface(ctx, interfaceID); err != nil {
-		return err
+	privateKey, err := service.repository.GeneratePrivateKey(ctx)
+	if err != nil {
+		return domain.AdminInterface{}, nil, err
 	}
 
-	if err := service.allowedEmailStore.DeleteByInterface(ctx, interfaceID); err != nil {
-		return err
+	if err := service.interfaceStore.UpdatePrivateKey(ctx, interfaceID, privateKey); err != nil {
+		return domain.AdminInterface{}, nil, err
 	}
 
-	if err := service.interfaceStore.Delete(ctx, interfaceID); err != nil {
-		return err
+	config.PrivateKey = privateKey
+	iface, err := service.repository.UpdateInterface(ctx, config)
+	if err != nil {
+		// Keep the stored key in sync with the key wireguard is still using.
+		if previousKey != "" {
+			if restoreErr := service.interfaceStore.UpdatePrivateKey(ctx, interfaceID, previousKey); restoreErr != nil {
+				return domain.AdminInterface{}, nil, errors.Join(err, restoreErr)
+			}
+		}
+		return domain.AdminInterface{}, nil, err
 	}
 
-	return nil
+	peers, err := service.peerStore.ListByInterface(ctx, interfaceID)
+	if err != nil {
+		return domain.AdminInterface{}, nil, err
+	}
+
+	updatedPeerIDs := make([]string, 0, len(peers))
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, peer := range peers {
+			updatedConfig := domain.ReplacePeerConfigServerPublicKey(peer.Config, iface.PublicKey)
+			if updatedConfig == "" || updatedConfig == peer.Config {
+				continue
+			}
+			if err := service.peerStore.UpdateConfig(ctx, peer.PeerID, updatedConfig); err != nil {
+				return err
+			}
+			updatedPeerIDs = append(updatedPeerIDs, peer.PeerID)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.AdminInterface{}, nil, err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceRotateKey,
+		TargetType: domain.AuditTargetInterface,
+		TargetID:   interfaceID,
+		After: auditValue(map[string]any{
+			"public_key":       iface.PublicKey,
+			"updated_peer_ids": updatedPeerIDs,
+		}),
+	})
+
+	return domain.AdminInterface{
+		ID:                iface.ID,
+		Name:              config.Name,
+		Address:           iface.Address,
+		ListenPort:        iface.ListenPort,
+		PublicKey:         iface.PublicKey,
+		MTU:               iface.MTU,
+		Endpoint:          config.Endpoint,
+		PeerKeyPolicy:     config.PeerKeyPolicy,
+		PeerTTL:           config.PeerTTL,
+		MaxDevicesPerUser: config.MaxDevicesPerUser,
+		IsolationMode:     config.IsolationMode,
+	}, updatedPeerIDs, nil
 }
 
 func (service *AdminService) ListAllowedEmails(ctx context.Context, interfaceID string) ([]domain.AllowedEmail, error) {
@@ -232,22 +447,117 @@ func (service *AdminService) ListAllowedEmails(ctx context.Context, interfaceID
 	return service.allowedEmailStore.ListByInterface(ctx, interfaceID)
 }
 
-func (service *AdminService) CreateAllowedEmail(ctx context.Context, interfaceID string, email string) error {
+// CreateAllowedEmail adds an access rule to the interface. ruleType is exact, domain or regex; empty means exact.
+func (service *AdminService) CreateAllowedEmail(ctx context.Context, interfaceID string, email string, ruleType string) error {
 	if interfaceID == "" || email == "" {
 		return errors.New("interfaceID and email are required")
 	}
+	ruleType, pattern, err := domain.NormalizeAllowedEmailRule(ruleType, email)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAllowedEmailRule, err)
+	}
 	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrInterfaceNotFound
 		}
 		return err
 	}
-	return service.allowedEmailStore.Create(ctx, interfaceID, email)
+	if err := service.allowedEmailStore.Create(ctx, interfaceID, pattern, ruleType); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAllowedEmailCreate,
+		TargetType: domain.AuditTargetAllowedEmail,
+		TargetID:   auditTargetID(interfaceID, pattern),
+		After:      auditValue(map[string]any{"interface_id": interfaceID, "email": pattern, "rule_type": ruleType}),
+	})
+	return nil
 }
 
-func (service *AdminService) DeleteAllowedEmail(ctx context.Context, interfaceID string, email string) error {
+// PreviewAllowedEmailRule returns the known emails, those owning a peer or listed in an exact rule,
+// that a rule would allow.
+func (service *AdminService) PreviewAllowedEmailRule(ctx context.Context, ruleType string, pattern string) ([]string, error) {
+	ruleType, pattern, err := domain.NormalizeAllowedEmailRule(ruleType, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAllowedEmailRule, err)
+	}
+
+	emails, err := service.allowedEmailStore.ListKnownEmails(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]string, 0, len(emails))
+	for _, email := range emails {
+		if domain.AllowedEmailRuleMatches(ruleType, pattern, email) {
+			matched = append(matched, email)
+		}
+	}
+	return matched, nil
+}
+
+// DeleteAllowedEmail deletes an access rule of the interface and removes, or with suspendPeers suspends,
+// the peers the rule granted unless another rule or a locally synced group still grants them. The rule is
+// deleted first so no new peer can be created while the existing ones are torn down. It returns the IDs of
+// the affected peers.
+func (service *AdminService) DeleteAllowedEmail(ctx context.Context, interfaceID string, email string, suspendPeers bool) ([]string, error) {
 	if interfaceID == "" || email == "" {
-		return errors.New("interfaceID and email are required")
+		return nil, errors.New("interfaceID and email are required")
+	}
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInterfaceNotFound
+		}
+		return nil, err
+	}
+
+	rules, err := service.allowedEmailStore.ListByInterface(ctx, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	ruleType := ""
+	for _, rule := range rules {
+		if rule.Email == email {
+			ruleType = rule.RuleType
+			break
+		}
+	}
+	if ruleType == "" {
+		return nil, ErrAllowedEmailNotFound
+	}
+
+	if err := service.allowedEmailStore.Delete(ctx, interfaceID, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAllowedEmailNotFound
+		}
+		return nil, err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAllowedEmailDelete,
+		TargetType: domain.AuditTargetAllowedEmail,
+		TargetID:   auditTargetID(interfaceID, email),
+		Before:     auditValue(map[string]any{"interface_id": interfaceID, "email": email, "rule_type": ruleType}),
+	})
+
+	return service.revokePeers(ctx, interfaceID, suspendPeers, func(peerEmail string) bool {
+		return domain.AllowedEmailRuleMatches(ruleType, email, peerEmail)
+	})
+}
+
+func (service *AdminService) ListInterfaceGroups(ctx context.Context, interfaceID string) ([]domain.InterfaceGroup, error) {
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInterfaceNotFound
+		}
+		return nil, err
+	}
+	return service.groupStore.ListByInterface(ctx, interfaceID)
+}
+
+func (service *AdminService) CreateInterfaceGroup(ctx context.Context, interfaceID string, groupName string) error {
+	groupName = strings.TrimSpace(groupName)
+	if interfaceID == "" || groupName == "" {
+		return errors.New("interface id and group name are required")
 	}
 	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -255,7 +565,110 @@ func (service *AdminService) DeleteAllowedEmail(ctx context.Context, interfaceID
 		}
 		return err
 	}
-	return service.allowedEmailStore.Delete(ctx, interfaceID, email)
+	if err := service.groupStore.Create(ctx, interfaceID, groupName); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceGroupCreate,
+		TargetType: domain.AuditTargetInterfaceGroup,
+		TargetID:   auditTargetID(interfaceID, groupName),
+		After:      auditValue(map[string]any{"interface_id": interfaceID, "group_name": groupName}),
+	})
+	return nil
+}
+
+// DeleteInterfaceGroup unbinds the group from the interface and removes, or with suspendPeers suspends, the peers
+// of its locally synced members that lost access. Members known only from identity provider claims keep their
+// peers until they are revoked individually.
+func (service *AdminService) DeleteInterfaceGroup(ctx context.Context, interfaceID string, groupName string, suspendPeers bool) ([]string, error) {
+	if interfaceID == "" || groupName == "" {
+		return nil, errors.New("interface id and group name are required")
+	}
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInterfaceNotFound
+		}
+		return nil, err
+	}
+	if err := service.groupStore.Delete(ctx, interfaceID, groupName); err != nil {
+		return nil, err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceGroupDelete,
+		TargetType: domain.AuditTargetInterfaceGroup,
+		TargetID:   auditTargetID(interfaceID, groupName),
+		Before:     auditValue(map[string]any{"interface_id": interfaceID, "group_name": groupName}),
+	})
+
+	var errs []error
+	affected, err := service.revokePeers(ctx, interfaceID, suspendPeers, func(peerEmail string) bool {
+		groups, err := service.groupStore.ListGroupsByEmail(ctx, peerEmail)
+		if err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		return slices.Contains(groups, groupName)
+	})
+	return affected, errors.Join(append(errs, err)...)
+}
+
+// revokePeers removes, or suspends, the peers on the interface whose email matches revoked and that no
+// allowed email rule or locally synced group bound to the interface still grants.
+func (service *AdminService) revokePeers(ctx context.Context, interfaceID string, suspendPeers bool, revoked func(email string) bool) ([]string, error) {
+	peers, err := service.peerStore.ListByInterface(ctx, interfaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	affected := make([]string, 0)
+	var errs []error
+	for _, peer := range peers {
+		if suspendPeers && peer.SuspendedAt != nil {
+			continue
+		}
+		if !revoked(peer.Email) {
+			continue
+		}
+		allowed, err := service.hasLocalAccess(ctx, interfaceID, peer.Email)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("check peer %s: %w", peer.PeerID, err))
+			continue
+		}
+		if allowed {
+			continue
+		}
+		if suspendPeers {
+			err = service.SuspendPeer(ctx, peer.PeerID)
+		} else {
+			err = service.DeletePeer(ctx, peer.PeerID)
+		}
+		if err != nil && !errors.Is(err, ErrPeerNotFound) {
+			errs = append(errs, fmt.Errorf("revoke peer %s: %w", peer.PeerID, err))
+			continue
+		}
+		affected = append(affected, peer.PeerID)
+	}
+
+	return affected, errors.Join(errs...)
+}
+
+// hasLocalAccess reports whether an allowed email rule or a locally synced group grants email the interface.
+// Groups asserted only by the identity provider are not visible to admin-server.
+func (service *AdminService) hasLocalAccess(ctx context.Context, interfaceID string, email string) (bool, error) {
+	allowed, err := service.allowedEmailStore.Exists(ctx, interfaceID, email)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	groups, err := service.groupStore.ListGroupsByEmail(ctx, email)
+	if err != nil {
+		return false, err
+	}
+	interfaceIDs, err := service.groupStore.ListInterfaceIDsByGroups(ctx, groups)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(interfaceIDs, interfaceID), nil
 }
 
 func (service *AdminService) ListPeers(ctx context.Context, interfaceID string) ([]domain.PeerRecord, error) {
@@ -278,6 +691,69 @@ func (service *AdminService) ListWireguardConfigs(ctx context.Context, interface
 }
 
 func (service *AdminService) DeletePeer(ctx context.Context, peerID string) error {
+	return service.deletePeer(ctx, peerID, domain.AuditActionPeerDelete)
+}
+
+// deletePeer removes the peer and records its removal as action. The firewall rules and the wireguard peer are
+// restored when a later step fails, and the database rows are deleted in one transaction.
+func (service *AdminService) deletePeer(ctx context.Context, peerID string, action string) error {
+	record, err := service.peerStore.GetByPeerID(ctx, peerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPeerNotFound
+		}
+		return err
+	}
+
+	var steps saga
+	// A suspended peer is already gone from wireguard and the firewall.
+	if record.SuspendedAt == nil {
+		allowedIPs, accessRules, err := peerAccess(ctx, service.interfaceRouteStore, service.peerRouteStore, record)
+		if err != nil {
+			return err
+		}
+
+		// Remove iptables rules for this peer
+		if err := service.repository.RemovePeerFirewallRules(ctx, record.AllowedIP); err != nil {
+			return err
+		}
+		steps.onRollback("firewall rule removal", func(ctx context.Context) error {
+			return service.repository.SyncPeerFirewallRules(ctx, record.InterfaceID, record.AllowedIP, accessRules)
+		})
+
+		if err := service.repository.DeletePeer(ctx, record.PeerID); err != nil {
+			return steps.fail(ctx, err)
+		}
+		steps.onRollback("wireguard peer removal", func(ctx context.Context) error {
+			return service.repository.UpdatePeerAllowedIPs(ctx, record.InterfaceID, record.PeerID, allowedIPs)
+		})
+	}
+
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := service.ipAllocationStore.ReleaseByPeer(ctx, record.PeerID); err != nil {
+			return err
+		}
+		if err := service.peerRouteStore.DeleteByPeer(ctx, record.PeerID); err != nil {
+			return err
+		}
+		return service.peerStore.DeleteByPeerID(ctx, record.PeerID)
+	})
+	if err != nil {
+		return steps.fail(ctx, err)
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     action,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		Before:     auditValue(auditPeerValue(record)),
+	})
+
+	return nil
+}
+
+// SuspendPeer removes the peer from wireguard and its firewall rules while keeping its record, address and routes,
+// so ResumePeer can restore it with the same config. Suspending a suspended peer does nothing.
+func (service *AdminService) SuspendPeer(ctx context.Context, peerID string) error {
 	record, err := service.peerStore.GetByPeerID(ctx, peerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -285,31 +761,172 @@ func (service *AdminService) DeletePeer(ctx context.Context, peerID string) erro
 		}
 		return err
 	}
+	if record.SuspendedAt != nil {
+		return nil
+	}
+
+	allowedIPs, accessRules, err := peerAccess(ctx, service.interfaceRouteStore, service.peerRouteStore, record)
+	if err != nil {
+		return err
+	}
 
-	// Remove iptables rules for this peer
+	var steps saga
 	if err := service.repository.RemovePeerFirewallRules(ctx, record.AllowedIP); err != nil {
 		return err
 	}
+	steps.onRollback("firewall rule removal", func(ctx context.Context) error {
+		return service.repository.SyncPeerFirewallRules(ctx, record.InterfaceID, record.AllowedIP, accessRules)
+	})
 
-	if err := service.repository.DeletePeer(ctx, record.PeerID); err != nil {
+	// A peer already missing from wireguard, e.g. removed by hand, only needs its record marked suspended.
+	err = service.repository.DeletePeer(ctx, record.PeerID)
+	if err != nil && !errors.Is(err, domain.ErrWireguardPeerNotFound) {
+		return steps.fail(ctx, err)
+	}
+	if err == nil {
+		steps.onRollback("wireguard peer removal", func(ctx context.Context) error {
+			return service.repository.UpdatePeerAllowedIPs(ctx, record.InterfaceID, record.PeerID, allowedIPs)
+		})
+	}
+
+	suspendedAt := time.Now()
+	if err := service.peerStore.UpdateSuspension(ctx, record.PeerID, &suspendedAt); err != nil {
+		return steps.fail(ctx, err)
+	}
+	suspended := record
+	suspended.SuspendedAt = &suspendedAt
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerSuspend,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		Before:     auditValue(auditPeerValue(record)),
+		After:      auditValue(auditPeerValue(suspended)),
+	})
+	return nil
+}
+
+// ResumePeer adds a suspended peer back to wireguard with its current routes and restores its firewall rules.
+func (service *AdminService) ResumePeer(ctx context.Context, peerID string) error {
+	record, err := service.peerStore.GetByPeerID(ctx, peerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPeerNotFound
+		}
 		return err
 	}
+	if record.SuspendedAt == nil {
+		return nil
+	}
 
-	if err := service.peerRouteStore.DeleteByPeer(ctx, record.PeerID); err != nil {
+	allowedIPs, accessRules, err := peerAccess(ctx, service.interfaceRouteStore, service.peerRouteStore, record)
+	if err != nil {
 		return err
 	}
 
-	if err := service.peerStore.DeleteByPeerID(ctx, record.PeerID); err != nil {
+	if err := service.repository.UpdatePeerAllowedIPs(ctx, record.InterfaceID, record.PeerID, allowedIPs); err != nil {
 		return err
 	}
 
+	if err := service.repository.SyncPeerFirewallRules(ctx, record.InterfaceID, record.AllowedIP, accessRules); err != nil {
+		return err
+	}
+
+	if err := service.peerStore.UpdateSuspension(ctx, record.PeerID, nil); err != nil {
+		return err
+	}
+	resumed := record
+	resumed.SuspendedAt = nil
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerResume,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		Before:     auditValue(auditPeerValue(record)),
+		After:      auditValue(auditPeerValue(resumed)),
+	})
 	return nil
 }
 
-func (service *AdminService) CreateWireguardPeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string) (domain.WireguardPeer, error) {
+// peerAccess returns the addresses wireguard routes to the peer, its own address and the CIDRs of the interface and
+// peer routes, together with the access rules of those routes.
+func peerAccess(ctx context.Context, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore, record domain.PeerRecord) ([]string, []domain.AccessRule, error) {
+	interfaceRoutes, err := interfaceRouteStore.ListByInterface(ctx, record.InterfaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	peerRoutes, err := peerRouteStore.ListByPeer(ctx, record.PeerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildAllowedIPs(record.AllowedIP, interfaceRoutes, peerRoutes), domain.RouteAccessRules(interfaceRoutes, peerRoutes), nil
+}
+
+// ReapExpiredPeers deletes every peer whose expiry is before now and returns the removed peer IDs.
+// A peer that cannot be removed is skipped so the rest are still reaped; its error is returned with the others.
+func (service *AdminService) ReapExpiredPeers(ctx context.Context, now time.Time) ([]string, error) {
+	records, err := service.peerStore.ListExpired(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	reaped := make([]string, 0, len(records))
+	var errs []error
+	for _, record := range records {
+		if err := service.deletePeer(ctx, record.PeerID, domain.AuditActionPeerExpire); err != nil && !errors.Is(err, ErrPeerNotFound) {
+			errs = append(errs, fmt.Errorf("reap peer %s: %w", record.PeerID, err))
+			continue
+		}
+		reaped = append(reaped, record.PeerID)
+	}
+
+	return reaped, errors.Join(errs...)
+}
+
+// ReconcileWireguard fixes drift between the database and live wireguard and firewall state and records every
+// change it made. Without a reconciler, e.g. against the dev repository, it does nothing.
+func (service *AdminService) ReconcileWireguard(ctx context.Context) ([]domain.ReconcileChange, error) {
+	if service.reconciler == nil {
+		return nil, nil
+	}
+
+	changes, err := service.reconciler.Apply(ctx)
+	for _, change := range changes {
+		event := domain.AuditEvent{
+			Action:     domain.AuditActionPeerReconcile,
+			TargetType: domain.AuditTargetPeer,
+			TargetID:   change.Target,
+			After:      auditValue(map[string]any{"kind": change.Kind, "interface_id": change.InterfaceID, "detail": change.Detail}),
+		}
+		switch change.Kind {
+		case domain.ReconcileCreateInterface, domain.ReconcileUpdateInterface:
+			event.Action, event.TargetType, event.TargetID = domain.AuditActionInterfaceReconcile, domain.AuditTargetInterface, change.InterfaceID
+		case domain.ReconcileSyncFirewallRules, domain.ReconcileRemoveFirewallRules:
+			event.Action, event.TargetType = domain.AuditActionFirewallReconcile, domain.AuditTargetFirewall
+		case domain.ReconcileSyncIsolationRules:
+			event.Action, event.TargetType, event.TargetID = domain.AuditActionFirewallReconcile, domain.AuditTargetFirewall, change.InterfaceID
+		}
+		service.auditor.Record(ctx, event)
+	}
+	return changes, err
+}
+
+// PlanWireguardReconcile returns the drift between the database and live wireguard and firewall state
+// as the changes that would fix it, without making them.
+func (service *AdminService) PlanWireguardReconcile(ctx context.Context) ([]domain.ReconcileChange, error) {
+	if service.reconciler == nil {
+		return nil, nil
+	}
+	return service.reconciler.Plan(ctx)
+}
+
+// CreateWireguardPeer adds a peer to the interface. address optionally requests specific tunnel addresses,
+// at most one per address family; families without a requested address are allocated automatically.
+func (service *AdminService) CreateWireguardPeer(ctx context.Context, interfaceID string, endpoint string, allowedIPs []string, publicKey string, address string) (domain.WireguardPeer, error) {
 	if interfaceID == "" {
 		return domain.WireguardPeer{}, errors.New("interface id is required")
 	}
+	if err := validatePeerPublicKey(publicKey); err != nil {
+		return domain.WireguardPeer{}, err
+	}
 
 	config, err := service.interfaceStore.Get(ctx, interfaceID)
 	if err != nil {
@@ -322,6 +939,20 @@ func (service *AdminService) CreateWireguardPeer(ctx context.Context, interfaceI
 	if endpoint == "" {
 		endpoint = config.Endpoint
 	}
+	if err := checkPeerKeyPolicy(config.PeerKeyPolicy, publicKey); err != nil {
+		return domain.WireguardPeer{}, err
+	}
+	requestedAddress, err := normalizePeerAddress(address, config.Address)
+	if err != nil {
+		return domain.WireguardPeer{}, err
+	}
+	if publicKey != "" {
+		if _, err := service.peerStore.GetByPeerID(ctx, publicKey); err == nil {
+			return domain.WireguardPeer{}, ErrPeerAlreadyExists
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return domain.WireguardPeer{}, err
+		}
+	}
 
 	interfaceRoutes, err := service.interfaceRouteStore.ListByInterface(ctx, interfaceID)
 	if err != nil {
@@ -333,16 +964,57 @@ func (service *AdminService) CreateWireguardPeer(ctx context.Context, interfaceI
 		return domain.WireguardPeer{}, err
 	}
 
-	peer, err := service.repository.CreatePeer(ctx, interfaceID, endpoint, normalizedAllowedIPs)
+	var steps saga
+	allowedIP, err := service.ipAllocationStore.Allocate(ctx, interfaceID, requestedAddress)
 	if err != nil {
+		if errors.Is(err, domain.ErrIPAddressAllocated) {
+			return domain.WireguardPeer{}, fmt.Errorf("%w: %s", ErrPeerAddressInUse, requestedAddress)
+		}
 		return domain.WireguardPeer{}, err
 	}
+	steps.onRollback("address allocation", func(ctx context.Context) error {
+		return service.ipAllocationStore.Release(ctx, interfaceID, allowedIP)
+	})
+
+	peer, err := service.repository.CreatePeer(ctx, domain.PeerSpec{
+		InterfaceID: interfaceID,
+		Endpoint:    endpoint,
+		AllowedIP:   allowedIP,
+		AllowedIPs:  normalizedAllowedIPs,
+		PublicKey:   publicKey,
+	})
+	if err != nil {
+		return domain.WireguardPeer{}, steps.fail(ctx, err)
+	}
+	steps.onRollback("wireguard peer", func(ctx context.Context) error {
+		return service.repository.DeletePeer(ctx, peer.ID)
+	})
 
-	// Sync iptables rules for the newly created peer
-	if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, normalizedAllowedIPs); err != nil {
-		return domain.WireguardPeer{}, err
+	if err := service.ipAllocationStore.Assign(ctx, interfaceID, allowedIP, peer.ID); err != nil {
+		return domain.WireguardPeer{}, steps.fail(ctx, err)
 	}
 
+	// Registered before the sync so rules it added before failing are removed as well.
+	steps.onRollback("firewall rules", func(ctx context.Context) error {
+		return service.repository.RemovePeerFirewallRules(ctx, peer.AllowedIP)
+	})
+	// Sync iptables rules for the newly created peer
+	// Only stored routes grant access; allowed IPs given here just route traffic into the tunnel.
+	if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, domain.RouteAccessRules(interfaceRoutes, nil)); err != nil {
+		return domain.WireguardPeer{}, steps.fail(ctx, err)
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerCreate,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   peer.ID,
+		After: auditValue(map[string]any{
+			"interface_id": interfaceID,
+			"allowed_ip":   peer.AllowedIP,
+			"allowed_ips":  normalizedAllowedIPs,
+			"endpoint":     endpoint,
+		}),
+	})
+
 	return peer, nil
 }
 
@@ -350,7 +1022,18 @@ func (service *AdminService) DeleteWireguardPeer(ctx context.Context, peerID str
 	if peerID == "" {
 		return errors.New("peer id is required")
 	}
-	return service.repository.DeletePeer(ctx, peerID)
+	if err := service.repository.DeletePeer(ctx, peerID); err != nil {
+		return err
+	}
+	if err := service.ipAllocationStore.ReleaseByPeer(ctx, peerID); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerDelete,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   peerID,
+	})
+	return nil
 }
 
 func (service *AdminService) UpdateWireguardPeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error {
@@ -363,6 +1046,12 @@ func (service *AdminService) UpdateWireguardPeerAllowedIPs(ctx context.Context,
 	if err := service.repository.UpdatePeerAllowedIPs(ctx, interfaceID, peerID, allowedIPs); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerUpdateAllowedIPs,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   peerID,
+		After:      auditValue(map[string]any{"interface_id": interfaceID, "allowed_ips": allowedIPs}),
+	})
 
 	record, err := service.peerStore.GetByPeerID(ctx, peerID)
 	if err != nil {
@@ -378,6 +1067,71 @@ func (service *AdminService) UpdateWireguardPeerAllowedIPs(ctx context.Context,
 	return service.peerStore.UpdateConfig(ctx, peerID, updatedConfig)
 }
 
+// SyncPeerFirewallRules replaces the firewall rules of the peer at peerAllowedIP with rules implementing rules.
+// william-server calls it to finish provisioning a user's peer.
+func (service *AdminService) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
+	if interfaceID == "" || peerAllowedIP == "" {
+		return errors.New("interface id and peer allowed ip are required")
+	}
+	cidrs := domain.SplitAddresses(peerAllowedIP)
+	for _, rule := range rules {
+		cidrs = append(cidrs, rule.CIDR)
+	}
+	if err := validateFirewallCIDRs(cidrs); err != nil {
+		return err
+	}
+	rules = slices.Clone(rules)
+	for index, rule := range rules {
+		normalized, err := normalizeAccessRule(rule)
+		if err != nil {
+			return err
+		}
+		rules[index] = normalized
+	}
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInterfaceNotFound
+		}
+		return err
+	}
+
+	if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peerAllowedIP, rules); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFirewallSync,
+		TargetType: domain.AuditTargetFirewall,
+		TargetID:   peerAllowedIP,
+		After:      auditValue(map[string]any{"interface_id": interfaceID, "rules": auditAccessRules(rules)}),
+	})
+	return nil
+}
+
+// RemovePeerFirewallRules removes every firewall rule of the peer at peerAllowedIP.
+func (service *AdminService) RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error {
+	if peerAllowedIP == "" {
+		return errors.New("peer allowed ip is required")
+	}
+	if err := validateFirewallCIDRs(domain.SplitAddresses(peerAllowedIP)); err != nil {
+		return err
+	}
+
+	if err := service.repository.RemovePeerFirewallRules(ctx, peerAllowedIP); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionFirewallRemove,
+		TargetType: domain.AuditTargetFirewall,
+		TargetID:   peerAllowedIP,
+	})
+	return nil
+}
+
+// EnsureFirewallChain creates the firewall chain peer rules are added to when it is missing.
+func (service *AdminService) EnsureFirewallChain(ctx context.Context) error {
+	return service.repository.EnsureFirewallChain(ctx)
+}
+
 func (service *AdminService) ListInterfaceRoutes(ctx context.Context, interfaceID string) ([]domain.InterfaceRoute, error) {
 	if interfaceID == "" {
 		return nil, errors.New("interface id is required")
@@ -385,11 +1139,17 @@ func (service *AdminService) ListInterfaceRoutes(ctx context.Context, interfaceI
 	return service.interfaceRouteStore.ListByInterface(ctx, interfaceID)
 }
 
-func (service *AdminService) CreateInterfaceRoute(ctx context.Context, interfaceID string, cidr string) error {
-	if interfaceID == "" || cidr == "" {
+// CreateInterfaceRoute gives every peer of an interface the access rule. Routes sharing a CIDR are separate rules
+// as long as their protocol or ports differ.
+func (service *AdminService) CreateInterfaceRoute(ctx context.Context, interfaceID string, rule domain.AccessRule) error {
+	if interfaceID == "" || rule.CIDR == "" {
 		return errors.New("interface id and cidr are required")
 	}
-	if err := validateIPv4CIDR(cidr); err != nil {
+	if err := validateRouteCIDR(rule.CIDR); err != nil {
+		return err
+	}
+	rule, err := normalizeAccessRule(rule)
+	if err != nil {
 		return err
 	}
 	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
@@ -398,19 +1158,35 @@ func (service *AdminService) CreateInterfaceRoute(ctx context.Context, interface
 		}
 		return err
 	}
-	if err := service.interfaceRouteStore.Create(ctx, interfaceID, cidr); err != nil {
+	if err := service.interfaceRouteStore.Create(ctx, interfaceID, rule); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceRouteCreate,
+		TargetType: domain.AuditTargetInterfaceRoute,
+		TargetID:   auditRouteTargetID(interfaceID, rule),
+		After:      auditValue(auditRouteValue("interface_id", interfaceID, rule)),
+	})
 	return service.applyAllowedRoutes(ctx, interfaceID)
 }
 
-func (service *AdminService) DeleteInterfaceRoute(ctx context.Context, interfaceID string, cidr string) error {
-	if interfaceID == "" || cidr == "" {
+func (service *AdminService) DeleteInterfaceRoute(ctx context.Context, interfaceID string, rule domain.AccessRule) error {
+	if interfaceID == "" || rule.CIDR == "" {
 		return errors.New("interface id and cidr are required")
 	}
-	if err := service.interfaceRouteStore.Delete(ctx, interfaceID, cidr); err != nil {
+	rule, err := normalizeAccessRule(rule)
+	if err != nil {
+		return err
+	}
+	if err := service.interfaceRouteStore.Delete(ctx, interfaceID, rule); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionInterfaceRouteDelete,
+		TargetType: domain.AuditTargetInterfaceRoute,
+		TargetID:   auditRouteTargetID(interfaceID, rule),
+		Before:     auditValue(auditRouteValue("interface_id", interfaceID, rule)),
+	})
 	return service.applyAllowedRoutes(ctx, interfaceID)
 }
 
@@ -421,11 +1197,15 @@ func (service *AdminService) ListPeerRoutes(ctx context.Context, peerID string)
 	return service.peerRouteStore.ListByPeer(ctx, peerID)
 }
 
-func (service *AdminService) CreatePeerRoute(ctx context.Context, peerID string, cidr string) error {
-	if peerID == "" || cidr == "" {
+func (service *AdminService) CreatePeerRoute(ctx context.Context, peerID string, rule domain.AccessRule) error {
+	if peerID == "" || rule.CIDR == "" {
 		return errors.New("peer id and cidr are required")
 	}
-	if err := validateIPv4CIDR(cidr); err != nil {
+	if err := validateRouteCIDR(rule.CIDR); err != nil {
+		return err
+	}
+	rule, err := normalizeAccessRule(rule)
+	if err != nil {
 		return err
 	}
 	record, err := service.peerStore.GetByPeerID(ctx, peerID)
@@ -435,16 +1215,26 @@ func (service *AdminService) CreatePeerRoute(ctx context.Context, peerID string,
 		}
 		return err
 	}
-	if err := service.peerRouteStore.Create(ctx, peerID, cidr); err != nil {
+	if err := service.peerRouteStore.Create(ctx, peerID, rule); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerRouteCreate,
+		TargetType: domain.AuditTargetPeerRoute,
+		TargetID:   auditRouteTargetID(peerID, rule),
+		After:      auditValue(auditRouteValue("peer_id", peerID, rule)),
+	})
 	return service.applyAllowedRoutes(ctx, record.InterfaceID)
 }
 
-func (service *AdminService) DeletePeerRoute(ctx context.Context, peerID string, cidr string) error {
-	if peerID == "" || cidr == "" {
+func (service *AdminService) DeletePeerRoute(ctx context.Context, peerID string, rule domain.AccessRule) error {
+	if peerID == "" || rule.CIDR == "" {
 		return errors.New("peer id and cidr are required")
 	}
+	rule, err := normalizeAccessRule(rule)
+	if err != nil {
+		return err
+	}
 	record, err := service.peerStore.GetByPeerID(ctx, peerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -452,12 +1242,94 @@ func (service *AdminService) DeletePeerRoute(ctx context.Context, peerID string,
 		}
 		return err
 	}
-	if err := service.peerRouteStore.Delete(ctx, peerID, cidr); err != nil {
+	if err := service.peerRouteStore.Delete(ctx, peerID, rule); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionPeerRouteDelete,
+		TargetType: domain.AuditTargetPeerRoute,
+		TargetID:   auditRouteTargetID(peerID, rule),
+		Before:     auditValue(auditRouteValue("peer_id", peerID, rule)),
+	})
 	return service.applyAllowedRoutes(ctx, record.InterfaceID)
 }
 
+// ListIPAllocations returns the addresses handed out on an interface together with its reserved ranges.
+func (service *AdminService) ListIPAllocations(ctx context.Context, interfaceID string) ([]domain.IPAllocation, []domain.IPReservation, error) {
+	if interfaceID == "" {
+		return nil, nil, errors.New("interface id is required")
+	}
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrInterfaceNotFound
+		}
+		return nil, nil, err
+	}
+
+	allocations, err := service.ipAllocationStore.ListByInterface(ctx, interfaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservations, err := service.ipAllocationStore.ListReservations(ctx, interfaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return allocations, reservations, nil
+}
+
+// CreateIPReservation keeps cidr out of automatic peer address allocation.
+// Addresses already handed out inside the range stay with their peers.
+func (service *AdminService) CreateIPReservation(ctx context.Context, interfaceID string, cidr string, description string) error {
+	if interfaceID == "" || cidr == "" {
+		return errors.New("interface id and cidr are required")
+	}
+	if err := validateRouteCIDR(cidr); err != nil {
+		return err
+	}
+	if _, err := service.interfaceStore.Get(ctx, interfaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInterfaceNotFound
+		}
+		return err
+	}
+	if err := service.ipAllocationStore.CreateReservation(ctx, domain.IPReservation{
+		InterfaceID: interfaceID,
+		CIDR:        cidr,
+		Description: description,
+	}); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionIPReservationCreate,
+		TargetType: domain.AuditTargetIPReservation,
+		TargetID:   auditTargetID(interfaceID, cidr),
+		After:      auditValue(map[string]any{"interface_id": interfaceID, "cidr": cidr, "description": description}),
+	})
+	return nil
+}
+
+func (service *AdminService) DeleteIPReservation(ctx context.Context, interfaceID string, cidr string) error {
+	if interfaceID == "" || cidr == "" {
+		return errors.New("interface id and cidr are required")
+	}
+	if err := service.ipAllocationStore.DeleteReservation(ctx, interfaceID, cidr); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionIPReservationDelete,
+		TargetType: domain.AuditTargetIPReservation,
+		TargetID:   auditTargetID(interfaceID, cidr),
+		Before:     auditValue(map[string]any{"interface_id": interfaceID, "cidr": cidr}),
+	})
+	return nil
+}
+
+// ListAuditEvents returns one page of audit events, newest first, and the ID to pass as BeforeID for the next page.
+// The next page ID is 0 on the last page.
+func (service *AdminService) ListAuditEvents(ctx context.Context, filter domain.AuditEventFilter) ([]domain.AuditEvent, int64, error) {
+	return service.auditor.List(ctx, filter)
+}
+
 func validateInterfaceConfig(config domain.InterfaceConfig) error {
 	if config.ID == "" {
 		return errors.New("interface id is required")
@@ -465,8 +1337,8 @@ func validateInterfaceConfig(config domain.InterfaceConfig) error {
 	if config.Name == "" {
 		return errors.New("name is required")
 	}
-	if config.Address == "" {
-		return errors.New("address is required")
+	if err := validateInterfaceAddress(config.Address); err != nil {
+		return err
 	}
 	if config.ListenPort == 0 {
 		return errors.New("listen port is required")
@@ -477,6 +1349,9 @@ func validateInterfaceConfig(config domain.InterfaceConfig) error {
 	if config.Endpoint == "" {
 		return errors.New("endpoint is required")
 	}
+	if config.PeerTTL < 0 {
+		return errors.New("peer ttl must not be negative")
+	}
 	return nil
 }
 
@@ -497,13 +1372,16 @@ func (service *AdminService) applyAllowedRoutes(ctx context.Context, interfaceID
 			return err
 		}
 		allowedIPs := buildAllowedIPs(peer.AllowedIP, interfaceRoutes, peerRoutes)
-		if err := service.repository.UpdatePeerAllowedIPs(ctx, interfaceID, peer.PeerID, allowedIPs); err != nil {
-			return err
-		}
+		// Suspended peers only get their stored config updated; ResumePeer applies the routes.
+		if peer.SuspendedAt == nil {
+			if err := service.repository.UpdatePeerAllowedIPs(ctx, interfaceID, peer.PeerID, allowedIPs); err != nil {
+				return err
+			}
 
-		// Sync iptables rules for this peer
-		if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, allowedIPs); err != nil {
-			return err
+			// Sync iptables rules for this peer
+			if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, domain.RouteAccessRules(interfaceRoutes, peerRoutes)); err != nil {
+				return err
+			}
 		}
 
 		updatedConfig := updatePeerConfigAllowedIPs(peer.Config, allowedIPs)
@@ -518,13 +1396,10 @@ func (service *AdminService) applyAllowedRoutes(ctx context.Context, interfaceID
 }
 
 func buildAllowedIPs(peerAllowedIP string, interfaceRoutes []domain.InterfaceRoute, peerRoutes []domain.PeerRoute) []string {
-	items := []string{}
-	if peerAllowedIP != "" {
-		items = append(items, peerAllowedIP)
-	}
+	items := domain.SplitAddresses(peerAllowedIP)
 
-	interfaceCIDRs := extractRouteCIDRs(interfaceRoutes)
-	peerCIDRs := extractPeerRouteCIDRs(peerRoutes)
+	interfaceCIDRs := domain.RouteCIDRs(interfaceRoutes, nil)
+	peerCIDRs := domain.RouteCIDRs(nil, peerRoutes)
 
 	sort.Strings(interfaceCIDRs)
 	sort.Strings(peerCIDRs)
@@ -566,33 +1441,17 @@ func normalizeCreateAllowedIPs(allowedIPs []string, interfaceRoutes []domain.Int
 		if cidr == "" {
 			continue
 		}
-		if err := validateIPv4CIDR(cidr); err != nil {
+		if err := validateRouteCIDR(cidr); err != nil {
 			return nil, err
 		}
 		items = append(items, cidr)
 	}
 
-	items = append(items, extractRouteCIDRs(interfaceRoutes)...)
+	items = append(items, domain.RouteCIDRs(interfaceRoutes, nil)...)
 	sort.Strings(items)
 	return dedupeStrings(items), nil
 }
 
-func extractRouteCIDRs(routes []domain.InterfaceRoute) []string {
-	cidrs := make([]string, 0, len(routes))
-	for _, route := range routes {
-		cidrs = append(cidrs, route.CIDR)
-	}
-	return cidrs
-}
-
-func extractPeerRouteCIDRs(routes []domain.PeerRoute) []string {
-	cidrs := make([]string, 0, len(routes))
-	for _, route := range routes {
-		cidrs = append(cidrs, route.CIDR)
-	}
-	return cidrs
-}
-
 func dedupeStrings(items []string) []string {
 	seen := make(map[string]struct{}, len(items))
 	result := make([]string, 0, len(items))
@@ -609,13 +1468,127 @@ func dedupeStrings(items []string) []string {
 	return result
 }
 
-func validateIPv4CIDR(cidr string) error {
-	prefix, err := netip.ParsePrefix(cidr)
+// validateFirewallCIDRs rejects anything but IPv4 and IPv6 CIDRs before it reaches iptables.
+func validateFirewallCIDRs(cidrs []string) error {
+	for _, cidr := range cidrs {
+		if err := validateRouteCIDR(cidr); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidFirewallCIDR, cidr)
+		}
+	}
+	return nil
+}
+
+// normalizeAccessRule defaults the protocol to any and rewrites the ports in canonical form ("8000-8080,22" -> "22,8000-8080").
+// Ports are only accepted for tcp and udp.
+func normalizeAccessRule(rule domain.AccessRule) (domain.AccessRule, error) {
+	switch rule.Protocol {
+	case "":
+		rule.Protocol = domain.AccessProtocolAny
+	case domain.AccessProtocolAny, domain.AccessProtocolTCP, domain.AccessProtocolUDP, domain.AccessProtocolICMP:
+	default:
+		return domain.AccessRule{}, fmt.Errorf("%w: unknown protocol %q", ErrInvalidAccessRule, rule.Protocol)
+	}
+
+	portRanges, err := domain.ParsePortRanges(rule.Ports)
 	if err != nil {
-		return err
+		return domain.AccessRule{}, fmt.Errorf("%w: %v", ErrInvalidAccessRule, err)
+	}
+	if len(portRanges) > 0 && rule.Protocol != domain.AccessProtocolTCP && rule.Protocol != domain.AccessProtocolUDP {
+		return domain.AccessRule{}, fmt.Errorf("%w: ports require protocol tcp or udp", ErrInvalidAccessRule)
+	}
+	rule.Ports = domain.JoinPortRanges(portRanges)
+	return rule, nil
+}
+
+// validateRouteCIDR accepts IPv4 and IPv6 CIDRs.
+func validateRouteCIDR(cidr string) error {
+	_, err := netip.ParsePrefix(cidr)
+	return err
+}
+
+// normalizePeerAddress validates requested peer addresses against the interface prefixes and
+// returns them as host prefixes (e.g. "10.0.0.50/32"). Bare addresses are accepted.
+func normalizePeerAddress(address string, interfaceAddress string) (string, error) {
+	items := domain.SplitAddresses(address)
+	if len(items) == 0 {
+		return "", nil
+	}
+
+	var interfacePrefixes []netip.Prefix
+	for _, item := range domain.SplitAddresses(interfaceAddress) {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return "", err
+		}
+		interfacePrefixes = append(interfacePrefixes, prefix)
+	}
+
+	families := make(map[bool]struct{}, 2)
+	normalized := make([]string, 0, len(items))
+	for _, item := range items {
+		addr, err := parseHostAddress(item)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q: %v", ErrInvalidPeerAddress, item, err)
+		}
+		if _, ok := families[addr.Is4()]; ok {
+			return "", fmt.Errorf("%w: at most one IPv4 and one IPv6 address can be requested", ErrInvalidPeerAddress)
+		}
+		families[addr.Is4()] = struct{}{}
+
+		inInterface := false
+		for _, prefix := range interfacePrefixes {
+			if !prefix.Contains(addr) {
+				continue
+			}
+			if addr == prefix.Addr() || addr == prefix.Masked().Addr() {
+				return "", fmt.Errorf("%w: %s is reserved by interface prefix %s", ErrInvalidPeerAddress, addr, prefix)
+			}
+			inInterface = true
+		}
+		if !inInterface {
+			return "", fmt.Errorf("%w: %s is outside interface address %s", ErrInvalidPeerAddress, addr, interfaceAddress)
+		}
+		normalized = append(normalized, netip.PrefixFrom(addr, addr.BitLen()).String())
+	}
+
+	return domain.JoinAddresses(normalized), nil
+}
+
+// parseHostAddress accepts "10.0.0.5" or a single host prefix such as "10.0.0.5/32".
+func parseHostAddress(value string) (netip.Addr, error) {
+	if !strings.Contains(value, "/") {
+		return netip.ParseAddr(value)
 	}
-	if !prefix.Addr().Is4() {
-		return errors.New("only IPv4 CIDR is supported")
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !prefix.IsSingleIP() {
+		return netip.Addr{}, errors.New("must be a single host address")
+	}
+	return prefix.Addr(), nil
+}
+
+// validateInterfaceAddress accepts an IPv4 prefix, an IPv6 prefix, or one of each separated by a comma.
+func validateInterfaceAddress(address string) error {
+	items := domain.SplitAddresses(address)
+	if len(items) == 0 {
+		return errors.New("address is required")
+	}
+
+	families := make(map[bool]struct{}, 2)
+	for _, item := range items {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return fmt.Errorf("invalid interface address %q: %w", item, err)
+		}
+		if _, ok := families[prefix.Addr().Is4()]; ok {
+			return errors.New("interface address allows at most one IPv4 and one IPv6 prefix")
+		}
+		families[prefix.Addr().Is4()] = struct{}{}
+		if prefix.Bits() >= prefix.Addr().BitLen()-1 {
+			return fmt.Errorf("interface prefix %s is too small to allocate peers", item)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+var ErrAdminForbidden = errors.New("admin access forbidden")
+var ErrInvalidAdminRole = errors.New("invalid admin role")
+
+type AdminAccessUsecase interface {
+	Authorize(ctx context.Context, principal domain.AdminPrincipal, requiredRole string) error
+	ListRoleAssignments(ctx context.Context) ([]domain.AdminRoleAssignment, error)
+	SetRoleAssignment(ctx context.Context, subject string, role string) error
+	DeleteRoleAssignment(ctx context.Context, subject string) error
+}
+
+// AdminAccessService decides which admin RPCs a principal may call.
+// Bootstrap owners are always owners so a fresh installation can hand out the first roles.
+type AdminAccessService struct {
+	roleStore       domain.AdminRoleStore
+	bootstrapOwners map[string]struct{}
+	auditor         *Auditor
+}
+
+func NewAdminAccessService(roleStore domain.AdminRoleStore, bootstrapOwners []string, auditor *Auditor) *AdminAccessService {
+	owners := make(map[string]struct{}, len(bootstrapOwners))
+	for _, owner := range bootstrapOwners {
+		if subject := normalizeAdminSubject(owner); subject != "" {
+			owners[subject] = struct{}{}
+		}
+	}
+	return &AdminAccessService{roleStore: roleStore, bootstrapOwners: owners, auditor: auditor}
+}
+
+func (service *AdminAccessService) Authorize(ctx context.Context, principal domain.AdminPrincipal, requiredRole string) error {
+	role, err := service.roleOf(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if !domain.AdminRoleAllows(role, requiredRole) {
+		return fmt.Errorf("%w: %s requires role %s", ErrAdminForbidden, principal.Subject, requiredRole)
+	}
+	return nil
+}
+
+func (service *AdminAccessService) ListRoleAssignments(ctx context.Context) ([]domain.AdminRoleAssignment, error) {
+	return service.roleStore.List(ctx)
+}
+
+func (service *AdminAccessService) SetRoleAssignment(ctx context.Context, subject string, role string) error {
+	subject = normalizeAdminSubject(subject)
+	if subject == "" {
+		return errors.New("subject is required")
+	}
+	if !domain.IsAdminRole(role) {
+		return fmt.Errorf("%w: %q", ErrInvalidAdminRole, role)
+	}
+
+	before, err := service.roleAssignmentValue(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if err := service.roleStore.Upsert(ctx, subject, role); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAdminRoleSet,
+		TargetType: domain.AuditTargetAdminRole,
+		TargetID:   subject,
+		Before:     auditValue(before),
+		After:      auditValue(map[string]any{"role": role}),
+	})
+	return nil
+}
+
+func (service *AdminAccessService) DeleteRoleAssignment(ctx context.Context, subject string) error {
+	subject = normalizeAdminSubject(subject)
+	if subject == "" {
+		return errors.New("subject is required")
+	}
+
+	before, err := service.roleAssignmentValue(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if err := service.roleStore.Delete(ctx, subject); err != nil {
+		return err
+	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Action:     domain.AuditActionAdminRoleDelete,
+		TargetType: domain.AuditTargetAdminRole,
+		TargetID:   subject,
+		Before:     auditValue(before),
+	})
+	return nil
+}
+
+// roleAssignmentValue returns the audit value of the subject's role assignment, or nil when it has none.
+func (service *AdminAccessService) roleAssignmentValue(ctx context.Context, subject string) (map[string]any, error) {
+	assignment, err := service.roleStore.Get(ctx, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return map[string]any{"role": assignment.Role}, nil
+}
+
+func (service *AdminAccessService) roleOf(ctx context.Context, principal domain.AdminPrincipal) (string, error) {
+	if principal.Role != "" {
+		return principal.Role, nil
+	}
+
+	subject := normalizeAdminSubject(principal.Subject)
+	if subject == "" {
+		return "", ErrAdminForbidden
+	}
+	if _, ok := service.bootstrapOwners[subject]; ok {
+		return domain.AdminRoleOwner, nil
+	}
+
+	assignment, err := service.roleStore.Get(ctx, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %s has no admin role", ErrAdminForbidden, subject)
+		}
+		return "", err
+	}
+	return assignment.Role, nil
+}
+
+// normalizeAdminSubject lowercases subjects so e-mail addresses match regardless of case.
+func normalizeAdminSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subject))
+}
@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+func TestAdminServiceCreateInterfaceUndoesCompletedSteps(t *testing.T) {
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: []string{"repo.CreateInterface", "interfaceStore.Create"}},
+		{failOn: "repo.CreateInterface", want: []string{"repo.CreateInterface"}},
+		{failOn: "interfaceStore.Create", want: []string{"repo.CreateInterface", "interfaceStore.Create", "repo.DeleteInterface"}},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			_, err := services.admin.CreateInterface(context.Background(), domain.InterfaceConfig{
+				ID:         "wg1",
+				Name:       "wg1",
+				Address:    "10.1.0.1/24",
+				ListenPort: 51821,
+				MTU:        1420,
+				Endpoint:   "vpn.example.com:51821",
+			})
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceUpdateInterfaceUndoesCompletedSteps(t *testing.T) {
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: []string{"repo.UpdateInterface", "interfaceStore.Update"}},
+		{failOn: "repo.UpdateInterface", want: []string{"repo.UpdateInterface"}},
+		{failOn: "interfaceStore.Update", want: []string{"repo.UpdateInterface", "interfaceStore.Update", "repo.UpdateInterface"}},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			_, err := services.admin.UpdateInterface(context.Background(), domain.InterfaceConfig{ID: "wg0", MTU: 1380})
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceDeleteInterfaceTearsDownAfterCommit(t *testing.T) {
+	rows := []string{
+		"peerRouteStore.DeleteByPeer",
+		"interfaceRouteStore.DeleteByInterface",
+		"peerStore.DeleteByInterface",
+		"allowedEmailStore.DeleteByInterface",
+		"interfaceStore.Delete",
+		"commit",
+	}
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: append(slices.Clone(rows), "repo.DeleteInterface")},
+		{failOn: "repo.DeleteInterface", want: append(slices.Clone(rows), "repo.DeleteInterface")},
+	}
+	// A failure inside the transaction must leave the live interface alone.
+	for index, step := range rows {
+		tests = append(tests, struct {
+			failOn string
+			want   []string
+		}{failOn: step, want: rows[:index+1]})
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			err := services.admin.DeleteInterface(context.Background(), "wg0")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceDeletePeerUndoesCompletedSteps(t *testing.T) {
+	removed := []string{"repo.RemovePeerFirewallRules", "repo.DeletePeer"}
+	restored := []string{"repo.UpdatePeerAllowedIPs", "repo.SyncPeerFirewallRules"}
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: append(slices.Clone(removed),
+			"ipAllocationStore.ReleaseByPeer", "peerRouteStore.DeleteByPeer", "peerStore.DeleteByPeerID", "commit")},
+		{failOn: "repo.RemovePeerFirewallRules", want: []string{"repo.RemovePeerFirewallRules"}},
+		{failOn: "repo.DeletePeer", want: []string{"repo.RemovePeerFirewallRules", "repo.DeletePeer", "repo.SyncPeerFirewallRules"}},
+		{failOn: "ipAllocationStore.ReleaseByPeer", want: slices.Concat(removed,
+			[]string{"ipAllocationStore.ReleaseByPeer"}, restored)},
+		{failOn: "peerRouteStore.DeleteByPeer", want: slices.Concat(removed,
+			[]string{"ipAllocationStore.ReleaseByPeer", "peerRouteStore.DeleteByPeer"}, restored)},
+		{failOn: "peerStore.DeleteByPeerID", want: slices.Concat(removed,
+			[]string{"ipAllocationStore.ReleaseByPeer", "peerRouteStore.DeleteByPeer", "peerStore.DeleteByPeerID"}, restored)},
+		{failOn: "commit", want: slices.Concat(removed,
+			[]string{"ipAllocationStore.ReleaseByPeer", "peerRouteStore.DeleteByPeer", "peerStore.DeleteByPeerID", "commit"}, restored)},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			err := services.admin.DeletePeer(context.Background(), "peer1")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceSuspendPeerUndoesCompletedSteps(t *testing.T) {
+	removed := []string{"repo.RemovePeerFirewallRules", "repo.DeletePeer"}
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: append(slices.Clone(removed), "peerStore.UpdateSuspension")},
+		{failOn: "repo.RemovePeerFirewallRules", want: []string{"repo.RemovePeerFirewallRules"}},
+		{failOn: "repo.DeletePeer", want: append(slices.Clone(removed), "repo.SyncPeerFirewallRules")},
+		{failOn: "peerStore.UpdateSuspension", want: append(slices.Clone(removed),
+			"peerStore.UpdateSuspension", "repo.UpdatePeerAllowedIPs", "repo.SyncPeerFirewallRules")},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			err := services.admin.SuspendPeer(context.Background(), "peer1")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceSuspendPeerMissingFromWireguard(t *testing.T) {
+	services := newFakeServices("")
+	services.repository.peerMissing = true
+	if err := services.admin.SuspendPeer(context.Background(), "peer1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"repo.RemovePeerFirewallRules", "repo.DeletePeer", "peerStore.UpdateSuspension"}; !slices.Equal(services.steps.calls, want) {
+		t.Errorf("calls = %v, want %v", services.steps.calls, want)
+	}
+
+	// A peer that was not in wireguard is not added back when a later step fails.
+	services = newFakeServices("peerStore.UpdateSuspension")
+	services.repository.peerMissing = true
+	err := services.admin.SuspendPeer(context.Background(), "peer1")
+	checkInjectedError(t, err, "peerStore.UpdateSuspension")
+	if want := []string{"repo.RemovePeerFirewallRules", "repo.DeletePeer", "peerStore.UpdateSuspension", "repo.SyncPeerFirewallRules"}; !slices.Equal(services.steps.calls, want) {
+		t.Errorf("calls = %v, want %v", services.steps.calls, want)
+	}
+}
+
+func TestAdminServiceCreateWireguardPeerUndoesCompletedSteps(t *testing.T) {
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: []string{
+			"ipAllocationStore.Allocate", "repo.CreatePeer", "ipAllocationStore.Assign", "repo.SyncPeerFirewallRules",
+		}},
+		{failOn: "ipAllocationStore.Allocate", want: []string{"ipAllocationStore.Allocate"}},
+		{failOn: "repo.CreatePeer", want: []string{
+			"ipAllocationStore.Allocate", "repo.CreatePeer",
+			"ipAllocationStore.Release",
+		}},
+		{failOn: "ipAllocationStore.Assign", want: []string{
+			"ipAllocationStore.Allocate", "repo.CreatePeer", "ipAllocationStore.Assign",
+			"repo.DeletePeer", "ipAllocationStore.Release",
+		}},
+		{failOn: "repo.SyncPeerFirewallRules", want: []string{
+			"ipAllocationStore.Allocate", "repo.CreatePeer", "ipAllocationStore.Assign", "repo.SyncPeerFirewallRules",
+			"repo.RemovePeerFirewallRules", "repo.DeletePeer", "ipAllocationStore.Release",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			_, err := services.admin.CreateWireguardPeer(context.Background(), "wg0", "", nil, "", "")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestAdminServiceCreateWireguardPeerGrantsStoredRoutesOnly(t *testing.T) {
+	services := newFakeServices("")
+	_, err := services.admin.CreateWireguardPeer(context.Background(), "wg0", "", []string{"192.168.0.0/16"}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []domain.AccessRule{{CIDR: "10.10.0.0/16", Protocol: domain.AccessProtocolAny}}
+	if !slices.Equal(services.repository.syncedRules, want) {
+		t.Errorf("firewall rules = %v, want the interface routes only", services.repository.syncedRules)
+	}
+}
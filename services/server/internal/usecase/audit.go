@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// unknownAuditActor is recorded when a mutation runs without an actor in its context.
+const unknownAuditActor = "unknown"
+
+// Auditor records mutations in the audit store and copies them to the audit sinks.
+// A failed write is logged but never fails the mutation, which has already been applied.
+// A nil Auditor records nothing.
+type Auditor struct {
+	store domain.AuditStore
+	sinks []domain.AuditSink
+}
+
+func NewAuditor(store domain.AuditStore, sinks ...domain.AuditSink) *Auditor {
+	return &Auditor{store: store, sinks: sinks}
+}
+
+// Record fills in the time, and the actor and request ID from ctx unless event already has them, and stores event.
+func (auditor *Auditor) Record(ctx context.Context, event domain.AuditEvent) {
+	if auditor == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	if event.Actor == "" {
+		event.Actor = domain.AuditActorFromContext(ctx)
+	}
+	if event.Actor == "" {
+		event.Actor = unknownAuditActor
+	}
+	if event.RequestID == "" {
+		event.RequestID = domain.RequestIDFromContext(ctx)
+	}
+
+	if auditor.store != nil {
+		// The event is stored even when the request context was canceled after the mutation.
+		if err := auditor.store.Append(context.WithoutCancel(ctx), event); err != nil {
+			log.Printf("append audit event %s %s/%s: %v", event.Action, event.TargetType, event.TargetID, err)
+		}
+	}
+	for _, sink := range auditor.sinks {
+		if err := sink.Write(event); err != nil {
+			log.Printf("write audit event %s %s/%s: %v", event.Action, event.TargetType, event.TargetID, err)
+		}
+	}
+}
+
+// List returns one page of audit events matching filter, newest first, and the cursor of the next page,
+// which is 0 on the last page.
+func (auditor *Auditor) List(ctx context.Context, filter domain.AuditEventFilter) ([]domain.AuditEvent, int64, error) {
+	if auditor == nil || auditor.store == nil {
+		return nil, 0, nil
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	pageSize := filter.Limit
+	filter.Limit++
+
+	events, err := auditor.store.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(events) <= pageSize {
+		return events, 0, nil
+	}
+	events = events[:pageSize]
+	return events, events[pageSize-1].ID, nil
+}
+
+// auditValue encodes the recorded state of a target. A nil value records no state.
+func auditValue(value map[string]any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("encode audit value: %v", err)
+		return nil
+	}
+	return encoded
+}
+
+func auditInterfaceValue(config domain.InterfaceConfig) map[string]any {
+	return map[string]any{
+		"name":                 config.Name,
+		"address":              config.Address,
+		"listen_port":          config.ListenPort,
+		"mtu":                  config.MTU,
+		"endpoint":             config.Endpoint,
+		"peer_key_policy":      config.PeerKeyPolicy,
+		"peer_ttl_seconds":     int64(config.PeerTTL / time.Second),
+		"max_devices_per_user": config.MaxDevicesPerUser,
+		"isolation_mode":       config.IsolationMode,
+	}
+}
+
+// auditRouteTargetID identifies a route by its owner, CIDR and, unless it allows any protocol, protocol and ports.
+func auditRouteTargetID(ownerID string, rule domain.AccessRule) string {
+	parts := []string{ownerID, rule.CIDR}
+	if rule.Protocol != domain.AccessProtocolAny {
+		parts = append(parts, rule.Protocol)
+	}
+	if rule.Ports != "" {
+		parts = append(parts, rule.Ports)
+	}
+	return auditTargetID(parts...)
+}
+
+func auditRouteValue(ownerKey string, ownerID string, rule domain.AccessRule) map[string]any {
+	return map[string]any{ownerKey: ownerID, "cidr": rule.CIDR, "protocol": rule.Protocol, "ports": rule.Ports}
+}
+
+func auditAccessRules(rules []domain.AccessRule) []map[string]any {
+	values := make([]map[string]any, 0, len(rules))
+	for _, rule := range rules {
+		values = append(values, map[string]any{"cidr": rule.CIDR, "protocol": rule.Protocol, "ports": rule.Ports})
+	}
+	return values
+}
+
+func auditPeerValue(record domain.PeerRecord) map[string]any {
+	value := map[string]any{
+		"email":        record.Email,
+		"interface_id": record.InterfaceID,
+		"allowed_ip":   record.AllowedIP,
+		"device_name":  record.DeviceName,
+		"suspended":    record.SuspendedAt != nil,
+	}
+	if record.ExpiresAt != nil {
+		value["expires_at"] = record.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// auditTargetID joins the parts that identify a target without an ID of its own, e.g. interface and CIDR of a route.
+func auditTargetID(parts ...string) string {
+	return strings.Join(parts, "/")
+}
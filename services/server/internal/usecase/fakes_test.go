@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+var errInjected = errors.New("injected failure")
+
+// fakeSteps records the state changing calls of the fakes in order and fails the step named in failOn.
+type fakeSteps struct {
+	failOn string
+	calls  []string
+}
+
+func (steps *fakeSteps) run(name string) error {
+	steps.calls = append(steps.calls, name)
+	if name == steps.failOn {
+		return errInjected
+	}
+	return nil
+}
+
+// fakeRepository is a wireguard repository whose state changes only get recorded. Methods the tests
+// do not need panic through the nil embedded interface.
+type fakeRepository struct {
+	domain.WireguardRepository
+	steps *fakeSteps
+	peer  domain.WireguardPeer
+	// peerMissing makes DeletePeer report that the peer is not on its interface.
+	peerMissing bool
+	// syncedRules holds the access rules of the last firewall sync.
+	syncedRules []domain.AccessRule
+}
+
+func (repo *fakeRepository) GeneratePrivateKey(ctx context.Context) (string, error) {
+	return "private-key", nil
+}
+
+func (repo *fakeRepository) CreateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
+	if err := repo.steps.run("repo.CreateInterface"); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+	return domain.WireguardInterface{ID: config.ID, Name: config.Name, Address: config.Address, ListenPort: config.ListenPort, MTU: config.MTU}, nil
+}
+
+func (repo *fakeRepository) UpdateInterface(ctx context.Context, config domain.InterfaceConfig) (domain.WireguardInterface, error) {
+	if err := repo.steps.run("repo.UpdateInterface"); err != nil {
+		return domain.WireguardInterface{}, err
+	}
+	return domain.WireguardInterface{ID: config.ID, Name: config.Name, Address: config.Address, ListenPort: config.ListenPort, MTU: config.MTU}, nil
+}
+
+func (repo *fakeRepository) DeleteInterface(ctx context.Context, interfaceID string) error {
+	return repo.steps.run("repo.DeleteInterface")
+}
+
+func (repo *fakeRepository) CreatePeer(ctx context.Context, spec domain.PeerSpec) (domain.WireguardPeer, error) {
+	if err := repo.steps.run("repo.CreatePeer"); err != nil {
+		return domain.WireguardPeer{}, err
+	}
+	return repo.peer, nil
+}
+
+func (repo *fakeRepository) DeletePeer(ctx context.Context, peerID string) error {
+	if err := repo.steps.run("repo.DeletePeer"); err != nil {
+		return err
+	}
+	if repo.peerMissing {
+		return domain.ErrWireguardPeerNotFound
+	}
+	return nil
+}
+
+func (repo *fakeRepository) UpdatePeerAllowedIPs(ctx context.Context, interfaceID string, peerID string, allowedIPs []string) error {
+	return repo.steps.run("repo.UpdatePeerAllowedIPs")
+}
+
+func (repo *fakeRepository) SyncPeerFirewallRules(ctx context.Context, interfaceID string, peerAllowedIP string, rules []domain.AccessRule) error {
+	repo.syncedRules = rules
+	return repo.steps.run("repo.SyncPeerFirewallRules")
+}
+
+func (repo *fakeRepository) RemovePeerFirewallRules(ctx context.Context, peerAllowedIP string) error {
+	return repo.steps.run("repo.RemovePeerFirewallRules")
+}
+
+type fakePeerStore struct {
+	domain.PeerStore
+	steps   *fakeSteps
+	records []domain.PeerRecord
+}
+
+func (store *fakePeerStore) GetByPeerID(ctx context.Context, peerID string) (domain.PeerRecord, error) {
+	for _, record := range store.records {
+		if record.PeerID == peerID {
+			return record, nil
+		}
+	}
+	return domain.PeerRecord{}, sql.ErrNoRows
+}
+
+func (store *fakePeerStore) ListByEmail(ctx context.Context, email string) ([]domain.PeerRecord, error) {
+	var records []domain.PeerRecord
+	for _, record := range store.records {
+		if record.Email == email {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (store *fakePeerStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.PeerRecord, error) {
+	var records []domain.PeerRecord
+	for _, record := range store.records {
+		if record.InterfaceID == interfaceID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (store *fakePeerStore) Create(ctx context.Context, record domain.PeerRecord) error {
+	return store.steps.run("peerStore.Create")
+}
+
+func (store *fakePeerStore) UpdateSuspension(ctx context.Context, peerID string, suspendedAt *time.Time) error {
+	return store.steps.run("peerStore.UpdateSuspension")
+}
+
+func (store *fakePeerStore) DeleteByPeerID(ctx context.Context, peerID string) error {
+	return store.steps.run("peerStore.DeleteByPeerID")
+}
+
+func (store *fakePeerStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
+	return store.steps.run("peerStore.DeleteByInterface")
+}
+
+type fakeInterfaceStore struct {
+	domain.InterfaceStore
+	steps   *fakeSteps
+	configs []domain.InterfaceConfig
+}
+
+func (store *fakeInterfaceStore) Get(ctx context.Context, id string) (domain.InterfaceConfig, error) {
+	index := slices.IndexFunc(store.configs, func(config domain.InterfaceConfig) bool { return config.ID == id })
+	if index < 0 {
+		return domain.InterfaceConfig{}, sql.ErrNoRows
+	}
+	return store.configs[index], nil
+}
+
+func (store *fakeInterfaceStore) Create(ctx context.Context, config domain.InterfaceConfig) error {
+	return store.steps.run("interfaceStore.Create")
+}
+
+func (store *fakeInterfaceStore) Update(ctx context.Context, config domain.InterfaceConfig) error {
+	return store.steps.run("interfaceStore.Update")
+}
+
+func (store *fakeInterfaceStore) Delete(ctx context.Context, id string) error {
+	return store.steps.run("interfaceStore.Delete")
+}
+
+type fakeAllowedEmailStore struct {
+	domain.AllowedEmailStore
+	steps *fakeSteps
+}
+
+func (store *fakeAllowedEmailStore) Exists(ctx context.Context, interfaceID string, email string) (bool, error) {
+	return true, nil
+}
+
+func (store *fakeAllowedEmailStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
+	return store.steps.run("allowedEmailStore.DeleteByInterface")
+}
+
+type fakeInterfaceRouteStore struct {
+	domain.InterfaceRouteStore
+	steps *fakeSteps
+}
+
+func (store *fakeInterfaceRouteStore) ListByInterface(ctx context.Context, interfaceID string) ([]domain.InterfaceRoute, error) {
+	return []domain.InterfaceRoute{{InterfaceID: interfaceID, CIDR: "10.10.0.0/16", Protocol: domain.AccessProtocolAny}}, nil
+}
+
+func (store *fakeInterfaceRouteStore) DeleteByInterface(ctx context.Context, interfaceID string) error {
+	return store.steps.run("interfaceRouteStore.DeleteByInterface")
+}
+
+type fakePeerRouteStore struct {
+	domain.PeerRouteStore
+	steps *fakeSteps
+}
+
+func (store *fakePeerRouteStore) ListByPeer(ctx context.Context, peerID string) ([]domain.PeerRoute, error) {
+	return nil, nil
+}
+
+func (store *fakePeerRouteStore) DeleteByPeer(ctx context.Context, peerID string) error {
+	return store.steps.run("peerRouteStore.DeleteByPeer")
+}
+
+type fakeGroupStore struct {
+	domain.GroupStore
+}
+
+func (store *fakeGroupStore) ListGroupsByEmail(ctx context.Context, email string) ([]string, error) {
+	return nil, nil
+}
+
+func (store *fakeGroupStore) ListInterfaceIDsByGroups(ctx context.Context, groups []string) ([]string, error) {
+	return nil, nil
+}
+
+type fakeIPAllocationStore struct {
+	domain.IPAllocationStore
+	steps *fakeSteps
+}
+
+func (store *fakeIPAllocationStore) Allocate(ctx context.Context, interfaceID string, requested string) (string, error) {
+	if err := store.steps.run("ipAllocationStore.Allocate"); err != nil {
+		return "", err
+	}
+	return "10.0.0.2/32", nil
+}
+
+func (store *fakeIPAllocationStore) Assign(ctx context.Context, interfaceID string, addresses string, peerID string) error {
+	return store.steps.run("ipAllocationStore.Assign")
+}
+
+func (store *fakeIPAllocationStore) Release(ctx context.Context, interfaceID string, addresses string) error {
+	return store.steps.run("ipAllocationStore.Release")
+}
+
+func (store *fakeIPAllocationStore) ReleaseByPeer(ctx context.Context, peerID string) error {
+	return store.steps.run("ipAllocationStore.ReleaseByPeer")
+}
+
+// fakeTransactor runs fn and then records the commit, which fails when it is the injected step.
+type fakeTransactor struct {
+	steps *fakeSteps
+}
+
+func (transactor *fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	return transactor.steps.run("commit")
+}
+
+// fakeServices holds services wired to fakes that share one step log. The store holds one interface, wg0,
+// with one active peer, peer1 of alice@example.com.
+type fakeServices struct {
+	steps      *fakeSteps
+	repository *fakeRepository
+	admin      *AdminService
+	wireguard  *WireguardService
+}
+
+func newFakeServices(failOn string) fakeServices {
+	steps := &fakeSteps{failOn: failOn}
+	repository := &fakeRepository{steps: steps, peer: domain.WireguardPeer{ID: "peer2", InterfaceID: "wg0", AllowedIP: "10.0.0.3/32", Config: "[Interface]"}}
+	peerStore := &fakePeerStore{steps: steps, records: []domain.PeerRecord{
+		{Email: "alice@example.com", PeerID: "peer1", InterfaceID: "wg0", AllowedIP: "10.0.0.2/32", DeviceName: domain.DefaultPeerDeviceName},
+	}}
+	interfaceStore := &fakeInterfaceStore{steps: steps, configs: []domain.InterfaceConfig{{
+		ID:                "wg0",
+		Name:              "wg0",
+		Address:           "10.0.0.1/24",
+		ListenPort:        51820,
+		MTU:               1420,
+		Endpoint:          "vpn.example.com:51820",
+		PeerKeyPolicy:     domain.PeerKeyPolicyServerGenerated,
+		MaxDevicesPerUser: 2,
+		IsolationMode:     domain.InterfaceIsolationRoutesOnly,
+	}}}
+	allowedEmailStore := &fakeAllowedEmailStore{steps: steps}
+	interfaceRouteStore := &fakeInterfaceRouteStore{steps: steps}
+	peerRouteStore := &fakePeerRouteStore{steps: steps}
+	groupStore := &fakeGroupStore{}
+
+	return fakeServices{
+		steps:      steps,
+		repository: repository,
+		admin: NewAdminService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore, peerRouteStore,
+			&fakeIPAllocationStore{steps: steps}, groupStore, &fakeTransactor{steps: steps}, nil, nil),
+		wireguard: NewWireguardService(repository, peerStore, interfaceStore, allowedEmailStore, interfaceRouteStore, peerRouteStore, groupStore, nil),
+	}
+}
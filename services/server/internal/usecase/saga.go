@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// saga runs an operation whose steps span wireguard, the firewall and the database, which cannot share
+// a transaction. Every completed step registers how to undo it; when a later step fails, the registered
+// compensations run newest first so the operation leaves nothing half done.
+type saga struct {
+	compensations []sagaCompensation
+}
+
+type sagaCompensation struct {
+	step string
+	undo func(ctx context.Context) error
+}
+
+// onRollback registers undo as the compensation of the step that just completed.
+func (s *saga) onRollback(step string, undo func(ctx context.Context) error) {
+	s.compensations = append(s.compensations, sagaCompensation{step: step, undo: undo})
+}
+
+// fail undoes the completed steps and returns err joined with the errors of compensations that failed.
+// Compensations run even when ctx has been canceled, since the steps they undo were already applied.
+func (s *saga) fail(ctx context.Context, err error) error {
+	ctx = context.WithoutCancel(ctx)
+	errs := []error{err}
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		compensation := s.compensations[i]
+		if undoErr := compensation.undo(ctx); undoErr != nil {
+			errs = append(errs, fmt.Errorf("undo %s: %w", compensation.step, undoErr))
+		}
+	}
+	s.compensations = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSagaFailUndoesStepsNewestFirst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var undone []string
+	undo := func(step string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				t.Errorf("undo %s ran with a canceled context", step)
+			}
+			undone = append(undone, step)
+			return err
+		}
+	}
+	undoErr := errors.New("undo failed")
+
+	var steps saga
+	steps.onRollback("first", undo("first", nil))
+	steps.onRollback("second", undo("second", undoErr))
+	steps.onRollback("third", undo("third", nil))
+	err := steps.fail(ctx, errInjected)
+
+	if want := []string{"third", "second", "first"}; !slices.Equal(undone, want) {
+		t.Errorf("undone = %v, want %v", undone, want)
+	}
+	if !errors.Is(err, errInjected) || !errors.Is(err, undoErr) {
+		t.Errorf("err = %v, want the step error joined with the undo error", err)
+	}
+
+	undone = nil
+	if err := steps.fail(ctx, errInjected); !errors.Is(err, errInjected) {
+		t.Errorf("second fail err = %v", err)
+	}
+	if len(undone) != 0 {
+		t.Errorf("second fail undid %v again", undone)
+	}
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+var ErrScimUserNotFound = errors.New("scim user not found")
+var ErrScimGroupNotFound = errors.New("scim group not found")
+var ErrInvalidScimResource = errors.New("invalid scim resource")
+
+type ScimUsecase interface {
+	ListUsers(ctx context.Context, userName string) ([]domain.ScimUser, error)
+	GetUser(ctx context.Context, id string) (domain.ScimUser, error)
+	CreateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error)
+	ReplaceUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	ListGroups(ctx context.Context, displayName string) ([]domain.ScimGroup, error)
+	GetGroup(ctx context.Context, id string) (domain.ScimGroup, error)
+	CreateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error)
+	ReplaceGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// ScimService keeps the users and groups provisioned by the identity provider.
+// Group memberships of active users grant interfaces like identity provider group claims do.
+// Deactivating or deleting a user revokes the user's allowed emails and peers through admin-server.
+type ScimService struct {
+	store             domain.ScimStore
+	allowedEmailStore domain.AllowedEmailStore
+	peerStore         domain.PeerStore
+	revoker           domain.AccessRevoker
+}
+
+func NewScimService(store domain.ScimStore, allowedEmailStore domain.AllowedEmailStore, peerStore domain.PeerStore, revoker domain.AccessRevoker) *ScimService {
+	return &ScimService{
+		store:             store,
+		allowedEmailStore: allowedEmailStore,
+		peerStore:         peerStore,
+		revoker:           revoker,
+	}
+}
+
+func (service *ScimService) ListUsers(ctx context.Context, userName string) ([]domain.ScimUser, error) {
+	return service.store.ListUsers(ctx, strings.TrimSpace(userName))
+}
+
+func (service *ScimService) GetUser(ctx context.Context, id string) (domain.ScimUser, error) {
+	user, err := service.store.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScimUser{}, ErrScimUserNotFound
+		}
+		return domain.ScimUser{}, err
+	}
+	return user, nil
+}
+
+func (service *ScimService) CreateUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	user.UserName = strings.TrimSpace(user.UserName)
+	if user.UserName == "" {
+		return domain.ScimUser{}, fmt.Errorf("%w: userName is required", ErrInvalidScimResource)
+	}
+	return service.store.CreateUser(ctx, user)
+}
+
+// ReplaceUser stores user over the existing user with the same ID.
+// When an active user is deactivated, the user's access is revoked before the change is stored,
+// so a failed revocation can be retried by sending the same request again.
+func (service *ScimService) ReplaceUser(ctx context.Context, user domain.ScimUser) (domain.ScimUser, error) {
+	user.UserName = strings.TrimSpace(user.UserName)
+	if user.UserName == "" {
+		return domain.ScimUser{}, fmt.Errorf("%w: userName is required", ErrInvalidScimResource)
+	}
+
+	current, err := service.GetUser(ctx, user.ID)
+	if err != nil {
+		return domain.ScimUser{}, err
+	}
+	if current.Active && !user.Active {
+		if err := service.revoke(ctx, current.UserName); err != nil {
+			return domain.ScimUser{}, err
+		}
+	}
+
+	updated, err := service.store.UpdateUser(ctx, user)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScimUser{}, ErrScimUserNotFound
+		}
+		return domain.ScimUser{}, err
+	}
+	return updated, nil
+}
+
+func (service *ScimService) DeleteUser(ctx context.Context, id string) error {
+	user, err := service.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := service.revoke(ctx, user.UserName); err != nil {
+		return err
+	}
+
+	if err := service.store.DeleteUser(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScimUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (service *ScimService) ListGroups(ctx context.Context, displayName string) ([]domain.ScimGroup, error) {
+	return service.store.ListGroups(ctx, strings.TrimSpace(displayName))
+}
+
+func (service *ScimService) GetGroup(ctx context.Context, id string) (domain.ScimGroup, error) {
+	group, err := service.store.GetGroup(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScimGroup{}, ErrScimGroupNotFound
+		}
+		return domain.ScimGroup{}, err
+	}
+	return group, nil
+}
+
+func (service *ScimService) CreateGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	group.DisplayName = strings.TrimSpace(group.DisplayName)
+	if group.DisplayName == "" {
+		return domain.ScimGroup{}, fmt.Errorf("%w: displayName is required", ErrInvalidScimResource)
+	}
+	return service.store.CreateGroup(ctx, group)
+}
+
+// ReplaceGroup stores group, including its full member list, over the existing group with the same ID.
+// Members that leave a group keep their peers; they only lose the ability to create new ones.
+func (service *ScimService) ReplaceGroup(ctx context.Context, group domain.ScimGroup) (domain.ScimGroup, error) {
+	group.DisplayName = strings.TrimSpace(group.DisplayName)
+	if group.DisplayName == "" {
+		return domain.ScimGroup{}, fmt.Errorf("%w: displayName is required", ErrInvalidScimResource)
+	}
+
+	updated, err := service.store.UpdateGroup(ctx, group)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScimGroup{}, ErrScimGroupNotFound
+		}
+		return domain.ScimGroup{}, err
+	}
+	return updated, nil
+}
+
+func (service *ScimService) DeleteGroup(ctx context.Context, id string) error {
+	if err := service.store.DeleteGroup(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScimGroupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// revoke deletes the allowed emails of email on every interface it can access and then the peers
+// that were kept because another rule still grants the interface.
+func (service *ScimService) revoke(ctx context.Context, email string) error {
+	interfaceIDs, err := service.allowedEmailStore.ListInterfaceIDsByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	for _, interfaceID := range interfaceIDs {
+		if _, err := service.revoker.DeleteAllowedEmail(ctx, interfaceID, email); err != nil {
+			return err
+		}
+	}
+
+	peers, err := service.peerStore.ListByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	for _, peer := range peers {
+		if err := service.revoker.DeletePeer(ctx, peer.PeerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
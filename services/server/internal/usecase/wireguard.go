@@ -3,18 +3,27 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/nomuken/william/services/server/internal/domain"
 )
 
 type WireguardUsecase interface {
-	ListInterfaces(ctx context.Context, email string) ([]domain.WireguardInterface, error)
-	CreatePeer(ctx context.Context, email string, interfaceID string) (domain.WireguardPeer, error)
-	GetPeerByEmail(ctx context.Context, email string) (domain.PeerRecord, error)
-	GetPeerByEmailAndInterface(ctx context.Context, email string, interfaceID string) (domain.PeerRecord, error)
-	DeletePeer(ctx context.Context, email string, peerID string) error
-	ListPeerStatuses(ctx context.Context, email string) ([]domain.PeerStatus, error)
+	ListInterfaces(ctx context.Context, user domain.UserIdentity) ([]domain.WireguardInterface, error)
+	CreatePeer(ctx context.Context, user domain.UserIdentity, interfaceID string, publicKey string, deviceName string) (domain.PeerRecord, error)
+	GetPeerByEmail(ctx context.Context, user domain.UserIdentity) (domain.PeerRecord, error)
+	GetPeerByEmailAndInterface(ctx context.Context, user domain.UserIdentity, interfaceID string) (domain.PeerRecord, error)
+	ListPeers(ctx context.Context, user domain.UserIdentity) ([]domain.PeerRecord, error)
+	DeletePeer(ctx context.Context, user domain.UserIdentity, peerID string) error
+	RenewPeer(ctx context.Context, user domain.UserIdentity, peerID string, duration time.Duration) (domain.PeerRecord, error)
+	ListPeerStatuses(ctx context.Context, user domain.UserIdentity) ([]domain.PeerStatus, error)
 }
 
 type WireguardService struct {
@@ -23,6 +32,9 @@ type WireguardService struct {
 	interfaceStore      domain.InterfaceStore
 	allowedEmailStore   domain.AllowedEmailStore
 	interfaceRouteStore domain.InterfaceRouteStore
+	peerRouteStore      domain.PeerRouteStore
+	groupStore          domain.GroupStore
+	auditor             *Auditor
 }
 
 var ErrPeerAlreadyExists = errors.New("peer already exists")
@@ -30,26 +42,54 @@ var ErrPeerNotFound = errors.New("peer not found")
 var ErrPeerForbidden = errors.New("peer access forbidden")
 var ErrEmailNotAllowed = errors.New("email is not allowed")
 var ErrInterfaceNotFound = errors.New("interface not found")
-
-func NewWireguardService(repository domain.WireguardRepository, store domain.PeerStore, interfaceStore domain.InterfaceStore, allowedEmailStore domain.AllowedEmailStore, interfaceRouteStore domain.InterfaceRouteStore) *WireguardService {
+var ErrInvalidPublicKey = errors.New("public key must be a base64 encoded 32 byte key")
+var ErrPublicKeyRequired = errors.New("interface requires a client supplied public key")
+var ErrInvalidPeerKeyPolicy = errors.New("invalid peer key policy")
+var ErrInvalidIsolationMode = errors.New("invalid interface isolation mode")
+var ErrInvalidPeerAddress = errors.New("invalid peer address")
+var ErrPeerAddressInUse = errors.New("peer address is already in use")
+var ErrPeerDoesNotExpire = errors.New("peer does not expire")
+var ErrInvalidRenewDuration = errors.New("renew duration must not be negative")
+var ErrInvalidAllowedEmailRule = errors.New("invalid allowed email rule")
+var ErrAllowedEmailNotFound = errors.New("allowed email not found")
+var ErrInvalidDeviceName = errors.New("device name must be at most 64 characters without control characters")
+var ErrDeviceLimitReached = errors.New("device limit reached for this interface")
+var ErrInvalidFirewallCIDR = errors.New("invalid firewall cidr")
+var ErrInvalidAccessRule = errors.New("invalid access rule")
+
+const maxDeviceNameLength = 64
+
+func NewWireguardService(repository domain.WireguardRepository, store domain.PeerStore, interfaceStore domain.InterfaceStore, allowedEmailStore domain.AllowedEmailStore, interfaceRouteStore domain.InterfaceRouteStore, peerRouteStore domain.PeerRouteStore, groupStore domain.GroupStore, auditor *Auditor) *WireguardService {
 	return &WireguardService{
 		repository:          repository,
 		store:               store,
 		interfaceStore:      interfaceStore,
 		allowedEmailStore:   allowedEmailStore,
 		interfaceRouteStore: interfaceRouteStore,
+		peerRouteStore:      peerRouteStore,
+		groupStore:          groupStore,
+		auditor:             auditor,
 	}
 }
 
-func (service *WireguardService) ListInterfaces(ctx context.Context, email string) ([]domain.WireguardInterface, error) {
-	if email == "" {
+func (service *WireguardService) ListInterfaces(ctx context.Context, user domain.UserIdentity) ([]domain.WireguardInterface, error) {
+	if user.Email == "" {
 		return nil, errors.New("email is required")
 	}
 
-	allowedInterfaces, err := service.allowedEmailStore.ListInterfaceIDsByEmail(ctx, email)
+	allowedInterfaces, err := service.allowedEmailStore.ListInterfaceIDsByEmail(ctx, user.Email)
 	if err != nil {
 		return nil, err
 	}
+	groups, err := service.userGroups(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	groupInterfaces, err := service.groupStore.ListInterfaceIDsByGroups(ctx, groups)
+	if err != nil {
+		return nil, err
+	}
+	allowedInterfaces = append(allowedInterfaces, groupInterfaces...)
 
 	allInterfaces, err := service.repository.ListInterfaces(ctx)
 	if err != nil {
@@ -73,8 +113,13 @@ func (service *WireguardService) ListInterfaces(ctx context.Context, email strin
 	interfaces := make([]domain.WireguardInterface, 0, len(allowedInterfaces))
 	for _, item := range allInterfaces {
 		if _, ok := allowed[item.ID]; ok {
-			if config, ok := configByID[item.ID]; ok && config.Name != "" {
-				item.Name = config.Name
+			if config, ok := configByID[item.ID]; ok {
+				if config.Name != "" {
+					item.Name = config.Name
+				}
+				item.PeerKeyPolicy = config.PeerKeyPolicy
+				item.PeerTTL = config.PeerTTL
+				item.MaxDevicesPerUser = config.MaxDevicesPerUser
 			}
 			interfaces = append(interfaces, item)
 		}
@@ -83,12 +128,12 @@ func (service *WireguardService) ListInterfaces(ctx context.Context, email strin
 	return interfaces, nil
 }
 
-func (service *WireguardService) ListPeerStatuses(ctx context.Context, email string) ([]domain.PeerStatus, error) {
-	if email == "" {
+func (service *WireguardService) ListPeerStatuses(ctx context.Context, user domain.UserIdentity) ([]domain.PeerStatus, error) {
+	if user.Email == "" {
 		return nil, errors.New("email is required")
 	}
 
-	peers, err := service.store.ListByEmail(ctx, email)
+	peers, err := service.store.ListByEmail(ctx, user.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -134,72 +179,110 @@ func (service *WireguardService) ListPeerStatuses(ctx context.Context, email str
 	return items, nil
 }
 
-func (service *WireguardService) CreatePeer(ctx context.Context, email string, interfaceID string) (domain.WireguardPeer, error) {
-	if email == "" {
-		return domain.WireguardPeer{}, errors.New("email is required")
+// CreatePeer adds a named device of the caller to the interface. An empty device name is DefaultPeerDeviceName.
+// Each email may own at most the interface's MaxDevicesPerUser devices, and device names are unique per interface.
+// When a step fails, the wireguard peer and firewall rules created before it are removed again.
+func (service *WireguardService) CreatePeer(ctx context.Context, user domain.UserIdentity, interfaceID string, publicKey string, deviceName string) (domain.PeerRecord, error) {
+	if user.Email == "" {
+		return domain.PeerRecord{}, errors.New("email is required")
+	}
+	if err := validatePeerPublicKey(publicKey); err != nil {
+		return domain.PeerRecord{}, err
+	}
+	deviceName, err := normalizeDeviceName(deviceName)
+	if err != nil {
+		return domain.PeerRecord{}, err
 	}
 
-	allowed, err := service.allowedEmailStore.Exists(ctx, interfaceID, email)
+	allowed, err := service.hasAccess(ctx, user, interfaceID)
 	if err != nil {
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, err
 	}
 	if !allowed {
-		return domain.WireguardPeer{}, ErrEmailNotAllowed
-	}
-
-	if _, err := service.store.GetByEmailAndInterface(ctx, email, interfaceID); err == nil {
-		return domain.WireguardPeer{}, ErrPeerAlreadyExists
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, ErrEmailNotAllowed
 	}
 
 	interfaceConfig, err := service.interfaceStore.Get(ctx, interfaceID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return domain.WireguardPeer{}, ErrInterfaceNotFound
+			return domain.PeerRecord{}, ErrInterfaceNotFound
 		}
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, err
+	}
+	if err := service.checkDeviceAvailable(ctx, user.Email, interfaceConfig, deviceName); err != nil {
+		return domain.PeerRecord{}, err
 	}
 	if interfaceConfig.Endpoint == "" {
-		return domain.WireguardPeer{}, errors.New("endpoint is required")
+		return domain.PeerRecord{}, errors.New("endpoint is required")
+	}
+	if err := checkPeerKeyPolicy(interfaceConfig.PeerKeyPolicy, publicKey); err != nil {
+		return domain.PeerRecord{}, err
+	}
+	if err := service.ensurePeerIDAvailable(ctx, publicKey); err != nil {
+		return domain.PeerRecord{}, err
 	}
 
-	allowedIPs, err := service.interfaceRouteStore.ListByInterface(ctx, interfaceID)
+	interfaceRoutes, err := service.interfaceRouteStore.ListByInterface(ctx, interfaceID)
 	if err != nil {
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, err
 	}
-	peerAllowedIPs := extractInterfaceRouteCIDRs(allowedIPs)
+	peerAllowedIPs := dedupeStrings(extractInterfaceRouteCIDRs(interfaceRoutes))
 
-	peer, err := service.repository.CreatePeer(ctx, interfaceID, interfaceConfig.Endpoint, peerAllowedIPs)
+	var steps saga
+	peer, err := service.repository.CreatePeer(ctx, domain.PeerSpec{
+		InterfaceID: interfaceID,
+		Endpoint:    interfaceConfig.Endpoint,
+		AllowedIPs:  peerAllowedIPs,
+		PublicKey:   publicKey,
+	})
 	if err != nil {
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, err
 	}
+	steps.onRollback("wireguard peer", func(ctx context.Context) error {
+		return service.repository.DeletePeer(ctx, peer.ID)
+	})
 
+	// Registered before the sync so rules it added before failing are removed as well.
+	steps.onRollback("firewall rules", func(ctx context.Context) error {
+		return service.repository.RemovePeerFirewallRules(ctx, peer.AllowedIP)
+	})
 	// Sync iptables rules for the newly created peer
-	if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, peerAllowedIPs); err != nil {
-		return domain.WireguardPeer{}, err
+	if err := service.repository.SyncPeerFirewallRules(ctx, interfaceID, peer.AllowedIP, domain.RouteAccessRules(interfaceRoutes, nil)); err != nil {
+		return domain.PeerRecord{}, steps.fail(ctx, err)
 	}
 
 	record := domain.PeerRecord{
-		Email:       email,
+		Email:       user.Email,
 		PeerID:      peer.ID,
 		InterfaceID: peer.InterfaceID,
 		AllowedIP:   peer.AllowedIP,
 		Config:      peer.Config,
+		DeviceName:  deviceName,
+	}
+	if interfaceConfig.PeerTTL > 0 {
+		expiresAt := time.Now().Add(interfaceConfig.PeerTTL)
+		record.ExpiresAt = &expiresAt
 	}
 	if err := service.store.Create(ctx, record); err != nil {
-		return domain.WireguardPeer{}, err
+		return domain.PeerRecord{}, steps.fail(ctx, err)
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Actor:      user.Email,
+		Action:     domain.AuditActionPeerCreate,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		After:      auditValue(auditPeerValue(record)),
+	})
 
-	return peer, nil
+	return record, nil
 }
 
-func (service *WireguardService) GetPeerByEmail(ctx context.Context, email string) (domain.PeerRecord, error) {
-	if email == "" {
+func (service *WireguardService) GetPeerByEmail(ctx context.Context, user domain.UserIdentity) (domain.PeerRecord, error) {
+	if user.Email == "" {
 		return domain.PeerRecord{}, errors.New("email is required")
 	}
 
-	record, err := service.store.GetByEmail(ctx, email)
+	record, err := service.store.GetByEmail(ctx, user.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return domain.PeerRecord{}, ErrPeerNotFound
@@ -207,7 +290,7 @@ func (service *WireguardService) GetPeerByEmail(ctx context.Context, email strin
 		return domain.PeerRecord{}, err
 	}
 
-	allowed, err := service.allowedEmailStore.Exists(ctx, record.InterfaceID, email)
+	allowed, err := service.hasAccess(ctx, user, record.InterfaceID)
 	if err != nil {
 		return domain.PeerRecord{}, err
 	}
@@ -218,15 +301,15 @@ func (service *WireguardService) GetPeerByEmail(ctx context.Context, email strin
 	return record, nil
 }
 
-func (service *WireguardService) GetPeerByEmailAndInterface(ctx context.Context, email string, interfaceID string) (domain.PeerRecord, error) {
-	if email == "" {
+func (service *WireguardService) GetPeerByEmailAndInterface(ctx context.Context, user domain.UserIdentity, interfaceID string) (domain.PeerRecord, error) {
+	if user.Email == "" {
 		return domain.PeerRecord{}, errors.New("email is required")
 	}
 	if interfaceID == "" {
 		return domain.PeerRecord{}, errors.New("interface id is required")
 	}
 
-	record, err := service.store.GetByEmailAndInterface(ctx, email, interfaceID)
+	record, err := service.store.GetByEmailAndInterface(ctx, user.Email, interfaceID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return domain.PeerRecord{}, ErrPeerNotFound
@@ -234,7 +317,7 @@ func (service *WireguardService) GetPeerByEmailAndInterface(ctx context.Context,
 		return domain.PeerRecord{}, err
 	}
 
-	allowed, err := service.allowedEmailStore.Exists(ctx, record.InterfaceID, email)
+	allowed, err := service.hasAccess(ctx, user, record.InterfaceID)
 	if err != nil {
 		return domain.PeerRecord{}, err
 	}
@@ -245,11 +328,45 @@ func (service *WireguardService) GetPeerByEmailAndInterface(ctx context.Context,
 	return record, nil
 }
 
-func (service *WireguardService) DeletePeer(ctx context.Context, email string, peerID string) error {
+// ListPeers returns every device of the caller on interfaces the caller can still access.
+func (service *WireguardService) ListPeers(ctx context.Context, user domain.UserIdentity) ([]domain.PeerRecord, error) {
+	if user.Email == "" {
+		return nil, errors.New("email is required")
+	}
+
+	records, err := service.store.ListByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	access := make(map[string]bool)
+	peers := make([]domain.PeerRecord, 0, len(records))
+	for _, record := range records {
+		allowed, ok := access[record.InterfaceID]
+		if !ok {
+			allowed, err = service.hasAccess(ctx, user, record.InterfaceID)
+			if err != nil {
+				return nil, err
+			}
+			access[record.InterfaceID] = allowed
+		}
+		if allowed {
+			peers = append(peers, record)
+		}
+	}
+
+	return peers, nil
+}
+
+// DeletePeer removes one device of the caller. The record is deleted before the peer is torn down: admin-server
+// releases the peer's addresses as it removes the wireguard peer, so undoing that removal after a failed record
+// delete would re-add the peer on addresses it no longer holds. Live state a failed teardown leaves behind
+// belongs to no stored peer, and the reconciler removes it.
+func (service *WireguardService) DeletePeer(ctx context.Context, user domain.UserIdentity, peerID string) error {
 	if peerID == "" {
 		return errors.New("peer id is required")
 	}
-	if email == "" {
+	if user.Email == "" {
 		return errors.New("email is required")
 	}
 
@@ -260,26 +377,227 @@ func (service *WireguardService) DeletePeer(ctx context.Context, email string, p
 		}
 		return err
 	}
-	if record.Email != email {
+	if record.Email != user.Email {
 		return ErrPeerForbidden
 	}
 
-	// Remove iptables rules for this peer
-	if err := service.repository.RemovePeerFirewallRules(ctx, record.AllowedIP); err != nil {
+	if err := service.store.DeleteByPeerID(ctx, record.PeerID); err != nil {
 		return err
 	}
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Actor:      user.Email,
+		Action:     domain.AuditActionPeerDelete,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		Before:     auditValue(auditPeerValue(record)),
+	})
+
+	// A suspended peer is already gone from wireguard and the firewall.
+	if record.SuspendedAt != nil {
+		return nil
+	}
+	// The wireguard peer is removed even when its firewall rules could not be, so its addresses are released.
+	err = errors.Join(
+		service.repository.RemovePeerFirewallRules(ctx, record.AllowedIP),
+		service.repository.DeletePeer(ctx, record.PeerID),
+	)
+	if err != nil {
+		return fmt.Errorf("peer %s was deleted from the database but not from wireguard: %w", record.PeerID, err)
+	}
+	return nil
+}
+
+// RenewPeer extends the expiry of the caller's peer to now plus duration. The duration is capped at the
+// interface peer TTL, zero requests the full TTL, and a renewal never moves the expiry earlier.
+func (service *WireguardService) RenewPeer(ctx context.Context, user domain.UserIdentity, peerID string, duration time.Duration) (domain.PeerRecord, error) {
+	if peerID == "" {
+		return domain.PeerRecord{}, errors.New("peer id is required")
+	}
+	if user.Email == "" {
+		return domain.PeerRecord{}, errors.New("email is required")
+	}
+	if duration < 0 {
+		return domain.PeerRecord{}, ErrInvalidRenewDuration
+	}
+
+	record, err := service.store.GetByPeerID(ctx, peerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PeerRecord{}, ErrPeerNotFound
+		}
+		return domain.PeerRecord{}, err
+	}
+	if record.Email != user.Email {
+		return domain.PeerRecord{}, ErrPeerForbidden
+	}
 
-	if err := service.repository.DeletePeer(ctx, record.PeerID); err != nil {
+	allowed, err := service.hasAccess(ctx, user, record.InterfaceID)
+	if err != nil {
+		return domain.PeerRecord{}, err
+	}
+	if !allowed {
+		return domain.PeerRecord{}, ErrEmailNotAllowed
+	}
+
+	interfaceConfig, err := service.interfaceStore.Get(ctx, record.InterfaceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PeerRecord{}, ErrInterfaceNotFound
+		}
+		return domain.PeerRecord{}, err
+	}
+	if interfaceConfig.PeerTTL <= 0 || record.ExpiresAt == nil {
+		return domain.PeerRecord{}, ErrPeerDoesNotExpire
+	}
+
+	if duration == 0 || duration > interfaceConfig.PeerTTL {
+		duration = interfaceConfig.PeerTTL
+	}
+	expiresAt := time.Now().Add(duration)
+	if expiresAt.Before(*record.ExpiresAt) {
+		return record, nil
+	}
+
+	if err := service.store.UpdateExpiry(ctx, record.PeerID, &expiresAt); err != nil {
+		return domain.PeerRecord{}, err
+	}
+	renewed := record
+	renewed.ExpiresAt = &expiresAt
+	service.auditor.Record(ctx, domain.AuditEvent{
+		Actor:      user.Email,
+		Action:     domain.AuditActionPeerRenew,
+		TargetType: domain.AuditTargetPeer,
+		TargetID:   record.PeerID,
+		Before:     auditValue(auditPeerValue(record)),
+		After:      auditValue(auditPeerValue(renewed)),
+	})
+	return renewed, nil
+}
+
+// hasAccess reports whether the user may use the interface, either through an allowed email rule
+// or through membership of a group bound to the interface.
+func (service *WireguardService) hasAccess(ctx context.Context, user domain.UserIdentity, interfaceID string) (bool, error) {
+	allowed, err := service.allowedEmailStore.Exists(ctx, interfaceID, user.Email)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	groups, err := service.userGroups(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	interfaceIDs, err := service.groupStore.ListInterfaceIDsByGroups(ctx, groups)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(interfaceIDs, interfaceID), nil
+}
+
+// userGroups merges the groups asserted by the identity provider with the locally synced memberships.
+func (service *WireguardService) userGroups(ctx context.Context, user domain.UserIdentity) ([]string, error) {
+	localGroups, err := service.groupStore.ListGroupsByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return dedupeStrings(append(slices.Clone(user.Groups), localGroups...)), nil
+}
+
+// ensurePeerIDAvailable rejects a client supplied public key that is already registered as a peer.
+func (service *WireguardService) ensurePeerIDAvailable(ctx context.Context, publicKey string) error {
+	if publicKey == "" {
+		return nil
+	}
+	if _, err := service.store.GetByPeerID(ctx, publicKey); err == nil {
+		return ErrPeerAlreadyExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
+	return nil
+}
 
-	if err := service.store.DeleteByPeerID(ctx, record.PeerID); err != nil {
+// checkDeviceAvailable rejects a device name the email already uses on the interface
+// and a new device beyond the interface's device limit.
+func (service *WireguardService) checkDeviceAvailable(ctx context.Context, email string, interfaceConfig domain.InterfaceConfig, deviceName string) error {
+	records, err := service.store.ListByEmail(ctx, email)
+	if err != nil {
 		return err
 	}
 
+	devices := 0
+	for _, record := range records {
+		if record.InterfaceID != interfaceConfig.ID {
+			continue
+		}
+		if record.DeviceName == deviceName {
+			return ErrPeerAlreadyExists
+		}
+		devices++
+	}
+
+	limit := interfaceConfig.MaxDevicesPerUser
+	if limit == 0 {
+		limit = domain.DefaultMaxDevicesPerUser
+	}
+	if devices >= int(limit) {
+		return ErrDeviceLimitReached
+	}
+	return nil
+}
+
+func normalizeDeviceName(deviceName string) (string, error) {
+	deviceName = strings.TrimSpace(deviceName)
+	if deviceName == "" {
+		return domain.DefaultPeerDeviceName, nil
+	}
+	if utf8.RuneCountInString(deviceName) > maxDeviceNameLength || strings.IndexFunc(deviceName, unicode.IsControl) >= 0 {
+		return "", ErrInvalidDeviceName
+	}
+	return deviceName, nil
+}
+
+// validatePeerPublicKey accepts an empty key (server generated) or a wireguard public key.
+func validatePeerPublicKey(publicKey string) error {
+	if publicKey == "" {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(decoded) != 32 {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+func checkPeerKeyPolicy(policy string, publicKey string) error {
+	if policy == domain.PeerKeyPolicyClientSupplied && publicKey == "" {
+		return ErrPublicKeyRequired
+	}
 	return nil
 }
 
+// normalizePeerKeyPolicy defaults an empty policy to server generated keys.
+func normalizePeerKeyPolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return domain.PeerKeyPolicyServerGenerated, nil
+	case domain.PeerKeyPolicyServerGenerated, domain.PeerKeyPolicyClientSupplied:
+		return policy, nil
+	default:
+		return "", ErrInvalidPeerKeyPolicy
+	}
+}
+
+// normalizeIsolationMode defaults an empty mode to routes only.
+func normalizeIsolationMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return domain.InterfaceIsolationRoutesOnly, nil
+	case domain.InterfaceIsolationRoutesOnly, domain.InterfaceIsolationPeerToPeer, domain.InterfaceIsolationOpen:
+		return mode, nil
+	default:
+		return "", ErrInvalidIsolationMode
+	}
+}
+
 func extractInterfaceRouteCIDRs(routes []domain.InterfaceRoute) []string {
 	cidrs := make([]string, 0, len(routes))
 	for _, route := range routes {
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nomuken/william/services/server/internal/domain"
+)
+
+func TestWireguardServiceCreatePeerUndoesCompletedSteps(t *testing.T) {
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: []string{"repo.CreatePeer", "repo.SyncPeerFirewallRules", "peerStore.Create"}},
+		{failOn: "repo.CreatePeer", want: []string{"repo.CreatePeer"}},
+		{failOn: "repo.SyncPeerFirewallRules", want: []string{
+			"repo.CreatePeer", "repo.SyncPeerFirewallRules",
+			"repo.RemovePeerFirewallRules", "repo.DeletePeer",
+		}},
+		{failOn: "peerStore.Create", want: []string{
+			"repo.CreatePeer", "repo.SyncPeerFirewallRules", "peerStore.Create",
+			"repo.RemovePeerFirewallRules", "repo.DeletePeer",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			user := domain.UserIdentity{Email: "alice@example.com"}
+			_, err := services.wireguard.CreatePeer(context.Background(), user, "wg0", "", "laptop")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestWireguardServiceDeletePeerDeletesRecordFirst(t *testing.T) {
+	all := []string{"peerStore.DeleteByPeerID", "repo.RemovePeerFirewallRules", "repo.DeletePeer"}
+	tests := []struct {
+		failOn string
+		want   []string
+	}{
+		{failOn: "", want: all},
+		{failOn: "peerStore.DeleteByPeerID", want: []string{"peerStore.DeleteByPeerID"}},
+		// The teardown is not undone, and the peer is removed even when its firewall rules are not.
+		{failOn: "repo.RemovePeerFirewallRules", want: all},
+		{failOn: "repo.DeletePeer", want: all},
+	}
+
+	for _, test := range tests {
+		t.Run("fail "+test.failOn, func(t *testing.T) {
+			services := newFakeServices(test.failOn)
+			user := domain.UserIdentity{Email: "alice@example.com"}
+			err := services.wireguard.DeletePeer(context.Background(), user, "peer1")
+
+			checkInjectedError(t, err, test.failOn)
+			if !slices.Equal(services.steps.calls, test.want) {
+				t.Errorf("calls = %v, want %v", services.steps.calls, test.want)
+			}
+		})
+	}
+}
+
+// checkInjectedError fails the test unless err is the injected failure, or nil when no step fails.
+func checkInjectedError(t *testing.T, err error, failOn string) {
+	t.Helper()
+	if failOn == "" {
+		if err != nil {
+			t.Fatalf("err = %v, want nil", err)
+		}
+		return
+	}
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("err = %v, want the injected failure", err)
+	}
+}